@@ -1 +1,207 @@
 // File: cmd/aiworker/main.go
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/khaled2049/server/internal/config"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/llm"
+	"github.com/khaled2049/server/internal/moderation"
+	"github.com/khaled2049/server/internal/mq/broker"
+	"github.com/khaled2049/server/internal/repository/postgres"
+	"github.com/khaled2049/server/internal/service"
+	"github.com/khaled2049/server/internal/worker"
+)
+
+// aiworker generates AI suggestions: it consumes the jobs the API publishes and sweeps
+// the database for suggestions whose job was lost. It also maintains the semantic search
+// index, reindexing chapters as they are saved, enqueues the automatic chapter
+// summaries and novel synopses, and runs the requested chapter consistency checks.
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: could not load .env file: %v", err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	initCtx, initCancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer initCancel()
+
+	dbPool, err := postgres.NewConnectionPool(&cfg.Database, initCtx)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer dbPool.Close()
+
+	client, err := llm.NewClient(cfg.LLM)
+	if err != nil {
+		log.Fatalf("Failed to create LLM client: %v", err)
+	}
+
+	classifier, err := moderation.NewClassifier(cfg.Moderation)
+	if err != nil {
+		log.Fatalf("Failed to create moderation classifier: %v", err)
+	}
+
+	embedder, err := llm.NewEmbedder(cfg.Embedding)
+	if err != nil {
+		log.Fatalf("Failed to create embedder: %v", err)
+	}
+
+	mqBroker, err := broker.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open message queue: %v", err)
+	}
+	defer mqBroker.Close()
+
+	consumer, streams := mqBroker.Consumer, mqBroker.PubSub
+	if consumer == nil || mqBroker.InProcess {
+		// Nothing published in another process can reach an in-process broker
+		log.Println("Warning: no shared message queue configured (MQ_DRIVER). AI suggestions and chapter edits are only picked up by the database sweeps and suggestions are not streamed.")
+		consumer, streams = nil, nil
+	}
+
+	transactor := postgres.NewTransactor(dbPool)
+	novelRepo := postgres.NewNovelRepository(dbPool)
+	chapterRepo := postgres.NewChapterRepository(dbPool)
+	noteRepo := postgres.NewNoteRepository(dbPool)
+	suggestionRepo := postgres.NewAISuggestionRepository(dbPool)
+	summaryRepo := postgres.NewSummaryRepository(dbPool)
+	usageRepo := postgres.NewAIUsageRepository(dbPool)
+	outboxRepo := postgres.NewOutboxRepository(dbPool)
+	characterRepo := postgres.NewCharacterRepository(dbPool)
+	placeRepo := postgres.NewPlaceRepository(dbPool)
+	timelineRepo := postgres.NewTimelineEventRepository(dbPool)
+	// Summaries and consistency checks count against the quotas of the users they are attributed to
+	usage := service.NewAIUsageService(usageRepo, service.AIQuotas{
+		UserDailyTokens:    cfg.AIQuota.UserDailyTokens,
+		UserMonthlyTokens:  cfg.AIQuota.UserMonthlyTokens,
+		NovelDailyTokens:   cfg.AIQuota.NovelDailyTokens,
+		NovelMonthlyTokens: cfg.AIQuota.NovelMonthlyTokens,
+	}, nil)
+	// The worker never checks access; only the API's search endpoint does
+	embeddingService := service.NewEmbeddingService(
+		transactor,
+		postgres.NewEmbeddingRepository(dbPool),
+		chapterRepo,
+		noteRepo,
+		embedder,
+		nil,
+		service.EmbeddingOptions{ChunkTokens: cfg.Embedding.ChunkTokens, ChunkOverlapTokens: cfg.Embedding.ChunkOverlapTokens},
+	)
+	assembler := service.NewContextAssembler(
+		novelRepo,
+		chapterRepo,
+		characterRepo,
+		placeRepo,
+		noteRepo,
+		timelineRepo,
+		summaryRepo,
+		embeddingService,
+		service.ContextOptions{
+			Truncation:     cfg.AIWorker.ContextTruncation,
+			Passages:       cfg.Embedding.ContextPassages,
+			ChaptersPerAct: cfg.Summary.ChaptersPerAct,
+		},
+	)
+	// Status changes are stored for the API servers, which push them to the browsers;
+	// nothing subscribes here, so access is never checked
+	live := service.NewLiveEventService(postgres.NewLiveEventRepository(dbPool), streams, nil, service.LiveEventOptions{})
+	generator := service.NewAIGenerationService(
+		transactor,
+		suggestionRepo,
+		summaryRepo,
+		postgres.NewPromptTemplateRepository(dbPool),
+		usageRepo,
+		outboxRepo,
+		assembler,
+		client,
+		streams,
+		live,
+		service.NewModerationService(classifier, postgres.NewModerationSettingsRepository(dbPool), nil,
+			domain.ContentRating(cfg.Moderation.DefaultRating)),
+		service.AIGenerationOptions{
+			MaxOutputTokens:     cfg.LLM.MaxOutputTokens,
+			ContextWindow:       cfg.LLM.ContextWindow,
+			ContextBudget:       cfg.AIWorker.ContextBudget,
+			Timeout:             cfg.AIWorker.JobTimeout,
+			PromptCostPer1K:     cfg.LLM.PromptCostPer1K,
+			CompletionCostPer1K: cfg.LLM.CompletionCostPer1K,
+			MaxAttempts:         cfg.AIWorker.MaxAttempts,
+			RetryBaseDelay:      cfg.AIWorker.RetryBaseDelay,
+			RetryMaxDelay:       cfg.AIWorker.RetryMaxDelay,
+		},
+	)
+	aiWorker := worker.NewAIWorker(consumer, generator, cfg.AIWorker.Concurrency, cfg.AIWorker.SweepInterval)
+	indexer := worker.NewEmbeddingIndexer(consumer, embeddingService, cfg.Embedding.SweepInterval)
+	checks := service.NewConsistencyService(
+		transactor,
+		postgres.NewConsistencyRepository(dbPool),
+		outboxRepo,
+		chapterRepo,
+		characterRepo,
+		placeRepo,
+		noteRepo,
+		timelineRepo,
+		usageRepo,
+		usage,
+		client,
+		nil,
+		service.ConsistencyOptions{
+			LLMEnabled:          cfg.Consistency.LLMEnabled,
+			MaxOutputTokens:     cfg.LLM.MaxOutputTokens,
+			Timeout:             cfg.AIWorker.JobTimeout,
+			PromptCostPer1K:     cfg.LLM.PromptCostPer1K,
+			CompletionCostPer1K: cfg.LLM.CompletionCostPer1K,
+		},
+	)
+	checker := worker.NewConsistencyChecker(consumer, checks, cfg.Consistency.SweepInterval)
+	loops := map[string]func(context.Context) error{
+		"AI worker":           aiWorker.Run,
+		"Embedding indexer":   indexer.Run,
+		"Consistency checker": checker.Run,
+	}
+	if cfg.Summary.SweepInterval > 0 {
+		summaryService := service.NewSummaryService(transactor, summaryRepo, suggestionRepo,
+			outboxRepo, novelRepo, chapterRepo, usage, nil,
+			service.SummaryOptions{
+				StableAfter:    cfg.Summary.StableAfter,
+				RetryAfter:     cfg.Summary.RetryAfter,
+				ChaptersPerAct: cfg.Summary.ChaptersPerAct,
+			})
+		loops["Summarizer"] = worker.NewSummarizer(summaryService, cfg.Summary.SweepInterval).Run
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("AI worker started (provider %q, concurrency %d; embeddings %q)",
+		cfg.LLM.Provider, cfg.AIWorker.Concurrency, embedder.Model())
+	// Either loop failing stops the other, so the process exits and is restarted whole
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for name, run := range loops {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer cancel()
+			if err := run(ctx); err != nil {
+				log.Printf("%s stopped: %v", name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	log.Println("AI worker stopped.")
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -18,11 +19,18 @@ import (
 	// Import necessary packages from your project
 	fbAuth "github.com/khaled2049/server/internal/auth"
 	"github.com/khaled2049/server/internal/config"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/email"
+	"github.com/khaled2049/server/internal/llm"
+	"github.com/khaled2049/server/internal/moderation"
+	"github.com/khaled2049/server/internal/mq/broker"
 	"github.com/khaled2049/server/internal/repository/postgres"
 	"github.com/khaled2049/server/internal/service"
 	"github.com/khaled2049/server/internal/transport/http"
 	"github.com/khaled2049/server/internal/transport/http/handlers"
+	"github.com/khaled2049/server/internal/transport/http/middleware"
 	"github.com/khaled2049/server/internal/util/jwt"
+	"github.com/khaled2049/server/internal/worker"
 
 	// --- Add firebase imports ---
 
@@ -97,6 +105,34 @@ func main() {
 	novelRepo := postgres.NewNovelRepository(dbPool)
 	chapterRepo := postgres.NewChapterRepository(dbPool)
 	characterRepo := postgres.NewCharacterRepository(dbPool)
+	characterAttributeRepo := postgres.NewCharacterAttributeRepository(dbPool)
+	collaboratorRepo := postgres.NewCollaboratorRepository(dbPool)
+	commentRepo := postgres.NewCommentRepository(dbPool)
+	chapterRevisionRepo := postgres.NewChapterRevisionRepository(dbPool)
+	chapterChangeRepo := postgres.NewChapterChangeRepository(dbPool)
+	privateNoteRepo := postgres.NewPrivateNoteRepository(dbPool)
+	aiSuggestionRepo := postgres.NewAISuggestionRepository(dbPool)
+	outboxRepo := postgres.NewOutboxRepository(dbPool)
+	placeRepo := postgres.NewPlaceRepository(dbPool)
+	noteRepo := postgres.NewNoteRepository(dbPool)
+	timelineEventRepo := postgres.NewTimelineEventRepository(dbPool)
+	promptTemplateRepo := postgres.NewPromptTemplateRepository(dbPool)
+	aiUsageRepo := postgres.NewAIUsageRepository(dbPool)
+	aiAnalyticsRepo := postgres.NewAIAnalyticsRepository(dbPool)
+	moderationSettingsRepo := postgres.NewModerationSettingsRepository(dbPool)
+	embeddingRepo := postgres.NewEmbeddingRepository(dbPool)
+	summaryRepo := postgres.NewSummaryRepository(dbPool)
+	consistencyRepo := postgres.NewConsistencyRepository(dbPool)
+	notificationRepo := postgres.NewNotificationRepository(dbPool)
+	notificationPreferenceRepo := postgres.NewNotificationPreferenceRepository(dbPool)
+	liveEventRepo := postgres.NewLiveEventRepository(dbPool)
+	transactor := postgres.NewTransactor(dbPool)
+
+	mqBroker, err := broker.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open message queue: %v", err)
+	}
+	defer mqBroker.Close()
 
 	var firebaseVerifier fbAuth.FirebaseVerifier
 	if firebaseAuthClient != nil {
@@ -107,13 +143,219 @@ func main() {
 	}
 
 	authService := service.NewAuthService(firebaseVerifier, userRepo, jwtGenerator)
-	novelService := service.NewNovelService(novelRepo, chapterRepo, characterRepo)
+	novelService := service.NewNovelService(novelRepo, chapterRepo)
+	accessService := service.NewAccessService(novelRepo, collaboratorRepo, userRepo)
+	characterService := service.NewCharacterService(novelRepo, characterRepo, characterAttributeRepo, accessService)
+	liveEventService := service.NewLiveEventService(liveEventRepo, mqBroker.PubSub, accessService, service.LiveEventOptions{
+		Retention:    cfg.LiveEvents.Retention,
+		PollInterval: cfg.LiveEvents.PollInterval,
+	})
+	collaboratorService := service.NewCollaboratorService(transactor, collaboratorRepo, outboxRepo, accessService)
+	commentService := service.NewCommentService(transactor, commentRepo, chapterRepo, outboxRepo, liveEventService, accessService)
+	chapterService := service.NewChapterService(transactor, chapterRepo, chapterRevisionRepo, commentRepo, outboxRepo,
+		liveEventService, accessService)
+	changeSetService := service.NewChangeSetService(transactor, chapterRepo, chapterRevisionRepo, chapterChangeRepo, chapterService, accessService)
+	privateNoteService := service.NewPrivateNoteService(privateNoteRepo, accessService)
+	promptTemplateService := service.NewPromptTemplateService(transactor, promptTemplateRepo, accessService)
+	aiUsageService := service.NewAIUsageService(aiUsageRepo, service.AIQuotas{
+		UserDailyTokens:    cfg.AIQuota.UserDailyTokens,
+		UserMonthlyTokens:  cfg.AIQuota.UserMonthlyTokens,
+		NovelDailyTokens:   cfg.AIQuota.NovelDailyTokens,
+		NovelMonthlyTokens: cfg.AIQuota.NovelMonthlyTokens,
+	}, accessService)
+	aiAnalyticsService := service.NewAIAnalyticsService(aiAnalyticsRepo, accessService)
+	notificationService := service.NewNotificationService(notificationRepo, commentRepo, collaboratorRepo, novelRepo, chapterRepo, userRepo,
+		liveEventService)
+
+	embedder, err := llm.NewEmbedder(cfg.Embedding)
+	if err != nil {
+		log.Fatalf("Failed to create embedder: %v", err)
+	}
+	classifier, err := moderation.NewClassifier(cfg.Moderation)
+	if err != nil {
+		log.Fatalf("Failed to create moderation classifier: %v", err)
+	}
+	emailSender, err := email.NewSender(cfg.Email)
+	if err != nil {
+		log.Fatalf("Failed to create email sender: %v", err)
+	}
+	notificationEmailService := service.NewNotificationEmailService(notificationRepo, notificationPreferenceRepo, novelRepo,
+		userRepo, emailSender, email.NewUnsubscribeSigner(cfg.Email.UnsubscribeSecret), accessService,
+		service.NotificationEmailOptions{
+			DefaultMode:  domain.NotificationEmailMode(cfg.Email.DefaultMode),
+			AppURL:       cfg.Email.AppURL,
+			PublicAPIURL: cfg.Email.PublicAPIURL,
+			DigestHour:   cfg.Email.DigestHour,
+			MaxAttempts:  cfg.Email.MaxAttempts,
+		})
+	moderationService := service.NewModerationService(classifier, moderationSettingsRepo, accessService,
+		domain.ContentRating(cfg.Moderation.DefaultRating))
+	embeddingService := service.NewEmbeddingService(transactor, embeddingRepo, chapterRepo, noteRepo, embedder, accessService,
+		service.EmbeddingOptions{ChunkTokens: cfg.Embedding.ChunkTokens, ChunkOverlapTokens: cfg.Embedding.ChunkOverlapTokens})
+
+	aiService := service.NewAIService(transactor, aiSuggestionRepo, outboxRepo, chapterRepo, chapterRevisionRepo,
+		characterRepo, placeRepo, noteRepo, chapterService, aiUsageService, mqBroker.PubSub, liveEventService, accessService,
+		service.AIOptions{DefaultModel: cfg.LLM.Model, AllowedModels: cfg.LLM.AllowedModels})
+	summaryService := service.NewSummaryService(transactor, summaryRepo, aiSuggestionRepo, outboxRepo, novelRepo, chapterRepo,
+		aiUsageService, accessService, service.SummaryOptions{
+			StableAfter:    cfg.Summary.StableAfter,
+			RetryAfter:     cfg.Summary.RetryAfter,
+			ChaptersPerAct: cfg.Summary.ChaptersPerAct,
+		})
+	consistencyOptions := service.ConsistencyOptions{
+		LLMEnabled:          cfg.Consistency.LLMEnabled,
+		MaxOutputTokens:     cfg.LLM.MaxOutputTokens,
+		Timeout:             cfg.AIWorker.JobTimeout,
+		PromptCostPer1K:     cfg.LLM.PromptCostPer1K,
+		CompletionCostPer1K: cfg.LLM.CompletionCostPer1K,
+	}
+	// The API only records check requests; the checks run in the AI worker
+	consistencyService := service.NewConsistencyService(transactor, consistencyRepo, outboxRepo, chapterRepo, characterRepo,
+		placeRepo, noteRepo, timelineEventRepo, aiUsageRepo, aiUsageService, nil, accessService, consistencyOptions)
+
+	// Domain events are written to the outbox with the change and relayed from here
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+	if mqBroker.Producer != nil {
+		relay := worker.NewOutboxRelay(transactor, outboxRepo, mqBroker.Producer,
+			cfg.Outbox.PollInterval, cfg.Outbox.BatchSize, cfg.Outbox.MaxAttempts, cfg.Outbox.Retention)
+		go func() {
+			defer close(relayDone)
+			log.Println("Starting outbox relay...")
+			relay.Run(relayCtx)
+		}()
+	} else {
+		log.Println("Warning: no message queue configured (MQ_DRIVER). Events stay in the outbox and AI suggestions stay pending until the worker sweeps them.")
+		close(relayDone)
+	}
+	defer func() {
+		stopRelay()
+		<-relayDone
+	}()
+
+	// The in-process broker cannot reach cmd/aiworker, so the API generates suggestions,
+	// maintains the semantic search index, enqueues summaries and runs consistency checks
+	// itself. Nor can it reach cmd/notifier, so it also fills the notification inboxes
+	// and sends their emails
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	if mqBroker.InProcess {
+		client, err := llm.NewClient(cfg.LLM)
+		if err != nil {
+			log.Fatalf("Failed to create LLM client: %v", err)
+		}
+		assembler := service.NewContextAssembler(novelRepo, chapterRepo, characterRepo, placeRepo, noteRepo, timelineEventRepo,
+			summaryRepo, embeddingService, service.ContextOptions{
+				Truncation:     cfg.AIWorker.ContextTruncation,
+				Passages:       cfg.Embedding.ContextPassages,
+				ChaptersPerAct: cfg.Summary.ChaptersPerAct,
+			})
+		generator := service.NewAIGenerationService(transactor, aiSuggestionRepo, summaryRepo, promptTemplateRepo, aiUsageRepo, outboxRepo, assembler, client,
+			mqBroker.PubSub, liveEventService, moderationService, service.AIGenerationOptions{
+				MaxOutputTokens:     cfg.LLM.MaxOutputTokens,
+				ContextWindow:       cfg.LLM.ContextWindow,
+				ContextBudget:       cfg.AIWorker.ContextBudget,
+				Timeout:             cfg.AIWorker.JobTimeout,
+				PromptCostPer1K:     cfg.LLM.PromptCostPer1K,
+				CompletionCostPer1K: cfg.LLM.CompletionCostPer1K,
+				MaxAttempts:         cfg.AIWorker.MaxAttempts,
+				RetryBaseDelay:      cfg.AIWorker.RetryBaseDelay,
+				RetryMaxDelay:       cfg.AIWorker.RetryMaxDelay,
+			})
+		checks := service.NewConsistencyService(transactor, consistencyRepo, outboxRepo, chapterRepo, characterRepo,
+			placeRepo, noteRepo, timelineEventRepo, aiUsageRepo, aiUsageService, client, accessService, consistencyOptions)
+		aiWorker := worker.NewAIWorker(mqBroker.Consumer, generator, cfg.AIWorker.Concurrency, cfg.AIWorker.SweepInterval)
+		indexer := worker.NewEmbeddingIndexer(mqBroker.Consumer, embeddingService, cfg.Embedding.SweepInterval)
+		checker := worker.NewConsistencyChecker(mqBroker.Consumer, checks, cfg.Consistency.SweepInterval)
+		notifier := worker.NewNotifier(mqBroker.Consumer, notificationService)
+		workers.Add(4)
+		go func() {
+			defer workers.Done()
+			log.Println("Starting in-process AI worker...")
+			if err := aiWorker.Run(workerCtx); err != nil {
+				log.Printf("In-process AI worker stopped: %v", err)
+			}
+		}()
+		go func() {
+			defer workers.Done()
+			log.Println("Starting in-process embedding indexer...")
+			if err := indexer.Run(workerCtx); err != nil {
+				log.Printf("In-process embedding indexer stopped: %v", err)
+			}
+		}()
+		go func() {
+			defer workers.Done()
+			log.Println("Starting in-process consistency checker...")
+			if err := checker.Run(workerCtx); err != nil {
+				log.Printf("In-process consistency checker stopped: %v", err)
+			}
+		}()
+		go func() {
+			defer workers.Done()
+			log.Println("Starting in-process notifier...")
+			if err := notifier.Run(workerCtx); err != nil {
+				log.Printf("In-process notifier stopped: %v", err)
+			}
+		}()
+		if notificationEmailService.Enabled() {
+			dispatcher := worker.NewEmailDispatcher(notificationEmailService, cfg.Email.SendInterval)
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				log.Println("Starting in-process email dispatcher...")
+				if err := dispatcher.Run(workerCtx); err != nil {
+					log.Printf("In-process email dispatcher stopped: %v", err)
+				}
+			}()
+		}
+		if cfg.Summary.SweepInterval > 0 {
+			summarizer := worker.NewSummarizer(summaryService, cfg.Summary.SweepInterval)
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				log.Println("Starting in-process summarizer...")
+				if err := summarizer.Run(workerCtx); err != nil {
+					log.Printf("In-process summarizer stopped: %v", err)
+				}
+			}()
+		}
+	}
+	// Every API server pushes the live events to its own clients
+	fanout := worker.NewLiveEventFanout(liveEventService)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		log.Println("Starting live event fanout...")
+		fanout.Run(workerCtx)
+	}()
+	defer func() {
+		stopWorker()
+		workers.Wait()
+	}()
 
 	authHandler := handlers.NewAuthHandler(authService)
 	helloHandler := handlers.NewHelloHandler()
 	novelHandler := handlers.NewNovelHandler(novelService)
+	characterHandler := handlers.NewCharacterHandler(characterService)
+	commentHandler := handlers.NewCommentHandler(commentService)
+	collaboratorHandler := handlers.NewCollaboratorHandler(collaboratorService)
+	chapterHandler := handlers.NewChapterHandler(chapterService)
+	changeSetHandler := handlers.NewChangeSetHandler(changeSetService)
+	privateNoteHandler := handlers.NewPrivateNoteHandler(privateNoteService)
+	aiHandler := handlers.NewAIHandler(aiService, aiUsageService, aiAnalyticsService)
+	promptTemplateHandler := handlers.NewPromptTemplateHandler(promptTemplateService)
+	searchHandler := handlers.NewSearchHandler(embeddingService)
+	synopsisHandler := handlers.NewSynopsisHandler(summaryService)
+	moderationHandler := handlers.NewModerationHandler(moderationService)
+	consistencyHandler := handlers.NewConsistencyHandler(consistencyService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	notificationPreferenceHandler := handlers.NewNotificationPreferenceHandler(notificationEmailService)
+	eventHandler := handlers.NewEventHandler(liveEventService)
 
-	srv := http.NewServer(cfg, authHandler, helloHandler, novelHandler)
+	srv := http.NewServer(cfg, authHandler, helloHandler, novelHandler, characterHandler,
+		commentHandler, collaboratorHandler, chapterHandler, changeSetHandler, privateNoteHandler, aiHandler, promptTemplateHandler,
+		searchHandler, synopsisHandler, moderationHandler, consistencyHandler, notificationHandler,
+		notificationPreferenceHandler, eventHandler, middleware.AuthMiddleware(jwtGenerator))
 
 	serverErrors := make(chan error, 1)
 	go func() {
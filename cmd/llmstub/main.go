@@ -0,0 +1,60 @@
+// File: cmd/llmstub/main.go
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/khaled2049/server/internal/llm"
+)
+
+// llmstub serves the fake LLM provider over the OpenAI-compatible and Ollama-style
+// HTTP APIs so the AI pipeline can run offline:
+//
+//	LLM_PROVIDER=openai LLM_BASE_URL=http://localhost:11435/v1
+//	LLM_PROVIDER=ollama LLM_BASE_URL=http://localhost:11435
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	addr := getEnv("LLM_STUB_ADDR", ":11435")
+	fake := llm.NewFakeClient(os.Getenv("LLM_MODEL"))
+	if delay, err := time.ParseDuration(os.Getenv("LLM_STUB_CHUNK_DELAY")); err == nil {
+		fake.ChunkDelay = delay
+	}
+
+	srv := &http.Server{Addr: addr, Handler: llm.NewFakeServer(fake)}
+	go func() {
+		log.Printf("LLM stub serving model %q on %s", fake.Model, addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("LLM stub failed: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("LLM stub shutdown error: %v", err)
+	}
+	log.Println("LLM stub stopped.")
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
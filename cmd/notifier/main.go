@@ -1 +1,115 @@
 // File: cmd/notifier/main.go
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/khaled2049/server/internal/config"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/email"
+	"github.com/khaled2049/server/internal/mq/broker"
+	"github.com/khaled2049/server/internal/repository/postgres"
+	"github.com/khaled2049/server/internal/service"
+	"github.com/khaled2049/server/internal/worker"
+)
+
+// notifier fills the users' notification inboxes: it consumes the domain events of
+// comments, collaborators, generated AI suggestions and published chapters. With an
+// email provider configured it also emails the notifications and the daily digests.
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: could not load .env file: %v", err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	initCtx, initCancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer initCancel()
+
+	dbPool, err := postgres.NewConnectionPool(&cfg.Database, initCtx)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer dbPool.Close()
+
+	mqBroker, err := broker.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open message queue: %v", err)
+	}
+	defer mqBroker.Close()
+
+	if mqBroker.Consumer == nil || mqBroker.InProcess {
+		// Nothing published in another process can reach an in-process broker, and the
+		// events are not swept from the database
+		log.Fatalln("The notifier needs a shared message queue (MQ_DRIVER=rabbitmq); with the in-process broker the API notifies itself.")
+	}
+
+	notificationRepo := postgres.NewNotificationRepository(dbPool)
+	collaboratorRepo := postgres.NewCollaboratorRepository(dbPool)
+	novelRepo := postgres.NewNovelRepository(dbPool)
+	userRepo := postgres.NewUserRepository(dbPool)
+
+	notificationService := service.NewNotificationService(
+		notificationRepo,
+		postgres.NewCommentRepository(dbPool),
+		collaboratorRepo,
+		novelRepo,
+		postgres.NewChapterRepository(dbPool),
+		userRepo,
+		// New notifications are pushed to the browsers by the API servers
+		service.NewLiveEventService(postgres.NewLiveEventRepository(dbPool), mqBroker.PubSub, nil, service.LiveEventOptions{}),
+	)
+	loops := map[string]func(context.Context) error{
+		"Notifier": worker.NewNotifier(mqBroker.Consumer, notificationService).Run,
+	}
+
+	sender, err := email.NewSender(cfg.Email)
+	if err != nil {
+		log.Fatalf("Failed to create email sender: %v", err)
+	}
+	if sender != nil {
+		emailService := service.NewNotificationEmailService(notificationRepo,
+			postgres.NewNotificationPreferenceRepository(dbPool), novelRepo, userRepo, sender,
+			email.NewUnsubscribeSigner(cfg.Email.UnsubscribeSecret),
+			service.NewAccessService(novelRepo, collaboratorRepo, userRepo),
+			service.NotificationEmailOptions{
+				DefaultMode:  domain.NotificationEmailMode(cfg.Email.DefaultMode),
+				AppURL:       cfg.Email.AppURL,
+				PublicAPIURL: cfg.Email.PublicAPIURL,
+				DigestHour:   cfg.Email.DigestHour,
+				MaxAttempts:  cfg.Email.MaxAttempts,
+			})
+		loops["Email dispatcher"] = worker.NewEmailDispatcher(emailService, cfg.Email.SendInterval).Run
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Notifier started (email provider %q)", cfg.Email.Provider)
+	// Either loop failing stops the other, so the process exits and is restarted whole
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for name, run := range loops {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer cancel()
+			if err := run(ctx); err != nil {
+				log.Printf("%s stopped: %v", name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	log.Println("Notifier stopped.")
+}
@@ -4,6 +4,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 	// If using Viper or godotenv, import them here
 	// "github.com/spf13/viper"
@@ -17,11 +18,22 @@ type JWTConfig struct {
 
 // Config holds all configuration for the application.
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Firebase FirebaseConfig `mapstructure:"firebase"`
-	Database DatabaseConfig `mapstructure:"database"` // Added Database config
-	// RabbitMQ RabbitMQConfig `mapstructure:"rabbitmq"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Firebase  FirebaseConfig  `mapstructure:"firebase"`
+	Database  DatabaseConfig  `mapstructure:"database"` // Added Database config
+	MQ        MQConfig        `mapstructure:"mq"`
+	RabbitMQ  RabbitMQConfig  `mapstructure:"rabbitmq"`
+	JWT       JWTConfig       `mapstructure:"jwt"`
+	LLM       LLMConfig       `mapstructure:"llm"`
+	Embedding EmbeddingConfig `mapstructure:"embedding"`
+	Summary   SummaryConfig   `mapstructure:"summary"`
+	Moderation ModerationConfig `mapstructure:"moderation"`
+	Consistency ConsistencyConfig `mapstructure:"consistency"`
+	Email     EmailConfig     `mapstructure:"email"`
+	LiveEvents LiveEventsConfig `mapstructure:"liveEvents"`
+	AIWorker  AIWorkerConfig  `mapstructure:"aiWorker"`
+	AIQuota   AIQuotaConfig   `mapstructure:"aiQuota"`
+	Outbox    OutboxConfig    `mapstructure:"outbox"`
 }
 
 // ServerConfig holds HTTP server specific configuration.
@@ -47,6 +59,137 @@ type DatabaseConfig struct {
 	SSLMode  string `mapstructure:"sslMode"`
 }
 
+// Message queue drivers accepted in MQConfig.Driver.
+const (
+	MQDriverRabbitMQ = "rabbitmq"
+	MQDriverMemory   = "memory" // In-process broker; the API then also runs the AI worker
+	MQDriverNone     = "none"   // No broker; the AI worker relies on its sweep
+)
+
+// MQConfig selects the message queue driver and its delivery semantics.
+type MQConfig struct {
+	Driver     string        `mapstructure:"driver"`
+	Prefetch   int           `mapstructure:"prefetch"`   // Unacknowledged deliveries per consumer
+	MaxRetries int           `mapstructure:"maxRetries"` // Before a message is dead-lettered
+	RetryDelay time.Duration `mapstructure:"retryDelay"` // First retry delay; doubles with each retry
+}
+
+// RabbitMQConfig holds RabbitMQ connection configuration.
+type RabbitMQConfig struct {
+	URL      string `mapstructure:"url"`
+	Exchange string `mapstructure:"exchange"` // Topic exchange all messages are published to
+}
+
+// AIWorkerConfig tunes the AI generation worker.
+type AIWorkerConfig struct {
+	Concurrency   int           `mapstructure:"concurrency"`   // Suggestions generated in parallel
+	JobTimeout    time.Duration `mapstructure:"jobTimeout"`    // Per suggestion; timed out suggestions fail
+	SweepInterval time.Duration `mapstructure:"sweepInterval"` // How often lost or stale suggestions are picked up
+	// ContextBudget caps the tokens of novel context in a prompt; 0 fills the model's context window
+	ContextBudget     int    `mapstructure:"contextBudget"`
+	ContextTruncation string `mapstructure:"contextTruncation"` // truncate or drop sections that do not fit
+	// Retry policy of generations that fail with transient errors (rate limits, outages, timeouts)
+	MaxAttempts    int           `mapstructure:"maxAttempts"`    // Attempts per suggestion, including the first
+	RetryBaseDelay time.Duration `mapstructure:"retryBaseDelay"` // First retry delay; doubles with each retry
+	RetryMaxDelay  time.Duration `mapstructure:"retryMaxDelay"`
+}
+
+// AIQuotaConfig caps the tokens AI generation may consume per UTC day and month, for
+// each user and for each novel. 0 disables a quota.
+type AIQuotaConfig struct {
+	UserDailyTokens    int64 `mapstructure:"userDailyTokens"`
+	UserMonthlyTokens  int64 `mapstructure:"userMonthlyTokens"`
+	NovelDailyTokens   int64 `mapstructure:"novelDailyTokens"`
+	NovelMonthlyTokens int64 `mapstructure:"novelMonthlyTokens"`
+}
+
+// OutboxConfig tunes the relay that publishes outbox events to the message queue.
+type OutboxConfig struct {
+	PollInterval time.Duration `mapstructure:"pollInterval"`
+	BatchSize    int           `mapstructure:"batchSize"`   // Events published per transaction
+	MaxAttempts  int           `mapstructure:"maxAttempts"` // Failed publishes before an event is parked
+	Retention    time.Duration `mapstructure:"retention"`   // How long sent events are kept; 0 keeps them
+}
+
+// LLMConfig selects and configures the LLM provider used by the AI worker.
+type LLMConfig struct {
+	Provider        string        `mapstructure:"provider"` // openai, ollama or fake
+	BaseURL         string        `mapstructure:"baseUrl"`
+	APIKey          string        `mapstructure:"apiKey"`
+	Model           string        `mapstructure:"model"`
+	AllowedModels   []string      `mapstructure:"allowedModels"` // Other models suggestion requests may choose
+	ContextWindow   int           `mapstructure:"contextWindow"` // Used when the provider does not report one
+	MaxOutputTokens int           `mapstructure:"maxOutputTokens"`
+	Timeout         time.Duration `mapstructure:"timeout"` // Per request; a stream only until it starts
+	// Prices (USD per 1,000 tokens) used to estimate the cost recorded in the usage ledger
+	PromptCostPer1K     float64 `mapstructure:"promptCostPer1K"`
+	CompletionCostPer1K float64 `mapstructure:"completionCostPer1K"`
+}
+
+// EmbeddingConfig selects the embedding provider of semantic search and tunes indexing.
+type EmbeddingConfig struct {
+	Provider   string        `mapstructure:"provider"` // openai, ollama or fake
+	BaseURL    string        `mapstructure:"baseUrl"`
+	APIKey     string        `mapstructure:"apiKey"`
+	Model      string        `mapstructure:"model"`
+	Dimensions int           `mapstructure:"dimensions"` // Vector size of the fake provider, or requested from OpenAI
+	Timeout    time.Duration `mapstructure:"timeout"`
+	// ChunkTokens and ChunkOverlapTokens size the passages texts are split into
+	ChunkTokens        int           `mapstructure:"chunkTokens"`
+	ChunkOverlapTokens int           `mapstructure:"chunkOverlapTokens"`
+	SweepInterval      time.Duration `mapstructure:"sweepInterval"` // How often stale or missing embeddings are looked for
+	// ContextPassages is how many retrieved passages the AI context assembler considers; 0 disables retrieval
+	ContextPassages int `mapstructure:"contextPassages"`
+}
+
+// SummaryConfig tunes the automatic chapter summaries and the novel synopsis built from them.
+type SummaryConfig struct {
+	StableAfter   time.Duration `mapstructure:"stableAfter"`   // How long a chapter must go unedited before it is summarized
+	RetryAfter    time.Duration `mapstructure:"retryAfter"`    // Wait before retrying a failed summary
+	SweepInterval time.Duration `mapstructure:"sweepInterval"` // How often summaries are enqueued; 0 disables them
+	// ChaptersPerAct groups consecutive chapters into the acts of the synopsis
+	ChaptersPerAct int `mapstructure:"chaptersPerAct"`
+}
+
+// ModerationConfig selects the classifier that screens AI output.
+type ModerationConfig struct {
+	Provider      string `mapstructure:"provider"`      // rules or none
+	RulesFile     string `mapstructure:"rulesFile"`     // JSON rules replacing the built-in ones
+	DefaultRating string `mapstructure:"defaultRating"` // Content rating of novels without settings
+}
+
+// EmailConfig configures the email channel of notifications.
+type EmailConfig struct {
+	Provider     string `mapstructure:"provider"` // smtp or none
+	SMTPHost     string `mapstructure:"smtpHost"`
+	SMTPPort     int    `mapstructure:"smtpPort"`
+	SMTPUsername string `mapstructure:"smtpUsername"` // Empty to send without authentication
+	SMTPPassword string `mapstructure:"smtpPassword"`
+	From         string `mapstructure:"from"`
+	// AppURL is where links to the application point; PublicAPIURL is this API as
+	// reached by recipients, for unsubscribe links
+	AppURL       string `mapstructure:"appUrl"`
+	PublicAPIURL string `mapstructure:"publicApiUrl"`
+	// UnsubscribeSecret signs unsubscribe links; defaults to the JWT secret
+	UnsubscribeSecret string        `mapstructure:"unsubscribeSecret"`
+	DefaultMode       string        `mapstructure:"defaultMode"` // instant, daily or off, for users without preferences
+	DigestHour        int           `mapstructure:"digestHour"`  // UTC hour the daily digests go out
+	SendInterval      time.Duration `mapstructure:"sendInterval"`
+	MaxAttempts       int           `mapstructure:"maxAttempts"` // Sends of an email before giving up
+}
+
+// LiveEventsConfig tunes the event stream pushed to browsers.
+type LiveEventsConfig struct {
+	Retention    time.Duration `mapstructure:"retention"`    // How long events are kept for reconnecting clients
+	PollInterval time.Duration `mapstructure:"pollInterval"` // How often the store is read without a message queue
+}
+
+// ConsistencyConfig tunes the chapter consistency checks against the story bible.
+type ConsistencyConfig struct {
+	LLMEnabled    bool          `mapstructure:"llmEnabled"`    // Allow checks to add an LLM pass to the rules
+	SweepInterval time.Duration `mapstructure:"sweepInterval"` // How often lost or stalled checks are picked up
+}
+
 // LoadConfig reads configuration from file or environment variables.
 // --- Updated Placeholder LoadConfig ---
 func LoadConfig() (*Config, error) {
@@ -89,6 +232,96 @@ func LoadConfig() (*Config, error) {
 			SecretKey: jwtSecret,
 			TTL:       time.Duration(jwtTTLMinutes) * time.Minute,
 		},
+		MQ: MQConfig{
+			Driver:     getEnv("MQ_DRIVER", defaultMQDriver()),
+			Prefetch:   getEnvInt("MQ_PREFETCH", 8),
+			MaxRetries: getEnvInt("MQ_MAX_RETRIES", 3),
+			RetryDelay: time.Duration(getEnvInt("MQ_RETRY_DELAY_SECONDS", 5)) * time.Second,
+		},
+		RabbitMQ: RabbitMQConfig{
+			URL:      getEnv("RABBITMQ_URL", ""),
+			Exchange: getEnv("RABBITMQ_EXCHANGE", "novelcraft"),
+		},
+		LLM: LLMConfig{
+			Provider:            getEnv("LLM_PROVIDER", "fake"), // Offline by default
+			BaseURL:             getEnv("LLM_BASE_URL", ""),
+			APIKey:              getEnv("LLM_API_KEY", ""),
+			Model:               getEnv("LLM_MODEL", ""),
+			AllowedModels:       getEnvList("LLM_ALLOWED_MODELS"),
+			ContextWindow:       getEnvInt("LLM_CONTEXT_WINDOW", 8192),
+			MaxOutputTokens:     getEnvInt("LLM_MAX_OUTPUT_TOKENS", 1024),
+			Timeout:             time.Duration(getEnvInt("LLM_TIMEOUT_SECONDS", 120)) * time.Second,
+			PromptCostPer1K:     getEnvFloat("LLM_PROMPT_COST_PER_1K", 0),
+			CompletionCostPer1K: getEnvFloat("LLM_COMPLETION_COST_PER_1K", 0),
+		},
+		Embedding: EmbeddingConfig{
+			Provider:           getEnv("EMBEDDING_PROVIDER", "fake"), // Offline by default
+			BaseURL:            getEnv("EMBEDDING_BASE_URL", ""),
+			APIKey:             getEnv("EMBEDDING_API_KEY", ""),
+			Model:              getEnv("EMBEDDING_MODEL", ""),
+			Dimensions:         getEnvInt("EMBEDDING_DIMENSIONS", 0),
+			Timeout:            time.Duration(getEnvInt("EMBEDDING_TIMEOUT_SECONDS", 60)) * time.Second,
+			ChunkTokens:        getEnvInt("EMBEDDING_CHUNK_TOKENS", 256),
+			ChunkOverlapTokens: getEnvInt("EMBEDDING_CHUNK_OVERLAP_TOKENS", 32),
+			SweepInterval:      time.Duration(getEnvInt("EMBEDDING_SWEEP_INTERVAL_SECONDS", 60)) * time.Second,
+			ContextPassages:    getEnvInt("AI_CONTEXT_PASSAGES", 4),
+		},
+		Summary: SummaryConfig{
+			StableAfter:    time.Duration(getEnvInt("SUMMARY_STABLE_AFTER_SECONDS", 600)) * time.Second,
+			RetryAfter:     time.Duration(getEnvInt("SUMMARY_RETRY_AFTER_SECONDS", 3600)) * time.Second,
+			SweepInterval:  time.Duration(getEnvInt("SUMMARY_SWEEP_INTERVAL_SECONDS", 60)) * time.Second,
+			ChaptersPerAct: getEnvInt("SYNOPSIS_CHAPTERS_PER_ACT", 5),
+		},
+		Moderation: ModerationConfig{
+			Provider:      getEnv("MODERATION_PROVIDER", "rules"),
+			RulesFile:     getEnv("MODERATION_RULES_FILE", ""),
+			DefaultRating: getEnv("MODERATION_DEFAULT_RATING", "general"),
+		},
+		Email: EmailConfig{
+			Provider:          getEnv("EMAIL_PROVIDER", "none"),
+			SMTPHost:          getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:          getEnvInt("SMTP_PORT", 1025), // Mailpit
+			SMTPUsername:      getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:      getEnv("SMTP_PASSWORD", ""),
+			From:              getEnv("EMAIL_FROM", "Novelcraft <notifications@novelcraft.local>"),
+			AppURL:            getEnv("APP_URL", "http://localhost:3000"),
+			PublicAPIURL:      getEnv("PUBLIC_API_URL", "http://localhost:8000"),
+			UnsubscribeSecret: getEnv("EMAIL_UNSUBSCRIBE_SECRET", jwtSecret),
+			DefaultMode:       getEnv("EMAIL_DEFAULT_MODE", "instant"),
+			DigestHour:        getEnvInt("EMAIL_DIGEST_HOUR_UTC", 8),
+			SendInterval:      time.Duration(getEnvInt("EMAIL_SEND_INTERVAL_SECONDS", 30)) * time.Second,
+			MaxAttempts:       getEnvInt("EMAIL_MAX_ATTEMPTS", 5),
+		},
+		LiveEvents: LiveEventsConfig{
+			Retention:    time.Duration(getEnvInt("LIVE_EVENTS_RETENTION_HOURS", 24)) * time.Hour,
+			PollInterval: time.Duration(getEnvInt("LIVE_EVENTS_POLL_INTERVAL_MS", 1000)) * time.Millisecond,
+		},
+		Consistency: ConsistencyConfig{
+			LLMEnabled:    getEnvBool("CONSISTENCY_LLM_ENABLED", true),
+			SweepInterval: time.Duration(getEnvInt("CONSISTENCY_SWEEP_INTERVAL_SECONDS", 60)) * time.Second,
+		},
+		AIWorker: AIWorkerConfig{
+			Concurrency:       getEnvInt("AI_WORKER_CONCURRENCY", 4),
+			JobTimeout:        time.Duration(getEnvInt("AI_WORKER_JOB_TIMEOUT_SECONDS", 180)) * time.Second,
+			SweepInterval:     time.Duration(getEnvInt("AI_WORKER_SWEEP_INTERVAL_SECONDS", 30)) * time.Second,
+			ContextBudget:     getEnvInt("AI_CONTEXT_BUDGET_TOKENS", 0),
+			ContextTruncation: getEnv("AI_CONTEXT_TRUNCATION", "truncate"),
+			MaxAttempts:       getEnvInt("AI_RETRY_MAX_ATTEMPTS", 3),
+			RetryBaseDelay:    time.Duration(getEnvInt("AI_RETRY_BASE_DELAY_SECONDS", 30)) * time.Second,
+			RetryMaxDelay:     time.Duration(getEnvInt("AI_RETRY_MAX_DELAY_SECONDS", 900)) * time.Second,
+		},
+		AIQuota: AIQuotaConfig{
+			UserDailyTokens:    int64(getEnvInt("AI_QUOTA_USER_DAILY_TOKENS", 0)),
+			UserMonthlyTokens:  int64(getEnvInt("AI_QUOTA_USER_MONTHLY_TOKENS", 0)),
+			NovelDailyTokens:   int64(getEnvInt("AI_QUOTA_NOVEL_DAILY_TOKENS", 0)),
+			NovelMonthlyTokens: int64(getEnvInt("AI_QUOTA_NOVEL_MONTHLY_TOKENS", 0)),
+		},
+		Outbox: OutboxConfig{
+			PollInterval: time.Duration(getEnvInt("OUTBOX_POLL_INTERVAL_MS", 500)) * time.Millisecond,
+			BatchSize:    getEnvInt("OUTBOX_BATCH_SIZE", 100),
+			MaxAttempts:  getEnvInt("OUTBOX_MAX_ATTEMPTS", 10),
+			Retention:    time.Duration(getEnvInt("OUTBOX_RETENTION_HOURS", 72)) * time.Hour,
+		},
 		// Initialize other configs
 	}, nil
 }
@@ -99,4 +332,50 @@ func getEnv(key, fallback string) string {
 		return value
 	}
 	return fallback
-}
\ No newline at end of file
+}
+
+// defaultMQDriver uses RabbitMQ when it is configured.
+func defaultMQDriver() string {
+	if getEnv("RABBITMQ_URL", "") != "" {
+		return MQDriverRabbitMQ
+	}
+	return MQDriverNone
+}
+
+// Helper function to get an integer env var or default
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(getEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// Helper function to get a comma-separated env var as a list, empty if unset
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Helper function to get a float env var or default
+func getEnvFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(getEnv(key, ""), 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// Helper function to get a boolean env var or default
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(getEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
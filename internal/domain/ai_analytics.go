@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AI analytics dimensions: what suggestion outcomes can be broken down by.
+const (
+	AIAnalyticsByType     = "type"
+	AIAnalyticsByModel    = "model"
+	AIAnalyticsByTemplate = "template" // Prompt template version
+)
+
+// AIAnalyticsDimensions lists every analytics dimension.
+var AIAnalyticsDimensions = []string{AIAnalyticsByType, AIAnalyticsByModel, AIAnalyticsByTemplate}
+
+// AISuggestionStats aggregates the outcomes of the suggestions requested in a time range
+// that share a type, model and prompt template version. A dimension the stats are not
+// broken down by is left empty, as are the model and template of suggestions that
+// failed before generation.
+type AISuggestionStats struct {
+	Type            AISuggestionType `json:"type,omitempty"`
+	Model           string           `json:"model,omitempty"`
+	TemplateID      *uuid.UUID       `json:"templateId,omitempty"`
+	TemplateVersion *int             `json:"templateVersion,omitempty"`
+	TemplateScope   string           `json:"templateScope,omitempty"`
+
+	Requested      int `json:"requested"`
+	InProgress     int `json:"inProgress"` // Pending or generating
+	Failed         int `json:"failed"`
+	Cancelled      int `json:"cancelled"`
+	Generated      int `json:"generated"`      // Produced content, whatever its review
+	AwaitingReview int `json:"awaitingReview"` // Generated or edited, neither accepted nor rejected
+	Accepted       int `json:"accepted"`
+	AcceptedEdited int `json:"acceptedEdited"` // Accepted after the author edited the text
+	Rejected       int `json:"rejected"`
+	Edited         int `json:"edited"` // Edited by the author, whatever the decision
+
+	// AcceptanceRate is accepted / (accepted + rejected), EditRate edited / generated
+	// and FailureRate failed / (generated + failed). Nil when the divisor is 0.
+	AcceptanceRate *float64 `json:"acceptanceRate"`
+	EditRate       *float64 `json:"editRate"`
+	FailureRate    *float64 `json:"failureRate"`
+
+	Ratings       int      `json:"ratings"`
+	AverageRating *float64 `json:"averageRating"` // 1-5
+
+	AverageLatencyMs *float64 `json:"averageLatencyMs"`
+	P95LatencyMs     *float64 `json:"p95LatencyMs"`
+
+	PromptTokens     int64   `json:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens"`
+	TotalTokens      int64   `json:"totalTokens"`
+	EstimatedCost    float64 `json:"estimatedCost"`
+}
+
+// AIAnalyticsReport breaks down the outcomes of the suggestions requested in
+// [From, To) by the GroupBy dimensions.
+type AIAnalyticsReport struct {
+	From    time.Time            `json:"from"`
+	To      time.Time            `json:"to"`
+	GroupBy []string             `json:"groupBy"`
+	Totals  *AISuggestionStats   `json:"totals"`
+	Groups  []*AISuggestionStats `json:"groups"`
+}
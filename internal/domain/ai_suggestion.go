@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AISuggestionType mirrors the ai_suggestion_type enum.
+type AISuggestionType string
+
+const (
+	AISuggestionContinuation  AISuggestionType = "continuation"
+	AISuggestionDialogue      AISuggestionType = "dialogue"
+	AISuggestionDescription   AISuggestionType = "description"
+	AISuggestionCharacterIdea AISuggestionType = "character_idea"
+	AISuggestionPlaceIdea     AISuggestionType = "place_idea"
+	AISuggestionPlotPoint     AISuggestionType = "plot_point"
+	AISuggestionSummary       AISuggestionType = "summary"
+	AISuggestionRewrite       AISuggestionType = "rewrite"
+	AISuggestionBrainstorm    AISuggestionType = "brainstorm"
+)
+
+// AISuggestionTypes lists every suggestion type.
+var AISuggestionTypes = []AISuggestionType{
+	AISuggestionContinuation, AISuggestionDialogue, AISuggestionDescription,
+	AISuggestionCharacterIdea, AISuggestionPlaceIdea, AISuggestionPlotPoint,
+	AISuggestionSummary, AISuggestionRewrite, AISuggestionBrainstorm,
+}
+
+// Valid reports whether t is a known suggestion type.
+func (t AISuggestionType) Valid() bool {
+	switch t {
+	case AISuggestionContinuation, AISuggestionDialogue, AISuggestionDescription,
+		AISuggestionCharacterIdea, AISuggestionPlaceIdea, AISuggestionPlotPoint,
+		AISuggestionSummary, AISuggestionRewrite, AISuggestionBrainstorm:
+		return true
+	}
+	return false
+}
+
+// AISuggestionStatus mirrors the ai_suggestion_status enum.
+type AISuggestionStatus string
+
+const (
+	AISuggestionPending    AISuggestionStatus = "pending"
+	AISuggestionGenerating AISuggestionStatus = "generating"
+	AISuggestionGenerated  AISuggestionStatus = "generated"
+	AISuggestionFailed     AISuggestionStatus = "failed"
+	AISuggestionAccepted   AISuggestionStatus = "accepted"
+	AISuggestionRejected   AISuggestionStatus = "rejected"
+	AISuggestionEdited     AISuggestionStatus = "edited"
+	AISuggestionCancelled  AISuggestionStatus = "cancelled"
+)
+
+// aiSuggestionTransitions is the suggestion lifecycle:
+//
+//	pending -> generating -> generated -> accepted | rejected | edited
+//	edited -> accepted | rejected | edited
+//	generating -> pending (job requeued or retry scheduled), pending/generating -> failed, failed -> pending (retry)
+//	pending/generating -> cancelled
+var aiSuggestionTransitions = map[AISuggestionStatus][]AISuggestionStatus{
+	AISuggestionPending:    {AISuggestionGenerating, AISuggestionFailed, AISuggestionCancelled},
+	AISuggestionGenerating: {AISuggestionGenerated, AISuggestionFailed, AISuggestionPending, AISuggestionCancelled},
+	AISuggestionGenerated:  {AISuggestionAccepted, AISuggestionRejected, AISuggestionEdited},
+	AISuggestionEdited:     {AISuggestionAccepted, AISuggestionRejected, AISuggestionEdited},
+	AISuggestionFailed:     {AISuggestionPending},
+}
+
+// Valid reports whether s is a known status.
+func (s AISuggestionStatus) Valid() bool {
+	switch s {
+	case AISuggestionPending, AISuggestionGenerating, AISuggestionGenerated, AISuggestionFailed,
+		AISuggestionAccepted, AISuggestionRejected, AISuggestionEdited, AISuggestionCancelled:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether the lifecycle allows moving from s to next.
+func (s AISuggestionStatus) CanTransitionTo(next AISuggestionStatus) bool {
+	for _, allowed := range aiSuggestionTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// InProgress reports whether the content is still to be generated.
+func (s AISuggestionStatus) InProgress() bool {
+	return s == AISuggestionPending || s == AISuggestionGenerating
+}
+
+// Terminal reports whether no further transitions are possible.
+func (s AISuggestionStatus) Terminal() bool {
+	return len(aiSuggestionTransitions[s]) == 0
+}
+
+// AIErrorKind classifies why a generation failed.
+type AIErrorKind string
+
+const (
+	// AIErrorTransient failures (rate limits, provider outages, timeouts) may pass on
+	// another attempt; the worker retries them with backoff.
+	AIErrorTransient AIErrorKind = "transient"
+	// AIErrorPermanent failures (rejected requests, broken templates) would fail again.
+	AIErrorPermanent AIErrorKind = "permanent"
+)
+
+// AISuggestion is a request for AI generated content and its result.
+type AISuggestion struct {
+	ID                 uuid.UUID                `json:"id"`
+	NovelID            uuid.UUID                `json:"novelId"`
+	UserID             uuid.UUID                `json:"userId"` // Requester
+	Type               AISuggestionType         `json:"type"`
+	Status             AISuggestionStatus       `json:"status"`
+	Automatic          bool                     `json:"automatic,omitempty"` // Requested by the server and applied without review
+	ContextChapterID   *uuid.UUID               `json:"contextChapterId,omitempty"`
+	ContextCharacterID *uuid.UUID               `json:"contextCharacterId,omitempty"`
+	ContextPlaceID     *uuid.UUID               `json:"contextPlaceId,omitempty"`
+	ContextNoteID      *uuid.UUID               `json:"contextNoteId,omitempty"`
+	SelectedText       string                   `json:"selectedText,omitempty"`  // Text the author selected (e.g. the passage to rewrite)
+	PromptContext      string                   `json:"promptContext,omitempty"` // Context assembled for the model, set by the worker
+	PromptInstructions string                   `json:"promptInstructions,omitempty"`
+	GeneratedContent   string                   `json:"generatedContent,omitempty"`
+	ModelUsed          string                   `json:"modelUsed,omitempty"`
+	GenerationMetadata map[string]any           `json:"generationMetadata,omitempty"`
+	ContentWithheld    bool                     `json:"contentWithheld,omitempty"` // Flagged by moderation and not revealed to the reader
+	UserFeedback       *int16                   `json:"userFeedback,omitempty"`    // 1-5 rating
+	UserNotes          string                   `json:"userNotes,omitempty"`
+	Application        *AISuggestionApplication `json:"application,omitempty"` // Set once accepted
+	Attempts           int                      `json:"attempts"`              // Generation attempts so far
+	LastError          string                   `json:"lastError,omitempty"`   // Error of the latest failed attempt
+	LastErrorKind      AIErrorKind              `json:"lastErrorKind,omitempty"`
+	RetryAt            *time.Time               `json:"retryAt,omitempty"`            // When a pending suggestion is retried
+	ParentSuggestionID *uuid.UUID               `json:"parentSuggestionId,omitempty"` // First variant of the group, for the other variants
+	VariantIndex       int                      `json:"variantIndex,omitempty"`       // Position in a variant group from 1; 0 outside groups
+	RequestedModel     string                   `json:"requestedModel,omitempty"`     // Overrides the worker's model
+	Temperature        *float64                 `json:"temperature,omitempty"`        // Overrides the worker's temperature
+	Variants           []*AISuggestion          `json:"variants,omitempty"`           // The other variants, when requested together
+	RequestedAt        time.Time                `json:"requestedAt"`
+	GeneratedAt        *time.Time               `json:"generatedAt,omitempty"`
+	UpdatedAt          time.Time                `json:"updatedAt"`
+}
+
+// VariantGroupID returns the ID shared by the variants requested together with the
+// suggestion, that of the first variant, or nil when it was requested alone.
+func (s *AISuggestion) VariantGroupID() *uuid.UUID {
+	switch {
+	case s.ParentSuggestionID != nil:
+		return s.ParentSuggestionID
+	case s.VariantIndex > 0:
+		return &s.ID
+	}
+	return nil
+}
+
+// AISuggestionApplication records where an accepted suggestion's text went: the rune
+// range [Start, End) of the chapter revision it created, or the character, place or
+// note created from it. Everything it points to has source ai. That of an automatic
+// summary is empty: the summary went to its chapter or to the novel's synopsis.
+type AISuggestionApplication struct {
+	AppliedAt   time.Time  `json:"appliedAt"`
+	RevisionID  *int64     `json:"revisionId,omitempty"`
+	Start       *int       `json:"start,omitempty"`
+	End         *int       `json:"end,omitempty"`
+	CharacterID *uuid.UUID `json:"characterId,omitempty"`
+	PlaceID     *uuid.UUID `json:"placeId,omitempty"`
+	NoteID      *uuid.UUID `json:"noteId,omitempty"`
+}
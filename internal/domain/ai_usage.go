@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AIUsageEntry is a row of the AI usage ledger: the tokens one generation consumed and
+// what they are estimated to cost (USD).
+type AIUsageEntry struct {
+	ID               int64      `json:"id"`
+	SuggestionID     *uuid.UUID `json:"suggestionId,omitempty"`
+	UserID           uuid.UUID  `json:"userId"`
+	NovelID          uuid.UUID  `json:"novelId"`
+	Model            string     `json:"model"`
+	PromptTokens     int        `json:"promptTokens"`
+	CompletionTokens int        `json:"completionTokens"`
+	TotalTokens      int        `json:"totalTokens"`
+	EstimatedCost    float64    `json:"estimatedCost"`
+	RecordedAt       time.Time  `json:"recordedAt"`
+}
+
+// AIUsageTotals sums ledger entries.
+type AIUsageTotals struct {
+	Generations      int     `json:"generations"`
+	PromptTokens     int64   `json:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens"`
+	TotalTokens      int64   `json:"totalTokens"`
+	EstimatedCost    float64 `json:"estimatedCost"`
+}
@@ -13,6 +13,15 @@ const (
 	ChapterStatusArchived  ChapterStatus = "archived"
 )
 
+// Valid reports whether s is one of the chapter_status enum values.
+func (s ChapterStatus) Valid() bool {
+	switch s {
+	case ChapterStatusDraft, ChapterStatusPublished, ChapterStatusArchived:
+		return true
+	}
+	return false
+}
+
 type Chapter struct {
 	ID               string   `json:"id"`
 	NovelID          string    `json:"novelId"`
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChapterChangeStatus is the review state of a proposed change.
+type ChapterChangeStatus string
+
+const (
+	ChapterChangePending  ChapterChangeStatus = "pending"
+	ChapterChangeAccepted ChapterChangeStatus = "accepted"
+	ChapterChangeRejected ChapterChangeStatus = "rejected"
+)
+
+// ChapterChangeSet groups the changes a collaborator proposed against one chapter revision.
+type ChapterChangeSet struct {
+	ID               uuid.UUID        `json:"id"`
+	ChapterID        uuid.UUID        `json:"chapterId"`
+	NovelID          uuid.UUID        `json:"novelId"`
+	BaseRevisionID   int64            `json:"baseRevisionId"`
+	ProposedByUserID uuid.UUID        `json:"proposedByUserId"`
+	Description      string           `json:"description,omitempty"`
+	CreatedAt        time.Time        `json:"createdAt"`
+	Changes          []*ChapterChange `json:"changes"`
+}
+
+// ChapterChange replaces the rune range [Start, End) of the base revision with InsertedText.
+// DeletedText is the base text of the range, kept to detect conflicting edits.
+type ChapterChange struct {
+	ID                uuid.UUID           `json:"id"`
+	ChangeSetID       uuid.UUID           `json:"changeSetId"`
+	Position          int                 `json:"position"`
+	Start             int                 `json:"start"`
+	End               int                 `json:"end"`
+	DeletedText       string              `json:"deletedText"`
+	InsertedText      string              `json:"insertedText"`
+	Status            ChapterChangeStatus `json:"status"`
+	ResolvedByUserID  *uuid.UUID          `json:"resolvedByUserId,omitempty"`
+	ResolvedAt        *time.Time          `json:"resolvedAt,omitempty"`
+	AppliedRevisionID *int64              `json:"appliedRevisionId,omitempty"`
+	CreatedAt         time.Time           `json:"createdAt"`
+	UpdatedAt         time.Time           `json:"updatedAt"`
+}
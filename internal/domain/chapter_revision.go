@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ContentSource records whether content was written by a user or generated by AI.
+type ContentSource string
+
+const (
+	ContentSourceUser ContentSource = "user"
+	ContentSourceAI   ContentSource = "ai"
+)
+
+// ChapterRevision is a snapshot of a chapter's content after a save.
+type ChapterRevision struct {
+	ID             int64         `json:"id"`
+	ChapterID      uuid.UUID     `json:"chapterId"`
+	Content        string        `json:"content,omitempty"`
+	Source         ContentSource `json:"source"`
+	EditedAt       time.Time     `json:"editedAt"`
+	EditedByUserID *uuid.UUID    `json:"editedByUserId,omitempty"`
+	RevisionNotes  string        `json:"revisionNotes,omitempty"`
+	WordCount      int           `json:"wordCount"`
+}
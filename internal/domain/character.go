@@ -14,6 +14,8 @@ type Character struct {
 	Backstory           string     `json:"backstory"`
 	Motivations         string     `json:"motivations"`
 	PhysicalDescription string     `json:"physicalDescription"`
+	Aliases             []string   `json:"aliases"`
+	Attributes          Attributes `json:"attributes"`
 	ImageURL            string     `json:"imageUrl"`
 	Source              string     `json:"source"`
 	CreatedAt           time.Time  `json:"createdAt"`
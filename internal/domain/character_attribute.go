@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attributes holds user-defined character attribute values keyed by definition key.
+// Values are the decoded JSON form: string, float64 or bool.
+type Attributes map[string]interface{}
+
+type CharacterAttributeType string
+
+const (
+	CharacterAttributeText    CharacterAttributeType = "text"
+	CharacterAttributeNumber  CharacterAttributeType = "number"
+	CharacterAttributeBoolean CharacterAttributeType = "boolean"
+	CharacterAttributeDate    CharacterAttributeType = "date" // Stored as YYYY-MM-DD
+	CharacterAttributeEnum    CharacterAttributeType = "enum"
+)
+
+// CharacterAttributeDefinition is one field of a novel's character attribute schema.
+type CharacterAttributeDefinition struct {
+	ID        uuid.UUID              `json:"id"`
+	NovelID   uuid.UUID              `json:"novelId"`
+	Key       string                 `json:"key"`
+	Label     string                 `json:"label"`
+	ValueType CharacterAttributeType `json:"valueType"`
+	Required  bool                   `json:"required"`
+	Options   []string               `json:"options,omitempty"`
+	Position  int                    `json:"position"`
+	CreatedAt time.Time              `json:"createdAt"`
+	UpdatedAt time.Time              `json:"updatedAt"`
+}
+
+// CharacterMention is an occurrence of a character's name or alias within a text.
+type CharacterMention struct {
+	CharacterID uuid.UUID `json:"characterId"`
+	Name        string    `json:"name"`
+	MatchedText string    `json:"matchedText"`
+	Start       int       `json:"start"` // Rune offset, inclusive
+	End         int       `json:"end"`   // Rune offset, exclusive
+}
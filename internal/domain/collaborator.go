@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CollaborationRole string
+
+const (
+	CollaborationRoleOwner     CollaborationRole = "owner"
+	CollaborationRoleEditor    CollaborationRole = "editor"
+	CollaborationRoleViewer    CollaborationRole = "viewer"
+	CollaborationRoleCommenter CollaborationRole = "commenter"
+)
+
+// Permission is an action a collaborator may take on a novel.
+type Permission string
+
+const (
+	PermissionView        Permission = "view"
+	PermissionComment     Permission = "comment"
+	PermissionEditContent Permission = "edit_content"
+	PermissionManage      Permission = "manage" // Collaborators, settings, accepting suggestions
+)
+
+var rolePermissions = map[CollaborationRole][]Permission{
+	CollaborationRoleOwner:     {PermissionView, PermissionComment, PermissionEditContent, PermissionManage},
+	CollaborationRoleEditor:    {PermissionView, PermissionComment, PermissionEditContent},
+	CollaborationRoleCommenter: {PermissionView, PermissionComment},
+	CollaborationRoleViewer:    {PermissionView},
+}
+
+// Can reports whether the role grants the permission.
+func (r CollaborationRole) Can(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid reports whether r is one of the collaboration_role enum values.
+func (r CollaborationRole) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// NovelCollaborator is a row of novel_collaborators.
+type NovelCollaborator struct {
+	NovelID   uuid.UUID         `json:"novelId"`
+	UserID    uuid.UUID         `json:"userId"`
+	Role      CollaborationRole `json:"role"`
+	InvitedAt *time.Time        `json:"invitedAt,omitempty"`
+	JoinedAt  *time.Time        `json:"joinedAt,omitempty"`
+}
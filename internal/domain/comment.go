@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CommentTargetType names the entity a comment thread is attached to.
+type CommentTargetType string
+
+const (
+	CommentTargetChapter       CommentTargetType = "chapter"
+	CommentTargetCharacter     CommentTargetType = "character"
+	CommentTargetPlace         CommentTargetType = "place"
+	CommentTargetNote          CommentTargetType = "note"
+	CommentTargetTimelineEvent CommentTargetType = "timeline_event"
+)
+
+// Valid reports whether t is a supported comment target.
+func (t CommentTargetType) Valid() bool {
+	switch t {
+	case CommentTargetChapter, CommentTargetCharacter, CommentTargetPlace, CommentTargetNote, CommentTargetTimelineEvent:
+		return true
+	}
+	return false
+}
+
+type CommentStatus string
+
+const (
+	CommentStatusOpen     CommentStatus = "open"
+	CommentStatusResolved CommentStatus = "resolved"
+)
+
+// Comment is a thread root (ParentCommentID nil) or a reply. Replies carry the
+// target of their thread so a target's threads load with one query.
+type Comment struct {
+	ID              uuid.UUID         `json:"id"`
+	NovelID         uuid.UUID         `json:"novelId"`
+	UserID          uuid.UUID         `json:"userId"`
+	TargetType      CommentTargetType `json:"targetType"`
+	TargetID        uuid.UUID         `json:"targetId"`
+	ParentCommentID *uuid.UUID        `json:"parentCommentId,omitempty"`
+	Anchor          *CommentAnchor    `json:"anchor,omitempty"`
+	Content         string            `json:"content"`
+	Status          CommentStatus     `json:"status"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	UpdatedAt       time.Time         `json:"updatedAt"`
+	Replies         []*Comment        `json:"replies,omitempty"`
+}
+
+// CommentAnchor pins a chapter comment to a rune range of the chapter content.
+// Quote is the anchored text as of the last remap; Orphaned is set once the text
+// has been deleted, in which case Start/End keep their last known position.
+type CommentAnchor struct {
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	Quote    string `json:"quote"`
+	Orphaned bool   `json:"orphaned"`
+}
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ConsistencyCheckStatus string
+
+const (
+	ConsistencyCheckPending   ConsistencyCheckStatus = "pending"
+	ConsistencyCheckRunning   ConsistencyCheckStatus = "running"
+	ConsistencyCheckCompleted ConsistencyCheckStatus = "completed"
+	ConsistencyCheckFailed    ConsistencyCheckStatus = "failed"
+)
+
+// ConsistencyCheck is a run of the consistency checker over a chapter. ContentHash
+// identifies the chapter content it checked; Error is set when the check failed, or
+// when it completed without its LLM pass.
+type ConsistencyCheck struct {
+	ID                uuid.UUID              `json:"id"`
+	NovelID           uuid.UUID              `json:"novelId"`
+	ChapterID         uuid.UUID              `json:"chapterId"`
+	RequestedByUserID uuid.UUID              `json:"requestedByUserId"`
+	Status            ConsistencyCheckStatus `json:"status"`
+	UseLLM            bool                   `json:"useLlm"`
+	ContentHash       string                 `json:"-"`
+	FindingsCount     int                    `json:"findingsCount"`
+	Error             string                 `json:"error,omitempty"`
+	RequestedAt       time.Time              `json:"requestedAt"`
+	StartedAt         *time.Time             `json:"startedAt,omitempty"`
+	CompletedAt       *time.Time             `json:"completedAt,omitempty"`
+	UpdatedAt         time.Time              `json:"updatedAt"`
+}
+
+// ConsistencyFindingKind is what a finding contradicts.
+type ConsistencyFindingKind string
+
+const (
+	ConsistencyCharacterDescription ConsistencyFindingKind = "character_description"
+	ConsistencyCharacterDeath       ConsistencyFindingKind = "character_death"
+	ConsistencyPlaceDescription     ConsistencyFindingKind = "place_description"
+	ConsistencyWorldRule            ConsistencyFindingKind = "world_rule"
+	ConsistencyTimelineOrder        ConsistencyFindingKind = "timeline_order"
+	ConsistencyOther                ConsistencyFindingKind = "other" // LLM findings of no other kind
+)
+
+// Valid reports whether k is a known kind.
+func (k ConsistencyFindingKind) Valid() bool {
+	switch k {
+	case ConsistencyCharacterDescription, ConsistencyCharacterDeath, ConsistencyPlaceDescription,
+		ConsistencyWorldRule, ConsistencyTimelineOrder, ConsistencyOther:
+		return true
+	}
+	return false
+}
+
+type ConsistencySeverity string
+
+const (
+	ConsistencySeverityInfo    ConsistencySeverity = "info"
+	ConsistencySeverityWarning ConsistencySeverity = "warning"
+	ConsistencySeverityError   ConsistencySeverity = "error"
+)
+
+// Valid reports whether s is a known severity.
+func (s ConsistencySeverity) Valid() bool {
+	return s == ConsistencySeverityInfo || s == ConsistencySeverityWarning || s == ConsistencySeverityError
+}
+
+// ConsistencyFindingSource is the pass of a check that reported a finding.
+type ConsistencyFindingSource string
+
+const (
+	ConsistencySourceRules ConsistencyFindingSource = "rules"
+	ConsistencySourceLLM   ConsistencyFindingSource = "llm"
+)
+
+type ConsistencyFindingStatus string
+
+const (
+	ConsistencyFindingOpen      ConsistencyFindingStatus = "open"
+	ConsistencyFindingResolved  ConsistencyFindingStatus = "resolved"  // Fixed in the text
+	ConsistencyFindingDismissed ConsistencyFindingStatus = "dismissed" // Not an error; never reported again
+)
+
+// Valid reports whether s is a known status.
+func (s ConsistencyFindingStatus) Valid() bool {
+	return s == ConsistencyFindingOpen || s == ConsistencyFindingResolved || s == ConsistencyFindingDismissed
+}
+
+// ConsistencyFinding is an inconsistency between a chapter and the story bible. Range
+// is the rune range of the offending text in the checked content, nil when the finding
+// is about the chapter as a whole. EntityType and EntityID name the story bible entry
+// it contradicts, if any (a character, place, note or timeline event).
+type ConsistencyFinding struct {
+	ID               uuid.UUID                `json:"id"`
+	CheckID          uuid.UUID                `json:"checkId"`
+	NovelID          uuid.UUID                `json:"novelId"`
+	ChapterID        uuid.UUID                `json:"chapterId"`
+	Kind             ConsistencyFindingKind   `json:"kind"`
+	Severity         ConsistencySeverity      `json:"severity"`
+	Source           ConsistencyFindingSource `json:"source"`
+	Range            *TextRange               `json:"range,omitempty"`
+	Quote            string                   `json:"quote,omitempty"`
+	Message          string                   `json:"message"`
+	EntityType       string                   `json:"entityType,omitempty"`
+	EntityID         *uuid.UUID               `json:"entityId,omitempty"`
+	Fingerprint      string                   `json:"-"`
+	Status           ConsistencyFindingStatus `json:"status"`
+	ResolutionNote   string                   `json:"resolutionNote,omitempty"`
+	ResolvedByUserID *uuid.UUID               `json:"resolvedByUserId,omitempty"`
+	ResolvedAt       *time.Time               `json:"resolvedAt,omitempty"`
+	CreatedAt        time.Time                `json:"createdAt"`
+}
+
+// TextRange is a rune range of a text: Start inclusive, End exclusive.
+type TextRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmbeddingSourceType is the kind of text indexed for semantic search.
+type EmbeddingSourceType string
+
+const (
+	EmbeddingSourceChapter EmbeddingSourceType = "chapter"
+	EmbeddingSourceNote    EmbeddingSourceType = "note"
+)
+
+// EmbeddingSource is a chapter or note in the semantic search index. Title and
+// UpdatedAt are those of the version that was indexed.
+type EmbeddingSource struct {
+	Type      EmbeddingSourceType `json:"type"`
+	ID        uuid.UUID           `json:"id"`
+	NovelID   uuid.UUID           `json:"novelId"`
+	Model     string              `json:"model"`
+	Title     string              `json:"title"`
+	UpdatedAt time.Time           `json:"updatedAt"`
+	IndexedAt time.Time           `json:"indexedAt"`
+}
+
+// EmbeddingChunk is an indexed passage of a source: the rune range [Start, End) of its
+// text and the passage's embedding.
+type EmbeddingChunk struct {
+	SourceType EmbeddingSourceType `json:"sourceType"`
+	SourceID   uuid.UUID           `json:"sourceId"`
+	Index      int                 `json:"index"`
+	Start      int                 `json:"start"`
+	End        int                 `json:"end"`
+	Content    string              `json:"content"`
+	Embedding  []float32           `json:"-"`
+	Title      string              `json:"title,omitempty"` // Title of the source, when listed for search
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LiveEventType is what a live event pushed to connected clients reports.
+type LiveEventType string
+
+const (
+	LiveEventNotification     LiveEventType = "notification"      // A notification for the user; Data is the notification
+	LiveEventChapterUpdated   LiveEventType = "chapter.updated"   // A chapter was saved; Data has its IDs and revision
+	LiveEventCommentCreated   LiveEventType = "comment.created"   // A thread or reply; Data is the comment
+	LiveEventSuggestionStatus LiveEventType = "suggestion.status" // An AI suggestion changed status
+)
+
+// LiveEvent is pushed to the clients following its novel, except its actor's, or to
+// those of UserID alone when set. IDs increase, so a client resumes after the last one
+// it saw.
+type LiveEvent struct {
+	ID          int64           `json:"id"`
+	Type        LiveEventType   `json:"type"`
+	NovelID     *uuid.UUID      `json:"novelId,omitempty"`
+	UserID      *uuid.UUID      `json:"userId,omitempty"`
+	ActorUserID *uuid.UUID      `json:"actorUserId,omitempty"`
+	Data        json.RawMessage `json:"data"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ContentRating is the audience a novel is written for, from the most to the least
+// restrictive. It decides which AI output moderation lets through.
+type ContentRating string
+
+const (
+	ContentRatingGeneral ContentRating = "general"
+	ContentRatingTeen    ContentRating = "teen"
+	ContentRatingMature  ContentRating = "mature" // Adult novels
+)
+
+// contentRatingRanks orders the ratings; a rating allows content rated at or below it.
+var contentRatingRanks = map[ContentRating]int{
+	ContentRatingGeneral: 1,
+	ContentRatingTeen:    2,
+	ContentRatingMature:  3,
+}
+
+// Valid reports whether r is a known rating.
+func (r ContentRating) Valid() bool {
+	_, ok := contentRatingRanks[r]
+	return ok
+}
+
+// Allows reports whether content that requires the rating required is acceptable in a
+// novel rated r. Content that requires no rating (empty) is never acceptable.
+func (r ContentRating) Allows(required ContentRating) bool {
+	return required.Valid() && contentRatingRanks[r] >= contentRatingRanks[required]
+}
+
+// NovelModerationSettings is how sensitive the moderation of a novel's AI output is.
+// AllowedCategories lets findings of those categories through whatever their rating,
+// except findings no rating allows.
+type NovelModerationSettings struct {
+	NovelID           uuid.UUID     `json:"novelId"`
+	ContentRating     ContentRating `json:"contentRating"`
+	AllowedCategories []string      `json:"allowedCategories"`
+	UpdatedByUserID   *uuid.UUID    `json:"updatedByUserId,omitempty"`
+	UpdatedAt         *time.Time    `json:"updatedAt,omitempty"` // Nil for the defaults
+}
+
+// Allows reports whether a finding is acceptable in the novel.
+func (s *NovelModerationSettings) Allows(finding ModerationFinding) bool {
+	if !finding.Rating.Valid() {
+		return false
+	}
+	return s.ContentRating.Allows(finding.Rating) || slices.Contains(s.AllowedCategories, finding.Category)
+}
+
+// ModerationFinding is something a moderation classifier found in a text: the rule that
+// matched, its category (e.g. profanity) and the lowest rating that allows it, empty
+// when none does.
+type ModerationFinding struct {
+	Category string        `json:"category"`
+	Rule     string        `json:"rule"`
+	Rating   ContentRating `json:"rating,omitempty"`
+	Detail   string        `json:"detail,omitempty"`
+}
+
+// ModerationReveal records a user choosing to see flagged output.
+type ModerationReveal struct {
+	UserID     uuid.UUID `json:"userId"`
+	RevealedAt time.Time `json:"revealedAt"`
+}
+
+// AIModeration is the moderation outcome of a generation, kept in the suggestion's
+// generation_metadata.moderation. Flagged output is withheld from each user until the
+// user reveals it; Reasons are the findings the novel's settings do not allow.
+type AIModeration struct {
+	Flagged       bool                `json:"flagged"`
+	Classifier    string              `json:"classifier"`
+	ContentRating ContentRating       `json:"contentRating"` // The novel's when checked
+	Reasons       []ModerationFinding `json:"reasons,omitempty"`
+	Reveals       []ModerationReveal  `json:"reveals,omitempty"`
+}
+
+// RevealedTo reports whether the user revealed the output.
+func (m *AIModeration) RevealedTo(userID uuid.UUID) bool {
+	return slices.ContainsFunc(m.Reveals, func(r ModerationReveal) bool { return r.UserID == userID })
+}
+
+// Moderation decodes the moderation outcome from the generation metadata; nil when
+// the output was not moderated.
+func (s *AISuggestion) Moderation() *AIModeration {
+	raw, ok := s.GenerationMetadata["moderation"]
+	if !ok {
+		return nil
+	}
+	body, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	m := &AIModeration{}
+	if err := json.Unmarshal(body, m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// SetModeration stores the moderation outcome in the generation metadata.
+func (s *AISuggestion) SetModeration(m *AIModeration) {
+	if s.GenerationMetadata == nil {
+		s.GenerationMetadata = map[string]any{}
+	}
+	s.GenerationMetadata["moderation"] = m
+}
+
+// Withheld reports whether the suggestion's content is flagged and not revealed to the user.
+func (s *AISuggestion) Withheld(userID uuid.UUID) bool {
+	m := s.Moderation()
+	return m != nil && m.Flagged && !m.RevealedTo(userID)
+}
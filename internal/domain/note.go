@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NoteType mirrors the note_type enum.
+type NoteType string
+
+const (
+	NoteTypeGeneral          NoteType = "general"
+	NoteTypeCharacterBio     NoteType = "character_bio"
+	NoteTypePlaceDescription NoteType = "place_description"
+	NoteTypePlotPoint        NoteType = "plot_point"
+	NoteTypeResearch         NoteType = "research"
+	NoteTypeWorldRule        NoteType = "world_rule"
+	NoteTypeItem             NoteType = "item"
+	NoteTypeMagicSystem      NoteType = "magic_system"
+	NoteTypeSpecies          NoteType = "species"
+	NoteTypeOrganization     NoteType = "organization"
+)
+
+// Note is a worldbuilding, plot or research note shared by the novel's collaborators,
+// optionally linked to a chapter, character or place.
+type Note struct {
+	ID                uuid.UUID  `json:"id"`
+	NovelID           uuid.UUID  `json:"novelId"`
+	Title             string     `json:"title,omitempty"`
+	Content           string     `json:"content"`
+	Type              NoteType   `json:"noteType"`
+	Source            string     `json:"source"`
+	LinkedChapterID   *uuid.UUID `json:"linkedChapterId,omitempty"`
+	LinkedCharacterID *uuid.UUID `json:"linkedCharacterId,omitempty"`
+	LinkedPlaceID     *uuid.UUID `json:"linkedPlaceId,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+	CreatedByUserID   *uuid.UUID `json:"createdByUserId,omitempty"`
+}
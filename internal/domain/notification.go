@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationType is what happened that a user is notified of.
+type NotificationType string
+
+const (
+	NotificationCommentReply          NotificationType = "comment_reply"           // A reply in a thread the user took part in
+	NotificationMention               NotificationType = "mention"                 // A comment mentioning the user
+	NotificationCollaboratorInvite    NotificationType = "collaborator_invite"     // The user was added to a novel
+	NotificationAISuggestionGenerated NotificationType = "ai_suggestion_generated" // A suggestion the user requested is ready
+	NotificationChapterPublished      NotificationType = "chapter_published"       // A chapter of a novel the user works on
+)
+
+// Valid reports whether t is a known notification type.
+func (t NotificationType) Valid() bool {
+	switch t {
+	case NotificationCommentReply, NotificationMention, NotificationCollaboratorInvite,
+		NotificationAISuggestionGenerated, NotificationChapterPublished:
+		return true
+	}
+	return false
+}
+
+// NotificationEmailStatus is where a notification is in the email channel.
+type NotificationEmailStatus string
+
+const (
+	NotificationEmailPending NotificationEmailStatus = "pending" // Not handled yet
+	NotificationEmailSending NotificationEmailStatus = "sending" // Claimed by a notifier
+	NotificationEmailDigest  NotificationEmailStatus = "digest"  // Waiting for the recipient's daily digest
+	NotificationEmailSent    NotificationEmailStatus = "sent"
+	NotificationEmailSkipped NotificationEmailStatus = "skipped" // Turned off or too old to send
+	NotificationEmailFailed  NotificationEmailStatus = "failed"  // Gave up after repeated failures
+)
+
+// Notification is an entry of a user's inbox. SubjectType and SubjectID point at what it
+// is about; Data holds the IDs a client needs to open it.
+type Notification struct {
+	ID          uuid.UUID        `json:"id"`
+	UserID      uuid.UUID        `json:"userId"`
+	NovelID     *uuid.UUID       `json:"novelId,omitempty"`
+	ActorUserID *uuid.UUID       `json:"actorUserId,omitempty"`
+	Type        NotificationType `json:"type"`
+	SubjectType string           `json:"subjectType"`
+	SubjectID   uuid.UUID        `json:"subjectId"`
+	Message     string           `json:"message"`
+	Data        map[string]any   `json:"data"`
+	DedupeKey   string           `json:"-"`
+	ReadAt      *time.Time       `json:"readAt,omitempty"`
+	CreatedAt   time.Time        `json:"createdAt"`
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationEmailMode is how a user gets the emails of notifications.
+type NotificationEmailMode string
+
+const (
+	NotificationEmailInstant NotificationEmailMode = "instant" // An email per notification
+	NotificationEmailDaily   NotificationEmailMode = "daily"   // A daily digest
+	NotificationEmailOff     NotificationEmailMode = "off"
+)
+
+// Valid reports whether m is a known email mode.
+func (m NotificationEmailMode) Valid() bool {
+	switch m {
+	case NotificationEmailInstant, NotificationEmailDaily, NotificationEmailOff:
+		return true
+	}
+	return false
+}
+
+// NotificationPreference sets the email mode of a user's notifications of a novel, of
+// a type, of both, or of all notifications when neither is set. The most specific
+// preference applies: novel and type, then novel, then type, then all.
+type NotificationPreference struct {
+	ID        uuid.UUID             `json:"id"`
+	UserID    uuid.UUID             `json:"userId"`
+	NovelID   *uuid.UUID            `json:"novelId,omitempty"`
+	Type      *NotificationType     `json:"type,omitempty"`
+	EmailMode NotificationEmailMode `json:"emailMode"`
+	CreatedAt time.Time             `json:"createdAt"`
+	UpdatedAt time.Time             `json:"updatedAt"`
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Aggregate types of outbox events. Events of one aggregate are published in order.
+const (
+	OutboxAggregateChapter          = "chapter"
+	OutboxAggregateAISuggestion     = "ai_suggestion"
+	OutboxAggregateCommentThread    = "comment_thread"
+	OutboxAggregateNovel            = "novel"
+	OutboxAggregateConsistencyCheck = "consistency_check"
+)
+
+// OutboxEvent is a domain event waiting in the outbox to be published.
+type OutboxEvent struct {
+	ID            int64             `json:"id"`
+	AggregateType string            `json:"aggregateType"`
+	AggregateID   uuid.UUID         `json:"aggregateId"`
+	Topic         string            `json:"topic"`
+	Payload       json.RawMessage   `json:"payload"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	SentAt        *time.Time        `json:"sentAt,omitempty"`
+	Attempts      int               `json:"attempts"`
+	LastError     string            `json:"lastError,omitempty"`
+}
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PrivateNote is a personal note only its author can ever read, optionally
+// kept in the context of a novel.
+type PrivateNote struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"userId"`
+	NovelID   *uuid.UUID `json:"novelId,omitempty"`
+	Title     string     `json:"title,omitempty"`
+	Content   string     `json:"content"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
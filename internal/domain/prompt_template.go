@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Variables available to prompt templates.
+const (
+	PromptVarContext      = "context"       // Novel context assembled for the suggestion
+	PromptVarSelectedText = "selected_text" // Text the author selected
+	PromptVarInstructions = "instructions"  // The author's instructions
+)
+
+// PromptVariables lists every variable a template may reference.
+var PromptVariables = []string{PromptVarContext, PromptVarSelectedText, PromptVarInstructions}
+
+// PromptTemplate is one version of the prompt for a suggestion type. Templates are
+// immutable; a change is a new version. NovelID is set for a novel's override of the
+// global template.
+type PromptTemplate struct {
+	ID                uuid.UUID        `json:"id"`
+	SuggestionType    AISuggestionType `json:"suggestionType"`
+	NovelID           *uuid.UUID       `json:"novelId,omitempty"`
+	Version           int              `json:"version"`
+	SystemPrompt      string           `json:"systemPrompt"`
+	UserPrompt        string           `json:"userPrompt"`                  // Go text/template, e.g. {{.context}}
+	RequiredVariables []string         `json:"requiredVariables,omitempty"` // Must not be empty when rendering
+	Active            bool             `json:"active"`
+	Notes             string           `json:"notes,omitempty"`
+	CreatedByUserID   *uuid.UUID       `json:"createdByUserId,omitempty"`
+	CreatedAt         time.Time        `json:"createdAt"`
+}
+
+// Scope names whose template this is: "global" or "novel".
+func (t *PromptTemplate) Scope() string {
+	if t.NovelID != nil {
+		return "novel"
+	}
+	return "global"
+}
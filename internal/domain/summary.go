@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChapterSummary is the generated summary of a chapter. ContentHash identifies the
+// chapter content it summarizes; the summary is stale once the content changes.
+type ChapterSummary struct {
+	ChapterID    uuid.UUID  `json:"chapterId"`
+	NovelID      uuid.UUID  `json:"novelId"`
+	Summary      string     `json:"summary"`
+	ContentHash  string     `json:"-"`
+	SuggestionID *uuid.UUID `json:"suggestionId,omitempty"`
+	GeneratedAt  time.Time  `json:"generatedAt"`
+}
+
+// SynopsisBook is the act number of a novel's whole-book synopsis.
+const SynopsisBook = 0
+
+// Synopsis is the generated synopsis of an act of a novel, or of the whole book
+// (SynopsisBook). SourceHash identifies the input it was generated from; PendingHash,
+// when set, the input of the generation under way. SuggestionID is the latest
+// generation, nil when the input needed no summarizing.
+type Synopsis struct {
+	NovelID      uuid.UUID
+	Act          int
+	Synopsis     string
+	SourceHash   string
+	PendingHash  string
+	SuggestionID *uuid.UUID
+	GeneratedAt  *time.Time
+	UpdatedAt    time.Time
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TimelineEventLink ties a timeline event to an entity of the novel (a character,
+// place, chapter, ...).
+type TimelineEventLink struct {
+	EntityType string    `json:"entityType"`
+	EntityID   uuid.UUID `json:"entityId"`
+}
+
+// TimelineEvent is a point on a novel's story timeline, ordered by EventOrder.
+type TimelineEvent struct {
+	ID              uuid.UUID           `json:"id"`
+	NovelID         uuid.UUID           `json:"novelId"`
+	Title           string              `json:"title"`
+	Description     string              `json:"description,omitempty"`
+	EventOrder      int                 `json:"eventOrder"`
+	EventDateText   string              `json:"eventDateText,omitempty"` // In-story date, free form
+	Links           []TimelineEventLink `json:"links,omitempty"`
+	CreatedAt       time.Time           `json:"createdAt"`
+	UpdatedAt       time.Time           `json:"updatedAt"`
+	CreatedByUserID *uuid.UUID          `json:"createdByUserId,omitempty"`
+}
@@ -0,0 +1,52 @@
+// File: internal/email/sender.go
+
+// Package email delivers notifications by email: a pluggable sender interface with an
+// SMTP implementation, the templates of the notification and digest emails, and the
+// signed tokens of unsubscribe links.
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/khaled2049/server/internal/config"
+)
+
+// Sender providers.
+const (
+	ProviderSMTP = "smtp"
+	ProviderNone = "none"
+)
+
+// ErrUnknownProvider is returned for an unsupported EMAIL_PROVIDER.
+var ErrUnknownProvider = errors.New("unknown email provider")
+
+// Message is an email to a single recipient, with a plain text and an HTML body.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	// Headers are added to the standard ones, e.g. List-Unsubscribe
+	Headers map[string]string
+}
+
+// Sender delivers emails.
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewSender creates the sender selected by cfg.Provider: smtp, or none (default),
+// which returns a nil Sender and disables the email channel.
+func NewSender(cfg config.EmailConfig) (Sender, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case ProviderSMTP:
+		return NewSMTPSender(cfg)
+	case ProviderNone, "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, cfg.Provider)
+	}
+}
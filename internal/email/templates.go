@@ -0,0 +1,223 @@
+// File: internal/email/templates.go
+package email
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/khaled2049/server/internal/domain"
+)
+
+// NotificationData fills the email of a single notification.
+type NotificationData struct {
+	RecipientName string
+	Message       string
+	NovelTitle    string
+	Link          string // Opens the notification's subject in the app
+	// UnsubscribeURL turns off the emails of the notification's type; MuteNovelURL, set
+	// for notifications about a novel, those of its novel
+	UnsubscribeURL string
+	MuteNovelURL   string
+	PreferencesURL string
+}
+
+// DigestData fills a daily digest email.
+type DigestData struct {
+	RecipientName  string
+	Items          []DigestItem // Oldest first
+	UnsubscribeURL string       // Turns off all notification emails
+	PreferencesURL string
+}
+
+// DigestItem is a notification listed in a digest.
+type DigestItem struct {
+	Message    string
+	NovelTitle string
+	Link       string
+	CreatedAt  time.Time
+}
+
+// kindTemplate is the template source of a notification type: the subject, and the
+// "body" of the plain text and the HTML layouts.
+type kindTemplate struct {
+	subject string
+	text    string
+	html    string
+}
+
+var kindTemplates = map[domain.NotificationType]kindTemplate{
+	domain.NotificationCommentReply: {
+		subject: `New reply in {{.NovelTitle}}`,
+		text: `{{.Message}} on {{.NovelTitle}}.
+
+Read the reply: {{.Link}}`,
+		html: `<p>{{.Message}} on <strong>{{.NovelTitle}}</strong>.</p>
+<p><a href="{{.Link}}">Read the reply</a></p>`,
+	},
+	domain.NotificationMention: {
+		subject: `You were mentioned in {{.NovelTitle}}`,
+		text: `{{.Message}} on {{.NovelTitle}}.
+
+View the comment: {{.Link}}`,
+		html: `<p>{{.Message}} on <strong>{{.NovelTitle}}</strong>.</p>
+<p><a href="{{.Link}}">View the comment</a></p>`,
+	},
+	domain.NotificationCollaboratorInvite: {
+		subject: `You were added to {{.NovelTitle}}`,
+		text: `{{.Message}}.
+
+Open the novel: {{.Link}}`,
+		html: `<p>{{.Message}}.</p>
+<p><a href="{{.Link}}">Open the novel</a></p>`,
+	},
+	domain.NotificationAISuggestionGenerated: {
+		subject: `{{.Message}}`,
+		text: `{{.Message}} for {{.NovelTitle}}.
+
+Review it: {{.Link}}`,
+		html: `<p>{{.Message}} for <strong>{{.NovelTitle}}</strong>.</p>
+<p><a href="{{.Link}}">Review it</a></p>`,
+	},
+	domain.NotificationChapterPublished: {
+		subject: `New chapter in {{.NovelTitle}}`,
+		text: `{{.Message}}.
+
+Read the chapter: {{.Link}}`,
+		html: `<p>{{.Message}}.</p>
+<p><a href="{{.Link}}">Read the chapter</a></p>`,
+	},
+}
+
+const notificationTextLayout = `Hi {{.RecipientName}},
+
+{{template "body" .}}
+
+--
+You receive this email because of your NovelCraft notification settings.
+Stop emails like this one: {{.UnsubscribeURL}}
+{{- if .MuteNovelURL}}
+Stop all emails about {{.NovelTitle}}: {{.MuteNovelURL}}
+{{- end}}
+Manage your notifications: {{.PreferencesURL}}
+`
+
+const notificationHTMLLayout = `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; line-height: 1.5; color: #222;">
+<p>Hi {{.RecipientName}},</p>
+{{template "body" .}}
+<hr>
+<p style="font-size: 12px; color: #666;">
+You receive this email because of your NovelCraft notification settings.<br>
+<a href="{{.UnsubscribeURL}}">Stop emails like this one</a>
+{{- if .MuteNovelURL}} · <a href="{{.MuteNovelURL}}">Stop all emails about {{.NovelTitle}}</a>{{end}}
+· <a href="{{.PreferencesURL}}">Manage your notifications</a>
+</p>
+</body>
+</html>
+`
+
+const digestSubject = `Your NovelCraft digest: {{len .Items}} new notification{{if ne (len .Items) 1}}s{{end}}`
+
+const digestText = `Hi {{.RecipientName}},
+
+Here is what happened since your last digest:
+{{range .Items}}
+- {{if .NovelTitle}}[{{.NovelTitle}}] {{end}}{{.Message}}
+  {{.Link}}
+{{- end}}
+
+--
+You receive this daily digest because of your NovelCraft notification settings.
+Stop all notification emails: {{.UnsubscribeURL}}
+Manage your notifications: {{.PreferencesURL}}
+`
+
+const digestHTML = `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; line-height: 1.5; color: #222;">
+<p>Hi {{.RecipientName}},</p>
+<p>Here is what happened since your last digest:</p>
+<ul>
+{{- range .Items}}
+<li>{{if .NovelTitle}}<strong>{{.NovelTitle}}</strong>: {{end}}<a href="{{.Link}}">{{.Message}}</a></li>
+{{- end}}
+</ul>
+<hr>
+<p style="font-size: 12px; color: #666;">
+You receive this daily digest because of your NovelCraft notification settings.<br>
+<a href="{{.UnsubscribeURL}}">Stop all notification emails</a>
+· <a href="{{.PreferencesURL}}">Manage your notifications</a>
+</p>
+</body>
+</html>
+`
+
+type compiledTemplate struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// The templates are parsed once; a template that does not parse is a programming error.
+var (
+	notificationTemplates = compileNotificationTemplates()
+	digestTemplate        = compiledTemplate{
+		subject: texttemplate.Must(texttemplate.New("subject").Parse(digestSubject)),
+		text:    texttemplate.Must(texttemplate.New("text").Parse(digestText)),
+		html:    htmltemplate.Must(htmltemplate.New("html").Parse(digestHTML)),
+	}
+)
+
+func compileNotificationTemplates() map[domain.NotificationType]compiledTemplate {
+	textLayout := texttemplate.Must(texttemplate.New("text").Parse(notificationTextLayout))
+	htmlLayout := htmltemplate.Must(htmltemplate.New("html").Parse(notificationHTMLLayout))
+
+	compiled := make(map[domain.NotificationType]compiledTemplate, len(kindTemplates))
+	for kind, source := range kindTemplates {
+		compiled[kind] = compiledTemplate{
+			subject: texttemplate.Must(texttemplate.New("subject").Parse(source.subject)),
+			text:    texttemplate.Must(texttemplate.Must(textLayout.Clone()).New("body").Parse(source.text)),
+			html:    htmltemplate.Must(htmltemplate.Must(htmlLayout.Clone()).New("body").Parse(source.html)),
+		}
+	}
+	return compiled
+}
+
+// RenderNotification renders the email of a notification of the given type. The
+// recipient and headers of the message are left to the caller.
+func RenderNotification(kind domain.NotificationType, data NotificationData) (*Message, error) {
+	t, ok := notificationTemplates[kind]
+	if !ok {
+		return nil, fmt.Errorf("no email template for notification type %q", kind)
+	}
+	return t.render(data)
+}
+
+// RenderDigest renders a daily digest email.
+func RenderDigest(data DigestData) (*Message, error) {
+	return digestTemplate.render(data)
+}
+
+func (t compiledTemplate) render(data any) (*Message, error) {
+	var subject, text, html bytes.Buffer
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return nil, fmt.Errorf("failed to render email subject: %w", err)
+	}
+	if err := t.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return nil, fmt.Errorf("failed to render email text: %w", err)
+	}
+	if err := t.html.ExecuteTemplate(&html, "html", data); err != nil {
+		return nil, fmt.Errorf("failed to render email HTML: %w", err)
+	}
+	return &Message{
+		// Subjects are a single line
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,187 @@
+// File: internal/llm/client.go
+
+// Package llm defines the provider-neutral client used by the AI pipeline and its
+// adapters: OpenAI-compatible APIs, Ollama-style APIs and a deterministic fake.
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/khaled2049/server/internal/config"
+)
+
+// Provider names accepted in config.LLMConfig.Provider.
+const (
+	ProviderOpenAI = "openai"
+	ProviderOllama = "ollama"
+	ProviderFake   = "fake"
+)
+
+// Role is the author of a chat message.
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Finish reasons reported in Response.FinishReason.
+const (
+	FinishStop   = "stop"
+	FinishLength = "length"
+)
+
+// Message is a single chat turn.
+type Message struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+}
+
+// CompletionRequest asks for a plain text continuation of Prompt.
+// An empty Model uses the client's configured model.
+type CompletionRequest struct {
+	Model       string
+	Prompt      string
+	MaxTokens   int
+	Temperature float64
+	Stop        []string
+}
+
+// ChatRequest asks for the next assistant message.
+type ChatRequest struct {
+	Model       string
+	Messages    []Message
+	MaxTokens   int
+	Temperature float64
+	Stop        []string
+}
+
+// Usage reports token consumption of a request.
+type Usage struct {
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+	TotalTokens      int `json:"totalTokens"`
+}
+
+// Response is the result of a completion or chat request.
+type Response struct {
+	Text         string `json:"text"`
+	Model        string `json:"model"`
+	FinishReason string `json:"finishReason"`
+	Usage        Usage  `json:"usage"`
+}
+
+// StreamChunk is an incremental piece of a streamed response.
+type StreamChunk struct {
+	Text string
+}
+
+// ModelInfo describes the model a client talks to.
+type ModelInfo struct {
+	Name            string `json:"name"`
+	Provider        string `json:"provider"`
+	ContextWindow   int    `json:"contextWindow"`   // Total tokens (prompt + output)
+	MaxOutputTokens int    `json:"maxOutputTokens"` // 0 if the provider does not report a limit
+}
+
+// Client is implemented by every LLM provider adapter.
+type Client interface {
+	Complete(ctx context.Context, req CompletionRequest) (*Response, error)
+	Chat(ctx context.Context, req ChatRequest) (*Response, error)
+	// ChatStream calls onChunk for each piece of the response as it arrives and returns
+	// the assembled response. An error from onChunk aborts the stream and is returned.
+	ChatStream(ctx context.Context, req ChatRequest, onChunk func(StreamChunk) error) (*Response, error)
+	CountTokens(ctx context.Context, text string) (int, error)
+	ModelInfo(ctx context.Context) (*ModelInfo, error)
+}
+
+var (
+	// ErrEmptyRequest is returned for requests without a prompt or messages.
+	ErrEmptyRequest = errors.New("llm: empty request")
+	// ErrUnknownProvider is returned by NewClient for unsupported provider names.
+	ErrUnknownProvider = errors.New("llm: unknown provider")
+)
+
+// APIError is returned when a provider responds with a non-2xx status.
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("llm: %s returned status %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// Temporary reports whether retrying the request may succeed (rate limits, server errors).
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// NewClient creates the client selected by cfg.Provider.
+func NewClient(cfg config.LLMConfig) (Client, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case ProviderOpenAI:
+		return NewOpenAIClient(cfg), nil
+	case ProviderOllama:
+		return NewOllamaClient(cfg), nil
+	case ProviderFake, "":
+		return NewFakeClient(cfg.Model), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, cfg.Provider)
+	}
+}
+
+// EstimateTokens approximates the token count of text for providers without a
+// tokenizer endpoint: roughly four characters per token, and at least one token
+// per word or punctuation mark.
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	words := 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			inWord = false
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			words++
+			inWord = false
+		case !inWord:
+			words++
+			inWord = true
+		}
+	}
+
+	byLength := int(math.Ceil(float64(utf8.RuneCountInString(text)) / 4))
+	return max(words, byLength)
+}
+
+// chatPrompt flattens chat messages for token estimates and the fake provider.
+func chatPrompt(messages []Message) string {
+	var b strings.Builder
+	for _, m := range messages {
+		b.WriteString(string(m.Role))
+		b.WriteString(": ")
+		b.WriteString(m.Content)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func validateChat(req ChatRequest) error {
+	if len(req.Messages) == 0 {
+		return ErrEmptyRequest
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+// File: internal/llm/embedding.go
+package llm
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/khaled2049/server/internal/config"
+)
+
+const defaultFakeEmbeddingDimensions = 256
+
+// Embedder turns texts into vectors for semantic search. Vectors are only comparable
+// when they come from the same model.
+type Embedder interface {
+	// Embed returns one vector per input, in input order.
+	Embed(ctx context.Context, inputs []string) ([][]float32, error)
+	// Model names the model the vectors come from.
+	Model() string
+}
+
+// NewEmbedder creates the embedder selected by cfg.Provider.
+func NewEmbedder(cfg config.EmbeddingConfig) (Embedder, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case ProviderOpenAI:
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = defaultOpenAIBaseURL
+		}
+		return &openAIEmbedder{
+			http:       newHTTPTransport(ProviderOpenAI, baseURL, cfg.APIKey, cfg.Timeout),
+			model:      cfg.Model,
+			dimensions: cfg.Dimensions,
+		}, nil
+	case ProviderOllama:
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = defaultOllamaBaseURL
+		}
+		return &ollamaEmbedder{
+			http:  newHTTPTransport(ProviderOllama, baseURL, cfg.APIKey, cfg.Timeout),
+			model: cfg.Model,
+		}, nil
+	case ProviderFake, "":
+		return NewFakeEmbedder(cfg.Dimensions), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, cfg.Provider)
+	}
+}
+
+// openAIEmbedder calls POST /embeddings of an OpenAI-compatible API.
+type openAIEmbedder struct {
+	http       *httpTransport
+	model      string
+	dimensions int // Requested from models that can shorten their vectors; 0 for the default
+}
+
+type openAIEmbeddingRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type openAIEmbeddingResponse struct {
+	Model string `json:"model"`
+	Data  []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func (e *openAIEmbedder) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	if len(inputs) == 0 {
+		return nil, ErrEmptyRequest
+	}
+
+	var out openAIEmbeddingResponse
+	err := e.http.postJSON(ctx, "/embeddings", openAIEmbeddingRequest{
+		Model:      e.model,
+		Input:      inputs,
+		Dimensions: e.dimensions,
+	}, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, len(inputs))
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("llm: %s returned an embedding for unknown input %d", ProviderOpenAI, d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return checkEmbeddings(ProviderOpenAI, vectors)
+}
+
+func (e *openAIEmbedder) Model() string {
+	return e.model
+}
+
+// ollamaEmbedder calls POST /api/embed of an Ollama-style API.
+type ollamaEmbedder struct {
+	http  *httpTransport
+	model string
+}
+
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+func (e *ollamaEmbedder) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	if len(inputs) == 0 {
+		return nil, ErrEmptyRequest
+	}
+
+	var out ollamaEmbedResponse
+	if err := e.http.postJSON(ctx, "/api/embed", ollamaEmbedRequest{Model: e.model, Input: inputs}, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Embeddings) != len(inputs) {
+		return nil, fmt.Errorf("llm: %s returned %d embeddings for %d inputs", ProviderOllama, len(out.Embeddings), len(inputs))
+	}
+	return checkEmbeddings(ProviderOllama, out.Embeddings)
+}
+
+func (e *ollamaEmbedder) Model() string {
+	return e.model
+}
+
+func checkEmbeddings(provider string, vectors [][]float32) ([][]float32, error) {
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return nil, fmt.Errorf("llm: %s returned no embedding for input %d", provider, i)
+		}
+	}
+	return vectors, nil
+}
+
+// FakeEmbedder is a deterministic, offline Embedder: each word is hashed into one of
+// Dimensions buckets, so texts sharing words get similar vectors. It is good enough to
+// develop and test semantic search without a model, not to capture meaning.
+type FakeEmbedder struct {
+	Dimensions int
+}
+
+// NewFakeEmbedder creates a fake embedder; dimensions <= 0 uses 256.
+func NewFakeEmbedder(dimensions int) *FakeEmbedder {
+	if dimensions <= 0 {
+		dimensions = defaultFakeEmbeddingDimensions
+	}
+	return &FakeEmbedder{Dimensions: dimensions}
+}
+
+// Embed returns the normalized, hashed word counts of each input.
+func (e *FakeEmbedder) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	if len(inputs) == 0 {
+		return nil, ErrEmptyRequest
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, len(inputs))
+	for i, input := range inputs {
+		v := make([]float32, e.Dimensions)
+		words := strings.FieldsFunc(strings.ToLower(input), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		for _, word := range words {
+			h := fnv.New32a()
+			h.Write([]byte(word))
+			sum := h.Sum32()
+			// The top bit picks the sign so unrelated words tend to cancel out
+			if sum&(1<<31) != 0 {
+				v[int(sum%uint32(e.Dimensions))]--
+			} else {
+				v[int(sum%uint32(e.Dimensions))]++
+			}
+		}
+		normalize(v)
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+func (e *FakeEmbedder) Model() string {
+	return fmt.Sprintf("fake-embedding-%d", e.Dimensions)
+}
+
+// normalize scales v to unit length; zero vectors are left alone.
+func normalize(v []float32) {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range v {
+		v[i] *= scale
+	}
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b, or 0 when their
+// lengths differ or either is zero.
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
@@ -0,0 +1,203 @@
+// File: internal/llm/fake.go
+package llm
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	defaultFakeModel         = "fake-model"
+	defaultFakeContextWindow = 8192
+	defaultFakeOutputTokens  = 64
+)
+
+// fakeVocabulary is the word pool the fake provider writes prose from.
+var fakeVocabulary = strings.Fields(`
+	the a an and but while as night morning river city tower road forest storm light
+	shadow voice door window letter sword map ship harbor garden bell lantern stone
+	walked waited whispered remembered turned listened watched opened crossed followed
+	quiet distant old silver narrow bright cold hidden gentle restless familiar broken
+	she he they someone nobody everyone again slowly suddenly still almost never once`)
+
+// FakeClient is a deterministic, offline Client: the same request always produces the
+// same response. It lets the AI pipeline be developed and tested without a model.
+type FakeClient struct {
+	Model         string
+	ContextWindow int
+	// Respond, when set, replaces the generated text (e.g. to script responses in tests).
+	Respond func(prompt string) string
+	// ChunkDelay slows down ChatStream between chunks to mimic a real model.
+	ChunkDelay time.Duration
+}
+
+// NewFakeClient creates a fake client; an empty model name uses "fake-model".
+func NewFakeClient(model string) *FakeClient {
+	if model == "" {
+		model = defaultFakeModel
+	}
+	return &FakeClient{Model: model, ContextWindow: defaultFakeContextWindow}
+}
+
+// Complete returns deterministic text derived from the prompt.
+func (c *FakeClient) Complete(ctx context.Context, req CompletionRequest) (*Response, error) {
+	if req.Prompt == "" {
+		return nil, ErrEmptyRequest
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.generate(c.model(req.Model), req.Prompt, req.MaxTokens, req.Stop), nil
+}
+
+// Chat returns deterministic text derived from the conversation.
+func (c *FakeClient) Chat(ctx context.Context, req ChatRequest) (*Response, error) {
+	if err := validateChat(req); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.generate(c.model(req.Model), chatPrompt(req.Messages), req.MaxTokens, req.Stop), nil
+}
+
+// ChatStream emits the Chat response word by word.
+func (c *FakeClient) ChatStream(ctx context.Context, req ChatRequest, onChunk func(StreamChunk) error) (*Response, error) {
+	resp, err := c.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, chunk := range splitChunks(resp.Text) {
+		if c.ChunkDelay > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.ChunkDelay):
+			}
+		} else if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := onChunk(StreamChunk{Text: chunk}); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// CountTokens uses the shared estimate so budgets behave like the HTTP adapters.
+func (c *FakeClient) CountTokens(_ context.Context, text string) (int, error) {
+	return EstimateTokens(text), nil
+}
+
+// ModelInfo describes the fake model.
+func (c *FakeClient) ModelInfo(_ context.Context) (*ModelInfo, error) {
+	window := c.ContextWindow
+	if window <= 0 {
+		window = defaultFakeContextWindow
+	}
+	return &ModelInfo{Name: c.Model, Provider: ProviderFake, ContextWindow: window}, nil
+}
+
+func (c *FakeClient) generate(model, prompt string, maxTokens int, stop []string) *Response {
+	if maxTokens <= 0 {
+		maxTokens = defaultFakeOutputTokens
+	}
+
+	var text string
+	reason := FinishStop
+	if c.Respond != nil {
+		text = c.Respond(prompt)
+	} else {
+		var truncated bool
+		text, truncated = fakeProse(model+"\x00"+prompt, maxTokens)
+		if truncated {
+			reason = FinishLength
+		}
+	}
+
+	for _, s := range stop {
+		if i := strings.Index(text, s); s != "" && i >= 0 {
+			text, reason = text[:i], FinishStop
+		}
+	}
+
+	return &Response{
+		Text:         text,
+		Model:        model,
+		FinishReason: reason,
+		Usage:        estimateUsage(prompt, text),
+	}
+}
+
+func (c *FakeClient) model(requested string) string {
+	if requested != "" {
+		return requested
+	}
+	return c.Model
+}
+
+// fakeProse writes two to four sentences from fakeVocabulary, seeded by seed.
+// truncated reports that the text was cut short by the maxTokens estimate.
+func fakeProse(seed string, maxTokens int) (text string, truncated bool) {
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	state := h.Sum64()
+	next := func() uint64 { // splitmix64
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		return z ^ (z >> 31)
+	}
+
+	var b strings.Builder
+	sentences := 2 + int(next()%3)
+	for sentences > 0 {
+		length := 6 + int(next()%9)
+		for i := 0; i < length; i++ {
+			word := fakeVocabulary[next()%uint64(len(fakeVocabulary))]
+			sep := " "
+			if i == 0 {
+				runes := []rune(word)
+				runes[0] = unicode.ToUpper(runes[0])
+				word = string(runes)
+			}
+			if b.Len() == 0 {
+				sep = ""
+			}
+
+			// Keep room for the closing period
+			if b.Len() > 0 && EstimateTokens(b.String()+sep+word+".") > maxTokens {
+				if i > 0 {
+					b.WriteString(".")
+				}
+				return b.String(), true
+			}
+			b.WriteString(sep)
+			b.WriteString(word)
+		}
+		b.WriteString(".")
+		sentences--
+	}
+	return b.String(), false
+}
+
+// splitChunks splits text into word-sized chunks that concatenate back to text.
+func splitChunks(text string) []string {
+	var chunks []string
+	start := 0
+	for i, r := range text {
+		if r == ' ' && i > start {
+			chunks = append(chunks, text[start:i])
+			start = i
+		}
+	}
+	if start < len(text) {
+		chunks = append(chunks, text[start:])
+	}
+	return chunks
+}
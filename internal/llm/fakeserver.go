@@ -0,0 +1,312 @@
+// File: internal/llm/fakeserver.go
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// NewFakeServer returns an HTTP handler that serves the OpenAI-compatible (/v1/...) and
+// Ollama-style (/api/...) endpoints used by the adapters, answered by the fake provider
+// and, for embeddings, a default FakeEmbedder. Point LLM_BASE_URL (or
+// EMBEDDING_BASE_URL) at it to run the real adapters fully offline.
+func NewFakeServer(fake *FakeClient) http.Handler {
+	s := &fakeServer{fake: fake, embedder: NewFakeEmbedder(0)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/completions", s.openAICompletions)
+	mux.HandleFunc("POST /v1/chat/completions", s.openAIChat)
+	mux.HandleFunc("GET /v1/models", s.openAIModels)
+	mux.HandleFunc("GET /v1/models/{model}", s.openAIModel)
+	mux.HandleFunc("POST /v1/embeddings", s.openAIEmbeddings)
+	mux.HandleFunc("POST /api/generate", s.ollamaGenerate)
+	mux.HandleFunc("POST /api/chat", s.ollamaChat)
+	mux.HandleFunc("POST /api/show", s.ollamaShow)
+	mux.HandleFunc("GET /api/tags", s.ollamaTags)
+	mux.HandleFunc("POST /api/embed", s.ollamaEmbed)
+	return mux
+}
+
+type fakeServer struct {
+	fake     *FakeClient
+	embedder *FakeEmbedder
+}
+
+// OpenAI-compatible endpoints
+
+func (s *fakeServer) openAICompletions(w http.ResponseWriter, r *http.Request) {
+	var req openAICompletionRequest
+	if !decodeFakeRequest(w, r, &req) {
+		return
+	}
+
+	resp, err := s.fake.Complete(r.Context(), CompletionRequest{
+		Model: req.Model, Prompt: req.Prompt, MaxTokens: req.MaxTokens, Stop: req.Stop,
+	})
+	if err != nil {
+		writeFakeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeFakeJSON(w, map[string]any{
+		"object": "text_completion",
+		"model":  resp.Model,
+		"choices": []map[string]any{
+			{"index": 0, "text": resp.Text, "finish_reason": resp.FinishReason},
+		},
+		"usage": openAIUsageOf(resp.Usage),
+	})
+}
+
+func (s *fakeServer) openAIChat(w http.ResponseWriter, r *http.Request) {
+	var req openAIChatRequest
+	if !decodeFakeRequest(w, r, &req) {
+		return
+	}
+	chat := ChatRequest{Model: req.Model, Messages: req.Messages, MaxTokens: req.MaxTokens, Stop: req.Stop}
+
+	if !req.Stream {
+		resp, err := s.fake.Chat(r.Context(), chat)
+		if err != nil {
+			writeFakeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeFakeJSON(w, map[string]any{
+			"object": "chat.completion",
+			"model":  resp.Model,
+			"choices": []map[string]any{{
+				"index":         0,
+				"message":       Message{Role: RoleAssistant, Content: resp.Text},
+				"finish_reason": resp.FinishReason,
+			}},
+			"usage": openAIUsageOf(resp.Usage),
+		})
+		return
+	}
+
+	if err := validateChat(chat); err != nil {
+		writeFakeError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	event := func(payload any) {
+		data, _ := json.Marshal(payload)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+
+	model := s.fake.model(req.Model)
+	resp, err := s.fake.ChatStream(r.Context(), chat, func(chunk StreamChunk) error {
+		event(map[string]any{
+			"object":  "chat.completion.chunk",
+			"model":   model,
+			"choices": []map[string]any{{"index": 0, "delta": Message{Content: chunk.Text}}},
+		})
+		return nil
+	})
+	if err != nil {
+		return // Client went away
+	}
+
+	event(map[string]any{
+		"object":  "chat.completion.chunk",
+		"model":   model,
+		"choices": []map[string]any{{"index": 0, "delta": map[string]any{}, "finish_reason": resp.FinishReason}},
+	})
+	if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+		event(map[string]any{"object": "chat.completion.chunk", "model": model, "choices": []any{}, "usage": openAIUsageOf(resp.Usage)})
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+func (s *fakeServer) openAIModels(w http.ResponseWriter, r *http.Request) {
+	info, _ := s.fake.ModelInfo(r.Context())
+	writeFakeJSON(w, map[string]any{
+		"object": "list",
+		"data":   []map[string]any{{"id": info.Name, "object": "model", "owned_by": ProviderFake}},
+	})
+}
+
+func (s *fakeServer) openAIModel(w http.ResponseWriter, r *http.Request) {
+	info, _ := s.fake.ModelInfo(r.Context())
+	writeFakeJSON(w, map[string]any{
+		"id":             r.PathValue("model"),
+		"object":         "model",
+		"owned_by":       ProviderFake,
+		"context_length": info.ContextWindow,
+	})
+}
+
+func (s *fakeServer) openAIEmbeddings(w http.ResponseWriter, r *http.Request) {
+	var req openAIEmbeddingRequest
+	if !decodeFakeRequest(w, r, &req) {
+		return
+	}
+
+	vectors, err := s.embedder.Embed(r.Context(), req.Input)
+	if err != nil {
+		writeFakeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	data := make([]map[string]any, len(vectors))
+	for i, v := range vectors {
+		data[i] = map[string]any{"object": "embedding", "index": i, "embedding": v}
+	}
+	writeFakeJSON(w, map[string]any{"object": "list", "model": s.embedder.Model(), "data": data})
+}
+
+// Ollama-style endpoints; like Ollama, stream defaults to true.
+
+type fakeOllamaRequest struct {
+	Model    string        `json:"model"`
+	Prompt   string        `json:"prompt"`
+	Messages []Message     `json:"messages"`
+	Stream   *bool         `json:"stream"`
+	Options  ollamaOptions `json:"options"`
+}
+
+func (req *fakeOllamaRequest) streaming() bool {
+	return req.Stream == nil || *req.Stream
+}
+
+func (s *fakeServer) ollamaGenerate(w http.ResponseWriter, r *http.Request) {
+	var req fakeOllamaRequest
+	if !decodeFakeRequest(w, r, &req) {
+		return
+	}
+
+	resp, err := s.fake.Complete(r.Context(), CompletionRequest{
+		Model: req.Model, Prompt: req.Prompt, MaxTokens: req.Options.NumPredict, Stop: req.Options.Stop,
+	})
+	if err != nil {
+		writeFakeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !req.streaming() {
+		writeFakeJSON(w, ollamaDone(resp, map[string]any{"response": resp.Text}))
+		return
+	}
+	s.writeNDJSON(w, r, resp, func(chunk string) map[string]any {
+		return map[string]any{"model": resp.Model, "response": chunk, "done": false}
+	}, map[string]any{"response": ""})
+}
+
+func (s *fakeServer) ollamaChat(w http.ResponseWriter, r *http.Request) {
+	var req fakeOllamaRequest
+	if !decodeFakeRequest(w, r, &req) {
+		return
+	}
+
+	resp, err := s.fake.Chat(r.Context(), ChatRequest{
+		Model: req.Model, Messages: req.Messages, MaxTokens: req.Options.NumPredict, Stop: req.Options.Stop,
+	})
+	if err != nil {
+		writeFakeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !req.streaming() {
+		writeFakeJSON(w, ollamaDone(resp, map[string]any{"message": Message{Role: RoleAssistant, Content: resp.Text}}))
+		return
+	}
+	s.writeNDJSON(w, r, resp, func(chunk string) map[string]any {
+		return map[string]any{"model": resp.Model, "message": Message{Role: RoleAssistant, Content: chunk}, "done": false}
+	}, map[string]any{"message": Message{Role: RoleAssistant}})
+}
+
+func (s *fakeServer) ollamaShow(w http.ResponseWriter, r *http.Request) {
+	info, _ := s.fake.ModelInfo(r.Context())
+	writeFakeJSON(w, map[string]any{
+		"details":    map[string]any{"family": ProviderFake},
+		"model_info": map[string]any{ProviderFake + ".context_length": info.ContextWindow},
+	})
+}
+
+func (s *fakeServer) ollamaTags(w http.ResponseWriter, r *http.Request) {
+	info, _ := s.fake.ModelInfo(r.Context())
+	writeFakeJSON(w, map[string]any{"models": []map[string]any{{"name": info.Name, "model": info.Name}}})
+}
+
+func (s *fakeServer) ollamaEmbed(w http.ResponseWriter, r *http.Request) {
+	var req ollamaEmbedRequest
+	if !decodeFakeRequest(w, r, &req) {
+		return
+	}
+
+	vectors, err := s.embedder.Embed(r.Context(), req.Input)
+	if err != nil {
+		writeFakeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeFakeJSON(w, map[string]any{"model": s.embedder.Model(), "embeddings": vectors})
+}
+
+// writeNDJSON streams resp word by word as newline-delimited JSON, then a final done line.
+func (s *fakeServer) writeNDJSON(
+	w http.ResponseWriter,
+	r *http.Request,
+	resp *Response,
+	line func(chunk string) map[string]any,
+	done map[string]any,
+) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	for _, chunk := range splitChunks(resp.Text) {
+		if s.fake.ChunkDelay > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(s.fake.ChunkDelay):
+			}
+		}
+		enc.Encode(line(chunk))
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+	enc.Encode(ollamaDone(resp, done))
+}
+
+func ollamaDone(resp *Response, fields map[string]any) map[string]any {
+	out := map[string]any{
+		"model":             resp.Model,
+		"done":              true,
+		"done_reason":       resp.FinishReason,
+		"prompt_eval_count": resp.Usage.PromptTokens,
+		"eval_count":        resp.Usage.CompletionTokens,
+	}
+	for k, v := range fields {
+		out[k] = v
+	}
+	return out
+}
+
+func openAIUsageOf(u Usage) openAIUsage {
+	return openAIUsage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens, TotalTokens: u.TotalTokens}
+}
+
+func decodeFakeRequest(w http.ResponseWriter, r *http.Request, out any) bool {
+	if err := json.NewDecoder(r.Body).Decode(out); err != nil {
+		writeFakeError(w, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
+
+func writeFakeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeFakeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
+}
@@ -0,0 +1,133 @@
+// File: internal/llm/http.go
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxErrorBody bounds how much of an error response is kept in APIError.
+const maxErrorBody = 2048
+
+// connectTimeout bounds dialing the provider and the TLS handshake.
+const connectTimeout = 10 * time.Second
+
+// httpTransport is the JSON-over-HTTP plumbing shared by the provider adapters.
+type httpTransport struct {
+	provider string
+	baseURL  string
+	apiKey   string
+	timeout  time.Duration
+	client   *http.Client
+}
+
+// newHTTPTransport bounds a whole JSON exchange by timeout, but only the wait for the
+// response headers of a stream: http.Client.Timeout would cut long streams, which end
+// with the caller's context instead.
+func newHTTPTransport(provider, baseURL, apiKey string, timeout time.Duration) *httpTransport {
+	if timeout <= 0 {
+		timeout = 120 * time.Second
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+	transport.ResponseHeaderTimeout = timeout
+	return &httpTransport{
+		provider: provider,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		apiKey:   apiKey,
+		timeout:  timeout,
+		client:   &http.Client{Transport: transport},
+	}
+}
+
+// postJSON sends body as JSON and decodes a 2xx response into out.
+func (t *httpTransport) postJSON(ctx context.Context, path string, body, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	resp, err := t.do(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return t.decode(resp, out)
+}
+
+// getJSON decodes a 2xx response to a GET request into out.
+func (t *httpTransport) getJSON(ctx context.Context, path string, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	resp, err := t.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return t.decode(resp, out)
+}
+
+// postStream sends body as JSON and returns the open response body for streaming.
+// The caller must close it, and bounds the stream with ctx.
+func (t *httpTransport) postStream(ctx context.Context, path string, body any) (io.ReadCloser, error) {
+	resp, err := t.do(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.checkStatus(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (t *httpTransport) do(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("llm: failed to encode %s request: %w", t.provider, err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, t.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("llm: failed to build %s request: %w", t.provider, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if t.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("llm: %s request failed: %w", t.provider, err)
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) decode(resp *http.Response, out any) error {
+	if err := t.checkStatus(resp); err != nil {
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("llm: failed to decode %s response: %w", t.provider, err)
+	}
+	return nil
+}
+
+func (t *httpTransport) checkStatus(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return &APIError{Provider: t.provider, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+}
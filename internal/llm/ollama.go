@@ -0,0 +1,219 @@
+// File: internal/llm/ollama.go
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/khaled2049/server/internal/config"
+)
+
+const defaultOllamaBaseURL = "http://localhost:11434"
+
+// ollamaClient talks to Ollama-style APIs (/api/generate, /api/chat, /api/show).
+type ollamaClient struct {
+	http   *httpTransport
+	config config.LLMConfig
+}
+
+// NewOllamaClient creates a client for an Ollama-style API.
+func NewOllamaClient(cfg config.LLMConfig) Client {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOllamaBaseURL
+	}
+	return &ollamaClient{
+		http:   newHTTPTransport(ProviderOllama, baseURL, cfg.APIKey, cfg.Timeout),
+		config: cfg,
+	}
+}
+
+// Wire types of the Ollama API
+
+type ollamaOptions struct {
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Temperature float64  `json:"temperature"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+type ollamaGenerateRequest struct {
+	Model   string        `json:"model"`
+	Prompt  string        `json:"prompt"`
+	Stream  bool          `json:"stream"`
+	Options ollamaOptions `json:"options"`
+}
+
+type ollamaChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []Message     `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Options  ollamaOptions `json:"options"`
+}
+
+// ollamaResponse covers /api/generate (Response) and /api/chat (Message) replies and stream lines.
+type ollamaResponse struct {
+	Model           string  `json:"model"`
+	Response        string  `json:"response"`
+	Message         Message `json:"message"`
+	Done            bool    `json:"done"`
+	DoneReason      string  `json:"done_reason"`
+	PromptEvalCount int     `json:"prompt_eval_count"`
+	EvalCount       int     `json:"eval_count"`
+	Error           string  `json:"error"`
+}
+
+func (r *ollamaResponse) toResponse(text string) *Response {
+	reason := r.DoneReason
+	if reason == "" {
+		reason = FinishStop
+	}
+	return &Response{
+		Text:         text,
+		Model:        r.Model,
+		FinishReason: reason,
+		Usage: Usage{
+			PromptTokens:     r.PromptEvalCount,
+			CompletionTokens: r.EvalCount,
+			TotalTokens:      r.PromptEvalCount + r.EvalCount,
+		},
+	}
+}
+
+type ollamaShowResponse struct {
+	Details struct {
+		Family string `json:"family"`
+	} `json:"details"`
+	ModelInfo map[string]any `json:"model_info"`
+}
+
+// Complete calls POST /api/generate.
+func (c *ollamaClient) Complete(ctx context.Context, req CompletionRequest) (*Response, error) {
+	if req.Prompt == "" {
+		return nil, ErrEmptyRequest
+	}
+
+	var out ollamaResponse
+	err := c.http.postJSON(ctx, "/api/generate", ollamaGenerateRequest{
+		Model:   c.model(req.Model),
+		Prompt:  req.Prompt,
+		Options: c.options(req.MaxTokens, req.Temperature, req.Stop),
+	}, &out)
+	if err != nil {
+		return nil, err
+	}
+
+	return out.toResponse(out.Response), nil
+}
+
+// Chat calls POST /api/chat.
+func (c *ollamaClient) Chat(ctx context.Context, req ChatRequest) (*Response, error) {
+	if err := validateChat(req); err != nil {
+		return nil, err
+	}
+
+	var out ollamaResponse
+	if err := c.http.postJSON(ctx, "/api/chat", c.chatRequest(req, false), &out); err != nil {
+		return nil, err
+	}
+
+	return out.toResponse(out.Message.Content), nil
+}
+
+// ChatStream calls POST /api/chat with stream=true and reads newline-delimited JSON.
+func (c *ollamaClient) ChatStream(ctx context.Context, req ChatRequest, onChunk func(StreamChunk) error) (*Response, error) {
+	if err := validateChat(req); err != nil {
+		return nil, err
+	}
+
+	body, err := c.http.postStream(ctx, "/api/chat", c.chatRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var text strings.Builder
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var event ollamaResponse
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return nil, fmt.Errorf("llm: failed to decode %s stream line: %w", ProviderOllama, err)
+		}
+		if event.Error != "" {
+			return nil, fmt.Errorf("llm: %s stream error: %s", ProviderOllama, event.Error)
+		}
+		if event.Message.Content != "" {
+			text.WriteString(event.Message.Content)
+			if err := onChunk(StreamChunk{Text: event.Message.Content}); err != nil {
+				return nil, err
+			}
+		}
+		if event.Done {
+			return event.toResponse(text.String()), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("llm: %s stream interrupted: %w", ProviderOllama, err)
+	}
+	return nil, fmt.Errorf("llm: %s stream ended before completion", ProviderOllama)
+}
+
+// CountTokens estimates locally; Ollama has no stable tokenizer endpoint.
+func (c *ollamaClient) CountTokens(_ context.Context, text string) (int, error) {
+	return EstimateTokens(text), nil
+}
+
+// ModelInfo calls POST /api/show and reads "<family>.context_length" from model_info.
+func (c *ollamaClient) ModelInfo(ctx context.Context) (*ModelInfo, error) {
+	var out ollamaShowResponse
+	if err := c.http.postJSON(ctx, "/api/show", map[string]string{"model": c.model("")}, &out); err != nil {
+		return nil, err
+	}
+
+	info := &ModelInfo{
+		Name:            c.model(""),
+		Provider:        ProviderOllama,
+		ContextWindow:   c.config.ContextWindow,
+		MaxOutputTokens: c.config.MaxOutputTokens,
+	}
+	for key, value := range out.ModelInfo {
+		if !strings.HasSuffix(key, ".context_length") {
+			continue
+		}
+		if length, ok := value.(float64); ok && length > 0 {
+			info.ContextWindow = int(length)
+		}
+	}
+	return info, nil
+}
+
+func (c *ollamaClient) chatRequest(req ChatRequest, stream bool) ollamaChatRequest {
+	return ollamaChatRequest{
+		Model:    c.model(req.Model),
+		Messages: req.Messages,
+		Stream:   stream,
+		Options:  c.options(req.MaxTokens, req.Temperature, req.Stop),
+	}
+}
+
+func (c *ollamaClient) options(maxTokens int, temperature float64, stop []string) ollamaOptions {
+	if maxTokens <= 0 {
+		maxTokens = c.config.MaxOutputTokens
+	}
+	return ollamaOptions{NumPredict: maxTokens, Temperature: temperature, Stop: stop}
+}
+
+func (c *ollamaClient) model(requested string) string {
+	if requested != "" {
+		return requested
+	}
+	return c.config.Model
+}
@@ -0,0 +1,269 @@
+// File: internal/llm/openai.go
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/khaled2049/server/internal/config"
+)
+
+const defaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// openAIClient talks to OpenAI-compatible APIs (OpenAI, vLLM, LM Studio, llama.cpp server, ...).
+// BaseURL includes the version prefix, e.g. http://localhost:8080/v1.
+type openAIClient struct {
+	http   *httpTransport
+	config config.LLMConfig
+}
+
+// NewOpenAIClient creates a client for an OpenAI-compatible API.
+func NewOpenAIClient(cfg config.LLMConfig) Client {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	return &openAIClient{
+		http:   newHTTPTransport(ProviderOpenAI, baseURL, cfg.APIKey, cfg.Timeout),
+		config: cfg,
+	}
+}
+
+// Wire types of the OpenAI API
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+func (u *openAIUsage) toUsage() Usage {
+	if u == nil {
+		return Usage{}
+	}
+	return Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens, TotalTokens: u.TotalTokens}
+}
+
+type openAICompletionRequest struct {
+	Model       string   `json:"model"`
+	Prompt      string   `json:"prompt"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+	Temperature float64  `json:"temperature"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+type openAICompletionResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Text         string `json:"text"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIChatRequest struct {
+	Model         string               `json:"model"`
+	Messages      []Message            `json:"messages"`
+	MaxTokens     int                  `json:"max_tokens,omitempty"`
+	Temperature   float64              `json:"temperature"`
+	Stop          []string             `json:"stop,omitempty"`
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIChatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      Message `json:"message"`
+		Delta        Message `json:"delta"` // Set instead of Message when streaming
+		FinishReason string  `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+type openAIModel struct {
+	ID            string `json:"id"`
+	ContextLength int    `json:"context_length"` // Non-standard, reported by some compatible servers
+}
+
+// Complete calls POST /completions.
+func (c *openAIClient) Complete(ctx context.Context, req CompletionRequest) (*Response, error) {
+	if req.Prompt == "" {
+		return nil, ErrEmptyRequest
+	}
+
+	var out openAICompletionResponse
+	err := c.http.postJSON(ctx, "/completions", openAICompletionRequest{
+		Model:       c.model(req.Model),
+		Prompt:      req.Prompt,
+		MaxTokens:   c.maxTokens(req.MaxTokens),
+		Temperature: req.Temperature,
+		Stop:        req.Stop,
+	}, &out)
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Choices) == 0 {
+		return nil, fmt.Errorf("llm: %s returned no choices", ProviderOpenAI)
+	}
+
+	return &Response{
+		Text:         out.Choices[0].Text,
+		Model:        out.Model,
+		FinishReason: out.Choices[0].FinishReason,
+		Usage:        out.Usage.toUsage(),
+	}, nil
+}
+
+// Chat calls POST /chat/completions.
+func (c *openAIClient) Chat(ctx context.Context, req ChatRequest) (*Response, error) {
+	if err := validateChat(req); err != nil {
+		return nil, err
+	}
+
+	var out openAIChatResponse
+	if err := c.http.postJSON(ctx, "/chat/completions", c.chatRequest(req, false), &out); err != nil {
+		return nil, err
+	}
+	if len(out.Choices) == 0 {
+		return nil, fmt.Errorf("llm: %s returned no choices", ProviderOpenAI)
+	}
+
+	return &Response{
+		Text:         out.Choices[0].Message.Content,
+		Model:        out.Model,
+		FinishReason: out.Choices[0].FinishReason,
+		Usage:        out.Usage.toUsage(),
+	}, nil
+}
+
+// ChatStream calls POST /chat/completions with stream=true and reads the server-sent events.
+func (c *openAIClient) ChatStream(ctx context.Context, req ChatRequest, onChunk func(StreamChunk) error) (*Response, error) {
+	if err := validateChat(req); err != nil {
+		return nil, err
+	}
+
+	body, err := c.http.postStream(ctx, "/chat/completions", c.chatRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	result := &Response{Model: c.model(req.Model)}
+	var text strings.Builder
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue // Blank separators and comments
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var event openAIChatResponse
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, fmt.Errorf("llm: failed to decode %s stream event: %w", ProviderOpenAI, err)
+		}
+		if event.Model != "" {
+			result.Model = event.Model
+		}
+		if event.Usage != nil {
+			result.Usage = event.Usage.toUsage()
+		}
+		if len(event.Choices) == 0 {
+			continue
+		}
+
+		choice := event.Choices[0]
+		if choice.FinishReason != "" {
+			result.FinishReason = choice.FinishReason
+		}
+		if choice.Delta.Content != "" {
+			text.WriteString(choice.Delta.Content)
+			if err := onChunk(StreamChunk{Text: choice.Delta.Content}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("llm: %s stream interrupted: %w", ProviderOpenAI, err)
+	}
+
+	result.Text = text.String()
+	if result.Usage.TotalTokens == 0 {
+		// Not every compatible server honours include_usage
+		result.Usage = estimateUsage(chatPrompt(req.Messages), result.Text)
+	}
+	return result, nil
+}
+
+// CountTokens estimates locally; the OpenAI API has no tokenizer endpoint.
+func (c *openAIClient) CountTokens(_ context.Context, text string) (int, error) {
+	return EstimateTokens(text), nil
+}
+
+// ModelInfo calls GET /models/{model}; the context window falls back to the configured value.
+func (c *openAIClient) ModelInfo(ctx context.Context) (*ModelInfo, error) {
+	var out openAIModel
+	if err := c.http.getJSON(ctx, "/models/"+url.PathEscape(c.model("")), &out); err != nil {
+		return nil, err
+	}
+
+	info := &ModelInfo{
+		Name:            out.ID,
+		Provider:        ProviderOpenAI,
+		ContextWindow:   c.config.ContextWindow,
+		MaxOutputTokens: c.config.MaxOutputTokens,
+	}
+	if out.ContextLength > 0 {
+		info.ContextWindow = out.ContextLength
+	}
+	return info, nil
+}
+
+func (c *openAIClient) chatRequest(req ChatRequest, stream bool) openAIChatRequest {
+	out := openAIChatRequest{
+		Model:       c.model(req.Model),
+		Messages:    req.Messages,
+		MaxTokens:   c.maxTokens(req.MaxTokens),
+		Temperature: req.Temperature,
+		Stop:        req.Stop,
+		Stream:      stream,
+	}
+	if stream {
+		out.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+	return out
+}
+
+func (c *openAIClient) model(requested string) string {
+	if requested != "" {
+		return requested
+	}
+	return c.config.Model
+}
+
+func (c *openAIClient) maxTokens(requested int) int {
+	if requested > 0 {
+		return requested
+	}
+	return c.config.MaxOutputTokens
+}
+
+// estimateUsage fills in usage for providers that do not report it.
+func estimateUsage(prompt, completion string) Usage {
+	promptTokens, completionTokens := EstimateTokens(prompt), EstimateTokens(completion)
+	return Usage{PromptTokens: promptTokens, CompletionTokens: completionTokens, TotalTokens: promptTokens + completionTokens}
+}
@@ -0,0 +1,54 @@
+// File: internal/moderation/classifier.go
+
+// Package moderation screens AI output before authors see it: a pluggable classifier
+// interface and a local keyword and regular expression rule engine.
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/khaled2049/server/internal/config"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+// Classifier providers.
+const (
+	ProviderRules = "rules"
+	ProviderNone  = "none"
+)
+
+// ErrUnknownProvider is returned for an unsupported MODERATION_PROVIDER.
+var ErrUnknownProvider = errors.New("unknown moderation provider")
+
+// Classifier inspects generated text for content that may not suit a novel. It reports
+// what it found with the rating each finding requires; deciding what a novel allows is
+// up to the caller.
+type Classifier interface {
+	Classify(ctx context.Context, text string) ([]domain.ModerationFinding, error)
+	// Name identifies the classifier in moderation outcomes.
+	Name() string
+}
+
+// NewClassifier creates the classifier selected by cfg.Provider: the rule engine
+// (default), with the rules of cfg.RulesFile or the built-in ones, or none, which
+// returns a nil Classifier and disables moderation.
+func NewClassifier(cfg config.ModerationConfig) (Classifier, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case ProviderRules, "":
+		rules := DefaultRules
+		if cfg.RulesFile != "" {
+			var err error
+			if rules, err = LoadRules(cfg.RulesFile); err != nil {
+				return nil, err
+			}
+		}
+		return NewRuleClassifier(rules)
+	case ProviderNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, cfg.Provider)
+	}
+}
@@ -0,0 +1,127 @@
+// File: internal/moderation/rules.go
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/khaled2049/server/internal/domain"
+)
+
+// Rule flags text matching any of its keywords (whole words, case-insensitive) or its
+// pattern (a case-insensitive Go regular expression). Rating is the lowest content
+// rating that allows the text; leave it empty for text no novel should get.
+type Rule struct {
+	Name     string               `json:"name"`
+	Category string               `json:"category"`
+	Rating   domain.ContentRating `json:"rating,omitempty"`
+	Keywords []string             `json:"keywords,omitempty"`
+	Pattern  string               `json:"pattern,omitempty"`
+}
+
+// DefaultRules is a small built-in rule set. It is meant as a starting point: replace
+// it with MODERATION_RULES_FILE to fit the platform's policy.
+var DefaultRules = []Rule{
+	{
+		Name: "profanity", Category: "profanity", Rating: domain.ContentRatingTeen,
+		Pattern: `\b(fuck|shit|bitch|asshole|bastard|motherfuck)\w*`,
+	},
+	{
+		Name: "explicit-sexual-content", Category: "sexual", Rating: domain.ContentRatingMature,
+		Keywords: []string{"orgasm", "orgasms", "blowjob", "handjob", "dildo", "masturbate", "masturbating", "masturbation"},
+	},
+	{
+		Name: "graphic-violence", Category: "violence", Rating: domain.ContentRatingMature,
+		Pattern: `\b(disembowel|decapitat|dismember|eviscerat)\w*|\bentrails\b|\bgouged? out\b`,
+	},
+	{
+		Name: "self-harm-instructions", Category: "self_harm",
+		Pattern: `\b(how to|ways? to|best way to|easiest way to) (kill|hurt|harm|cut) (yourself|myself)\b`,
+	},
+	{
+		Name: "incitement-against-groups", Category: "hate",
+		Pattern: `\b(kill|exterminate|wipe out|gas) (all|every) (the )?(jews|muslims|christians|blacks|whites|gays|immigrants)\b`,
+	},
+}
+
+// LoadRules reads a JSON array of rules from path.
+func LoadRules(path string) ([]Rule, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read moderation rules: %w", err)
+	}
+	var rules []Rule
+	if err := json.Unmarshal(body, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse moderation rules %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// RuleClassifier is a local keyword and regular expression rule engine. It reports
+// each matching rule once.
+type RuleClassifier struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	Rule
+	re *regexp.Regexp
+}
+
+// NewRuleClassifier compiles rules, rejecting rules that match nothing or have an
+// invalid pattern or rating.
+func NewRuleClassifier(rules []Rule) (*RuleClassifier, error) {
+	c := &RuleClassifier{}
+	for _, rule := range rules {
+		if rule.Name == "" || rule.Category == "" {
+			return nil, fmt.Errorf("moderation rule %q needs a name and a category", rule.Name)
+		}
+		if rule.Rating != "" && !rule.Rating.Valid() {
+			return nil, fmt.Errorf("moderation rule %q has an unknown rating %q", rule.Name, rule.Rating)
+		}
+
+		var alternatives []string
+		if len(rule.Keywords) > 0 {
+			words := make([]string, len(rule.Keywords))
+			for i, keyword := range rule.Keywords {
+				words[i] = regexp.QuoteMeta(strings.TrimSpace(keyword))
+			}
+			alternatives = append(alternatives, `\b(?:`+strings.Join(words, "|")+`)\b`)
+		}
+		if rule.Pattern != "" {
+			alternatives = append(alternatives, "(?:"+rule.Pattern+")")
+		}
+		if len(alternatives) == 0 {
+			return nil, fmt.Errorf("moderation rule %q needs keywords or a pattern", rule.Name)
+		}
+
+		re, err := regexp.Compile(`(?i)` + strings.Join(alternatives, "|"))
+		if err != nil {
+			return nil, fmt.Errorf("moderation rule %q: %w", rule.Name, err)
+		}
+		c.rules = append(c.rules, compiledRule{Rule: rule, re: re})
+	}
+	return c, nil
+}
+
+func (c *RuleClassifier) Classify(ctx context.Context, text string) ([]domain.ModerationFinding, error) {
+	findings := []domain.ModerationFinding{}
+	for _, rule := range c.rules {
+		if rule.re.MatchString(text) {
+			findings = append(findings, domain.ModerationFinding{
+				Category: rule.Category,
+				Rule:     rule.Name,
+				Rating:   rule.Rating,
+			})
+		}
+	}
+	return findings, nil
+}
+
+func (c *RuleClassifier) Name() string {
+	return ProviderRules
+}
@@ -0,0 +1,78 @@
+// File: internal/mq/broker/broker.go
+
+// Package broker opens the message queue driver selected in config.MQConfig.
+package broker
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/khaled2049/server/internal/config"
+	"github.com/khaled2049/server/internal/mq"
+	"github.com/khaled2049/server/internal/mq/memory"
+	"github.com/khaled2049/server/internal/mq/rabbitmq"
+)
+
+// ErrUnknownDriver is returned by Open for unsupported driver names.
+var ErrUnknownDriver = errors.New("mq: unknown driver")
+
+// Broker is an opened driver. Producer, Consumer and PubSub are nil for the "none"
+// driver.
+type Broker struct {
+	Producer mq.Producer
+	Consumer mq.Consumer
+	PubSub   mq.PubSub
+	// InProcess reports that messages never leave the process, so consumers must run
+	// in the process that publishes.
+	InProcess bool
+
+	closers []func() error
+}
+
+// Open connects to the configured driver.
+func Open(cfg *config.Config) (*Broker, error) {
+	policy := retryPolicy(cfg.MQ)
+
+	switch cfg.MQ.Driver {
+	case config.MQDriverRabbitMQ:
+		conn, err := rabbitmq.Dial(cfg.RabbitMQ)
+		if err != nil {
+			return nil, err
+		}
+		publisher, err := rabbitmq.NewPublisher(conn)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		consumer := rabbitmq.NewConsumer(conn, cfg.MQ.Prefetch, policy)
+		broadcaster := rabbitmq.NewBroadcaster(conn)
+		return &Broker{
+			Producer: publisher,
+			Consumer: consumer,
+			PubSub:   broadcaster,
+			closers:  []func() error{consumer.Close, broadcaster.Close, publisher.Close, conn.Close},
+		}, nil
+	case config.MQDriverMemory:
+		b := memory.NewBroker(cfg.MQ.Prefetch, policy)
+		return &Broker{Producer: b, Consumer: b, PubSub: b, InProcess: true, closers: []func() error{b.Close}}, nil
+	case config.MQDriverNone, "":
+		return &Broker{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDriver, cfg.MQ.Driver)
+	}
+}
+
+// retryPolicy returns the redelivery policy configured for consumers.
+func retryPolicy(cfg config.MQConfig) mq.RetryPolicy {
+	return mq.RetryPolicy{MaxRetries: cfg.MaxRetries, BaseDelay: cfg.RetryDelay}
+}
+
+// Close closes the consumer, the broadcaster, the producer and the connection, in that
+// order.
+func (b *Broker) Close() error {
+	var errs []error
+	for _, closeFn := range b.closers {
+		errs = append(errs, closeFn())
+	}
+	return errors.Join(errs...)
+}
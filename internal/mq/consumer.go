@@ -0,0 +1,80 @@
+// File: internal/mq/consumer.go
+package mq
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrSettled is returned when a delivery is settled a second time.
+var ErrSettled = errors.New("mq: delivery already settled")
+
+// Delivery is a received message. The consumer must settle every delivery exactly
+// once with Ack, Nack or Retry; unsettled deliveries are redelivered by the broker when
+// the consumer goes away, which gives at-least-once semantics.
+type Delivery struct {
+	Message
+	// Redelivered reports that the broker delivered this message before.
+	Redelivered bool
+	// Attempt is 1 on the first delivery and grows with every Retry.
+	Attempt int
+
+	ack   func() error
+	nack  func(requeue bool) error
+	retry func() error
+}
+
+// NewDelivery wraps a broker message with its settlement callbacks.
+func NewDelivery(
+	msg Message,
+	redelivered bool,
+	attempt int,
+	ack func() error,
+	nack func(requeue bool) error,
+	retry func() error) Delivery {
+	return Delivery{Message: msg, Redelivered: redelivered, Attempt: attempt, ack: ack, nack: nack, retry: retry}
+}
+
+// Ack confirms the message was processed.
+func (d Delivery) Ack() error {
+	return d.ack()
+}
+
+// Nack returns the message to the queue immediately (requeue, e.g. on shutdown) or
+// moves it to the topic's dead-letter queue.
+func (d Delivery) Nack(requeue bool) error {
+	return d.nack(requeue)
+}
+
+// Retry redelivers the message after a backoff delay that grows with Attempt. Once
+// the retry budget is spent the message is dead-lettered instead.
+func (d Delivery) Retry() error {
+	return d.retry()
+}
+
+// Consumer receives messages.
+type Consumer interface {
+	// Consume delivers the messages of topic on the returned channel until ctx is done
+	// or the consumer is closed; the channel is then closed. Adapters resubscribe on
+	// their own after a lost broker connection.
+	Consume(ctx context.Context, topic string) (<-chan Delivery, error)
+	Close() error
+}
+
+// RetryPolicy bounds redelivery through Delivery.Retry.
+type RetryPolicy struct {
+	MaxRetries int
+	// BaseDelay is the delay before the first retry; each further retry doubles it.
+	BaseDelay time.Duration
+}
+
+// Delay returns the backoff before retrying a message on its attempt-th delivery.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	return p.BaseDelay << max(attempt-1, 0)
+}
+
+// Exhausted reports whether a message on its attempt-th delivery may not be retried.
+func (p RetryPolicy) Exhausted(attempt int) bool {
+	return attempt > p.MaxRetries
+}
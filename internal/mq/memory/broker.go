@@ -0,0 +1,304 @@
+// File: internal/mq/memory/broker.go
+
+// Package memory is an in-process message broker with the delivery semantics of the
+// RabbitMQ adapter: competing consumers per topic, a prefetch limit, requeue on Nack,
+// delayed retries and a dead-letter queue. It lets services and tests run without a
+// broker; messages do not survive the process. It also broadcasts to in-process
+// subscribers (mq.PubSub).
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/khaled2049/server/internal/mq"
+)
+
+// ErrClosed is returned by operations on a closed Broker.
+var ErrClosed = errors.New("memory broker: closed")
+
+// Broker implements mq.Producer, mq.Consumer and mq.PubSub.
+type Broker struct {
+	prefetch int
+	policy   mq.RetryPolicy
+
+	mu          sync.Mutex
+	queues      map[string]*queue
+	subscribers map[string]map[chan mq.Message]struct{}
+	changed     chan struct{} // Closed and replaced whenever a queue gains messages
+	done        chan struct{} // Closed by Close
+	closed      bool
+}
+
+type queue struct {
+	ready []*entry
+	dead  []mq.Message
+}
+
+type entry struct {
+	msg         mq.Message
+	attempt     int
+	redelivered bool
+}
+
+// NewBroker creates an empty broker.
+func NewBroker(prefetch int, policy mq.RetryPolicy) *Broker {
+	return &Broker{
+		prefetch:    max(prefetch, 1),
+		policy:      policy,
+		queues:      map[string]*queue{},
+		subscribers: map[string]map[chan mq.Message]struct{}{},
+		changed:     make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Publish enqueues msg on its topic. Unlike RabbitMQ, messages published before any
+// consumer subscribed are kept.
+func (b *Broker) Publish(ctx context.Context, msg mq.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	b.enqueue(&entry{msg: msg, attempt: 1}, false)
+	return nil
+}
+
+// Consume delivers the messages of topic, at most prefetch unsettled at a time.
+func (b *Broker) Consume(ctx context.Context, topic string) (<-chan mq.Delivery, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	out := make(chan mq.Delivery)
+	slots := make(chan struct{}, b.prefetch) // Holds a token per unsettled delivery
+	go b.deliver(ctx, topic, slots, out)
+	return out, nil
+}
+
+// Broadcast sends msg to the current subscribers of its topic, skipping those whose
+// buffer is full.
+func (b *Broker) Broadcast(ctx context.Context, msg mq.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	for ch := range b.subscribers[msg.Topic] {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe delivers the messages broadcast to topic until ctx is done or the broker
+// is closed.
+func (b *Broker) Subscribe(ctx context.Context, topic string) (<-chan mq.Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	ch := make(chan mq.Message, mq.SubscriberBuffer)
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = map[chan mq.Message]struct{}{}
+	}
+	b.subscribers[topic][ch] = struct{}{}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-b.done:
+		}
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers[topic], ch)
+		if len(b.subscribers[topic]) == 0 {
+			delete(b.subscribers, topic)
+		}
+		close(ch)
+	}()
+	return ch, nil
+}
+
+// DeadLetters returns the messages of topic that were rejected or ran out of retries.
+func (b *Broker) DeadLetters(topic string) []mq.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if q, ok := b.queues[topic]; ok {
+		return append([]mq.Message(nil), q.dead...)
+	}
+	return nil
+}
+
+// Pending returns the number of messages of topic waiting for a consumer.
+func (b *Broker) Pending(topic string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if q, ok := b.queues[topic]; ok {
+		return len(q.ready)
+	}
+	return 0
+}
+
+// Close stops every consumer and subscriber. Messages still queued are dropped.
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.changed)
+		close(b.done)
+	}
+	return nil
+}
+
+func (b *Broker) deliver(ctx context.Context, topic string, slots chan struct{}, out chan<- mq.Delivery) {
+	defer close(out)
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		e, ok := b.next(ctx, topic)
+		if !ok {
+			return
+		}
+
+		select {
+		case out <- b.delivery(e, slots):
+		case <-ctx.Done():
+			b.mu.Lock()
+			b.enqueue(e, true)
+			b.mu.Unlock()
+			return
+		}
+	}
+}
+
+// next waits for a message of topic.
+func (b *Broker) next(ctx context.Context, topic string) (*entry, bool) {
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return nil, false
+		}
+		if q := b.queues[topic]; q != nil && len(q.ready) > 0 {
+			e := q.ready[0]
+			q.ready = q.ready[1:]
+			b.mu.Unlock()
+			return e, true
+		}
+		changed := b.changed
+		b.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+func (b *Broker) delivery(e *entry, slots chan struct{}) mq.Delivery {
+	var once sync.Once
+	settle := func(fn func()) error {
+		err := mq.ErrSettled
+		once.Do(func() {
+			<-slots
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if b.closed {
+				err = ErrClosed
+				return
+			}
+			fn()
+			err = nil
+		})
+		return err
+	}
+
+	return mq.NewDelivery(e.msg, e.redelivered, e.attempt,
+		func() error {
+			return settle(func() {})
+		},
+		func(requeue bool) error {
+			return settle(func() {
+				if requeue {
+					e.redelivered = true
+					b.enqueue(e, true)
+				} else {
+					b.deadLetter(e)
+				}
+			})
+		},
+		func() error {
+			return settle(func() { b.retry(e) })
+		},
+	)
+}
+
+// retry re-enqueues e after the policy's delay, or dead-letters it. Called with b.mu held.
+func (b *Broker) retry(e *entry) {
+	if b.policy.Exhausted(e.attempt) {
+		b.deadLetter(e)
+		return
+	}
+
+	next := &entry{msg: e.msg, attempt: e.attempt + 1}
+	time.AfterFunc(b.policy.Delay(e.attempt), func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.enqueue(next, false)
+	})
+}
+
+// enqueue adds e to the back of its queue (front when requeued) and wakes consumers.
+// Called with b.mu held; a closed broker drops e.
+func (b *Broker) enqueue(e *entry, front bool) {
+	if b.closed {
+		return
+	}
+	q := b.queue(e.msg.Topic)
+	if front {
+		q.ready = append([]*entry{e}, q.ready...)
+	} else {
+		q.ready = append(q.ready, e)
+	}
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// deadLetter keeps e for DeadLetters. Called with b.mu held.
+func (b *Broker) deadLetter(e *entry) {
+	q := b.queue(e.msg.Topic)
+	q.dead = append(q.dead, e.msg)
+}
+
+func (b *Broker) queue(topic string) *queue {
+	q, ok := b.queues[topic]
+	if !ok {
+		q = &queue{}
+		b.queues[topic] = q
+	}
+	return q
+}
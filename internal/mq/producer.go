@@ -0,0 +1,22 @@
+// File: internal/mq/producer.go
+
+// Package mq defines the message queue contracts shared by the API server and the
+// workers; internal/mq/rabbitmq implements them.
+package mq
+
+import "context"
+
+// Message is published to a topic (the routing key). Key identifies the entity the
+// message is about and is used for logging and de-duplication by consumers.
+type Message struct {
+	Topic   string
+	Key     string
+	Body    []byte
+	Headers map[string]string
+}
+
+// Producer publishes messages.
+type Producer interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
@@ -0,0 +1,21 @@
+// File: internal/mq/pubsub.go
+package mq
+
+import "context"
+
+// PubSub broadcasts transient messages to every current subscriber of a topic. Unlike
+// Producer and Consumer nothing is stored, acknowledged or retried: subscribers only
+// receive messages broadcast while they are subscribed, and a subscriber that falls
+// behind loses messages. It suits progress updates whose final state is stored
+// elsewhere.
+type PubSub interface {
+	Broadcast(ctx context.Context, msg Message) error
+	// Subscribe delivers the messages broadcast to topic until ctx is done, the
+	// subscription is lost or the PubSub is closed; the channel is then closed.
+	Subscribe(ctx context.Context, topic string) (<-chan Message, error)
+	Close() error
+}
+
+// SubscriberBuffer is the number of messages a subscriber may lag behind before
+// messages are dropped.
+const SubscriberBuffer = 256
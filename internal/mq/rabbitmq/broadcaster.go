@@ -0,0 +1,159 @@
+// File: internal/mq/rabbitmq/broadcaster.go
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"github.com/khaled2049/server/internal/mq"
+)
+
+// Broadcaster implements mq.PubSub. Broadcasts are transient and unconfirmed; each
+// subscription consumes its own exclusive, auto-deleted queue bound to the broadcast
+// exchange with the topic as key. A subscription ends when its channel is lost.
+type Broadcaster struct {
+	conn *Connection
+
+	mu     sync.Mutex
+	ch     *amqp.Channel // Publishing channel, opened on demand
+	subs   map[*amqp.Channel]struct{}
+	closed bool
+}
+
+func NewBroadcaster(conn *Connection) *Broadcaster {
+	return &Broadcaster{
+		conn: conn,
+		subs: map[*amqp.Channel]struct{}{},
+	}
+}
+
+// Broadcast sends msg to the subscribers of msg.Topic.
+func (b *Broadcaster) Broadcast(ctx context.Context, msg mq.Message) error {
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	if b.ch == nil {
+		ch, err := b.conn.channel(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to broadcast %s message: %w", msg.Topic, err)
+		}
+		b.ch = ch
+	}
+
+	err := b.ch.Publish(broadcastExchange(b.conn.cfg.Exchange), msg.Topic, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Transient,
+		MessageId:    msg.Key,
+		Timestamp:    time.Now(),
+		Headers:      headers,
+		Body:         msg.Body,
+	})
+	if err != nil {
+		b.ch.Close()
+		b.ch = nil
+		return fmt.Errorf("failed to broadcast %s message: %w", msg.Topic, err)
+	}
+	return nil
+}
+
+// Subscribe binds a private queue to topic and delivers its messages until ctx is
+// done or the channel is lost. Messages are dropped while the subscriber's buffer is
+// full.
+func (b *Broadcaster) Subscribe(ctx context.Context, topic string) (<-chan mq.Message, error) {
+	ch, err := b.conn.channel(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, err := func() (<-chan amqp.Delivery, error) {
+		q, err := ch.QueueDeclare("", false, true, true, false, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to declare subscriber queue: %w", err)
+		}
+		if err := ch.QueueBind(q.Name, topic, broadcastExchange(b.conn.cfg.Exchange), false, nil); err != nil {
+			return nil, fmt.Errorf("failed to bind subscriber queue: %w", err)
+		}
+		msgs, err := ch.Consume(q.Name, "", true, true, false, false, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to consume subscriber queue: %w", err)
+		}
+		return msgs, nil
+	}()
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		ch.Close()
+		return nil, ErrClosed
+	}
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	out := make(chan mq.Message, mq.SubscriberBuffer)
+	go func() {
+		defer close(out)
+		defer b.release(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case d, ok := <-msgs:
+				if !ok {
+					return
+				}
+				select {
+				case out <- mq.Message{Topic: d.RoutingKey, Key: d.MessageId, Body: d.Body, Headers: stringHeaders(d.Headers)}:
+				default:
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Close closes the publishing channel and ends every subscription. The connection
+// stays open.
+func (b *Broadcaster) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subs {
+		ch.Close()
+		delete(b.subs, ch)
+	}
+	if b.ch == nil {
+		return nil
+	}
+	err := b.ch.Close()
+	b.ch = nil
+	if errors.Is(err, amqp.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// release closes a subscription's channel, which deletes its queue.
+func (b *Broadcaster) release(ch *amqp.Channel) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[ch]; ok {
+		ch.Close()
+		delete(b.subs, ch)
+	}
+}
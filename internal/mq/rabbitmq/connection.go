@@ -0,0 +1,181 @@
+// File: internal/mq/rabbitmq/connection.go
+
+// Package rabbitmq implements the mq contracts on RabbitMQ. Every message is published
+// to one durable topic exchange with its topic as the routing key; each topic is
+// consumed from a durable queue of the same name with its own retry and dead-letter
+// queues (see declareTopic). Broadcasts go through a separate transient exchange.
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"github.com/khaled2049/server/internal/config"
+)
+
+// ErrClosed is returned by operations on a closed Connection.
+var ErrClosed = errors.New("rabbitmq: connection closed")
+
+const (
+	minBackoff = 500 * time.Millisecond
+	maxBackoff = 30 * time.Second
+)
+
+// Connection is a broker connection shared by a process's publishers and consumers.
+// When the broker goes away it reconnects with exponential backoff; channels opened
+// afterwards use the new connection.
+type Connection struct {
+	cfg config.RabbitMQConfig
+
+	mu    sync.Mutex
+	conn  *amqp.Connection
+	ready chan struct{} // Closed while conn is usable
+
+	done      chan struct{} // Closed by Close
+	closeOnce sync.Once
+}
+
+// Dial connects to the broker and declares the exchanges. The first connection must
+// succeed; later losses are recovered in the background.
+func Dial(cfg config.RabbitMQConfig) (*Connection, error) {
+	c := &Connection{cfg: cfg, done: make(chan struct{})}
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	c.conn = conn
+	c.ready = make(chan struct{})
+	close(c.ready)
+	go c.watch(conn)
+	return c, nil
+}
+
+// Close closes the connection and every channel opened on it.
+func (c *Connection) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		err = c.conn.Close()
+	})
+	if errors.Is(err, amqp.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// channel opens a channel, waiting for a reconnect in progress.
+func (c *Connection) channel(ctx context.Context) (*amqp.Channel, error) {
+	for {
+		c.mu.Lock()
+		conn, ready := c.conn, c.ready
+		c.mu.Unlock()
+
+		select {
+		case <-ready:
+		case <-c.done:
+			return nil, ErrClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		ch, err := conn.Channel()
+		if err == nil {
+			return ch, nil
+		}
+		if !errors.Is(err, amqp.ErrClosed) {
+			return nil, fmt.Errorf("failed to open rabbitmq channel: %w", err)
+		}
+
+		// The connection just dropped; give watch a moment to notice
+		if err := sleep(ctx, c.done, minBackoff); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Connection) dial() (*amqp.Connection, error) {
+	conn, err := amqp.Dial(c.cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to rabbitmq: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open rabbitmq channel: %w", err)
+	}
+	defer ch.Close()
+
+	for _, exchange := range []string{c.cfg.Exchange, deadLetterExchange(c.cfg.Exchange)} {
+		if err := ch.ExchangeDeclare(exchange, amqp.ExchangeTopic, true, false, false, false, nil); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to declare exchange %q: %w", exchange, err)
+		}
+	}
+	broadcast := broadcastExchange(c.cfg.Exchange)
+	if err := ch.ExchangeDeclare(broadcast, amqp.ExchangeTopic, false, false, false, false, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to declare exchange %q: %w", broadcast, err)
+	}
+
+	return conn, nil
+}
+
+// watch reconnects whenever the connection is lost, until Close.
+func (c *Connection) watch(conn *amqp.Connection) {
+	for {
+		reason := <-conn.NotifyClose(make(chan *amqp.Error, 1))
+		select {
+		case <-c.done:
+			return
+		default:
+		}
+		log.Printf("Warning: rabbitmq connection lost: %v; reconnecting", reason)
+
+		c.mu.Lock()
+		c.ready = make(chan struct{})
+		c.mu.Unlock()
+
+		backoff := minBackoff
+		for {
+			if sleep(context.Background(), c.done, backoff) != nil {
+				return
+			}
+			var err error
+			if conn, err = c.dial(); err == nil {
+				break
+			}
+			log.Printf("Warning: rabbitmq reconnect failed: %v", err)
+			backoff = min(backoff*2, maxBackoff)
+		}
+
+		c.mu.Lock()
+		c.conn = conn
+		close(c.ready)
+		c.mu.Unlock()
+		log.Println("RabbitMQ connection restored.")
+	}
+}
+
+// sleep waits for d unless ctx is done or the connection closed.
+func sleep(ctx context.Context, done <-chan struct{}, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,235 @@
+// File: internal/mq/rabbitmq/consumer.go
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/streadway/amqp"
+
+	"github.com/khaled2049/server/internal/mq"
+)
+
+// Consumer implements mq.Consumer with manual acknowledgements, a per-channel
+// prefetch limit, delayed retries and dead-lettering.
+type Consumer struct {
+	conn     *Connection
+	prefetch int
+	policy   mq.RetryPolicy
+
+	mu       sync.Mutex
+	channels map[*amqp.Channel]struct{}
+	closed   bool
+
+	tags atomic.Uint64
+}
+
+// NewConsumer creates a consumer; each Consume call gets its own channel so the
+// prefetch limit applies per topic.
+func NewConsumer(conn *Connection, prefetch int, policy mq.RetryPolicy) *Consumer {
+	return &Consumer{
+		conn:     conn,
+		prefetch: max(prefetch, 1),
+		policy:   policy,
+		channels: map[*amqp.Channel]struct{}{},
+	}
+}
+
+// Consume declares the topic's queues and starts delivering. After a lost connection
+// it resubscribes once the connection is restored; deliveries that were in flight are
+// redelivered by the broker.
+func (c *Consumer) Consume(ctx context.Context, topic string) (<-chan mq.Delivery, error) {
+	tag := fmt.Sprintf("%s-%d", topic, c.tags.Add(1))
+	ch, msgs, err := c.subscribe(ctx, topic, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan mq.Delivery)
+	go func() {
+		defer close(out)
+		for {
+			if !c.forward(ctx, ch, tag, msgs, out) {
+				return
+			}
+
+			backoff := minBackoff
+			for {
+				if ch, msgs, err = c.subscribe(ctx, topic, tag); err == nil {
+					break
+				}
+				if errors.Is(err, ErrClosed) || ctx.Err() != nil {
+					return
+				}
+				log.Printf("Warning: failed to resubscribe to %s: %v", topic, err)
+				if sleep(ctx, c.conn.done, backoff) != nil {
+					return
+				}
+				backoff = min(backoff*2, maxBackoff)
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// Close closes the consuming channels; unsettled deliveries return to their queues.
+// The connection stays open.
+func (c *Consumer) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for ch := range c.channels {
+		ch.Close()
+		delete(c.channels, ch)
+	}
+	return nil
+}
+
+func (c *Consumer) subscribe(ctx context.Context, topic, tag string) (*amqp.Channel, <-chan amqp.Delivery, error) {
+	ch, err := c.conn.channel(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	msgs, err := func() (<-chan amqp.Delivery, error) {
+		if err := ch.Qos(c.prefetch, 0, false); err != nil {
+			return nil, fmt.Errorf("failed to set prefetch: %w", err)
+		}
+		if err := declareTopic(ch, c.conn.cfg.Exchange, topic, c.policy); err != nil {
+			return nil, err
+		}
+		msgs, err := ch.Consume(topic, tag, false, false, false, false, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to consume queue %q: %w", topic, err)
+		}
+		return msgs, nil
+	}()
+	if err != nil {
+		ch.Close()
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		ch.Close()
+		return nil, nil, ErrClosed
+	}
+	c.channels[ch] = struct{}{}
+	return ch, msgs, nil
+}
+
+// forward passes deliveries on until ctx is done (false) or the channel is lost (true).
+// On ctx done the subscription is cancelled but the channel stays open, so deliveries
+// already handed out can still be settled until Close.
+func (c *Consumer) forward(
+	ctx context.Context,
+	ch *amqp.Channel,
+	tag string,
+	msgs <-chan amqp.Delivery,
+	out chan<- mq.Delivery,
+) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			// msgs is closed once the broker confirms the cancel; requeue what it still holds
+			ch.Cancel(tag, false)
+			for d := range msgs {
+				d.Nack(false, true)
+			}
+			return false
+		case d, ok := <-msgs:
+			if !ok {
+				c.mu.Lock()
+				delete(c.channels, ch)
+				closed := c.closed
+				c.mu.Unlock()
+				return !closed
+			}
+			select {
+			case out <- c.toDelivery(ch, d):
+			case <-ctx.Done():
+				d.Nack(false, true)
+			}
+		}
+	}
+}
+
+func (c *Consumer) toDelivery(ch *amqp.Channel, d amqp.Delivery) mq.Delivery {
+	headers := stringHeaders(d.Headers)
+	attempt := deliveryAttempt(d.Headers)
+
+	var once sync.Once
+	settle := func(fn func() error) error {
+		err := mq.ErrSettled
+		once.Do(func() { err = fn() })
+		return err
+	}
+
+	msg := mq.Message{Topic: d.RoutingKey, Key: d.MessageId, Body: d.Body, Headers: headers}
+	return mq.NewDelivery(msg, d.Redelivered, attempt,
+		func() error {
+			return settle(func() error { return d.Ack(false) })
+		},
+		func(requeue bool) error {
+			return settle(func() error { return d.Nack(false, requeue) })
+		},
+		func() error {
+			return settle(func() error { return c.retry(ch, d, attempt) })
+		},
+	)
+}
+
+// retry moves d to the retry queue for its attempt, or dead-letters it once the
+// retry budget is spent.
+func (c *Consumer) retry(ch *amqp.Channel, d amqp.Delivery, attempt int) error {
+	if c.policy.Exhausted(attempt) {
+		return d.Nack(false, false)
+	}
+
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[attemptHeader] = int32(attempt + 1)
+
+	// The default exchange routes by queue name
+	err := ch.Publish("", retryQueue(d.RoutingKey, attempt), false, false, amqp.Publishing{
+		ContentType:  d.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    d.MessageId,
+		Timestamp:    d.Timestamp,
+		Headers:      headers,
+		Body:         d.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to schedule retry: %w", err)
+	}
+	return d.Ack(false)
+}
+
+// stringHeaders returns the string valued headers of a delivery.
+func stringHeaders(table amqp.Table) map[string]string {
+	headers := make(map[string]string, len(table))
+	for k, v := range table {
+		if s, ok := v.(string); ok {
+			headers[k] = s
+		}
+	}
+	return headers
+}
+
+func deliveryAttempt(headers amqp.Table) int {
+	switch v := headers[attemptHeader].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	}
+	return 1
+}
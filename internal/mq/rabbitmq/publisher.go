@@ -0,0 +1,128 @@
+// File: internal/mq/rabbitmq/publisher.go
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"github.com/khaled2049/server/internal/mq"
+)
+
+// publishAttempts bounds how often a message is resent after its channel failed.
+const publishAttempts = 3
+
+// Publisher implements mq.Producer with publisher confirms: Publish returns once the
+// broker has taken responsibility for the (persistent) message.
+type Publisher struct {
+	conn *Connection
+
+	mu       sync.Mutex // Publishes are serialized so confirms match their message
+	ch       *amqp.Channel
+	confirms chan amqp.Confirmation
+}
+
+// NewPublisher opens a confirming channel on conn.
+func NewPublisher(conn *Connection) (*Publisher, error) {
+	p := &Publisher{conn: conn}
+	if err := p.open(context.Background()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Publish sends msg to the exchange with msg.Topic as routing key and waits for the
+// broker's confirm. A message whose channel fails is resent, so it may arrive twice.
+func (p *Publisher) Publish(ctx context.Context, msg mq.Message) error {
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	publishing := amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    msg.Key,
+		Timestamp:    time.Now(),
+		Headers:      headers,
+		Body:         msg.Body,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < publishAttempts; attempt++ {
+		if p.ch == nil {
+			if err = p.open(ctx); err != nil {
+				return fmt.Errorf("failed to publish %s message: %w", msg.Topic, err)
+			}
+		}
+
+		if err = p.ch.Publish(p.conn.cfg.Exchange, msg.Topic, false, false, publishing); err != nil {
+			p.ch.Close()
+			p.ch = nil
+		} else {
+			err = p.awaitConfirm(ctx)
+		}
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to publish %s message: %w", msg.Topic, err)
+	}
+	return nil
+}
+
+// Close closes the publishing channel; the connection stays open.
+func (p *Publisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ch == nil {
+		return nil
+	}
+	err := p.ch.Close()
+	p.ch = nil
+	if errors.Is(err, amqp.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+func (p *Publisher) open(ctx context.Context) error {
+	ch, err := p.conn.channel(ctx)
+	if err != nil {
+		return err
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+	p.ch = ch
+	p.confirms = ch.NotifyPublish(make(chan amqp.Confirmation, 1))
+	return nil
+}
+
+// awaitConfirm waits for the confirm of the last publish. The channel is dropped on
+// any failure so a late confirm cannot be taken for the next message's.
+func (p *Publisher) awaitConfirm(ctx context.Context) error {
+	select {
+	case confirm, ok := <-p.confirms:
+		if !ok {
+			p.ch = nil
+			return amqp.ErrClosed
+		}
+		if !confirm.Ack {
+			return errors.New("message rejected by broker")
+		}
+		return nil
+	case <-ctx.Done():
+		p.ch.Close()
+		p.ch = nil
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,72 @@
+// File: internal/mq/rabbitmq/topology.go
+package rabbitmq
+
+import (
+	"fmt"
+
+	"github.com/streadway/amqp"
+
+	"github.com/khaled2049/server/internal/mq"
+)
+
+// attemptHeader counts the deliveries of a message across retries.
+const attemptHeader = "x-attempt"
+
+func deadLetterExchange(exchange string) string {
+	return exchange + ".dlx"
+}
+
+// broadcastExchange carries mq.PubSub messages. It is not durable and each subscriber
+// binds its own exclusive queue, so broadcasts are never stored.
+func broadcastExchange(exchange string) string {
+	return exchange + ".broadcast"
+}
+
+func deadLetterQueue(topic string) string {
+	return topic + ".dead"
+}
+
+func retryQueue(topic string, attempt int) string {
+	return fmt.Sprintf("%s.retry.%d", topic, attempt)
+}
+
+type queueSpec struct {
+	name     string
+	args     amqp.Table
+	exchange string // Bound with the topic as key when set
+}
+
+// declareTopic declares the queues of a topic:
+//
+//	<topic>          bound to the exchange; rejected messages go to the dead-letter exchange
+//	<topic>.dead     bound to the dead-letter exchange, kept for inspection
+//	<topic>.retry.N  one per retry, holding a message for policy.Delay(N) before it
+//	                 dead-letters back to the exchange and thus to <topic>
+func declareTopic(ch *amqp.Channel, exchange, topic string, policy mq.RetryPolicy) error {
+	dlx := deadLetterExchange(exchange)
+
+	queues := []queueSpec{
+		{topic, amqp.Table{"x-dead-letter-exchange": dlx}, exchange},
+		{deadLetterQueue(topic), nil, dlx},
+	}
+	for attempt := 1; attempt <= policy.MaxRetries; attempt++ {
+		queues = append(queues, queueSpec{retryQueue(topic, attempt), amqp.Table{
+			"x-message-ttl":             policy.Delay(attempt).Milliseconds(),
+			"x-dead-letter-exchange":    exchange,
+			"x-dead-letter-routing-key": topic,
+		}, ""})
+	}
+
+	for _, q := range queues {
+		if _, err := ch.QueueDeclare(q.name, true, false, false, false, q.args); err != nil {
+			return fmt.Errorf("failed to declare queue %q: %w", q.name, err)
+		}
+		if q.exchange == "" {
+			continue
+		}
+		if err := ch.QueueBind(q.name, topic, q.exchange, false, nil); err != nil {
+			return fmt.Errorf("failed to bind queue %q: %w", q.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+// File: internal/mq/topics.go
+package mq
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Topics published by the API server through the outbox.
+const (
+	// TopicAISuggestionRequested carries an AISuggestionJob for the AI worker.
+	TopicAISuggestionRequested = "ai.suggestion.requested"
+	// TopicChapterSaved carries a ChapterSavedEvent.
+	TopicChapterSaved = "chapter.saved"
+	// TopicCommentCreated carries a CommentCreatedEvent.
+	TopicCommentCreated = "comment.created"
+	// TopicCollaboratorChanged carries a CollaboratorChangedEvent.
+	TopicCollaboratorChanged = "collaborator.changed"
+	// TopicConsistencyCheckRequested carries a ConsistencyCheckJob for the AI worker.
+	TopicConsistencyCheckRequested = "consistency.check.requested"
+	// TopicChapterPublished carries a ChapterPublishedEvent.
+	TopicChapterPublished = "chapter.published"
+	// TopicAISuggestionGenerated carries an AISuggestionGeneratedEvent; it is written by
+	// the AI worker.
+	TopicAISuggestionGenerated = "ai.suggestion.generated"
+)
+
+// AISuggestionJob asks the AI worker to generate the content of a pending suggestion.
+type AISuggestionJob struct {
+	SuggestionID uuid.UUID `json:"suggestionId"`
+}
+
+// ConsistencyCheckJob asks the AI worker to run a pending consistency check.
+type ConsistencyCheckJob struct {
+	CheckID uuid.UUID `json:"checkId"`
+}
+
+// ChapterSavedEvent is published after a chapter edit. RevisionID is set when the
+// content changed.
+type ChapterSavedEvent struct {
+	ChapterID  uuid.UUID `json:"chapterId"`
+	NovelID    uuid.UUID `json:"novelId"`
+	EditorID   uuid.UUID `json:"editorId"`
+	RevisionID *int64    `json:"revisionId,omitempty"`
+}
+
+// ChapterPublishedEvent is published when a chapter's status becomes published.
+type ChapterPublishedEvent struct {
+	ChapterID   uuid.UUID `json:"chapterId"`
+	NovelID     uuid.UUID `json:"novelId"`
+	PublisherID uuid.UUID `json:"publisherId"`
+}
+
+// CommentCreatedEvent is published for new threads and replies.
+type CommentCreatedEvent struct {
+	CommentID       uuid.UUID  `json:"commentId"`
+	NovelID         uuid.UUID  `json:"novelId"`
+	AuthorID        uuid.UUID  `json:"authorId"`
+	TargetType      string     `json:"targetType"`
+	TargetID        uuid.UUID  `json:"targetId"`
+	ParentCommentID *uuid.UUID `json:"parentCommentId,omitempty"`
+}
+
+// CollaboratorChangedEvent is published when a collaborator is added (Added), changes
+// role or is removed (Removed, with an empty Role).
+type CollaboratorChangedEvent struct {
+	NovelID uuid.UUID `json:"novelId"`
+	UserID  uuid.UUID `json:"userId"`
+	ActorID uuid.UUID `json:"actorId"`
+	Role    string    `json:"role,omitempty"`
+	Added   bool      `json:"added,omitempty"`
+	Removed bool      `json:"removed,omitempty"`
+}
+
+// AISuggestionGeneratedEvent is published when the content of a suggestion requested by
+// a user has been generated. Automatic summaries have none. VariantGroupID is set for
+// variants requested together.
+type AISuggestionGeneratedEvent struct {
+	SuggestionID   uuid.UUID  `json:"suggestionId"`
+	NovelID        uuid.UUID  `json:"novelId"`
+	UserID         uuid.UUID  `json:"userId"`
+	Type           string     `json:"type"`
+	VariantGroupID *uuid.UUID `json:"variantGroupId,omitempty"`
+}
+
+// AISuggestionStreamTopic is the PubSub topic on which the AI worker broadcasts the
+// AISuggestionStreamEvents of a suggestion while generating it.
+func AISuggestionStreamTopic(suggestionID uuid.UUID) string {
+	return "ai.suggestion.stream." + suggestionID.String()
+}
+
+// Types of AISuggestionStreamEvent.
+const (
+	// StreamStart is sent when a worker starts generating; a suggestion whose worker
+	// was interrupted starts again from scratch.
+	StreamStart = "start"
+	// StreamToken carries the next piece of generated text.
+	StreamToken = "token"
+	// StreamWithheld is sent when moderation flags the output; no more tokens follow,
+	// and the content is withheld until revealed.
+	StreamWithheld = "withheld"
+	// StreamRetry is sent when an attempt failed with a transient error; the suggestion
+	// is pending again until RetryAt, and a new attempt starts with StreamStart.
+	StreamRetry = "retry"
+	// StreamDone is sent once the result is stored, with its status, and when the
+	// suggestion is cancelled.
+	StreamDone = "done"
+)
+
+// AISuggestionStreamEvent reports the progress of a generation. Seq numbers the tokens
+// of a generation from 1, so subscribers can tell that they joined late or missed one.
+type AISuggestionStreamEvent struct {
+	SuggestionID uuid.UUID  `json:"suggestionId"`
+	Type         string     `json:"type"`
+	Seq          int        `json:"seq,omitempty"`
+	Text         string     `json:"text,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	Error        string     `json:"error,omitempty"`   // StreamRetry
+	RetryAt      *time.Time `json:"retryAt,omitempty"` // StreamRetry
+}
+
+// TopicAISuggestionControl is the PubSub topic on which the API server broadcasts
+// AISuggestionControl commands to the AI workers.
+const TopicAISuggestionControl = "ai.suggestion.control"
+
+// Commands of AISuggestionControl.
+const (
+	// ControlCancel stops the generation of a cancelled suggestion.
+	ControlCancel = "cancel"
+)
+
+// AISuggestionControl is a command for the worker generating a suggestion, if any.
+type AISuggestionControl struct {
+	SuggestionID uuid.UUID `json:"suggestionId"`
+	Command      string    `json:"command"`
+}
+
+// TopicLiveEvents is the PubSub topic on which every process broadcasts the
+// domain.LiveEvents it stores, so each API server can push them to its connected clients.
+const TopicLiveEvents = "live.events"
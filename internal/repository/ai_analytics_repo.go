@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+// AIAnalyticsFilter selects the suggestions requested in [From, To), optionally of a
+// type, model or novel, and the dimensions to break their stats down by. Automatic
+// suggestions are never selected: no author reviews them.
+type AIAnalyticsFilter struct {
+	From    time.Time
+	To      time.Time
+	Type    domain.AISuggestionType // Empty for all
+	Model   string                  // Empty for all
+	NovelID *uuid.UUID
+	GroupBy []string // domain.AIAnalyticsDimensions; none for a single row of totals
+}
+
+type AIAnalyticsRepository interface {
+	// SuggestionStats aggregates the suggestions matching filter, one row per group,
+	// most requested first. Rates are left nil.
+	SuggestionStats(ctx context.Context, filter AIAnalyticsFilter) ([]*domain.AISuggestionStats, error)
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+var (
+	ErrAISuggestionNotFound = errors.New("ai suggestion not found")
+	// ErrAISuggestionContextNotFound is returned when a context entity does not exist in the suggestion's novel.
+	ErrAISuggestionContextNotFound = errors.New("ai suggestion context entity not found in novel")
+	// ErrAISuggestionStatusChanged is returned when a conditional update finds the suggestion in another status.
+	ErrAISuggestionStatusChanged = errors.New("ai suggestion status changed concurrently")
+)
+
+type AISuggestionFilter struct {
+	NovelID uuid.UUID
+	Status  domain.AISuggestionStatus // Empty for all
+}
+
+type AISuggestionRepository interface {
+	Create(ctx context.Context, suggestion *domain.AISuggestion) (*domain.AISuggestion, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.AISuggestion, error)
+	// List returns suggestions newest first.
+	List(ctx context.Context, filter AISuggestionFilter) ([]*domain.AISuggestion, error)
+	// UpdateReview stores the status, generated content, feedback, notes and application
+	// of suggestion, provided its stored status is still expected.
+	UpdateReview(ctx context.Context, suggestion *domain.AISuggestion, expected domain.AISuggestionStatus) (*domain.AISuggestion, error)
+	// ListVariants returns the variants of the group whose first variant is groupID, in
+	// order; it is empty when there is no such group.
+	ListVariants(ctx context.Context, groupID uuid.UUID) ([]*domain.AISuggestion, error)
+	// ResolveVariants rejects the generated and edited variants of a group other than
+	// chosenID and cancels those still in progress, returning them.
+	ResolveVariants(ctx context.Context, groupID, chosenID uuid.UUID) ([]*domain.AISuggestion, error)
+	// RecordReveal adds the user to the reveals of the suggestion's moderation outcome
+	// (generation_metadata.moderation.reveals) unless already there.
+	RecordReveal(ctx context.Context, id, userID uuid.UUID) (*domain.AISuggestion, error)
+
+	// Cancel moves a pending or generating suggestion to cancelled.
+	Cancel(ctx context.Context, id uuid.UUID) (*domain.AISuggestion, error)
+	// Requeue moves a failed suggestion back to pending for a fresh round of attempts.
+	Requeue(ctx context.Context, id uuid.UUID) (*domain.AISuggestion, error)
+
+	// Claim moves a pending suggestion that is not waiting for its retry, or one left
+	// generating since before staleBefore, to generating and counts the attempt. The
+	// returned UpdatedAt identifies the claim for FinishGeneration, ScheduleRetry and
+	// Release; ErrAISuggestionStatusChanged means the suggestion is not claimable.
+	Claim(ctx context.Context, id uuid.UUID, staleBefore time.Time) (*domain.AISuggestion, error)
+	// FinishGeneration stores the status (generated or failed), content, model, metadata,
+	// assembled prompt context and last error of suggestion, provided it is still
+	// generating under the claim taken at claimedAt.
+	FinishGeneration(ctx context.Context, suggestion *domain.AISuggestion, claimedAt time.Time) (*domain.AISuggestion, error)
+	// ScheduleRetry returns a suggestion claimed at claimedAt to pending after a transient
+	// failure, recording the error; it is not claimable before retryAt.
+	ScheduleRetry(ctx context.Context, id uuid.UUID, claimedAt time.Time, lastError string, retryAt time.Time) error
+	// Release returns a suggestion claimed at claimedAt to pending without counting the
+	// attempt.
+	Release(ctx context.Context, id uuid.UUID, claimedAt time.Time) error
+	// ListClaimable returns pending suggestions requested before pendingBefore or whose
+	// retry is due, and generating suggestions claimed before staleBefore, oldest first.
+	ListClaimable(ctx context.Context, pendingBefore, staleBefore time.Time, limit int) ([]uuid.UUID, error)
+}
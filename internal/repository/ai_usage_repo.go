@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+// AIUsageFilter selects ledger entries recorded since Since, of a user, a novel or both.
+type AIUsageFilter struct {
+	UserID  *uuid.UUID
+	NovelID *uuid.UUID
+	Since   time.Time
+}
+
+type AIUsageRepository interface {
+	Record(ctx context.Context, entry *domain.AIUsageEntry) (*domain.AIUsageEntry, error)
+	// Totals sums the entries matching filter.
+	Totals(ctx context.Context, filter AIUsageFilter) (*domain.AIUsageTotals, error)
+	// NovelsUsedBy returns the novels a user has entries for since the given time.
+	NovelsUsedBy(ctx context.Context, userID uuid.UUID, since time.Time) ([]uuid.UUID, error)
+}
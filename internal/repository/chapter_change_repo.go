@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+var (
+	ErrChangeSetNotFound = errors.New("change set not found")
+	ErrChangeNotFound    = errors.New("chapter change not found")
+)
+
+type ChapterChangeRepository interface {
+	// CreateSet stores a change set with its changes; call it within a transaction.
+	CreateSet(ctx context.Context, set *domain.ChapterChangeSet) (*domain.ChapterChangeSet, error)
+	// GetSet returns a change set with its changes in position order.
+	GetSet(ctx context.Context, id uuid.UUID) (*domain.ChapterChangeSet, error)
+	// ListSetsByChapter returns a chapter's change sets, newest first. A non-empty
+	// status keeps the sets with at least one change in that status.
+	ListSetsByChapter(ctx context.Context, chapterID uuid.UUID, status domain.ChapterChangeStatus) ([]*domain.ChapterChangeSet, error)
+	GetChange(ctx context.Context, id uuid.UUID) (*domain.ChapterChange, error)
+	// LockChange is GetChange with a row lock; call it within a transaction.
+	LockChange(ctx context.Context, id uuid.UUID) (*domain.ChapterChange, error)
+	Resolve(ctx context.Context, id uuid.UUID, status domain.ChapterChangeStatus, resolvedBy uuid.UUID, appliedRevisionID *int64) (*domain.ChapterChange, error)
+}
@@ -2,14 +2,19 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
 	"github.com/khaled2049/server/internal/domain"
 )
 
+var ErrChapterNotFound = errors.New("chapter not found")
+
 type ChapterRepository interface {
 	Create(ctx context.Context, chapter *domain.Chapter) (*domain.Chapter, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Chapter, error)
+	// LockByID is GetByID with a row lock; call it within a transaction.
+	LockByID(ctx context.Context, id uuid.UUID) (*domain.Chapter, error)
 	Update(ctx context.Context, chapter *domain.Chapter) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	ListByNovelID(ctx context.Context, novelID uuid.UUID) ([]*domain.Chapter, error)
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+var ErrRevisionNotFound = errors.New("chapter revision not found")
+
+type ChapterRevisionRepository interface {
+	Create(ctx context.Context, revision *domain.ChapterRevision) (*domain.ChapterRevision, error)
+	GetByID(ctx context.Context, chapterID uuid.UUID, id int64) (*domain.ChapterRevision, error)
+	// GetLatest returns the newest revision of a chapter.
+	GetLatest(ctx context.Context, chapterID uuid.UUID) (*domain.ChapterRevision, error)
+	// ListByChapterID returns revisions newest first, without content.
+	ListByChapterID(ctx context.Context, chapterID uuid.UUID) ([]*domain.ChapterRevision, error)
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+// CharacterAttributeRepository stores the per-novel character attribute schema.
+type CharacterAttributeRepository interface {
+	ListByNovelID(ctx context.Context, novelID uuid.UUID) ([]*domain.CharacterAttributeDefinition, error)
+
+	// ReplaceForNovel swaps the whole schema of a novel for the given definitions.
+	ReplaceForNovel(ctx context.Context, novelID uuid.UUID, defs []*domain.CharacterAttributeDefinition) ([]*domain.CharacterAttributeDefinition, error)
+}
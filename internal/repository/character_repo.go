@@ -2,11 +2,14 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
 	"github.com/khaled2049/server/internal/domain"
 )
 
+var ErrCharacterNotFound = errors.New("character not found")
+
 // CharacterRepository defines the interface for character data operations
 type CharacterRepository interface {
 	// Core CRUD operations
@@ -17,7 +20,20 @@ type CharacterRepository interface {
 
 	// Novel-specific operations
 	ListByNovelID(ctx context.Context, novelID uuid.UUID) ([]*domain.Character, error)
+	List(ctx context.Context, filter CharacterFilter) ([]*domain.Character, error)
+	// ListIDsByChapterID returns the characters linked to a chapter as appearing in it.
+	ListIDsByChapterID(ctx context.Context, chapterID uuid.UUID) ([]uuid.UUID, error)
 
 	// Search operations
+	// SearchByName matches the query against names and aliases.
 	SearchByName(ctx context.Context, novelID uuid.UUID, nameQuery string) ([]*domain.Character, error)
 }
+
+// CharacterFilter narrows a character listing within a novel.
+type CharacterFilter struct {
+	NovelID uuid.UUID
+	// Query matches names and aliases (case-insensitive substring)
+	Query string
+	// Attributes must all be contained in the character's attributes (JSONB @>)
+	Attributes domain.Attributes
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+// ErrCollaboratorNotFound is returned when a user has no role on a novel.
+var ErrCollaboratorNotFound = errors.New("collaborator not found")
+
+type CollaboratorRepository interface {
+	// GetRole returns the user's role on the novel. The novel owner always resolves
+	// to CollaborationRoleOwner even without a novel_collaborators row.
+	GetRole(ctx context.Context, novelID, userID uuid.UUID) (domain.CollaborationRole, error)
+	ListByNovelID(ctx context.Context, novelID uuid.UUID) ([]*domain.NovelCollaborator, error)
+	// Upsert adds the user or changes their role.
+	Upsert(ctx context.Context, collaborator *domain.NovelCollaborator) error
+	Remove(ctx context.Context, novelID, userID uuid.UUID) error
+}
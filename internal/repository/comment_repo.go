@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+	// ErrCommentTargetNotFound is returned when the commented entity does not exist.
+	ErrCommentTargetNotFound = errors.New("comment target not found")
+)
+
+// CommentFilter selects the comments of one target.
+type CommentFilter struct {
+	TargetType domain.CommentTargetType
+	TargetID   uuid.UUID
+	// Status, when set, keeps only threads whose root has this status
+	Status domain.CommentStatus
+}
+
+type CommentRepository interface {
+	Create(ctx context.Context, comment *domain.Comment) (*domain.Comment, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Comment, error)
+	UpdateContent(ctx context.Context, id uuid.UUID, content string) (*domain.Comment, error)
+	UpdateStatus(ctx context.Context, id uuid.UUID, status domain.CommentStatus) (*domain.Comment, error)
+	// Delete removes the comment; replies cascade.
+	Delete(ctx context.Context, id uuid.UUID) error
+	// ListByTarget returns roots and replies flat, oldest first.
+	ListByTarget(ctx context.Context, filter CommentFilter) ([]*domain.Comment, error)
+
+	// ListAnchoredByChapter returns every anchored comment of a chapter, orphaned ones included.
+	ListAnchoredByChapter(ctx context.Context, chapterID uuid.UUID) ([]*domain.Comment, error)
+	UpdateAnchor(ctx context.Context, id uuid.UUID, anchor *domain.CommentAnchor) error
+
+	// TargetNovelID resolves the novel owning a comment target.
+	TargetNovelID(ctx context.Context, targetType domain.CommentTargetType, targetID uuid.UUID) (uuid.UUID, error)
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+var (
+	ErrConsistencyCheckNotFound   = errors.New("consistency check not found")
+	ErrConsistencyFindingNotFound = errors.New("consistency finding not found")
+	// ErrConsistencyCheckStatusChanged is returned when a check is not in the status an
+	// update expects, e.g. another worker claimed it.
+	ErrConsistencyCheckStatusChanged = errors.New("consistency check status changed")
+)
+
+// ConsistencyFindingFilter selects the findings of a chapter.
+type ConsistencyFindingFilter struct {
+	ChapterID uuid.UUID
+	Status    domain.ConsistencyFindingStatus // All statuses when empty
+}
+
+// ConsistencyRepository stores consistency checks and their findings.
+type ConsistencyRepository interface {
+	CreateCheck(ctx context.Context, check *domain.ConsistencyCheck) (*domain.ConsistencyCheck, error)
+	GetCheck(ctx context.Context, id uuid.UUID) (*domain.ConsistencyCheck, error)
+	// ClaimCheck takes a pending check, or a running one last updated before
+	// staleBefore, and marks it running with the hash of the content it checks.
+	ClaimCheck(ctx context.Context, id uuid.UUID, contentHash string, staleBefore time.Time) (*domain.ConsistencyCheck, error)
+	// FinishCheck records the outcome of a claimed check: completed with its findings
+	// count, or failed. startedAt guards against a worker that lost its claim.
+	FinishCheck(ctx context.Context, check *domain.ConsistencyCheck, startedAt time.Time) (*domain.ConsistencyCheck, error)
+	// ListClaimableChecks returns checks with no live job: pending ones last updated
+	// before pendingBefore and running ones last updated before staleBefore.
+	ListClaimableChecks(ctx context.Context, pendingBefore, staleBefore time.Time, limit int) ([]uuid.UUID, error)
+
+	// ReplaceOpenFindings deletes the open findings of the check's chapter and stores
+	// findings in their place, except those whose fingerprint was dismissed. It returns
+	// the findings stored. Call it within a transaction.
+	ReplaceOpenFindings(ctx context.Context, check *domain.ConsistencyCheck, findings []*domain.ConsistencyFinding) ([]*domain.ConsistencyFinding, error)
+	GetFinding(ctx context.Context, id uuid.UUID) (*domain.ConsistencyFinding, error)
+	// ListFindings returns the findings of a chapter in text order, findings without a
+	// range first.
+	ListFindings(ctx context.Context, filter ConsistencyFindingFilter) ([]*domain.ConsistencyFinding, error)
+	// UpdateFindingStatus sets the status of a finding, recording who resolved or
+	// dismissed it; reopening clears the resolution.
+	UpdateFindingStatus(ctx context.Context, finding *domain.ConsistencyFinding) (*domain.ConsistencyFinding, error)
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+var ErrEmbeddingSourceNotFound = errors.New("embedding source not found")
+
+// EmbeddingRepository stores the semantic search index of chapters and shared notes.
+type EmbeddingRepository interface {
+	GetSource(ctx context.Context, sourceType domain.EmbeddingSourceType, id uuid.UUID) (*domain.EmbeddingSource, error)
+	// ListChunksBySource returns a source's passages in order.
+	ListChunksBySource(ctx context.Context, sourceType domain.EmbeddingSourceType, id uuid.UUID) ([]*domain.EmbeddingChunk, error)
+	// ReplaceSource stores the passages of a source in place of its previous ones. It
+	// reports false, storing nothing, when a newer version of the source is already
+	// indexed with the same model. Call it within a transaction.
+	ReplaceSource(ctx context.Context, source *domain.EmbeddingSource, chunks []*domain.EmbeddingChunk) (bool, error)
+	// ListChunksByNovel returns the passages of a novel's existing sources indexed with
+	// model, with their source titles.
+	ListChunksByNovel(ctx context.Context, novelID uuid.UUID, model string) ([]*domain.EmbeddingChunk, error)
+	// ListStale returns chapters and notes that are not indexed with model or changed
+	// since they were, least recently updated first. Only Type, ID, NovelID and
+	// UpdatedAt are set.
+	ListStale(ctx context.Context, model string, limit int) ([]*domain.EmbeddingSource, error)
+	// DeleteOrphans drops the index of deleted chapters and notes.
+	DeleteOrphans(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+type LiveEventRepository interface {
+	// Create stores an event and sets its ID and CreatedAt.
+	Create(ctx context.Context, event *domain.LiveEvent) error
+	// ListAfter returns up to limit events with an ID above afterID, in ID order.
+	ListAfter(ctx context.Context, afterID int64, limit int) ([]*domain.LiveEvent, error)
+	// ListForUser is ListAfter restricted to the events of userID and the novel events
+	// of novelIDs.
+	ListForUser(ctx context.Context, userID uuid.UUID, novelIDs []uuid.UUID, afterID int64, limit int) ([]*domain.LiveEvent, error)
+	// Bounds returns the lowest and highest stored IDs, both 0 when there are none.
+	Bounds(ctx context.Context) (oldest, latest int64, err error)
+	// DeleteBefore prunes the events created before the given time, always keeping the
+	// latest so Bounds still tells how far IDs went.
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+// ErrModerationSettingsNotFound is returned for a novel that has no moderation settings.
+var ErrModerationSettingsNotFound = errors.New("novel moderation settings not found")
+
+type ModerationSettingsRepository interface {
+	Get(ctx context.Context, novelID uuid.UUID) (*domain.NovelModerationSettings, error)
+	// Upsert stores the settings of a novel in place of its previous ones.
+	Upsert(ctx context.Context, settings *domain.NovelModerationSettings) (*domain.NovelModerationSettings, error)
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+var ErrNoteNotFound = errors.New("note not found")
+
+// NoteRepository stores and reads the shared notes of a novel.
+type NoteRepository interface {
+	Create(ctx context.Context, note *domain.Note) (*domain.Note, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Note, error)
+	// ListByNovelID returns the novel's notes, most recently updated first.
+	ListByNovelID(ctx context.Context, novelID uuid.UUID) ([]*domain.Note, error)
+}
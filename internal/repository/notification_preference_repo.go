@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+// ErrNotificationPreferenceNotFound is returned when a user has no preference for a scope.
+var ErrNotificationPreferenceNotFound = errors.New("notification preference not found")
+
+type NotificationPreferenceRepository interface {
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*domain.NotificationPreference, error)
+	// Upsert creates the preference of its scope or replaces its email mode.
+	Upsert(ctx context.Context, preference *domain.NotificationPreference) error
+	Delete(ctx context.Context, userID uuid.UUID, novelID *uuid.UUID, notificationType *domain.NotificationType) error
+	// Resolve returns the email mode of the most specific preference of the user that
+	// applies to a notification, or ErrNotificationPreferenceNotFound.
+	Resolve(
+		ctx context.Context,
+		userID uuid.UUID,
+		novelID *uuid.UUID,
+		notificationType domain.NotificationType,
+	) (domain.NotificationEmailMode, error)
+}
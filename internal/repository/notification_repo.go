@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+// ErrNotificationNotFound is returned when a notification does not exist or belongs to
+// another user.
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationFilter selects a page of a user's notifications, newest first.
+type NotificationFilter struct {
+	UnreadOnly bool
+	// Before, when set, keeps the notifications created before this time
+	Before *time.Time
+	Limit  int
+}
+
+type NotificationRepository interface {
+	// Create stores a notification unless the user already has one with its DedupeKey,
+	// and reports whether it was stored.
+	Create(ctx context.Context, notification *domain.Notification) (bool, error)
+	ListByUser(ctx context.Context, userID uuid.UUID, filter NotificationFilter) ([]*domain.Notification, error)
+	// MarkRead marks one of the user's notifications read; it stays read if it was.
+	MarkRead(ctx context.Context, userID, id uuid.UUID) (*domain.Notification, error)
+	// MarkAllRead marks every unread notification of the user read and returns how many.
+	MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error)
+	CountUnread(ctx context.Context, userID uuid.UUID) (int, error)
+
+	// ClaimEmails marks up to limit pending notifications, and those claimed before
+	// staleBefore and never settled, as sending and returns them, oldest first.
+	// Notifications claimed by another transaction are skipped.
+	ClaimEmails(ctx context.Context, limit int, staleBefore time.Time) ([]*domain.Notification, error)
+	// SetEmailStatus settles the email of notifications, clearing their last error.
+	SetEmailStatus(ctx context.Context, ids []uuid.UUID, status domain.NotificationEmailStatus) error
+	// FailEmails records a failed email of notifications and sets them back to retry,
+	// or to failed once they were tried maxAttempts times.
+	FailEmails(ctx context.Context, ids []uuid.UUID, retry domain.NotificationEmailStatus, maxAttempts int, reason string) error
+	// ListDigestRecipients returns up to limit users with notifications created before
+	// the given time waiting for their digest.
+	ListDigestRecipients(ctx context.Context, before time.Time, limit int) ([]uuid.UUID, error)
+	// ClaimDigest marks the user's notifications created before the given time waiting
+	// for their digest as sending and returns them, oldest first.
+	ClaimDigest(ctx context.Context, userID uuid.UUID, before time.Time) ([]*domain.Notification, error)
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/khaled2049/server/internal/domain"
+)
+
+type OutboxRepository interface {
+	// Add stores an event. Call it with the context of the transaction making the change.
+	// It holds off other transactions adding events of the same aggregate until that
+	// transaction ends, so each aggregate's events are numbered in commit order.
+	Add(ctx context.Context, event *domain.OutboxEvent) error
+	// LockRelay takes a transaction-scoped lock that lets a single relay publish at a
+	// time, which keeps per-aggregate order across processes. It reports false if
+	// another relay holds the lock. Call it within a transaction.
+	LockRelay(ctx context.Context) (bool, error)
+	// ListPending returns unsent events that failed fewer than maxAttempts times, in
+	// publishing order.
+	ListPending(ctx context.Context, maxAttempts, limit int) ([]*domain.OutboxEvent, error)
+	MarkSent(ctx context.Context, ids []int64) error
+	// MarkFailed counts a failed publish attempt.
+	MarkFailed(ctx context.Context, id int64, reason string) error
+	// DeleteSentBefore prunes events sent before the given time.
+	DeleteSentBefore(ctx context.Context, before time.Time) (int64, error)
+}
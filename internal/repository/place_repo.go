@@ -2,15 +2,20 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
 	"github.com/khaled2049/server/internal/domain"
 )
 
+var ErrPlaceNotFound = errors.New("place not found")
+
 type PlaceRepository interface {
 	Create(ctx context.Context, place *domain.Place) (*domain.Place, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Place, error)
 	Update(ctx context.Context, place *domain.Place) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	ListByNovelID(ctx context.Context, novelID uuid.UUID) ([]*domain.Place, error)
-}
\ No newline at end of file
+	// ListIDsByChapterID returns the places linked to a chapter as scene settings.
+	ListIDsByChapterID(ctx context.Context, chapterID uuid.UUID) ([]uuid.UUID, error)
+}
@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// postgresAIAnalyticsRepository implements the repository.AIAnalyticsRepository interface.
+type postgresAIAnalyticsRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewAIAnalyticsRepository creates a new instance of postgresAIAnalyticsRepository.
+func NewAIAnalyticsRepository(pool *pgxpool.Pool) repository.AIAnalyticsRepository {
+	return &postgresAIAnalyticsRepository{pool: pool}
+}
+
+// SuggestionStats aggregates suggestion outcomes and their ledger usage. A suggestion
+// counts as edited once EditSuggestion kept its original content in the metadata.
+func (r *postgresAIAnalyticsRepository) SuggestionStats(
+	ctx context.Context,
+	filter repository.AIAnalyticsFilter,
+) ([]*domain.AISuggestionStats, error) {
+	query := `
+		SELECT
+			CASE WHEN $6::boolean THEN a.suggestion_type::text END,
+			CASE WHEN $7::boolean THEN a.model_used END,
+			CASE WHEN $8::boolean THEN (a.generation_metadata->'template'->>'id')::uuid END,
+			CASE WHEN $8::boolean THEN (a.generation_metadata->'template'->>'version')::int END,
+			CASE WHEN $8::boolean THEN a.generation_metadata->'template'->>'scope' END,
+			count(*),
+			count(*) FILTER (WHERE a.status IN ('pending', 'generating')),
+			count(*) FILTER (WHERE a.status = 'failed'),
+			count(*) FILTER (WHERE a.status = 'cancelled'),
+			count(*) FILTER (WHERE a.status IN ('generated', 'edited', 'accepted', 'rejected')),
+			count(*) FILTER (WHERE a.status IN ('generated', 'edited')),
+			count(*) FILTER (WHERE a.status = 'accepted'),
+			count(*) FILTER (WHERE a.status = 'accepted' AND a.generation_metadata ? 'originalContent'),
+			count(*) FILTER (WHERE a.status = 'rejected'),
+			count(*) FILTER (WHERE a.status = 'edited' OR a.generation_metadata ? 'originalContent'),
+			count(a.user_feedback),
+			avg(a.user_feedback)::float8,
+			avg((a.generation_metadata->>'latencyMs')::float8),
+			percentile_cont(0.95) WITHIN GROUP (ORDER BY (a.generation_metadata->>'latencyMs')::float8),
+			COALESCE(sum(u.prompt_tokens), 0)::bigint,
+			COALESCE(sum(u.completion_tokens), 0)::bigint,
+			COALESCE(sum(u.total_tokens), 0)::bigint,
+			COALESCE(sum(u.estimated_cost), 0)::float8
+		FROM ai_suggestions a
+		LEFT JOIN LATERAL (
+			SELECT sum(prompt_tokens) AS prompt_tokens, sum(completion_tokens) AS completion_tokens,
+				sum(total_tokens) AS total_tokens, sum(estimated_cost) AS estimated_cost
+			FROM ai_usage
+			WHERE suggestion_id = a.id
+		) u ON TRUE
+		WHERE NOT a.automatic
+			AND a.requested_at >= $1 AND a.requested_at < $2
+			AND ($3::text = '' OR a.suggestion_type::text = $3)
+			AND ($4::text = '' OR a.model_used = $4)
+			AND ($5::uuid IS NULL OR a.novel_id = $5)
+		GROUP BY 1, 2, 3, 4, 5
+		ORDER BY 6 DESC, 1, 2, 4;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query,
+		filter.From, filter.To, string(filter.Type), filter.Model, filter.NovelID,
+		slices.Contains(filter.GroupBy, domain.AIAnalyticsByType),
+		slices.Contains(filter.GroupBy, domain.AIAnalyticsByModel),
+		slices.Contains(filter.GroupBy, domain.AIAnalyticsByTemplate),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate ai suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []*domain.AISuggestionStats{}
+	for rows.Next() {
+		s := &domain.AISuggestionStats{}
+		var suggestionType, model, templateScope *string
+		if err := rows.Scan(
+			&suggestionType, &model, &s.TemplateID, &s.TemplateVersion, &templateScope,
+			&s.Requested, &s.InProgress, &s.Failed, &s.Cancelled, &s.Generated, &s.AwaitingReview,
+			&s.Accepted, &s.AcceptedEdited, &s.Rejected, &s.Edited,
+			&s.Ratings, &s.AverageRating, &s.AverageLatencyMs, &s.P95LatencyMs,
+			&s.PromptTokens, &s.CompletionTokens, &s.TotalTokens, &s.EstimatedCost,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan ai suggestion stats: %w", err)
+		}
+		if suggestionType != nil {
+			s.Type = domain.AISuggestionType(*suggestionType)
+		}
+		if model != nil {
+			s.Model = *model
+		}
+		if templateScope != nil {
+			s.TemplateScope = *templateScope
+		}
+		groups = append(groups, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate ai suggestion stats rows: %w", err)
+	}
+	return groups, nil
+}
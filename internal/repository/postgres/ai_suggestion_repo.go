@@ -0,0 +1,434 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+const aiSuggestionColumns = `
+	id, novel_id, user_id, suggestion_type, status, automatic,
+	context_chapter_id, context_character_id, context_place_id, context_note_id,
+	COALESCE(selected_text, ''), COALESCE(prompt_context, ''), COALESCE(prompt_instructions, ''), COALESCE(generated_content, ''),
+	COALESCE(model_used, ''), generation_metadata, user_feedback, COALESCE(user_notes, ''),
+	applied_at, applied_revision_id, applied_start, applied_end,
+	applied_character_id, applied_place_id, applied_note_id,
+	attempts, COALESCE(last_error, ''), COALESCE(last_error_kind, ''), retry_at,
+	parent_suggestion_id, COALESCE(variant_index, 0), COALESCE(requested_model, ''), temperature,
+	requested_at, generated_at, updated_at`
+
+// postgresAISuggestionRepository implements the repository.AISuggestionRepository interface.
+type postgresAISuggestionRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewAISuggestionRepository creates a new instance of postgresAISuggestionRepository.
+func NewAISuggestionRepository(pool *pgxpool.Pool) repository.AISuggestionRepository {
+	return &postgresAISuggestionRepository{pool: pool}
+}
+
+// Create stores a new suggestion. Context entities must belong to the suggestion's novel.
+func (r *postgresAISuggestionRepository) Create(ctx context.Context, suggestion *domain.AISuggestion) (*domain.AISuggestion, error) {
+	// The foreign keys only check existence; the guards also check the novel
+	query := fmt.Sprintf(`
+		INSERT INTO ai_suggestions (
+			novel_id, user_id, suggestion_type, status,
+			context_chapter_id, context_character_id, context_place_id, context_note_id,
+			selected_text, prompt_instructions, automatic,
+			parent_suggestion_id, variant_index, requested_model, temperature
+		)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''), $11,
+			$12, NULLIF($13, 0), NULLIF($14, ''), $15
+		WHERE ($5::uuid IS NULL OR EXISTS (SELECT 1 FROM chapters WHERE id = $5 AND novel_id = $1))
+			AND ($6::uuid IS NULL OR EXISTS (SELECT 1 FROM characters WHERE id = $6 AND novel_id = $1))
+			AND ($7::uuid IS NULL OR EXISTS (SELECT 1 FROM places WHERE id = $7 AND novel_id = $1))
+			AND ($8::uuid IS NULL OR EXISTS (SELECT 1 FROM notes WHERE id = $8 AND novel_id = $1))
+		RETURNING %s;`, aiSuggestionColumns)
+
+	status := suggestion.Status
+	if status == "" {
+		status = domain.AISuggestionPending
+	}
+
+	created, err := scanAISuggestion(conn(ctx, r.pool).QueryRow(ctx, query,
+		suggestion.NovelID, suggestion.UserID, suggestion.Type, status,
+		suggestion.ContextChapterID, suggestion.ContextCharacterID, suggestion.ContextPlaceID, suggestion.ContextNoteID,
+		suggestion.SelectedText, suggestion.PromptInstructions, suggestion.Automatic,
+		suggestion.ParentSuggestionID, suggestion.VariantIndex, suggestion.RequestedModel, suggestion.Temperature,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrAISuggestionContextNotFound
+		}
+		return nil, fmt.Errorf("failed to create ai suggestion: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetByID retrieves a single suggestion.
+func (r *postgresAISuggestionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.AISuggestion, error) {
+	query := fmt.Sprintf(`SELECT %s FROM ai_suggestions WHERE id = $1;`, aiSuggestionColumns)
+
+	suggestion, err := scanAISuggestion(conn(ctx, r.pool).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrAISuggestionNotFound
+		}
+		return nil, fmt.Errorf("failed to find ai suggestion by ID: %w", err)
+	}
+
+	return suggestion, nil
+}
+
+// List retrieves the suggestions of a novel, newest first.
+func (r *postgresAISuggestionRepository) List(ctx context.Context, filter repository.AISuggestionFilter) ([]*domain.AISuggestion, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM ai_suggestions
+		WHERE novel_id = $1 AND ($2 = '' OR status::text = $2)
+		ORDER BY requested_at DESC;`, aiSuggestionColumns)
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, filter.NovelID, string(filter.Status))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ai suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []*domain.AISuggestion{}
+	for rows.Next() {
+		suggestion, err := scanAISuggestion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ai suggestion: %w", err)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate ai suggestion rows: %w", err)
+	}
+
+	return suggestions, nil
+}
+
+// ListVariants retrieves the variants of a group in order.
+func (r *postgresAISuggestionRepository) ListVariants(ctx context.Context, groupID uuid.UUID) ([]*domain.AISuggestion, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM ai_suggestions
+		WHERE (id = $1 AND variant_index IS NOT NULL) OR parent_suggestion_id = $1
+		ORDER BY variant_index;`, aiSuggestionColumns)
+
+	return r.listVariants(ctx, query, groupID)
+}
+
+// ResolveVariants closes the other variants of a group once one is chosen: generated
+// and edited ones are rejected, those still in progress cancelled, both noting the
+// chosen variant in generation_metadata.supersededBy. Failed and reviewed ones are left.
+func (r *postgresAISuggestionRepository) ResolveVariants(ctx context.Context, groupID, chosenID uuid.UUID) ([]*domain.AISuggestion, error) {
+	query := fmt.Sprintf(`
+		UPDATE ai_suggestions
+		SET status = CASE WHEN status IN ('pending', 'generating') THEN 'cancelled' ELSE 'rejected' END::ai_suggestion_status,
+			retry_at = NULL,
+			generation_metadata = COALESCE(generation_metadata, '{}'::jsonb) || jsonb_build_object('supersededBy', $3::text)
+		WHERE ((id = $1 AND variant_index IS NOT NULL) OR parent_suggestion_id = $1)
+			AND id <> $2 AND status IN ('pending', 'generating', 'generated', 'edited')
+		RETURNING %s;`, aiSuggestionColumns)
+
+	return r.listVariants(ctx, query, groupID, chosenID, chosenID.String())
+}
+
+func (r *postgresAISuggestionRepository) listVariants(ctx context.Context, query string, args ...any) ([]*domain.AISuggestion, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ai suggestion variants: %w", err)
+	}
+	defer rows.Close()
+
+	variants := []*domain.AISuggestion{}
+	for rows.Next() {
+		variant, err := scanAISuggestion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ai suggestion variant: %w", err)
+		}
+		variants = append(variants, variant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate ai suggestion variant rows: %w", err)
+	}
+
+	return variants, nil
+}
+
+// UpdateReview writes the review fields if the stored status is still expected.
+func (r *postgresAISuggestionRepository) UpdateReview(
+	ctx context.Context,
+	suggestion *domain.AISuggestion,
+	expected domain.AISuggestionStatus,
+) (*domain.AISuggestion, error) {
+	query := fmt.Sprintf(`
+		UPDATE ai_suggestions
+		SET status = $3, generated_content = $4, generation_metadata = $5, user_feedback = $6, user_notes = NULLIF($7, ''),
+			applied_at = CASE WHEN $8 THEN COALESCE(applied_at, NOW()) END,
+			applied_revision_id = $9, applied_start = $10, applied_end = $11,
+			applied_character_id = $12, applied_place_id = $13, applied_note_id = $14
+		WHERE id = $1 AND status = $2
+		RETURNING %s;`, aiSuggestionColumns)
+
+	a := suggestion.Application
+	if a == nil {
+		a = &domain.AISuggestionApplication{}
+	}
+	updated, err := scanAISuggestion(conn(ctx, r.pool).QueryRow(ctx, query,
+		suggestion.ID, expected, suggestion.Status, suggestion.GeneratedContent,
+		suggestion.GenerationMetadata, suggestion.UserFeedback, suggestion.UserNotes,
+		suggestion.Application != nil, a.RevisionID, a.Start, a.End,
+		a.CharacterID, a.PlaceID, a.NoteID,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.missOrConflict(ctx, suggestion.ID)
+		}
+		return nil, fmt.Errorf("failed to update ai suggestion: %w", err)
+	}
+
+	return updated, nil
+}
+
+// RecordReveal appends a reveal to the moderation outcome in one statement, so
+// concurrent reveals are all kept.
+func (r *postgresAISuggestionRepository) RecordReveal(ctx context.Context, id, userID uuid.UUID) (*domain.AISuggestion, error) {
+	query := fmt.Sprintf(`
+		UPDATE ai_suggestions
+		SET generation_metadata = jsonb_set(generation_metadata, '{moderation,reveals}',
+			COALESCE(generation_metadata->'moderation'->'reveals', '[]'::jsonb)
+				|| jsonb_build_array(jsonb_build_object('userId', $2::text, 'revealedAt', NOW())))
+		WHERE id = $1 AND generation_metadata ? 'moderation'
+			AND NOT COALESCE(generation_metadata->'moderation'->'reveals', '[]'::jsonb)
+				@> jsonb_build_array(jsonb_build_object('userId', $2::text))
+		RETURNING %s;`, aiSuggestionColumns)
+
+	revealed, err := scanAISuggestion(conn(ctx, r.pool).QueryRow(ctx, query, id, userID.String()))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Already revealed to the user, not moderated or missing
+			return r.GetByID(ctx, id)
+		}
+		return nil, fmt.Errorf("failed to record ai suggestion reveal: %w", err)
+	}
+	return revealed, nil
+}
+
+// Cancel stops a suggestion that is still to be generated.
+func (r *postgresAISuggestionRepository) Cancel(ctx context.Context, id uuid.UUID) (*domain.AISuggestion, error) {
+	query := fmt.Sprintf(`
+		UPDATE ai_suggestions
+		SET status = 'cancelled', retry_at = NULL
+		WHERE id = $1 AND status IN ('pending', 'generating')
+		RETURNING %s;`, aiSuggestionColumns)
+
+	cancelled, err := scanAISuggestion(conn(ctx, r.pool).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.missOrConflict(ctx, id)
+		}
+		return nil, fmt.Errorf("failed to cancel ai suggestion: %w", err)
+	}
+
+	return cancelled, nil
+}
+
+// Requeue resets the attempts of a failed suggestion and makes it pending again. The
+// last error is kept until the next attempt.
+func (r *postgresAISuggestionRepository) Requeue(ctx context.Context, id uuid.UUID) (*domain.AISuggestion, error) {
+	query := fmt.Sprintf(`
+		UPDATE ai_suggestions
+		SET status = 'pending', attempts = 0, retry_at = NULL
+		WHERE id = $1 AND status = 'failed'
+		RETURNING %s;`, aiSuggestionColumns)
+
+	requeued, err := scanAISuggestion(conn(ctx, r.pool).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.missOrConflict(ctx, id)
+		}
+		return nil, fmt.Errorf("failed to requeue ai suggestion: %w", err)
+	}
+
+	return requeued, nil
+}
+
+// Claim takes a suggestion for generation. The updated_at trigger stamps the claim.
+func (r *postgresAISuggestionRepository) Claim(ctx context.Context, id uuid.UUID, staleBefore time.Time) (*domain.AISuggestion, error) {
+	query := fmt.Sprintf(`
+		UPDATE ai_suggestions
+		SET status = 'generating', attempts = attempts + 1, retry_at = NULL
+		WHERE id = $1
+			AND ((status = 'pending' AND (retry_at IS NULL OR retry_at <= NOW()))
+				OR (status = 'generating' AND updated_at < $2))
+		RETURNING %s;`, aiSuggestionColumns)
+
+	claimed, err := scanAISuggestion(conn(ctx, r.pool).QueryRow(ctx, query, id, staleBefore))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.missOrConflict(ctx, id)
+		}
+		return nil, fmt.Errorf("failed to claim ai suggestion: %w", err)
+	}
+
+	return claimed, nil
+}
+
+// FinishGeneration records the generation outcome of a claimed suggestion.
+func (r *postgresAISuggestionRepository) FinishGeneration(
+	ctx context.Context,
+	suggestion *domain.AISuggestion,
+	claimedAt time.Time,
+) (*domain.AISuggestion, error) {
+	query := fmt.Sprintf(`
+		UPDATE ai_suggestions
+		SET status = $3, generated_content = NULLIF($4, ''), model_used = NULLIF($5, ''), generation_metadata = $6,
+			prompt_context = COALESCE(NULLIF($7, ''), prompt_context),
+			last_error = NULLIF($8, ''), last_error_kind = NULLIF($9, ''),
+			generated_at = CASE WHEN $3 = 'generated'::ai_suggestion_status THEN NOW() ELSE generated_at END
+		WHERE id = $1 AND status = 'generating' AND updated_at = $2
+		RETURNING %s;`, aiSuggestionColumns)
+
+	updated, err := scanAISuggestion(conn(ctx, r.pool).QueryRow(ctx, query,
+		suggestion.ID, claimedAt, suggestion.Status, suggestion.GeneratedContent,
+		suggestion.ModelUsed, suggestion.GenerationMetadata, suggestion.PromptContext,
+		suggestion.LastError, string(suggestion.LastErrorKind),
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.missOrConflict(ctx, suggestion.ID)
+		}
+		return nil, fmt.Errorf("failed to finish ai suggestion generation: %w", err)
+	}
+
+	return updated, nil
+}
+
+// ScheduleRetry hands a claimed suggestion back after a transient failure.
+func (r *postgresAISuggestionRepository) ScheduleRetry(
+	ctx context.Context,
+	id uuid.UUID,
+	claimedAt time.Time,
+	lastError string,
+	retryAt time.Time,
+) error {
+	query := `
+		UPDATE ai_suggestions
+		SET status = 'pending', last_error = $3, last_error_kind = 'transient', retry_at = $4
+		WHERE id = $1 AND status = 'generating' AND updated_at = $2;`
+
+	tag, err := conn(ctx, r.pool).Exec(ctx, query, id, claimedAt, lastError, retryAt)
+	if err != nil {
+		return fmt.Errorf("failed to schedule ai suggestion retry: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return r.missOrConflict(ctx, id)
+	}
+	return nil
+}
+
+// Release hands a claimed suggestion back for another attempt. The interrupted attempt
+// is not counted.
+func (r *postgresAISuggestionRepository) Release(ctx context.Context, id uuid.UUID, claimedAt time.Time) error {
+	query := `
+		UPDATE ai_suggestions
+		SET status = 'pending', attempts = GREATEST(attempts - 1, 0)
+		WHERE id = $1 AND status = 'generating' AND updated_at = $2;`
+
+	tag, err := conn(ctx, r.pool).Exec(ctx, query, id, claimedAt)
+	if err != nil {
+		return fmt.Errorf("failed to release ai suggestion: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return r.missOrConflict(ctx, id)
+	}
+	return nil
+}
+
+// ListClaimable finds suggestions whose job was lost or whose worker died.
+func (r *postgresAISuggestionRepository) ListClaimable(
+	ctx context.Context,
+	pendingBefore, staleBefore time.Time,
+	limit int,
+) ([]uuid.UUID, error) {
+	query := `
+		SELECT id
+		FROM ai_suggestions
+		WHERE (status = 'pending' AND (retry_at <= NOW() OR (retry_at IS NULL AND updated_at < $1)))
+			OR (status = 'generating' AND updated_at < $2)
+		ORDER BY updated_at
+		LIMIT $3;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, pendingBefore, staleBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list claimable ai suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan claimable ai suggestion: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate claimable ai suggestion rows: %w", err)
+	}
+
+	return ids, nil
+}
+
+// missOrConflict tells apart a missing suggestion from a failed status guard.
+func (r *postgresAISuggestionRepository) missOrConflict(ctx context.Context, id uuid.UUID) error {
+	var exists bool
+	err := conn(ctx, r.pool).QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM ai_suggestions WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check ai suggestion: %w", err)
+	}
+	if !exists {
+		return repository.ErrAISuggestionNotFound
+	}
+	return repository.ErrAISuggestionStatusChanged
+}
+
+// scanAISuggestion reads a row selected with aiSuggestionColumns.
+func scanAISuggestion(row pgx.Row) (*domain.AISuggestion, error) {
+	s := &domain.AISuggestion{}
+	var appliedAt *time.Time
+	a := &domain.AISuggestionApplication{}
+	err := row.Scan(
+		&s.ID, &s.NovelID, &s.UserID, &s.Type, &s.Status, &s.Automatic,
+		&s.ContextChapterID, &s.ContextCharacterID, &s.ContextPlaceID, &s.ContextNoteID,
+		&s.SelectedText, &s.PromptContext, &s.PromptInstructions, &s.GeneratedContent,
+		&s.ModelUsed, &s.GenerationMetadata, &s.UserFeedback, &s.UserNotes,
+		&appliedAt, &a.RevisionID, &a.Start, &a.End,
+		&a.CharacterID, &a.PlaceID, &a.NoteID,
+		&s.Attempts, &s.LastError, &s.LastErrorKind, &s.RetryAt,
+		&s.ParentSuggestionID, &s.VariantIndex, &s.RequestedModel, &s.Temperature,
+		&s.RequestedAt, &s.GeneratedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if appliedAt != nil {
+		a.AppliedAt = *appliedAt
+		s.Application = a
+	}
+	return s, nil
+}
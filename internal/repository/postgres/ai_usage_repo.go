@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// postgresAIUsageRepository implements the repository.AIUsageRepository interface.
+type postgresAIUsageRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewAIUsageRepository creates a new instance of postgresAIUsageRepository.
+func NewAIUsageRepository(pool *pgxpool.Pool) repository.AIUsageRepository {
+	return &postgresAIUsageRepository{pool: pool}
+}
+
+// Record appends an entry to the usage ledger.
+func (r *postgresAIUsageRepository) Record(ctx context.Context, entry *domain.AIUsageEntry) (*domain.AIUsageEntry, error) {
+	query := `
+		INSERT INTO ai_usage (suggestion_id, user_id, novel_id, model, prompt_tokens, completion_tokens, total_tokens, estimated_cost)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8::float8)
+		RETURNING id, recorded_at;`
+
+	recorded := *entry
+	err := conn(ctx, r.pool).QueryRow(ctx, query,
+		entry.SuggestionID, entry.UserID, entry.NovelID, entry.Model,
+		entry.PromptTokens, entry.CompletionTokens, entry.TotalTokens, entry.EstimatedCost,
+	).Scan(&recorded.ID, &recorded.RecordedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record ai usage: %w", err)
+	}
+	return &recorded, nil
+}
+
+// Totals sums the ledger entries of a user and/or novel since the filter's time.
+func (r *postgresAIUsageRepository) Totals(ctx context.Context, filter repository.AIUsageFilter) (*domain.AIUsageTotals, error) {
+	query := `
+		SELECT count(*), COALESCE(sum(prompt_tokens), 0), COALESCE(sum(completion_tokens), 0),
+			COALESCE(sum(total_tokens), 0), COALESCE(sum(estimated_cost), 0)::float8
+		FROM ai_usage
+		WHERE ($1::uuid IS NULL OR user_id = $1) AND ($2::uuid IS NULL OR novel_id = $2) AND recorded_at >= $3;`
+
+	totals := &domain.AIUsageTotals{}
+	err := conn(ctx, r.pool).QueryRow(ctx, query, filter.UserID, filter.NovelID, filter.Since).Scan(
+		&totals.Generations, &totals.PromptTokens, &totals.CompletionTokens, &totals.TotalTokens, &totals.EstimatedCost,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum ai usage: %w", err)
+	}
+	return totals, nil
+}
+
+// NovelsUsedBy lists the novels a user generated suggestions for since the given time.
+func (r *postgresAIUsageRepository) NovelsUsedBy(ctx context.Context, userID uuid.UUID, since time.Time) ([]uuid.UUID, error) {
+	query := `
+		SELECT novel_id
+		FROM ai_usage
+		WHERE user_id = $1 AND recorded_at >= $2
+		GROUP BY novel_id
+		ORDER BY max(recorded_at) DESC;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list novels with ai usage: %w", err)
+	}
+	defer rows.Close()
+
+	novelIDs := []uuid.UUID{}
+	for rows.Next() {
+		var novelID uuid.UUID
+		if err := rows.Scan(&novelID); err != nil {
+			return nil, fmt.Errorf("failed to scan novel ID: %w", err)
+		}
+		novelIDs = append(novelIDs, novelID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate ai usage rows: %w", err)
+	}
+
+	return novelIDs, nil
+}
@@ -0,0 +1,216 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+const chapterChangeColumns = `
+	id, change_set_id, position, base_start, base_end, deleted_text, inserted_text,
+	status, resolved_by_user_id, resolved_at, applied_revision_id, created_at, updated_at`
+
+// postgresChapterChangeRepository implements the repository.ChapterChangeRepository interface.
+type postgresChapterChangeRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewChapterChangeRepository creates a new instance of postgresChapterChangeRepository.
+func NewChapterChangeRepository(pool *pgxpool.Pool) repository.ChapterChangeRepository {
+	return &postgresChapterChangeRepository{pool: pool}
+}
+
+// CreateSet stores a change set and its changes.
+func (r *postgresChapterChangeRepository) CreateSet(ctx context.Context, set *domain.ChapterChangeSet) (*domain.ChapterChangeSet, error) {
+	q := conn(ctx, r.pool)
+
+	err := q.QueryRow(ctx, `
+		INSERT INTO chapter_change_sets (chapter_id, novel_id, base_revision_id, proposed_by_user_id, description)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING id, created_at;`,
+		set.ChapterID, set.NovelID, set.BaseRevisionID, set.ProposedByUserID, set.Description,
+	).Scan(&set.ID, &set.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create change set: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO chapter_changes (change_set_id, position, base_start, base_end, deleted_text, inserted_text)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING %s;`, chapterChangeColumns)
+
+	changes := make([]*domain.ChapterChange, 0, len(set.Changes))
+	for i, change := range set.Changes {
+		created, err := scanChapterChange(q.QueryRow(ctx, query,
+			set.ID, i, change.Start, change.End, change.DeletedText, change.InsertedText,
+		))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create chapter change: %w", err)
+		}
+		changes = append(changes, created)
+	}
+	set.Changes = changes
+
+	return set, nil
+}
+
+// GetSet retrieves a change set with its changes.
+func (r *postgresChapterChangeRepository) GetSet(ctx context.Context, id uuid.UUID) (*domain.ChapterChangeSet, error) {
+	set := &domain.ChapterChangeSet{}
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT id, chapter_id, novel_id, base_revision_id, proposed_by_user_id, COALESCE(description, ''), created_at
+		FROM chapter_change_sets
+		WHERE id = $1;`, id,
+	).Scan(&set.ID, &set.ChapterID, &set.NovelID, &set.BaseRevisionID, &set.ProposedByUserID, &set.Description, &set.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrChangeSetNotFound
+		}
+		return nil, fmt.Errorf("failed to find change set by ID: %w", err)
+	}
+
+	if err := r.attachChanges(ctx, []*domain.ChapterChangeSet{set}); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// ListSetsByChapter retrieves the change sets of a chapter with their changes.
+func (r *postgresChapterChangeRepository) ListSetsByChapter(
+	ctx context.Context,
+	chapterID uuid.UUID,
+	status domain.ChapterChangeStatus,
+) ([]*domain.ChapterChangeSet, error) {
+	query := `
+		SELECT s.id, s.chapter_id, s.novel_id, s.base_revision_id, s.proposed_by_user_id, COALESCE(s.description, ''), s.created_at
+		FROM chapter_change_sets s
+		WHERE s.chapter_id = $1
+			AND ($2 = '' OR EXISTS (
+				SELECT 1 FROM chapter_changes c WHERE c.change_set_id = s.id AND c.status::text = $2))
+		ORDER BY s.created_at DESC;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, chapterID, string(status))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list change sets: %w", err)
+	}
+	defer rows.Close()
+
+	sets := []*domain.ChapterChangeSet{}
+	for rows.Next() {
+		set := &domain.ChapterChangeSet{}
+		if err := rows.Scan(
+			&set.ID, &set.ChapterID, &set.NovelID, &set.BaseRevisionID, &set.ProposedByUserID, &set.Description, &set.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan change set: %w", err)
+		}
+		sets = append(sets, set)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate change set rows: %w", err)
+	}
+
+	if err := r.attachChanges(ctx, sets); err != nil {
+		return nil, err
+	}
+	return sets, nil
+}
+
+// GetChange retrieves a single change.
+func (r *postgresChapterChangeRepository) GetChange(ctx context.Context, id uuid.UUID) (*domain.ChapterChange, error) {
+	return r.getChange(ctx, fmt.Sprintf(`SELECT %s FROM chapter_changes WHERE id = $1;`, chapterChangeColumns), id)
+}
+
+// LockChange retrieves a single change and locks its row until the transaction ends.
+func (r *postgresChapterChangeRepository) LockChange(ctx context.Context, id uuid.UUID) (*domain.ChapterChange, error) {
+	return r.getChange(ctx, fmt.Sprintf(`SELECT %s FROM chapter_changes WHERE id = $1 FOR UPDATE;`, chapterChangeColumns), id)
+}
+
+// Resolve records the review decision on a change.
+func (r *postgresChapterChangeRepository) Resolve(
+	ctx context.Context,
+	id uuid.UUID,
+	status domain.ChapterChangeStatus,
+	resolvedBy uuid.UUID,
+	appliedRevisionID *int64,
+) (*domain.ChapterChange, error) {
+	query := fmt.Sprintf(`
+		UPDATE chapter_changes
+		SET status = $2, resolved_by_user_id = $3, resolved_at = NOW(), applied_revision_id = $4
+		WHERE id = $1
+		RETURNING %s;`, chapterChangeColumns)
+
+	return r.getChange(ctx, query, id, status, resolvedBy, appliedRevisionID)
+}
+
+func (r *postgresChapterChangeRepository) getChange(ctx context.Context, query string, args ...any) (*domain.ChapterChange, error) {
+	change, err := scanChapterChange(conn(ctx, r.pool).QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrChangeNotFound
+		}
+		return nil, fmt.Errorf("failed to find chapter change: %w", err)
+	}
+	return change, nil
+}
+
+// attachChanges loads the changes of the given sets in one query.
+func (r *postgresChapterChangeRepository) attachChanges(ctx context.Context, sets []*domain.ChapterChangeSet) error {
+	if len(sets) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*domain.ChapterChangeSet, len(sets))
+	ids := make([]uuid.UUID, 0, len(sets))
+	for _, set := range sets {
+		set.Changes = []*domain.ChapterChange{}
+		byID[set.ID] = set
+		ids = append(ids, set.ID)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM chapter_changes
+		WHERE change_set_id = ANY($1)
+		ORDER BY change_set_id, position;`, chapterChangeColumns)
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, ids)
+	if err != nil {
+		return fmt.Errorf("failed to list chapter changes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		change, err := scanChapterChange(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan chapter change: %w", err)
+		}
+		if set, ok := byID[change.ChangeSetID]; ok {
+			set.Changes = append(set.Changes, change)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate chapter change rows: %w", err)
+	}
+	return nil
+}
+
+// scanChapterChange reads a row selected with chapterChangeColumns.
+func scanChapterChange(row pgx.Row) (*domain.ChapterChange, error) {
+	change := &domain.ChapterChange{}
+	err := row.Scan(
+		&change.ID, &change.ChangeSetID, &change.Position, &change.Start, &change.End,
+		&change.DeletedText, &change.InsertedText, &change.Status, &change.ResolvedByUserID,
+		&change.ResolvedAt, &change.AppliedRevisionID, &change.CreatedAt, &change.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
@@ -54,7 +54,7 @@ func (r *postgresChapterRepository) Create(ctx context.Context, chapter *domain.
 		fmt.Println("Chapter ID is empty, creating a new chapter")
 	}
 
-	err := r.pool.QueryRow(ctx, query,
+	err := conn(ctx, r.pool).QueryRow(ctx, query,
 		chapter.NovelID, chapter.Title, chapter.Content,
 		status, chapter.OrderIndex, wordCount, chapter.LastEditedByUserID,
 	).Scan(&chapter.ID, &chapter.CreatedAt, &chapter.UpdatedAt)
@@ -74,13 +74,13 @@ func (r *postgresChapterRepository) Create(ctx context.Context, chapter *domain.
 // GetByID retrieves a chapter by its ID.
 func (r *postgresChapterRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Chapter, error) {
 	query := `
-		SELECT id, novel_id, title, content, status, order_index, word_count,
-			last_edited_by_user_id, created_at, updated_at, published_at
+		SELECT id, novel_id, title, COALESCE(content, ''), status, order_index, word_count,
+			COALESCE(last_edited_by_user_id::text, ''), created_at, updated_at, published_at
 		FROM chapters
 		WHERE id = $1;`
 
 	chapter := &domain.Chapter{}
-	err := r.pool.QueryRow(ctx, query, id).Scan(
+	err := conn(ctx, r.pool).QueryRow(ctx, query, id).Scan(
 		&chapter.ID, &chapter.NovelID, &chapter.Title, &chapter.Content,
 		&chapter.Status, &chapter.OrderIndex, &chapter.WordCount,
 		&chapter.LastEditedByUserID, &chapter.CreatedAt, &chapter.UpdatedAt, &chapter.PublishedAt,
@@ -88,7 +88,7 @@ func (r *postgresChapterRepository) GetByID(ctx context.Context, id uuid.UUID) (
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errors.New("chapter not found")
+			return nil, repository.ErrChapterNotFound
 		}
 		log.Printf("Error scanning chapter by ID %s: %v", id, err)
 		return nil, fmt.Errorf("failed to find chapter by ID: %w", err)
@@ -97,6 +97,33 @@ func (r *postgresChapterRepository) GetByID(ctx context.Context, id uuid.UUID) (
 	return chapter, nil
 }
 
+// LockByID retrieves a chapter and locks its row until the surrounding transaction ends.
+func (r *postgresChapterRepository) LockByID(ctx context.Context, id uuid.UUID) (*domain.Chapter, error) {
+	query := `
+		SELECT id, novel_id, title, COALESCE(content, ''), status, order_index, word_count,
+			COALESCE(last_edited_by_user_id::text, ''), created_at, updated_at, published_at
+		FROM chapters
+		WHERE id = $1
+		FOR UPDATE;`
+
+	chapter := &domain.Chapter{}
+	err := conn(ctx, r.pool).QueryRow(ctx, query, id).Scan(
+		&chapter.ID, &chapter.NovelID, &chapter.Title, &chapter.Content,
+		&chapter.Status, &chapter.OrderIndex, &chapter.WordCount,
+		&chapter.LastEditedByUserID, &chapter.CreatedAt, &chapter.UpdatedAt, &chapter.PublishedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrChapterNotFound
+		}
+		log.Printf("Error locking chapter by ID %s: %v", id, err)
+		return nil, fmt.Errorf("failed to lock chapter by ID: %w", err)
+	}
+
+	return chapter, nil
+}
+
 // Update updates an existing chapter in the storage.
 func (r *postgresChapterRepository) Update(ctx context.Context, chapter *domain.Chapter) error {
 	query := `
@@ -106,10 +133,11 @@ func (r *postgresChapterRepository) Update(ctx context.Context, chapter *domain.
 			status = $4,
 			order_index = $5,
 			word_count = $6,
-			last_edited_by_user_id = $7,
+			last_edited_by_user_id = NULLIF($7, '')::uuid,
+			published_at = CASE WHEN $4 = 'published' THEN COALESCE(published_at, NOW()) ELSE published_at END,
 			updated_at = NOW()
 		WHERE id = $1
-		RETURNING updated_at;`
+		RETURNING updated_at, published_at;`
 
 	// Calculate word count if content is provided
 	wordCount := chapter.WordCount
@@ -117,14 +145,14 @@ func (r *postgresChapterRepository) Update(ctx context.Context, chapter *domain.
 		wordCount = countWords(chapter.Content)
 	}
 
-	err := r.pool.QueryRow(ctx, query,
+	err := conn(ctx, r.pool).QueryRow(ctx, query,
 		chapter.ID, chapter.Title, chapter.Content, chapter.Status,
 		chapter.OrderIndex, wordCount, chapter.LastEditedByUserID,
-	).Scan(&chapter.UpdatedAt)
+	).Scan(&chapter.UpdatedAt, &chapter.PublishedAt)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return errors.New("chapter not found")
+			return repository.ErrChapterNotFound
 		}
 		log.Printf("Error updating chapter with ID %s: %v", chapter.ID, err)
 		return fmt.Errorf("failed to update chapter: %w", err)
@@ -142,7 +170,7 @@ func (r *postgresChapterRepository) Delete(ctx context.Context, id uuid.UUID) er
 		DELETE FROM chapters
 		WHERE id = $1;`
 
-	result, err := r.pool.Exec(ctx, query, id)
+	result, err := conn(ctx, r.pool).Exec(ctx, query, id)
 	if err != nil {
 		log.Printf("Error deleting chapter with ID %s: %v", id, err)
 		return fmt.Errorf("failed to delete chapter: %w", err)
@@ -151,7 +179,7 @@ func (r *postgresChapterRepository) Delete(ctx context.Context, id uuid.UUID) er
 	rowsAffected := result.RowsAffected()
 
 	if rowsAffected == 0 {
-		return errors.New("chapter not found")
+		return repository.ErrChapterNotFound
 	}
 
 	return nil
@@ -160,14 +188,14 @@ func (r *postgresChapterRepository) Delete(ctx context.Context, id uuid.UUID) er
 // ListByNovelID retrieves all chapters belonging to a specific novel.
 func (r *postgresChapterRepository) ListByNovelID(ctx context.Context, novelID uuid.UUID) ([]*domain.Chapter, error) {
 	query := `
-		SELECT id, novel_id, title, content, status, order_index, word_count,
-			last_edited_by_user_id, created_at, updated_at, published_at
+		SELECT id, novel_id, title, COALESCE(content, ''), status, order_index, word_count,
+			COALESCE(last_edited_by_user_id::text, ''), created_at, updated_at, published_at
 		FROM chapters
 		WHERE novel_id = $1
 		ORDER BY order_index ASC;`
 
 	var chapters []*domain.Chapter
-	rows, err := r.pool.Query(ctx, query, novelID)
+	rows, err := conn(ctx, r.pool).Query(ctx, query, novelID)
 	if err != nil {
 		log.Printf("Error querying chapters by novel ID %s: %v", novelID, err)
 		return nil, fmt.Errorf("failed to find chapters by novel ID: %w", err)
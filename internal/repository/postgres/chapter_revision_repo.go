@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// postgresChapterRevisionRepository implements the repository.ChapterRevisionRepository interface.
+type postgresChapterRevisionRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewChapterRevisionRepository creates a new instance of postgresChapterRevisionRepository.
+func NewChapterRevisionRepository(pool *pgxpool.Pool) repository.ChapterRevisionRepository {
+	return &postgresChapterRevisionRepository{pool: pool}
+}
+
+// Create stores a new revision snapshot.
+func (r *postgresChapterRevisionRepository) Create(ctx context.Context, revision *domain.ChapterRevision) (*domain.ChapterRevision, error) {
+	query := `
+		INSERT INTO chapter_revisions (chapter_id, content, source, edited_by_user_id, revision_notes, word_count)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		RETURNING id, edited_at;`
+
+	if revision.Source == "" {
+		revision.Source = domain.ContentSourceUser
+	}
+	if revision.WordCount == 0 {
+		revision.WordCount = countWords(revision.Content)
+	}
+
+	err := conn(ctx, r.pool).QueryRow(ctx, query,
+		revision.ChapterID, revision.Content, revision.Source, revision.EditedByUserID,
+		revision.RevisionNotes, revision.WordCount,
+	).Scan(&revision.ID, &revision.EditedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chapter revision: %w", err)
+	}
+
+	return revision, nil
+}
+
+// GetByID retrieves a revision of a chapter, including its content.
+func (r *postgresChapterRevisionRepository) GetByID(ctx context.Context, chapterID uuid.UUID, id int64) (*domain.ChapterRevision, error) {
+	query := `
+		SELECT id, chapter_id, content, source, edited_at, edited_by_user_id, COALESCE(revision_notes, ''), word_count
+		FROM chapter_revisions
+		WHERE chapter_id = $1 AND id = $2;`
+
+	return r.getOne(ctx, query, chapterID, id)
+}
+
+// GetLatest retrieves the newest revision of a chapter, including its content.
+func (r *postgresChapterRevisionRepository) GetLatest(ctx context.Context, chapterID uuid.UUID) (*domain.ChapterRevision, error) {
+	query := `
+		SELECT id, chapter_id, content, source, edited_at, edited_by_user_id, COALESCE(revision_notes, ''), word_count
+		FROM chapter_revisions
+		WHERE chapter_id = $1
+		ORDER BY id DESC
+		LIMIT 1;`
+
+	return r.getOne(ctx, query, chapterID)
+}
+
+// ListByChapterID retrieves the revision history of a chapter, newest first, without content.
+func (r *postgresChapterRevisionRepository) ListByChapterID(ctx context.Context, chapterID uuid.UUID) ([]*domain.ChapterRevision, error) {
+	query := `
+		SELECT id, chapter_id, source, edited_at, edited_by_user_id, COALESCE(revision_notes, ''), word_count
+		FROM chapter_revisions
+		WHERE chapter_id = $1
+		ORDER BY id DESC;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chapter revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []*domain.ChapterRevision
+	for rows.Next() {
+		revision := &domain.ChapterRevision{}
+		if err := rows.Scan(
+			&revision.ID, &revision.ChapterID, &revision.Source, &revision.EditedAt,
+			&revision.EditedByUserID, &revision.RevisionNotes, &revision.WordCount,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan chapter revision: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate chapter revision rows: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (r *postgresChapterRevisionRepository) getOne(ctx context.Context, query string, args ...any) (*domain.ChapterRevision, error) {
+	revision := &domain.ChapterRevision{}
+	err := conn(ctx, r.pool).QueryRow(ctx, query, args...).Scan(
+		&revision.ID, &revision.ChapterID, &revision.Content, &revision.Source, &revision.EditedAt,
+		&revision.EditedByUserID, &revision.RevisionNotes, &revision.WordCount,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("failed to find chapter revision: %w", err)
+	}
+	return revision, nil
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// postgresCharacterAttributeRepository implements the repository.CharacterAttributeRepository interface.
+type postgresCharacterAttributeRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewCharacterAttributeRepository creates a new instance of postgresCharacterAttributeRepository.
+func NewCharacterAttributeRepository(pool *pgxpool.Pool) repository.CharacterAttributeRepository {
+	return &postgresCharacterAttributeRepository{pool: pool}
+}
+
+// ListByNovelID retrieves the attribute schema of a novel in display order.
+func (r *postgresCharacterAttributeRepository) ListByNovelID(ctx context.Context, novelID uuid.UUID) ([]*domain.CharacterAttributeDefinition, error) {
+	query := `
+		SELECT id, novel_id, key, label, value_type, required, options, position, created_at, updated_at
+		FROM character_attribute_definitions
+		WHERE novel_id = $1
+		ORDER BY position, key;`
+
+	rows, err := r.pool.Query(ctx, query, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing character attribute definitions: %w", err)
+	}
+	defer rows.Close()
+
+	var defs []*domain.CharacterAttributeDefinition
+	for rows.Next() {
+		def := &domain.CharacterAttributeDefinition{}
+		err := rows.Scan(
+			&def.ID, &def.NovelID, &def.Key, &def.Label, &def.ValueType,
+			&def.Required, &def.Options, &def.Position, &def.CreatedAt, &def.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning character attribute definition: %w", err)
+		}
+		defs = append(defs, def)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating character attribute definitions: %w", err)
+	}
+
+	return defs, nil
+}
+
+// ReplaceForNovel deletes the current schema and inserts the given definitions in one transaction.
+// Existing character values are left untouched; they are re-validated on the next write.
+func (r *postgresCharacterAttributeRepository) ReplaceForNovel(ctx context.Context, novelID uuid.UUID, defs []*domain.CharacterAttributeDefinition) ([]*domain.CharacterAttributeDefinition, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting attribute schema transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // No-op once committed
+
+	if _, err := tx.Exec(ctx, `DELETE FROM character_attribute_definitions WHERE novel_id = $1`, novelID); err != nil {
+		return nil, fmt.Errorf("error clearing character attribute definitions: %w", err)
+	}
+
+	query := `
+		INSERT INTO character_attribute_definitions (novel_id, key, label, value_type, required, options, position)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at;`
+
+	for i, def := range defs {
+		def.NovelID = novelID
+		def.Position = i
+		if def.Options == nil {
+			def.Options = []string{}
+		}
+		err := tx.QueryRow(ctx, query,
+			def.NovelID, def.Key, def.Label, def.ValueType, def.Required, def.Options, def.Position,
+		).Scan(&def.ID, &def.CreatedAt, &def.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error inserting character attribute definition %q: %w", def.Key, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing attribute schema: %w", err)
+	}
+
+	return defs, nil
+}
@@ -12,7 +12,7 @@ import (
 	"github.com/khaled2049/server/internal/repository"
 )
 
-var ErrCharacterNotFound = errors.New("character not found")
+var ErrCharacterNotFound = repository.ErrCharacterNotFound
 var ErrNovelNotFound = errors.New("novel not found")
 
 type postgresCharacterRepository struct {
@@ -25,9 +25,6 @@ func NewCharacterRepository(pool *pgxpool.Pool) repository.CharacterRepository {
 
 func (r *postgresCharacterRepository) Create(ctx context.Context, character *domain.Character) (*domain.Character, error) {
 	// Make sure we have a novel ID
-
-	fmt.Println("Creating character with ID:", character.ID)
-
 	if character.NovelID == uuid.Nil {
 		return nil, errors.New("novel ID is required")
 	}
@@ -36,17 +33,19 @@ func (r *postgresCharacterRepository) Create(ctx context.Context, character *dom
 		character.ID = uuid.New()
 	}
 
+	normalizeCharacter(character)
+
 	query := `
 		INSERT INTO characters (
-			id, novel_id, name, description, backstory, motivations, 
-			physical_description, image_url, source, created_by_user_id
+			id, novel_id, name, description, backstory, motivations,
+			physical_description, aliases, attributes, image_url, source, created_by_user_id
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE(NULLIF($11, ''), 'user')::content_source, $12
 		) RETURNING id, novel_id, name, description, backstory, motivations, 
-			physical_description, image_url, source, created_at, updated_at, created_by_user_id
+			physical_description, aliases, attributes, image_url, source, created_at, updated_at, created_by_user_id
 	`
 
-	row := r.pool.QueryRow(
+	row := conn(ctx, r.pool).QueryRow(
 		ctx,
 		query,
 		character.ID,
@@ -56,6 +55,8 @@ func (r *postgresCharacterRepository) Create(ctx context.Context, character *dom
 		character.Backstory,
 		character.Motivations,
 		character.PhysicalDescription,
+		character.Aliases,
+		character.Attributes,
 		character.ImageURL,
 		character.Source,
 		character.CreatedByUserID,
@@ -69,6 +70,8 @@ func (r *postgresCharacterRepository) Create(ctx context.Context, character *dom
 		&character.Backstory,
 		&character.Motivations,
 		&character.PhysicalDescription,
+		&character.Aliases,
+		&character.Attributes,
 		&character.ImageURL,
 		&character.Source,
 		&character.CreatedAt,
@@ -87,7 +90,7 @@ func (r *postgresCharacterRepository) GetByID(ctx context.Context, id uuid.UUID)
 	query := `
 		SELECT 
 			id, novel_id, name, description, backstory, motivations, 
-			physical_description, image_url, source, created_at, updated_at, created_by_user_id
+			physical_description, aliases, attributes, image_url, source, created_at, updated_at, created_by_user_id
 		FROM characters
 		WHERE id = $1
 	`
@@ -103,6 +106,8 @@ func (r *postgresCharacterRepository) GetByID(ctx context.Context, id uuid.UUID)
 		&character.Backstory,
 		&character.Motivations,
 		&character.PhysicalDescription,
+		&character.Aliases,
+		&character.Attributes,
 		&character.ImageURL,
 		&character.Source,
 		&character.CreatedAt,
@@ -129,13 +134,17 @@ func (r *postgresCharacterRepository) Update(ctx context.Context, character *dom
 			backstory = $3,
 			motivations = $4,
 			physical_description = $5,
-			image_url = $6
+			aliases = $6,
+			attributes = $7,
+			image_url = $8
 		-- novel_id, source, created_by_user_id are not updated here
 		-- updated_at is handled by the trigger
-		WHERE id = $7
+		WHERE id = $9
 		RETURNING updated_at 
 	`
 
+	normalizeCharacter(character)
+
 	err := r.pool.QueryRow(
 		ctx,
 		query,
@@ -144,6 +153,8 @@ func (r *postgresCharacterRepository) Update(ctx context.Context, character *dom
 		character.Backstory,
 		character.Motivations,
 		character.PhysicalDescription,
+		character.Aliases,
+		character.Attributes,
 		character.ImageURL,
 		character.ID,
 	).Scan(&character.UpdatedAt) // Scan the returned updated_at
@@ -177,7 +188,7 @@ func (r *postgresCharacterRepository) ListByNovelID(ctx context.Context, novelID
 	query := `
 		SELECT 
 			id, novel_id, name, description, backstory, motivations, 
-			physical_description, image_url, source, created_at, updated_at, created_by_user_id
+			physical_description, aliases, attributes, image_url, source, created_at, updated_at, created_by_user_id
 		FROM characters
 		WHERE novel_id = $1
 		ORDER BY name
@@ -200,6 +211,8 @@ func (r *postgresCharacterRepository) ListByNovelID(ctx context.Context, novelID
 			&character.Backstory,
 			&character.Motivations,
 			&character.PhysicalDescription,
+			&character.Aliases,
+			&character.Attributes,
 			&character.ImageURL,
 			&character.Source,
 			&character.CreatedAt,
@@ -223,9 +236,10 @@ func (r *postgresCharacterRepository) SearchByName(ctx context.Context, novelID
 	query := `
 		SELECT 
 			id, novel_id, name, description, backstory, motivations, 
-			physical_description, image_url, source, created_at, updated_at, created_by_user_id
+			physical_description, aliases, attributes, image_url, source, created_at, updated_at, created_by_user_id
 		FROM characters
-		WHERE novel_id = $1 AND name ILIKE $2
+		WHERE novel_id = $1
+			AND (name ILIKE $2 OR EXISTS (SELECT 1 FROM unnest(aliases) AS alias WHERE alias ILIKE $2))
 		ORDER BY name
 		LIMIT 20
 	`
@@ -247,6 +261,8 @@ func (r *postgresCharacterRepository) SearchByName(ctx context.Context, novelID
 			&character.Backstory,
 			&character.Motivations,
 			&character.PhysicalDescription,
+			&character.Aliases,
+			&character.Attributes,
 			&character.ImageURL,
 			&character.Source,
 			&character.CreatedAt,
@@ -265,3 +281,79 @@ func (r *postgresCharacterRepository) SearchByName(ctx context.Context, novelID
 
 	return characters, nil
 }
+
+// List retrieves the characters of a novel matching the filter.
+func (r *postgresCharacterRepository) List(ctx context.Context, filter repository.CharacterFilter) ([]*domain.Character, error) {
+	query := `
+		SELECT 
+			id, novel_id, name, description, backstory, motivations, 
+			physical_description, aliases, attributes, image_url, source, created_at, updated_at, created_by_user_id
+		FROM characters
+		WHERE novel_id = $1
+			AND ($2 = '' OR name ILIKE '%' || $2 || '%'
+				OR EXISTS (SELECT 1 FROM unnest(aliases) AS alias WHERE alias ILIKE '%' || $2 || '%'))
+			AND attributes @> $3
+		ORDER BY name
+	`
+
+	attributes := filter.Attributes
+	if attributes == nil {
+		attributes = domain.Attributes{}
+	}
+
+	rows, err := r.pool.Query(ctx, query, filter.NovelID, filter.Query, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("error filtering characters: %w", err)
+	}
+	defer rows.Close()
+
+	var characters []*domain.Character
+	for rows.Next() {
+		character := &domain.Character{}
+		err := rows.Scan(
+			&character.ID,
+			&character.NovelID,
+			&character.Name,
+			&character.Description,
+			&character.Backstory,
+			&character.Motivations,
+			&character.PhysicalDescription,
+			&character.Aliases,
+			&character.Attributes,
+			&character.ImageURL,
+			&character.Source,
+			&character.CreatedAt,
+			&character.UpdatedAt,
+			&character.CreatedByUserID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning filtered character row: %w", err)
+		}
+		characters = append(characters, character)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating filtered character rows: %w", err)
+	}
+
+	return characters, nil
+}
+
+// ListIDsByChapterID returns the characters linked to a chapter in chapter_characters.
+func (r *postgresCharacterRepository) ListIDsByChapterID(ctx context.Context, chapterID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, `SELECT character_id FROM chapter_characters WHERE chapter_id = $1`, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing chapter characters: %w", err)
+	}
+	return collectIDs(rows)
+}
+
+// normalizeCharacter replaces nil collections so they satisfy the NOT NULL columns.
+func normalizeCharacter(character *domain.Character) {
+	if character.Aliases == nil {
+		character.Aliases = []string{}
+	}
+	if character.Attributes == nil {
+		character.Attributes = domain.Attributes{}
+	}
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// postgresCollaboratorRepository implements the repository.CollaboratorRepository interface.
+type postgresCollaboratorRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewCollaboratorRepository creates a new instance of postgresCollaboratorRepository.
+func NewCollaboratorRepository(pool *pgxpool.Pool) repository.CollaboratorRepository {
+	return &postgresCollaboratorRepository{pool: pool}
+}
+
+// GetRole resolves the user's role, treating novels.owner_user_id as 'owner'.
+func (r *postgresCollaboratorRepository) GetRole(ctx context.Context, novelID, userID uuid.UUID) (domain.CollaborationRole, error) {
+	query := `
+		SELECT CASE WHEN n.owner_user_id = $2 THEN 'owner' ELSE nc.role::text END
+		FROM novels n
+		LEFT JOIN novel_collaborators nc ON nc.novel_id = n.id AND nc.user_id = $2
+		WHERE n.id = $1;`
+
+	var role *string
+	err := conn(ctx, r.pool).QueryRow(ctx, query, novelID, userID).Scan(&role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", repository.ErrNovelNotFound
+		}
+		return "", fmt.Errorf("failed to resolve collaborator role: %w", err)
+	}
+	if role == nil {
+		return "", repository.ErrCollaboratorNotFound
+	}
+
+	return domain.CollaborationRole(*role), nil
+}
+
+// ListByNovelID retrieves the explicit collaborators of a novel.
+func (r *postgresCollaboratorRepository) ListByNovelID(ctx context.Context, novelID uuid.UUID) ([]*domain.NovelCollaborator, error) {
+	query := `
+		SELECT novel_id, user_id, role, invited_at, joined_at
+		FROM novel_collaborators
+		WHERE novel_id = $1
+		ORDER BY invited_at;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collaborators: %w", err)
+	}
+	defer rows.Close()
+
+	var collaborators []*domain.NovelCollaborator
+	for rows.Next() {
+		collaborator := &domain.NovelCollaborator{}
+		if err := rows.Scan(
+			&collaborator.NovelID, &collaborator.UserID, &collaborator.Role,
+			&collaborator.InvitedAt, &collaborator.JoinedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan collaborator: %w", err)
+		}
+		collaborators = append(collaborators, collaborator)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate collaborator rows: %w", err)
+	}
+
+	return collaborators, nil
+}
+
+// Upsert adds a collaborator or updates the role of an existing one.
+func (r *postgresCollaboratorRepository) Upsert(ctx context.Context, collaborator *domain.NovelCollaborator) error {
+	query := `
+		INSERT INTO novel_collaborators (novel_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (novel_id, user_id) DO UPDATE SET role = EXCLUDED.role
+		RETURNING invited_at, joined_at;`
+
+	err := conn(ctx, r.pool).QueryRow(ctx, query, collaborator.NovelID, collaborator.UserID, collaborator.Role).
+		Scan(&collaborator.InvitedAt, &collaborator.JoinedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation
+			if pgErr.ConstraintName == "novel_collaborators_user_id_fkey" {
+				return repository.ErrUserNotFound
+			}
+			return repository.ErrNovelNotFound
+		}
+		return fmt.Errorf("failed to save collaborator: %w", err)
+	}
+
+	return nil
+}
+
+// Remove deletes a collaborator from a novel.
+func (r *postgresCollaboratorRepository) Remove(ctx context.Context, novelID, userID uuid.UUID) error {
+	result, err := conn(ctx, r.pool).Exec(ctx, `DELETE FROM novel_collaborators WHERE novel_id = $1 AND user_id = $2`, novelID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove collaborator: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return repository.ErrCollaboratorNotFound
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// commentTargetColumns maps each target type to its column in comments and its table.
+var commentTargetColumns = map[domain.CommentTargetType]struct{ column, table string }{
+	domain.CommentTargetChapter:       {"target_chapter_id", "chapters"},
+	domain.CommentTargetCharacter:     {"target_character_id", "characters"},
+	domain.CommentTargetPlace:         {"target_place_id", "places"},
+	domain.CommentTargetNote:          {"target_note_id", "notes"},
+	domain.CommentTargetTimelineEvent: {"target_timeline_event_id", "timeline_events"},
+}
+
+const commentColumns = `
+	id, novel_id, user_id,
+	target_chapter_id, target_character_id, target_place_id, target_note_id, target_timeline_event_id,
+	parent_comment_id, anchor_start, anchor_end, anchor_quote, anchor_orphaned,
+	content, status, created_at, updated_at`
+
+// postgresCommentRepository implements the repository.CommentRepository interface.
+type postgresCommentRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewCommentRepository creates a new instance of postgresCommentRepository.
+func NewCommentRepository(pool *pgxpool.Pool) repository.CommentRepository {
+	return &postgresCommentRepository{pool: pool}
+}
+
+// Create stores a new comment or reply.
+func (r *postgresCommentRepository) Create(ctx context.Context, comment *domain.Comment) (*domain.Comment, error) {
+	target, ok := commentTargetColumns[comment.TargetType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported comment target %q", comment.TargetType)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO comments (
+			novel_id, user_id, %s, parent_comment_id, content, status,
+			anchor_start, anchor_end, anchor_quote
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING %s;`, target.column, commentColumns)
+
+	status := comment.Status
+	if status == "" {
+		status = domain.CommentStatusOpen
+	}
+
+	var anchorStart, anchorEnd *int
+	var anchorQuote *string
+	if comment.Anchor != nil {
+		anchorStart, anchorEnd, anchorQuote = &comment.Anchor.Start, &comment.Anchor.End, &comment.Anchor.Quote
+	}
+
+	created, err := scanComment(conn(ctx, r.pool).QueryRow(ctx, query,
+		comment.NovelID, comment.UserID, comment.TargetID, comment.ParentCommentID, comment.Content, status,
+		anchorStart, anchorEnd, anchorQuote,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetByID retrieves a single comment.
+func (r *postgresCommentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Comment, error) {
+	query := fmt.Sprintf(`SELECT %s FROM comments WHERE id = $1;`, commentColumns)
+
+	comment, err := scanComment(conn(ctx, r.pool).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("failed to find comment by ID: %w", err)
+	}
+
+	return comment, nil
+}
+
+// UpdateContent replaces the text of a comment.
+func (r *postgresCommentRepository) UpdateContent(ctx context.Context, id uuid.UUID, content string) (*domain.Comment, error) {
+	query := fmt.Sprintf(`UPDATE comments SET content = $2 WHERE id = $1 RETURNING %s;`, commentColumns)
+
+	comment, err := scanComment(conn(ctx, r.pool).QueryRow(ctx, query, id, content))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("failed to update comment: %w", err)
+	}
+
+	return comment, nil
+}
+
+// UpdateStatus sets the open/resolved status of a comment.
+func (r *postgresCommentRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status domain.CommentStatus) (*domain.Comment, error) {
+	query := fmt.Sprintf(`UPDATE comments SET status = $2 WHERE id = $1 RETURNING %s;`, commentColumns)
+
+	comment, err := scanComment(conn(ctx, r.pool).QueryRow(ctx, query, id, status))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("failed to update comment status: %w", err)
+	}
+
+	return comment, nil
+}
+
+// Delete removes a comment; replies are removed by ON DELETE CASCADE.
+func (r *postgresCommentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := conn(ctx, r.pool).Exec(ctx, `DELETE FROM comments WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return repository.ErrCommentNotFound
+	}
+	return nil
+}
+
+// ListByTarget retrieves every comment attached to a target, oldest first.
+func (r *postgresCommentRepository) ListByTarget(ctx context.Context, filter repository.CommentFilter) ([]*domain.Comment, error) {
+	target, ok := commentTargetColumns[filter.TargetType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported comment target %q", filter.TargetType)
+	}
+
+	// The status filter applies to thread roots; replies follow their root.
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM comments c
+		WHERE c.%s = $1
+			AND ($2 = '' OR COALESCE(
+				(SELECT root.status FROM comments root WHERE root.id = c.parent_comment_id),
+				c.status) = $2)
+		ORDER BY c.created_at ASC;`, commentColumns, target.column)
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, filter.TargetID, string(filter.Status))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []*domain.Comment
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate comment rows: %w", err)
+	}
+
+	return comments, nil
+}
+
+// ListAnchoredByChapter retrieves the anchored comments of a chapter in anchor order.
+func (r *postgresCommentRepository) ListAnchoredByChapter(ctx context.Context, chapterID uuid.UUID) ([]*domain.Comment, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM comments
+		WHERE target_chapter_id = $1 AND anchor_start IS NOT NULL
+		ORDER BY anchor_start, created_at;`, commentColumns)
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list anchored comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []*domain.Comment
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan anchored comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate anchored comment rows: %w", err)
+	}
+
+	return comments, nil
+}
+
+// UpdateAnchor stores a remapped anchor without touching updated_at semantics of the content.
+func (r *postgresCommentRepository) UpdateAnchor(ctx context.Context, id uuid.UUID, anchor *domain.CommentAnchor) error {
+	query := `
+		UPDATE comments
+		SET anchor_start = $2, anchor_end = $3, anchor_quote = $4, anchor_orphaned = $5
+		WHERE id = $1;`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, id, anchor.Start, anchor.End, anchor.Quote, anchor.Orphaned)
+	if err != nil {
+		return fmt.Errorf("failed to update comment anchor: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return repository.ErrCommentNotFound
+	}
+	return nil
+}
+
+// TargetNovelID resolves the novel a comment target belongs to.
+func (r *postgresCommentRepository) TargetNovelID(ctx context.Context, targetType domain.CommentTargetType, targetID uuid.UUID) (uuid.UUID, error) {
+	target, ok := commentTargetColumns[targetType]
+	if !ok {
+		return uuid.Nil, fmt.Errorf("unsupported comment target %q", targetType)
+	}
+
+	var novelID uuid.UUID
+	err := conn(ctx, r.pool).QueryRow(ctx, fmt.Sprintf(`SELECT novel_id FROM %s WHERE id = $1`, target.table), targetID).Scan(&novelID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, repository.ErrCommentTargetNotFound
+		}
+		return uuid.Nil, fmt.Errorf("failed to resolve comment target: %w", err)
+	}
+
+	return novelID, nil
+}
+
+// scanComment reads a row selected with commentColumns.
+func scanComment(row pgx.Row) (*domain.Comment, error) {
+	comment := &domain.Comment{}
+	var chapterID, characterID, placeID, noteID, eventID *uuid.UUID
+	var status, anchorQuote *string
+	var anchorStart, anchorEnd *int
+	var anchorOrphaned bool
+
+	err := row.Scan(
+		&comment.ID, &comment.NovelID, &comment.UserID,
+		&chapterID, &characterID, &placeID, &noteID, &eventID,
+		&comment.ParentCommentID, &anchorStart, &anchorEnd, &anchorQuote, &anchorOrphaned,
+		&comment.Content, &status, &comment.CreatedAt, &comment.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if anchorStart != nil && anchorEnd != nil {
+		comment.Anchor = &domain.CommentAnchor{Start: *anchorStart, End: *anchorEnd, Orphaned: anchorOrphaned}
+		if anchorQuote != nil {
+			comment.Anchor.Quote = *anchorQuote
+		}
+	}
+
+	switch {
+	case chapterID != nil:
+		comment.TargetType, comment.TargetID = domain.CommentTargetChapter, *chapterID
+	case characterID != nil:
+		comment.TargetType, comment.TargetID = domain.CommentTargetCharacter, *characterID
+	case placeID != nil:
+		comment.TargetType, comment.TargetID = domain.CommentTargetPlace, *placeID
+	case noteID != nil:
+		comment.TargetType, comment.TargetID = domain.CommentTargetNote, *noteID
+	case eventID != nil:
+		comment.TargetType, comment.TargetID = domain.CommentTargetTimelineEvent, *eventID
+	}
+
+	comment.Status = domain.CommentStatusOpen
+	if status != nil {
+		comment.Status = domain.CommentStatus(*status)
+	}
+
+	return comment, nil
+}
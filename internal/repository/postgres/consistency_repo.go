@@ -0,0 +1,314 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+const consistencyCheckColumns = `
+	id, novel_id, chapter_id, requested_by_user_id, status, use_llm,
+	COALESCE(content_hash, ''), findings_count, COALESCE(error, ''),
+	requested_at, started_at, completed_at, updated_at`
+
+const consistencyFindingColumns = `
+	id, check_id, novel_id, chapter_id, kind, severity, source,
+	range_start, range_end, quote, message, COALESCE(entity_type, ''), entity_id, fingerprint,
+	status, COALESCE(resolution_note, ''), resolved_by_user_id, resolved_at, created_at`
+
+// postgresConsistencyRepository implements the repository.ConsistencyRepository interface.
+type postgresConsistencyRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewConsistencyRepository creates a new instance of postgresConsistencyRepository.
+func NewConsistencyRepository(pool *pgxpool.Pool) repository.ConsistencyRepository {
+	return &postgresConsistencyRepository{pool: pool}
+}
+
+// CreateCheck stores a new pending check.
+func (r *postgresConsistencyRepository) CreateCheck(ctx context.Context, check *domain.ConsistencyCheck) (*domain.ConsistencyCheck, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO consistency_checks (novel_id, chapter_id, requested_by_user_id, use_llm)
+		VALUES ($1, $2, $3, $4)
+		RETURNING %s;`, consistencyCheckColumns)
+
+	created, err := scanConsistencyCheck(conn(ctx, r.pool).QueryRow(ctx, query,
+		check.NovelID, check.ChapterID, check.RequestedByUserID, check.UseLLM,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consistency check: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetCheck retrieves a single check.
+func (r *postgresConsistencyRepository) GetCheck(ctx context.Context, id uuid.UUID) (*domain.ConsistencyCheck, error) {
+	query := fmt.Sprintf(`SELECT %s FROM consistency_checks WHERE id = $1;`, consistencyCheckColumns)
+
+	check, err := scanConsistencyCheck(conn(ctx, r.pool).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrConsistencyCheckNotFound
+		}
+		return nil, fmt.Errorf("failed to get consistency check: %w", err)
+	}
+
+	return check, nil
+}
+
+// ClaimCheck takes a check for a run. The updated_at trigger stamps the claim.
+func (r *postgresConsistencyRepository) ClaimCheck(
+	ctx context.Context,
+	id uuid.UUID,
+	contentHash string,
+	staleBefore time.Time,
+) (*domain.ConsistencyCheck, error) {
+	query := fmt.Sprintf(`
+		UPDATE consistency_checks
+		SET status = 'running', content_hash = $2, started_at = NOW(), error = NULL
+		WHERE id = $1 AND (status = 'pending' OR (status = 'running' AND updated_at < $3))
+		RETURNING %s;`, consistencyCheckColumns)
+
+	claimed, err := scanConsistencyCheck(conn(ctx, r.pool).QueryRow(ctx, query, id, contentHash, staleBefore))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.missOrConflict(ctx, id)
+		}
+		return nil, fmt.Errorf("failed to claim consistency check: %w", err)
+	}
+
+	return claimed, nil
+}
+
+// FinishCheck records the outcome of a claimed check.
+func (r *postgresConsistencyRepository) FinishCheck(
+	ctx context.Context,
+	check *domain.ConsistencyCheck,
+	startedAt time.Time,
+) (*domain.ConsistencyCheck, error) {
+	query := fmt.Sprintf(`
+		UPDATE consistency_checks
+		SET status = $3, findings_count = $4, error = NULLIF($5, ''), completed_at = NOW()
+		WHERE id = $1 AND status = 'running' AND started_at = $2
+		RETURNING %s;`, consistencyCheckColumns)
+
+	finished, err := scanConsistencyCheck(conn(ctx, r.pool).QueryRow(ctx, query,
+		check.ID, startedAt, check.Status, check.FindingsCount, check.Error,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.missOrConflict(ctx, check.ID)
+		}
+		return nil, fmt.Errorf("failed to finish consistency check: %w", err)
+	}
+
+	return finished, nil
+}
+
+// ListClaimableChecks finds checks whose job was lost or whose worker stopped.
+func (r *postgresConsistencyRepository) ListClaimableChecks(
+	ctx context.Context,
+	pendingBefore, staleBefore time.Time,
+	limit int,
+) ([]uuid.UUID, error) {
+	query := `
+		SELECT id
+		FROM consistency_checks
+		WHERE (status = 'pending' AND updated_at < $1) OR (status = 'running' AND updated_at < $2)
+		ORDER BY updated_at
+		LIMIT $3;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, pendingBefore, staleBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list claimable consistency checks: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan claimable consistency check: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate claimable consistency check rows: %w", err)
+	}
+
+	return ids, nil
+}
+
+// ReplaceOpenFindings swaps the open findings of a chapter for those of a new check.
+func (r *postgresConsistencyRepository) ReplaceOpenFindings(
+	ctx context.Context,
+	check *domain.ConsistencyCheck,
+	findings []*domain.ConsistencyFinding,
+) ([]*domain.ConsistencyFinding, error) {
+	db := conn(ctx, r.pool)
+	if _, err := db.Exec(ctx, `DELETE FROM consistency_findings WHERE chapter_id = $1 AND status = 'open';`, check.ChapterID); err != nil {
+		return nil, fmt.Errorf("failed to delete open consistency findings: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO consistency_findings (
+			check_id, novel_id, chapter_id, kind, severity, source,
+			range_start, range_end, quote, message, entity_type, entity_id, fingerprint
+		)
+		SELECT $1::uuid, $2::uuid, $3::uuid, $4, $5, $6, $7::int, $8::int, $9, $10, NULLIF($11, ''), $12::uuid, $13::text
+		WHERE NOT EXISTS (
+			SELECT 1 FROM consistency_findings
+			WHERE chapter_id = $3::uuid AND fingerprint = $13::text AND status = 'dismissed'
+		)
+		RETURNING %s;`, consistencyFindingColumns)
+
+	stored := []*domain.ConsistencyFinding{}
+	for _, f := range findings {
+		var rangeStart, rangeEnd *int
+		if f.Range != nil {
+			rangeStart, rangeEnd = &f.Range.Start, &f.Range.End
+		}
+
+		created, err := scanConsistencyFinding(db.QueryRow(ctx, query,
+			check.ID, check.NovelID, check.ChapterID, f.Kind, f.Severity, f.Source,
+			rangeStart, rangeEnd, f.Quote, f.Message, f.EntityType, f.EntityID, f.Fingerprint,
+		))
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue // Dismissed
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create consistency finding: %w", err)
+		}
+		stored = append(stored, created)
+	}
+
+	return stored, nil
+}
+
+// GetFinding retrieves a single finding.
+func (r *postgresConsistencyRepository) GetFinding(ctx context.Context, id uuid.UUID) (*domain.ConsistencyFinding, error) {
+	query := fmt.Sprintf(`SELECT %s FROM consistency_findings WHERE id = $1;`, consistencyFindingColumns)
+
+	finding, err := scanConsistencyFinding(conn(ctx, r.pool).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrConsistencyFindingNotFound
+		}
+		return nil, fmt.Errorf("failed to get consistency finding: %w", err)
+	}
+
+	return finding, nil
+}
+
+// ListFindings retrieves the findings of a chapter, optionally of one status.
+func (r *postgresConsistencyRepository) ListFindings(
+	ctx context.Context,
+	filter repository.ConsistencyFindingFilter,
+) ([]*domain.ConsistencyFinding, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM consistency_findings
+		WHERE chapter_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY range_start NULLS FIRST, created_at, id;`, consistencyFindingColumns)
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, filter.ChapterID, string(filter.Status))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list consistency findings: %w", err)
+	}
+	defer rows.Close()
+
+	findings := []*domain.ConsistencyFinding{}
+	for rows.Next() {
+		finding, err := scanConsistencyFinding(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan consistency finding: %w", err)
+		}
+		findings = append(findings, finding)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate consistency finding rows: %w", err)
+	}
+
+	return findings, nil
+}
+
+// UpdateFindingStatus resolves, dismisses or reopens a finding.
+func (r *postgresConsistencyRepository) UpdateFindingStatus(
+	ctx context.Context,
+	finding *domain.ConsistencyFinding,
+) (*domain.ConsistencyFinding, error) {
+	query := fmt.Sprintf(`
+		UPDATE consistency_findings
+		SET status = $2, resolution_note = NULLIF($3, ''), resolved_by_user_id = $4,
+			resolved_at = CASE WHEN $2 = 'open' THEN NULL ELSE NOW() END
+		WHERE id = $1
+		RETURNING %s;`, consistencyFindingColumns)
+
+	updated, err := scanConsistencyFinding(conn(ctx, r.pool).QueryRow(ctx, query,
+		finding.ID, finding.Status, finding.ResolutionNote, finding.ResolvedByUserID,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrConsistencyFindingNotFound
+		}
+		return nil, fmt.Errorf("failed to update consistency finding status: %w", err)
+	}
+
+	return updated, nil
+}
+
+// missOrConflict tells apart a missing check from a failed status guard.
+func (r *postgresConsistencyRepository) missOrConflict(ctx context.Context, id uuid.UUID) error {
+	var exists bool
+	err := conn(ctx, r.pool).QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM consistency_checks WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check consistency check: %w", err)
+	}
+	if !exists {
+		return repository.ErrConsistencyCheckNotFound
+	}
+	return repository.ErrConsistencyCheckStatusChanged
+}
+
+// scanConsistencyCheck reads a row selected with consistencyCheckColumns.
+func scanConsistencyCheck(row pgx.Row) (*domain.ConsistencyCheck, error) {
+	c := &domain.ConsistencyCheck{}
+	err := row.Scan(
+		&c.ID, &c.NovelID, &c.ChapterID, &c.RequestedByUserID, &c.Status, &c.UseLLM,
+		&c.ContentHash, &c.FindingsCount, &c.Error,
+		&c.RequestedAt, &c.StartedAt, &c.CompletedAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// scanConsistencyFinding reads a row selected with consistencyFindingColumns.
+func scanConsistencyFinding(row pgx.Row) (*domain.ConsistencyFinding, error) {
+	f := &domain.ConsistencyFinding{}
+	var rangeStart, rangeEnd *int
+	err := row.Scan(
+		&f.ID, &f.CheckID, &f.NovelID, &f.ChapterID, &f.Kind, &f.Severity, &f.Source,
+		&rangeStart, &rangeEnd, &f.Quote, &f.Message, &f.EntityType, &f.EntityID, &f.Fingerprint,
+		&f.Status, &f.ResolutionNote, &f.ResolvedByUserID, &f.ResolvedAt, &f.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if rangeStart != nil && rangeEnd != nil {
+		f.Range = &domain.TextRange{Start: *rangeStart, End: *rangeEnd}
+	}
+	return f, nil
+}
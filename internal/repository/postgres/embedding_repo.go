@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// postgresEmbeddingRepository implements the repository.EmbeddingRepository interface.
+type postgresEmbeddingRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewEmbeddingRepository creates a new instance of postgresEmbeddingRepository.
+func NewEmbeddingRepository(pool *pgxpool.Pool) repository.EmbeddingRepository {
+	return &postgresEmbeddingRepository{pool: pool}
+}
+
+// GetSource retrieves the index entry of a chapter or note.
+func (r *postgresEmbeddingRepository) GetSource(ctx context.Context, sourceType domain.EmbeddingSourceType, id uuid.UUID) (*domain.EmbeddingSource, error) {
+	query := `
+		SELECT source_type, source_id, novel_id, model, title, source_updated_at, indexed_at
+		FROM embedding_sources
+		WHERE source_type = $1 AND source_id = $2;`
+
+	source := &domain.EmbeddingSource{}
+	err := conn(ctx, r.pool).QueryRow(ctx, query, string(sourceType), id).Scan(
+		&source.Type, &source.ID, &source.NovelID, &source.Model, &source.Title, &source.UpdatedAt, &source.IndexedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrEmbeddingSourceNotFound
+		}
+		return nil, fmt.Errorf("failed to find embedding source: %w", err)
+	}
+	return source, nil
+}
+
+// ListChunksBySource retrieves the passages of a source in order.
+func (r *postgresEmbeddingRepository) ListChunksBySource(ctx context.Context, sourceType domain.EmbeddingSourceType, id uuid.UUID) ([]*domain.EmbeddingChunk, error) {
+	query := `
+		SELECT source_type, source_id, chunk_index, start_offset, end_offset, content, embedding, ''
+		FROM embedding_chunks
+		WHERE source_type = $1 AND source_id = $2
+		ORDER BY chunk_index;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, string(sourceType), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list embedding chunks: %w", err)
+	}
+	return scanEmbeddingChunks(rows)
+}
+
+// ReplaceSource upserts the source unless a newer version is indexed with the same
+// model, then swaps its passages.
+func (r *postgresEmbeddingRepository) ReplaceSource(ctx context.Context, source *domain.EmbeddingSource, chunks []*domain.EmbeddingChunk) (bool, error) {
+	upsert := `
+		INSERT INTO embedding_sources (source_type, source_id, novel_id, model, title, source_updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (source_type, source_id) DO UPDATE
+		SET novel_id = EXCLUDED.novel_id,
+			model = EXCLUDED.model,
+			title = EXCLUDED.title,
+			source_updated_at = EXCLUDED.source_updated_at,
+			indexed_at = NOW()
+		WHERE embedding_sources.model <> EXCLUDED.model
+			OR embedding_sources.source_updated_at <= EXCLUDED.source_updated_at
+		RETURNING indexed_at;`
+
+	q := conn(ctx, r.pool)
+	err := q.QueryRow(ctx, upsert,
+		string(source.Type), source.ID, source.NovelID, source.Model, source.Title, source.UpdatedAt,
+	).Scan(&source.IndexedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to upsert embedding source: %w", err)
+	}
+
+	if _, err := q.Exec(ctx, `DELETE FROM embedding_chunks WHERE source_type = $1 AND source_id = $2;`,
+		string(source.Type), source.ID); err != nil {
+		return false, fmt.Errorf("failed to delete embedding chunks: %w", err)
+	}
+
+	insert := `
+		INSERT INTO embedding_chunks (source_type, source_id, chunk_index, novel_id, start_offset, end_offset, content, embedding)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`
+	for _, chunk := range chunks {
+		if _, err := q.Exec(ctx, insert,
+			string(source.Type), source.ID, chunk.Index, source.NovelID,
+			chunk.Start, chunk.End, chunk.Content, chunk.Embedding,
+		); err != nil {
+			return false, fmt.Errorf("failed to insert embedding chunk: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// ListChunksByNovel retrieves the passages of a novel's chapters and notes indexed
+// with model. Sources deleted since they were indexed are left out.
+func (r *postgresEmbeddingRepository) ListChunksByNovel(ctx context.Context, novelID uuid.UUID, model string) ([]*domain.EmbeddingChunk, error) {
+	query := `
+		SELECT k.source_type, k.source_id, k.chunk_index, k.start_offset, k.end_offset, k.content, k.embedding,
+			COALESCE(c.title, n.title, '')
+		FROM embedding_chunks k
+		JOIN embedding_sources s ON s.source_type = k.source_type AND s.source_id = k.source_id
+		LEFT JOIN chapters c ON k.source_type = 'chapter' AND c.id = k.source_id
+		LEFT JOIN notes n ON k.source_type = 'note' AND n.id = k.source_id
+		WHERE k.novel_id = $1 AND s.model = $2 AND (c.id IS NOT NULL OR n.id IS NOT NULL)
+		ORDER BY k.source_type, k.source_id, k.chunk_index;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, novelID, model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list embedding chunks by novel: %w", err)
+	}
+	return scanEmbeddingChunks(rows)
+}
+
+// ListStale lists the chapters and notes whose index is missing or out of date.
+func (r *postgresEmbeddingRepository) ListStale(ctx context.Context, model string, limit int) ([]*domain.EmbeddingSource, error) {
+	query := `
+		SELECT src.source_type, src.id, src.novel_id, src.updated_at
+		FROM (
+			SELECT 'chapter' AS source_type, id, novel_id, updated_at FROM chapters
+			UNION ALL
+			SELECT 'note' AS source_type, id, novel_id, updated_at FROM notes
+		) src
+		LEFT JOIN embedding_sources s ON s.source_type = src.source_type AND s.source_id = src.id
+		WHERE s.source_id IS NULL OR s.model <> $1 OR s.source_updated_at < src.updated_at
+		ORDER BY src.updated_at
+		LIMIT $2;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, model, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale embedding sources: %w", err)
+	}
+	defer rows.Close()
+
+	sources := []*domain.EmbeddingSource{}
+	for rows.Next() {
+		source := &domain.EmbeddingSource{}
+		if err := rows.Scan(&source.Type, &source.ID, &source.NovelID, &source.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan stale embedding source: %w", err)
+		}
+		sources = append(sources, source)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate stale embedding source rows: %w", err)
+	}
+	return sources, nil
+}
+
+// DeleteOrphans removes the index of chapters and notes that no longer exist; their
+// passages go with them.
+func (r *postgresEmbeddingRepository) DeleteOrphans(ctx context.Context) (int64, error) {
+	query := `
+		DELETE FROM embedding_sources s
+		WHERE (s.source_type = 'chapter' AND NOT EXISTS (SELECT 1 FROM chapters c WHERE c.id = s.source_id))
+			OR (s.source_type = 'note' AND NOT EXISTS (SELECT 1 FROM notes n WHERE n.id = s.source_id));`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete orphaned embedding sources: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+func scanEmbeddingChunks(rows pgx.Rows) ([]*domain.EmbeddingChunk, error) {
+	defer rows.Close()
+
+	chunks := []*domain.EmbeddingChunk{}
+	for rows.Next() {
+		chunk := &domain.EmbeddingChunk{}
+		if err := rows.Scan(
+			&chunk.SourceType, &chunk.SourceID, &chunk.Index, &chunk.Start, &chunk.End,
+			&chunk.Content, &chunk.Embedding, &chunk.Title,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan embedding chunk: %w", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate embedding chunk rows: %w", err)
+	}
+	return chunks, nil
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+const liveEventColumns = `id, type, novel_id, user_id, actor_user_id, data, created_at`
+
+// postgresLiveEventRepository implements the repository.LiveEventRepository interface.
+type postgresLiveEventRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewLiveEventRepository creates a new instance of postgresLiveEventRepository.
+func NewLiveEventRepository(pool *pgxpool.Pool) repository.LiveEventRepository {
+	return &postgresLiveEventRepository{pool: pool}
+}
+
+// Create inserts a live event.
+func (r *postgresLiveEventRepository) Create(ctx context.Context, event *domain.LiveEvent) error {
+	query := `
+		INSERT INTO live_events (type, novel_id, user_id, actor_user_id, data)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at;`
+
+	err := conn(ctx, r.pool).QueryRow(ctx, query,
+		event.Type, event.NovelID, event.UserID, event.ActorUserID, event.Data,
+	).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create live event: %w", err)
+	}
+	return nil
+}
+
+// ListAfter retrieves the events following afterID, oldest first.
+func (r *postgresLiveEventRepository) ListAfter(ctx context.Context, afterID int64, limit int) ([]*domain.LiveEvent, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM live_events
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2;`, liveEventColumns)
+
+	return r.list(ctx, query, afterID, limit)
+}
+
+// ListForUser retrieves the events following afterID that are for the user or about one
+// of the novels, oldest first.
+func (r *postgresLiveEventRepository) ListForUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	novelIDs []uuid.UUID,
+	afterID int64,
+	limit int,
+) ([]*domain.LiveEvent, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM live_events
+		WHERE id > $1
+			AND (user_id = $2 OR (user_id IS NULL AND novel_id = ANY($3)))
+		ORDER BY id
+		LIMIT $4;`, liveEventColumns)
+
+	if novelIDs == nil {
+		novelIDs = []uuid.UUID{}
+	}
+	return r.list(ctx, query, afterID, userID, novelIDs, limit)
+}
+
+// Bounds reads the lowest and highest event IDs.
+func (r *postgresLiveEventRepository) Bounds(ctx context.Context) (int64, int64, error) {
+	var oldest, latest int64
+	err := conn(ctx, r.pool).QueryRow(ctx, `SELECT COALESCE(MIN(id), 0), COALESCE(MAX(id), 0) FROM live_events;`).
+		Scan(&oldest, &latest)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read live event bounds: %w", err)
+	}
+	return oldest, latest, nil
+}
+
+// DeleteBefore removes the events created before the given time, except the latest.
+func (r *postgresLiveEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM live_events
+		WHERE created_at < $1 AND id < (SELECT MAX(id) FROM live_events);`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune live events: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+func (r *postgresLiveEventRepository) list(ctx context.Context, query string, args ...any) ([]*domain.LiveEvent, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list live events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*domain.LiveEvent{}
+	for rows.Next() {
+		event, err := scanLiveEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan live event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate live event rows: %w", err)
+	}
+
+	return events, nil
+}
+
+// scanLiveEvent reads a row selected with liveEventColumns.
+func scanLiveEvent(row pgx.Row) (*domain.LiveEvent, error) {
+	e := &domain.LiveEvent{}
+	err := row.Scan(&e.ID, &e.Type, &e.NovelID, &e.UserID, &e.ActorUserID, &e.Data, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
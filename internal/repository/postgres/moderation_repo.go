@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// postgresModerationSettingsRepository implements the repository.ModerationSettingsRepository interface.
+type postgresModerationSettingsRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewModerationSettingsRepository creates a new instance of postgresModerationSettingsRepository.
+func NewModerationSettingsRepository(pool *pgxpool.Pool) repository.ModerationSettingsRepository {
+	return &postgresModerationSettingsRepository{pool: pool}
+}
+
+// Get retrieves the moderation settings of a novel.
+func (r *postgresModerationSettingsRepository) Get(ctx context.Context, novelID uuid.UUID) (*domain.NovelModerationSettings, error) {
+	query := `
+		SELECT novel_id, content_rating, allowed_categories, updated_by_user_id, updated_at
+		FROM novel_moderation_settings
+		WHERE novel_id = $1;`
+
+	settings := &domain.NovelModerationSettings{}
+	err := conn(ctx, r.pool).QueryRow(ctx, query, novelID).Scan(
+		&settings.NovelID, &settings.ContentRating, &settings.AllowedCategories, &settings.UpdatedByUserID, &settings.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrModerationSettingsNotFound
+		}
+		return nil, fmt.Errorf("failed to get novel moderation settings: %w", err)
+	}
+	return settings, nil
+}
+
+// Upsert stores the moderation settings of a novel.
+func (r *postgresModerationSettingsRepository) Upsert(
+	ctx context.Context,
+	settings *domain.NovelModerationSettings,
+) (*domain.NovelModerationSettings, error) {
+	query := `
+		INSERT INTO novel_moderation_settings (novel_id, content_rating, allowed_categories, updated_by_user_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (novel_id) DO UPDATE
+		SET content_rating = EXCLUDED.content_rating,
+			allowed_categories = EXCLUDED.allowed_categories,
+			updated_by_user_id = EXCLUDED.updated_by_user_id,
+			updated_at = NOW()
+		RETURNING updated_at;`
+
+	saved := *settings
+	if saved.AllowedCategories == nil {
+		saved.AllowedCategories = []string{}
+	}
+	err := conn(ctx, r.pool).QueryRow(ctx, query,
+		saved.NovelID, saved.ContentRating, saved.AllowedCategories, saved.UpdatedByUserID,
+	).Scan(&saved.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save novel moderation settings: %w", err)
+	}
+	return &saved, nil
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+const noteColumns = `
+	id, novel_id, COALESCE(title, ''), content, note_type, source,
+	linked_chapter_id, linked_character_id, linked_place_id, created_at, updated_at, created_by_user_id`
+
+// postgresNoteRepository implements the repository.NoteRepository interface.
+type postgresNoteRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewNoteRepository creates a new instance of postgresNoteRepository.
+func NewNoteRepository(pool *pgxpool.Pool) repository.NoteRepository {
+	return &postgresNoteRepository{pool: pool}
+}
+
+// Create stores a new note. Type and source default to general and user.
+func (r *postgresNoteRepository) Create(ctx context.Context, note *domain.Note) (*domain.Note, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO notes (
+			novel_id, title, content, note_type, source,
+			linked_chapter_id, linked_character_id, linked_place_id, created_by_user_id
+		)
+		VALUES (
+			$1, NULLIF($2, ''), $3, COALESCE(NULLIF($4, ''), 'general')::note_type,
+			COALESCE(NULLIF($5, ''), 'user')::content_source, $6, $7, $8, $9
+		)
+		RETURNING %s;`, noteColumns)
+
+	created, err := scanNote(conn(ctx, r.pool).QueryRow(ctx, query,
+		note.NovelID, note.Title, note.Content, string(note.Type), note.Source,
+		note.LinkedChapterID, note.LinkedCharacterID, note.LinkedPlaceID, note.CreatedByUserID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create note: %w", err)
+	}
+	return created, nil
+}
+
+// GetByID retrieves a single note.
+func (r *postgresNoteRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Note, error) {
+	query := fmt.Sprintf(`SELECT %s FROM notes WHERE id = $1;`, noteColumns)
+
+	note, err := scanNote(conn(ctx, r.pool).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNoteNotFound
+		}
+		return nil, fmt.Errorf("failed to find note by ID: %w", err)
+	}
+	return note, nil
+}
+
+// ListByNovelID retrieves the notes of a novel, most recently updated first.
+func (r *postgresNoteRepository) ListByNovelID(ctx context.Context, novelID uuid.UUID) ([]*domain.Note, error) {
+	query := fmt.Sprintf(`SELECT %s FROM notes WHERE novel_id = $1 ORDER BY updated_at DESC;`, noteColumns)
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := []*domain.Note{}
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+		notes = append(notes, note)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate note rows: %w", err)
+	}
+
+	return notes, nil
+}
+
+func scanNote(row pgx.Row) (*domain.Note, error) {
+	n := &domain.Note{}
+	err := row.Scan(
+		&n.ID, &n.NovelID, &n.Title, &n.Content, &n.Type, &n.Source,
+		&n.LinkedChapterID, &n.LinkedCharacterID, &n.LinkedPlaceID, &n.CreatedAt, &n.UpdatedAt, &n.CreatedByUserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+const notificationPreferenceColumns = `id, user_id, novel_id, type, email_mode, created_at, updated_at`
+
+// postgresNotificationPreferenceRepository implements the repository.NotificationPreferenceRepository interface.
+type postgresNotificationPreferenceRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewNotificationPreferenceRepository creates a new instance of postgresNotificationPreferenceRepository.
+func NewNotificationPreferenceRepository(pool *pgxpool.Pool) repository.NotificationPreferenceRepository {
+	return &postgresNotificationPreferenceRepository{pool: pool}
+}
+
+// ListByUser retrieves a user's preferences, the broadest first.
+func (r *postgresNotificationPreferenceRepository) ListByUser(
+	ctx context.Context,
+	userID uuid.UUID,
+) ([]*domain.NotificationPreference, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM notification_preferences
+		WHERE user_id = $1
+		ORDER BY novel_id NULLS FIRST, type NULLS FIRST;`, notificationPreferenceColumns)
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification preferences: %w", err)
+	}
+	defer rows.Close()
+
+	preferences := []*domain.NotificationPreference{}
+	for rows.Next() {
+		preference, err := scanNotificationPreference(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification preference: %w", err)
+		}
+		preferences = append(preferences, preference)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate notification preference rows: %w", err)
+	}
+
+	return preferences, nil
+}
+
+// Upsert stores the preference of its scope, replacing the email mode of an existing one.
+func (r *postgresNotificationPreferenceRepository) Upsert(ctx context.Context, preference *domain.NotificationPreference) error {
+	query := fmt.Sprintf(`
+		INSERT INTO notification_preferences (user_id, novel_id, type, email_mode)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, COALESCE(novel_id, '00000000-0000-0000-0000-000000000000'), COALESCE(type, ''))
+		DO UPDATE SET email_mode = EXCLUDED.email_mode, updated_at = NOW()
+		RETURNING %s;`, notificationPreferenceColumns)
+
+	stored, err := scanNotificationPreference(conn(ctx, r.pool).QueryRow(ctx, query,
+		preference.UserID, preference.NovelID, preference.Type, preference.EmailMode))
+	if err != nil {
+		return fmt.Errorf("failed to save notification preference: %w", err)
+	}
+
+	*preference = *stored
+	return nil
+}
+
+// Delete removes the preference of a scope.
+func (r *postgresNotificationPreferenceRepository) Delete(
+	ctx context.Context,
+	userID uuid.UUID,
+	novelID *uuid.UUID,
+	notificationType *domain.NotificationType,
+) error {
+	query := `
+		DELETE FROM notification_preferences
+		WHERE user_id = $1 AND novel_id IS NOT DISTINCT FROM $2 AND type IS NOT DISTINCT FROM $3;`
+
+	result, err := conn(ctx, r.pool).Exec(ctx, query, userID, novelID, notificationType)
+	if err != nil {
+		return fmt.Errorf("failed to delete notification preference: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return repository.ErrNotificationPreferenceNotFound
+	}
+	return nil
+}
+
+// Resolve finds the email mode of the most specific preference applying to a notification.
+func (r *postgresNotificationPreferenceRepository) Resolve(
+	ctx context.Context,
+	userID uuid.UUID,
+	novelID *uuid.UUID,
+	notificationType domain.NotificationType,
+) (domain.NotificationEmailMode, error) {
+	query := `
+		SELECT email_mode
+		FROM notification_preferences
+		WHERE user_id = $1
+			AND (novel_id IS NULL OR novel_id = $2)
+			AND (type IS NULL OR type = $3)
+		ORDER BY novel_id IS NULL, type IS NULL
+		LIMIT 1;`
+
+	var mode domain.NotificationEmailMode
+	err := conn(ctx, r.pool).QueryRow(ctx, query, userID, novelID, notificationType).Scan(&mode)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", repository.ErrNotificationPreferenceNotFound
+		}
+		return "", fmt.Errorf("failed to resolve notification preference: %w", err)
+	}
+
+	return mode, nil
+}
+
+// scanNotificationPreference reads a row selected with notificationPreferenceColumns.
+func scanNotificationPreference(row pgx.Row) (*domain.NotificationPreference, error) {
+	p := &domain.NotificationPreference{}
+	err := row.Scan(&p.ID, &p.UserID, &p.NovelID, &p.Type, &p.EmailMode, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
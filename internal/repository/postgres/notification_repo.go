@@ -0,0 +1,307 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+const notificationColumns = `
+	id, user_id, novel_id, actor_user_id, type, subject_type, subject_id,
+	message, data, dedupe_key, read_at, created_at`
+
+// qualifiedNotificationColumns are notificationColumns of the table aliased n.
+const qualifiedNotificationColumns = `
+	n.id, n.user_id, n.novel_id, n.actor_user_id, n.type, n.subject_type, n.subject_id,
+	n.message, n.data, n.dedupe_key, n.read_at, n.created_at`
+
+// postgresNotificationRepository implements the repository.NotificationRepository interface.
+type postgresNotificationRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewNotificationRepository creates a new instance of postgresNotificationRepository.
+func NewNotificationRepository(pool *pgxpool.Pool) repository.NotificationRepository {
+	return &postgresNotificationRepository{pool: pool}
+}
+
+// Create stores a notification, skipping it when its dedupe key was used for the user.
+func (r *postgresNotificationRepository) Create(ctx context.Context, notification *domain.Notification) (bool, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO notifications (
+			user_id, novel_id, actor_user_id, type, subject_type, subject_id, message, data, dedupe_key
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8, '{}'::jsonb), $9)
+		ON CONFLICT (user_id, dedupe_key) DO NOTHING
+		RETURNING %s;`, notificationColumns)
+
+	created, err := scanNotification(conn(ctx, r.pool).QueryRow(ctx, query,
+		notification.UserID, notification.NovelID, notification.ActorUserID, notification.Type,
+		notification.SubjectType, notification.SubjectID, notification.Message, notification.Data,
+		notification.DedupeKey,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil // Already notified
+		}
+		return false, fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	*notification = *created
+	return true, nil
+}
+
+// ListByUser retrieves a page of a user's notifications, newest first.
+func (r *postgresNotificationRepository) ListByUser(
+	ctx context.Context,
+	userID uuid.UUID,
+	filter repository.NotificationFilter,
+) ([]*domain.Notification, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM notifications
+		WHERE user_id = $1
+			AND (NOT $2 OR read_at IS NULL)
+			AND ($3::timestamptz IS NULL OR created_at < $3)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4;`, notificationColumns)
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, userID, filter.UnreadOnly, filter.Before, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []*domain.Notification{}
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, notification)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate notification rows: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// MarkRead stamps read_at on one of the user's notifications, keeping an earlier stamp.
+func (r *postgresNotificationRepository) MarkRead(ctx context.Context, userID, id uuid.UUID) (*domain.Notification, error) {
+	query := fmt.Sprintf(`
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND user_id = $2
+		RETURNING %s;`, notificationColumns)
+
+	notification, err := scanNotification(conn(ctx, r.pool).QueryRow(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotificationNotFound
+		}
+		return nil, fmt.Errorf("failed to mark notification read: %w", err)
+	}
+
+	return notification, nil
+}
+
+// MarkAllRead stamps read_at on every unread notification of the user.
+func (r *postgresNotificationRepository) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := conn(ctx, r.pool).Exec(ctx,
+		`UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// CountUnread counts the user's unread notifications.
+func (r *postgresNotificationRepository) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := conn(ctx, r.pool).QueryRow(ctx,
+		`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+
+	return count, nil
+}
+
+// ClaimEmails claims pending notifications, and stale claims, for the email channel.
+func (r *postgresNotificationRepository) ClaimEmails(
+	ctx context.Context,
+	limit int,
+	staleBefore time.Time,
+) ([]*domain.Notification, error) {
+	query := fmt.Sprintf(`
+		WITH claimable AS (
+			SELECT id
+			FROM notifications
+			WHERE email_status = 'pending' OR (email_status = 'sending' AND email_claimed_at < $2)
+			ORDER BY created_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE notifications n
+		SET email_status = 'sending', email_claimed_at = NOW()
+		FROM claimable
+		WHERE n.id = claimable.id
+		RETURNING %s;`, qualifiedNotificationColumns)
+
+	notifications, err := r.queryNotifications(ctx, query, limit, staleBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim notification emails: %w", err)
+	}
+	return sortNotificationsByCreatedAt(notifications), nil
+}
+
+// SetEmailStatus settles the email of notifications; sent ones are stamped emailed_at.
+func (r *postgresNotificationRepository) SetEmailStatus(
+	ctx context.Context,
+	ids []uuid.UUID,
+	status domain.NotificationEmailStatus,
+) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	query := `
+		UPDATE notifications
+		SET email_status = $2, email_error = NULL, email_claimed_at = NULL,
+			emailed_at = CASE WHEN $2 = 'sent' THEN NOW() ELSE emailed_at END
+		WHERE id = ANY($1);`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, ids, status); err != nil {
+		return fmt.Errorf("failed to set notification email status: %w", err)
+	}
+	return nil
+}
+
+// FailEmails records a failed email attempt of notifications.
+func (r *postgresNotificationRepository) FailEmails(
+	ctx context.Context,
+	ids []uuid.UUID,
+	retry domain.NotificationEmailStatus,
+	maxAttempts int,
+	reason string,
+) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	query := `
+		UPDATE notifications
+		SET email_attempts = email_attempts + 1, email_error = $4, email_claimed_at = NULL,
+			email_status = CASE WHEN email_attempts + 1 >= $3 THEN 'failed' ELSE $2 END
+		WHERE id = ANY($1);`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, ids, retry, maxAttempts, reason); err != nil {
+		return fmt.Errorf("failed to record failed notification emails: %w", err)
+	}
+	return nil
+}
+
+// ListDigestRecipients lists the users with notifications due in their digest.
+func (r *postgresNotificationRepository) ListDigestRecipients(
+	ctx context.Context,
+	before time.Time,
+	limit int,
+) ([]uuid.UUID, error) {
+	query := `
+		SELECT user_id
+		FROM notifications
+		WHERE email_status = 'digest' AND created_at < $1
+		GROUP BY user_id
+		ORDER BY MIN(created_at)
+		LIMIT $2;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list digest recipients: %w", err)
+	}
+	defer rows.Close()
+
+	userIDs := []uuid.UUID{}
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan digest recipient: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate digest recipient rows: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+// ClaimDigest claims the notifications of a user's digest.
+func (r *postgresNotificationRepository) ClaimDigest(
+	ctx context.Context,
+	userID uuid.UUID,
+	before time.Time,
+) ([]*domain.Notification, error) {
+	query := fmt.Sprintf(`
+		UPDATE notifications
+		SET email_status = 'sending', email_claimed_at = NOW()
+		WHERE user_id = $1 AND email_status = 'digest' AND created_at < $2
+		RETURNING %s;`, notificationColumns)
+
+	notifications, err := r.queryNotifications(ctx, query, userID, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim notification digest: %w", err)
+	}
+	return sortNotificationsByCreatedAt(notifications), nil
+}
+
+// queryNotifications runs a query returning notificationColumns rows.
+func (r *postgresNotificationRepository) queryNotifications(ctx context.Context, query string, args ...any) ([]*domain.Notification, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*domain.Notification{}
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, rows.Err()
+}
+
+// sortNotificationsByCreatedAt orders notifications oldest first; UPDATE ... RETURNING
+// has no order of its own.
+func sortNotificationsByCreatedAt(notifications []*domain.Notification) []*domain.Notification {
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.Before(notifications[j].CreatedAt)
+	})
+	return notifications
+}
+
+// scanNotification reads a row selected with notificationColumns.
+func scanNotification(row pgx.Row) (*domain.Notification, error) {
+	n := &domain.Notification{}
+	err := row.Scan(
+		&n.ID, &n.UserID, &n.NovelID, &n.ActorUserID, &n.Type, &n.SubjectType, &n.SubjectID,
+		&n.Message, &n.Data, &n.DedupeKey, &n.ReadAt, &n.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// outboxRelayLockKey is the advisory lock key held by the publishing relay.
+const outboxRelayLockKey = 0x6f7574626f78 // "outbox"
+
+// postgresOutboxRepository implements the repository.OutboxRepository interface.
+type postgresOutboxRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewOutboxRepository creates a new instance of postgresOutboxRepository.
+func NewOutboxRepository(pool *pgxpool.Pool) repository.OutboxRepository {
+	return &postgresOutboxRepository{pool: pool}
+}
+
+// Add inserts an event into the outbox. IDs come from a sequence, and transactions may
+// commit out of ID order; a transaction-scoped advisory lock on the aggregate (keyed by
+// two integers, apart from the relay's single-key lock) makes the next writer of the same
+// aggregate wait for this one's commit before it takes an ID, which keeps the order per
+// aggregate that the relay relies on.
+func (r *postgresOutboxRepository) Add(ctx context.Context, event *domain.OutboxEvent) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1), hashtext($2))`,
+		event.AggregateType, event.AggregateID.String())
+	if err != nil {
+		return fmt.Errorf("failed to lock outbox aggregate: %w", err)
+	}
+
+	query := `
+		INSERT INTO outbox (aggregate_type, aggregate_id, topic, payload, headers)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at;`
+
+	err = conn(ctx, r.pool).QueryRow(ctx, query,
+		event.AggregateType, event.AggregateID, event.Topic, event.Payload, event.Headers,
+	).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add outbox event: %w", err)
+	}
+	return nil
+}
+
+// LockRelay takes the relay's advisory lock for the current transaction.
+func (r *postgresOutboxRepository) LockRelay(ctx context.Context) (bool, error) {
+	var locked bool
+	err := conn(ctx, r.pool).QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, outboxRelayLockKey).Scan(&locked)
+	if err != nil {
+		return false, fmt.Errorf("failed to lock outbox relay: %w", err)
+	}
+	return locked, nil
+}
+
+// ListPending retrieves unsent events, oldest first, skipping those out of attempts.
+func (r *postgresOutboxRepository) ListPending(ctx context.Context, maxAttempts, limit int) ([]*domain.OutboxEvent, error) {
+	query := `
+		SELECT id, aggregate_type, aggregate_id, topic, payload, headers, created_at, attempts, COALESCE(last_error, '')
+		FROM outbox
+		WHERE sent_at IS NULL AND attempts < $1
+		ORDER BY id
+		LIMIT $2;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, maxAttempts, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*domain.OutboxEvent{}
+	for rows.Next() {
+		event, err := scanOutboxEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate outbox rows: %w", err)
+	}
+
+	return events, nil
+}
+
+// MarkSent records that events were published.
+func (r *postgresOutboxRepository) MarkSent(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	query := `
+		UPDATE outbox
+		SET sent_at = NOW(), attempts = attempts + 1, last_error = NULL
+		WHERE id = ANY($1);`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, ids); err != nil {
+		return fmt.Errorf("failed to mark outbox events sent: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed publish attempt.
+func (r *postgresOutboxRepository) MarkFailed(ctx context.Context, id int64, reason string) error {
+	query := `
+		UPDATE outbox
+		SET attempts = attempts + 1, last_error = $2
+		WHERE id = $1;`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, id, reason); err != nil {
+		return fmt.Errorf("failed to mark outbox event failed: %w", err)
+	}
+	return nil
+}
+
+// DeleteSentBefore removes published events older than before.
+func (r *postgresOutboxRepository) DeleteSentBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `DELETE FROM outbox WHERE sent_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune outbox: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func scanOutboxEvent(row pgx.Row) (*domain.OutboxEvent, error) {
+	e := &domain.OutboxEvent{}
+	err := row.Scan(
+		&e.ID, &e.AggregateType, &e.AggregateID, &e.Topic, &e.Payload, &e.Headers,
+		&e.CreatedAt, &e.Attempts, &e.LastError,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
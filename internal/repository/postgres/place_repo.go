@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+const placeColumns = `
+	id, novel_id, name, COALESCE(description, ''), COALESCE(location_details, ''), COALESCE(atmosphere, ''),
+	COALESCE(image_url, ''), source, created_at, updated_at, created_by_user_id`
+
+// postgresPlaceRepository implements the repository.PlaceRepository interface.
+type postgresPlaceRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPlaceRepository creates a new instance of postgresPlaceRepository.
+func NewPlaceRepository(pool *pgxpool.Pool) repository.PlaceRepository {
+	return &postgresPlaceRepository{pool: pool}
+}
+
+// Create stores a new place.
+func (r *postgresPlaceRepository) Create(ctx context.Context, place *domain.Place) (*domain.Place, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO places (novel_id, name, description, location_details, atmosphere, image_url, source, created_by_user_id)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), COALESCE(NULLIF($7, ''), 'user')::content_source, $8)
+		RETURNING %s;`, placeColumns)
+
+	created, err := scanPlace(conn(ctx, r.pool).QueryRow(ctx, query,
+		place.NovelID, place.Name, place.Description, place.LocationDetails, place.Atmosphere,
+		place.ImageURL, place.Source, place.CreatedByUserID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create place: %w", err)
+	}
+	return created, nil
+}
+
+// GetByID retrieves a single place.
+func (r *postgresPlaceRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Place, error) {
+	query := fmt.Sprintf(`SELECT %s FROM places WHERE id = $1;`, placeColumns)
+
+	place, err := scanPlace(conn(ctx, r.pool).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrPlaceNotFound
+		}
+		return nil, fmt.Errorf("failed to find place by ID: %w", err)
+	}
+	return place, nil
+}
+
+// Update replaces the descriptive fields of a place.
+func (r *postgresPlaceRepository) Update(ctx context.Context, place *domain.Place) error {
+	query := `
+		UPDATE places
+		SET name = $2, description = NULLIF($3, ''), location_details = NULLIF($4, ''),
+			atmosphere = NULLIF($5, ''), image_url = NULLIF($6, '')
+		WHERE id = $1;`
+
+	tag, err := conn(ctx, r.pool).Exec(ctx, query,
+		place.ID, place.Name, place.Description, place.LocationDetails, place.Atmosphere, place.ImageURL,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update place: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrPlaceNotFound
+	}
+	return nil
+}
+
+// Delete removes a place.
+func (r *postgresPlaceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `DELETE FROM places WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete place: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrPlaceNotFound
+	}
+	return nil
+}
+
+// ListByNovelID retrieves the places of a novel by name.
+func (r *postgresPlaceRepository) ListByNovelID(ctx context.Context, novelID uuid.UUID) ([]*domain.Place, error) {
+	query := fmt.Sprintf(`SELECT %s FROM places WHERE novel_id = $1 ORDER BY name;`, placeColumns)
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list places: %w", err)
+	}
+	defer rows.Close()
+
+	places := []*domain.Place{}
+	for rows.Next() {
+		place, err := scanPlace(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan place: %w", err)
+		}
+		places = append(places, place)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate place rows: %w", err)
+	}
+
+	return places, nil
+}
+
+// ListIDsByChapterID returns the places linked to a chapter in chapter_places.
+func (r *postgresPlaceRepository) ListIDsByChapterID(ctx context.Context, chapterID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, `SELECT place_id FROM chapter_places WHERE chapter_id = $1`, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chapter places: %w", err)
+	}
+	return collectIDs(rows)
+}
+
+func scanPlace(row pgx.Row) (*domain.Place, error) {
+	p := &domain.Place{}
+	err := row.Scan(
+		&p.ID, &p.NovelID, &p.Name, &p.Description, &p.LocationDetails, &p.Atmosphere,
+		&p.ImageURL, &p.Source, &p.CreatedAt, &p.UpdatedAt, &p.CreatedByUserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// collectIDs reads a single UUID column and closes rows.
+func collectIDs(rows pgx.Rows) ([]uuid.UUID, error) {
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate id rows: %w", err)
+	}
+
+	return ids, nil
+}
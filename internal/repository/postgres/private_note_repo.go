@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+const privateNoteColumns = `id, user_id, novel_id, COALESCE(title, ''), content, created_at, updated_at`
+
+// postgresPrivateNoteRepository implements the repository.PrivateNoteRepository interface.
+// Every query filters on user_id so notes never leak across users.
+type postgresPrivateNoteRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPrivateNoteRepository creates a new instance of postgresPrivateNoteRepository.
+func NewPrivateNoteRepository(pool *pgxpool.Pool) repository.PrivateNoteRepository {
+	return &postgresPrivateNoteRepository{pool: pool}
+}
+
+// Create stores a new private note.
+func (r *postgresPrivateNoteRepository) Create(ctx context.Context, note *domain.PrivateNote) (*domain.PrivateNote, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO user_private_notes (user_id, novel_id, title, content)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		RETURNING %s;`, privateNoteColumns)
+
+	created, err := scanPrivateNote(conn(ctx, r.pool).QueryRow(ctx, query, note.UserID, note.NovelID, note.Title, note.Content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create private note: %w", err)
+	}
+	return created, nil
+}
+
+// GetByID retrieves one of the user's notes.
+func (r *postgresPrivateNoteRepository) GetByID(ctx context.Context, userID, id uuid.UUID) (*domain.PrivateNote, error) {
+	query := fmt.Sprintf(`SELECT %s FROM user_private_notes WHERE id = $1 AND user_id = $2;`, privateNoteColumns)
+
+	note, err := scanPrivateNote(conn(ctx, r.pool).QueryRow(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrPrivateNoteNotFound
+		}
+		return nil, fmt.Errorf("failed to find private note by ID: %w", err)
+	}
+	return note, nil
+}
+
+// Update replaces the novel context, title and content of one of the user's notes.
+func (r *postgresPrivateNoteRepository) Update(ctx context.Context, note *domain.PrivateNote) (*domain.PrivateNote, error) {
+	query := fmt.Sprintf(`
+		UPDATE user_private_notes
+		SET novel_id = $3, title = NULLIF($4, ''), content = $5
+		WHERE id = $1 AND user_id = $2
+		RETURNING %s;`, privateNoteColumns)
+
+	updated, err := scanPrivateNote(conn(ctx, r.pool).QueryRow(ctx, query,
+		note.ID, note.UserID, note.NovelID, note.Title, note.Content,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrPrivateNoteNotFound
+		}
+		return nil, fmt.Errorf("failed to update private note: %w", err)
+	}
+	return updated, nil
+}
+
+// Delete removes one of the user's notes.
+func (r *postgresPrivateNoteRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	result, err := conn(ctx, r.pool).Exec(ctx, `DELETE FROM user_private_notes WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete private note: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return repository.ErrPrivateNoteNotFound
+	}
+	return nil
+}
+
+// List retrieves the user's notes, best search matches first when a query is given,
+// otherwise most recently updated first.
+func (r *postgresPrivateNoteRepository) List(ctx context.Context, filter repository.PrivateNoteFilter) ([]*domain.PrivateNote, error) {
+	// The tsvector expression must match idx_user_private_notes_search
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM user_private_notes
+		WHERE user_id = $1
+			AND ($2::uuid IS NULL OR novel_id = $2)
+			AND ($3 = '' OR to_tsvector('simple', COALESCE(title, '') || ' ' || content) @@ websearch_to_tsquery('simple', $3)
+				OR title ILIKE '%%' || $3 || '%%' OR content ILIKE '%%' || $3 || '%%')
+		ORDER BY
+			CASE WHEN $3 = '' THEN 0
+				ELSE ts_rank(to_tsvector('simple', COALESCE(title, '') || ' ' || content), websearch_to_tsquery('simple', $3))
+			END DESC,
+			updated_at DESC;`, privateNoteColumns)
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, filter.UserID, filter.NovelID, filter.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list private notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := []*domain.PrivateNote{}
+	for rows.Next() {
+		note, err := scanPrivateNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan private note: %w", err)
+		}
+		notes = append(notes, note)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate private note rows: %w", err)
+	}
+	return notes, nil
+}
+
+// scanPrivateNote reads a row selected with privateNoteColumns.
+func scanPrivateNote(row pgx.Row) (*domain.PrivateNote, error) {
+	note := &domain.PrivateNote{}
+	err := row.Scan(&note.ID, &note.UserID, &note.NovelID, &note.Title, &note.Content, &note.CreatedAt, &note.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return note, nil
+}
@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+const promptTemplateColumns = `
+	id, suggestion_type, novel_id, version, system_prompt, user_prompt, required_variables,
+	is_active, COALESCE(notes, ''), created_by_user_id, created_at`
+
+// postgresPromptTemplateRepository implements the repository.PromptTemplateRepository interface.
+type postgresPromptTemplateRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPromptTemplateRepository creates a new instance of postgresPromptTemplateRepository.
+func NewPromptTemplateRepository(pool *pgxpool.Pool) repository.PromptTemplateRepository {
+	return &postgresPromptTemplateRepository{pool: pool}
+}
+
+// Create stores a new, inactive version numbered after the latest of its scope.
+func (r *postgresPromptTemplateRepository) Create(ctx context.Context, template *domain.PromptTemplate) (*domain.PromptTemplate, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO prompt_templates (
+			suggestion_type, novel_id, version, system_prompt, user_prompt, required_variables, notes, created_by_user_id
+		)
+		SELECT $1, $2, COALESCE(MAX(version), 0) + 1, $3, $4, $5, NULLIF($6, ''), $7
+		FROM prompt_templates
+		WHERE suggestion_type = $1 AND novel_id IS NOT DISTINCT FROM $2
+		RETURNING %s;`, promptTemplateColumns)
+
+	required := template.RequiredVariables
+	if required == nil {
+		required = []string{}
+	}
+
+	created, err := scanPromptTemplate(conn(ctx, r.pool).QueryRow(ctx, query,
+		template.SuggestionType, template.NovelID, template.SystemPrompt, template.UserPrompt,
+		required, template.Notes, template.CreatedByUserID,
+	))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, repository.ErrPromptTemplateVersionConflict
+		}
+		return nil, fmt.Errorf("failed to create prompt template: %w", err)
+	}
+	return created, nil
+}
+
+// GetByID retrieves a single template version.
+func (r *postgresPromptTemplateRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.PromptTemplate, error) {
+	query := fmt.Sprintf(`SELECT %s FROM prompt_templates WHERE id = $1;`, promptTemplateColumns)
+
+	template, err := scanPromptTemplate(conn(ctx, r.pool).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrPromptTemplateNotFound
+		}
+		return nil, fmt.Errorf("failed to find prompt template by ID: %w", err)
+	}
+	return template, nil
+}
+
+// List retrieves the versions of a scope, newest first.
+func (r *postgresPromptTemplateRepository) List(ctx context.Context, filter repository.PromptTemplateFilter) ([]*domain.PromptTemplate, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM prompt_templates
+		WHERE novel_id IS NOT DISTINCT FROM $1 AND ($2 = '' OR suggestion_type::text = $2)
+		ORDER BY suggestion_type, version DESC;`, promptTemplateColumns)
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, filter.NovelID, string(filter.Type))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []*domain.PromptTemplate{}
+	for rows.Next() {
+		template, err := scanPromptTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan prompt template: %w", err)
+		}
+		templates = append(templates, template)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate prompt template rows: %w", err)
+	}
+
+	return templates, nil
+}
+
+// Resolve prefers the novel's active override over the active global template.
+func (r *postgresPromptTemplateRepository) Resolve(
+	ctx context.Context,
+	suggestionType domain.AISuggestionType,
+	novelID uuid.UUID,
+) (*domain.PromptTemplate, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM prompt_templates
+		WHERE suggestion_type = $1 AND is_active AND (novel_id = $2 OR novel_id IS NULL)
+		ORDER BY novel_id NULLS LAST
+		LIMIT 1;`, promptTemplateColumns)
+
+	template, err := scanPromptTemplate(conn(ctx, r.pool).QueryRow(ctx, query, suggestionType, novelID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrPromptTemplateNotFound
+		}
+		return nil, fmt.Errorf("failed to resolve prompt template: %w", err)
+	}
+	return template, nil
+}
+
+// Activate deactivates the other versions of the template's scope, then activates it.
+// The unique index on active versions is checked per row, hence two statements.
+func (r *postgresPromptTemplateRepository) Activate(ctx context.Context, id uuid.UUID) (*domain.PromptTemplate, error) {
+	deactivate := `
+		UPDATE prompt_templates o
+		SET is_active = false
+		FROM prompt_templates t
+		WHERE t.id = $1 AND o.id <> t.id AND o.is_active
+			AND o.suggestion_type = t.suggestion_type AND o.novel_id IS NOT DISTINCT FROM t.novel_id;`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, deactivate, id); err != nil {
+		return nil, fmt.Errorf("failed to deactivate prompt templates: %w", err)
+	}
+
+	query := fmt.Sprintf(`UPDATE prompt_templates SET is_active = true WHERE id = $1 RETURNING %s;`, promptTemplateColumns)
+
+	activated, err := scanPromptTemplate(conn(ctx, r.pool).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrPromptTemplateNotFound
+		}
+		return nil, fmt.Errorf("failed to activate prompt template: %w", err)
+	}
+	return activated, nil
+}
+
+// Deactivate clears the active version of a type and scope.
+func (r *postgresPromptTemplateRepository) Deactivate(
+	ctx context.Context,
+	suggestionType domain.AISuggestionType,
+	novelID *uuid.UUID,
+) error {
+	query := `
+		UPDATE prompt_templates
+		SET is_active = false
+		WHERE suggestion_type = $1 AND novel_id IS NOT DISTINCT FROM $2 AND is_active;`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, suggestionType, novelID); err != nil {
+		return fmt.Errorf("failed to deactivate prompt template: %w", err)
+	}
+	return nil
+}
+
+func scanPromptTemplate(row pgx.Row) (*domain.PromptTemplate, error) {
+	t := &domain.PromptTemplate{}
+	err := row.Scan(
+		&t.ID, &t.SuggestionType, &t.NovelID, &t.Version, &t.SystemPrompt, &t.UserPrompt, &t.RequiredVariables,
+		&t.Active, &t.Notes, &t.CreatedByUserID, &t.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,248 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// summarySweepLockKey is the advisory lock key held by the process enqueueing summaries.
+const summarySweepLockKey = 0x73756d6d617279 // "summary"
+
+// postgresSummaryRepository implements the repository.SummaryRepository interface.
+type postgresSummaryRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewSummaryRepository creates a new instance of postgresSummaryRepository.
+func NewSummaryRepository(pool *pgxpool.Pool) repository.SummaryRepository {
+	return &postgresSummaryRepository{pool: pool}
+}
+
+// UpsertChapterSummary stores a chapter summary, replacing the previous one.
+func (r *postgresSummaryRepository) UpsertChapterSummary(ctx context.Context, summary *domain.ChapterSummary) error {
+	query := `
+		INSERT INTO chapter_summaries (chapter_id, novel_id, summary, content_hash, suggestion_id)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (chapter_id) DO UPDATE
+		SET summary = EXCLUDED.summary,
+			content_hash = EXCLUDED.content_hash,
+			suggestion_id = EXCLUDED.suggestion_id,
+			generated_at = NOW()
+		RETURNING generated_at;`
+
+	err := conn(ctx, r.pool).QueryRow(ctx, query,
+		summary.ChapterID, summary.NovelID, summary.Summary, summary.ContentHash, summary.SuggestionID,
+	).Scan(&summary.GeneratedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert chapter summary: %w", err)
+	}
+	return nil
+}
+
+// ListChapterSummaries retrieves the summaries of a novel's chapters.
+func (r *postgresSummaryRepository) ListChapterSummaries(ctx context.Context, novelID uuid.UUID) ([]*domain.ChapterSummary, error) {
+	query := `
+		SELECT chapter_id, novel_id, summary, content_hash, suggestion_id, generated_at
+		FROM chapter_summaries
+		WHERE novel_id = $1;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chapter summaries: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := []*domain.ChapterSummary{}
+	for rows.Next() {
+		s := &domain.ChapterSummary{}
+		if err := rows.Scan(&s.ChapterID, &s.NovelID, &s.Summary, &s.ContentHash, &s.SuggestionID, &s.GeneratedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan chapter summary: %w", err)
+		}
+		summaries = append(summaries, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate chapter summary rows: %w", err)
+	}
+	return summaries, nil
+}
+
+// ListChaptersToSummarize finds stable chapters whose summary is missing or stale.
+func (r *postgresSummaryRepository) ListChaptersToSummarize(
+	ctx context.Context,
+	stableBefore, retryBefore time.Time,
+	limit int,
+) ([]*repository.ChapterToSummarize, error) {
+	query := `
+		SELECT c.id, c.novel_id, c.title, c.content, COALESCE(c.last_edited_by_user_id, n.owner_user_id)
+		FROM chapters c
+		JOIN novels n ON n.id = c.novel_id
+		LEFT JOIN chapter_summaries s ON s.chapter_id = c.id
+		WHERE btrim(COALESCE(c.content, '')) <> ''
+			AND c.updated_at < $1
+			AND (s.chapter_id IS NULL OR s.content_hash <> md5(c.content))
+			AND NOT EXISTS (
+				SELECT 1 FROM ai_suggestions a
+				WHERE a.context_chapter_id = c.id AND a.automatic
+					AND (a.status IN ('pending', 'generating') OR (a.status = 'failed' AND a.updated_at > $2))
+			)
+		ORDER BY c.updated_at
+		LIMIT $3;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, stableBefore, retryBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chapters to summarize: %w", err)
+	}
+	defer rows.Close()
+
+	chapters := []*repository.ChapterToSummarize{}
+	for rows.Next() {
+		c := &repository.ChapterToSummarize{}
+		if err := rows.Scan(&c.ChapterID, &c.NovelID, &c.Title, &c.Content, &c.RequesterID); err != nil {
+			return nil, fmt.Errorf("failed to scan chapter to summarize: %w", err)
+		}
+		chapters = append(chapters, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate chapter to summarize rows: %w", err)
+	}
+	return chapters, nil
+}
+
+// ListSynopses retrieves the synopses of a novel by act.
+func (r *postgresSummaryRepository) ListSynopses(ctx context.Context, novelID uuid.UUID) ([]*domain.Synopsis, error) {
+	query := `
+		SELECT novel_id, act, synopsis, source_hash, COALESCE(pending_hash, ''), suggestion_id, generated_at, updated_at
+		FROM novel_synopses
+		WHERE novel_id = $1
+		ORDER BY act;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list novel synopses: %w", err)
+	}
+	defer rows.Close()
+
+	synopses := []*domain.Synopsis{}
+	for rows.Next() {
+		s := &domain.Synopsis{}
+		if err := rows.Scan(
+			&s.NovelID, &s.Act, &s.Synopsis, &s.SourceHash, &s.PendingHash, &s.SuggestionID, &s.GeneratedAt, &s.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan novel synopsis: %w", err)
+		}
+		synopses = append(synopses, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate novel synopsis rows: %w", err)
+	}
+	return synopses, nil
+}
+
+// SaveSynopsis upserts the synopsis of an act, leaving the book's checked_at alone.
+func (r *postgresSummaryRepository) SaveSynopsis(ctx context.Context, synopsis *domain.Synopsis) error {
+	query := `
+		INSERT INTO novel_synopses (novel_id, act, synopsis, source_hash, pending_hash, suggestion_id, generated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+		ON CONFLICT (novel_id, act) DO UPDATE
+		SET synopsis = EXCLUDED.synopsis,
+			source_hash = EXCLUDED.source_hash,
+			pending_hash = EXCLUDED.pending_hash,
+			suggestion_id = EXCLUDED.suggestion_id,
+			generated_at = EXCLUDED.generated_at,
+			updated_at = NOW()
+		RETURNING updated_at;`
+
+	err := conn(ctx, r.pool).QueryRow(ctx, query,
+		synopsis.NovelID, synopsis.Act, synopsis.Synopsis, synopsis.SourceHash, synopsis.PendingHash,
+		synopsis.SuggestionID, synopsis.GeneratedAt,
+	).Scan(&synopsis.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save novel synopsis: %w", err)
+	}
+	return nil
+}
+
+// CompleteSynopsis stores a generated synopsis if its generation is still the pending one.
+func (r *postgresSummaryRepository) CompleteSynopsis(ctx context.Context, suggestionID uuid.UUID, text string) (bool, error) {
+	query := `
+		UPDATE novel_synopses
+		SET synopsis = $2, source_hash = pending_hash, pending_hash = NULL, generated_at = NOW(), updated_at = NOW()
+		WHERE suggestion_id = $1 AND pending_hash IS NOT NULL;`
+
+	tag, err := conn(ctx, r.pool).Exec(ctx, query, suggestionID, text)
+	if err != nil {
+		return false, fmt.Errorf("failed to complete novel synopsis: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// DeleteActsAfter removes the synopses of acts past the novel's last one.
+func (r *postgresSummaryRepository) DeleteActsAfter(ctx context.Context, novelID uuid.UUID, act int) error {
+	if _, err := conn(ctx, r.pool).Exec(ctx, `DELETE FROM novel_synopses WHERE novel_id = $1 AND act > $2;`, novelID, act); err != nil {
+		return fmt.Errorf("failed to delete novel synopses: %w", err)
+	}
+	return nil
+}
+
+// MarkChecked stamps the book row of a novel, creating it if needed.
+func (r *postgresSummaryRepository) MarkChecked(ctx context.Context, novelID uuid.UUID) error {
+	query := `
+		INSERT INTO novel_synopses (novel_id, act, checked_at)
+		VALUES ($1, 0, NOW())
+		ON CONFLICT (novel_id, act) DO UPDATE SET checked_at = NOW();`
+
+	if _, err := conn(ctx, r.pool).Exec(ctx, query, novelID); err != nil {
+		return fmt.Errorf("failed to mark novel synopsis checked: %w", err)
+	}
+	return nil
+}
+
+// ListNovelsToRefresh finds novels with activity since their synopses were last checked.
+func (r *postgresSummaryRepository) ListNovelsToRefresh(ctx context.Context, recheckBefore time.Time, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT n.id
+		FROM novels n
+		LEFT JOIN novel_synopses b ON b.novel_id = n.id AND b.act = 0
+		WHERE EXISTS (SELECT 1 FROM chapter_summaries s WHERE s.novel_id = n.id)
+			AND (b.checked_at IS NULL OR b.checked_at < $1
+				OR EXISTS (SELECT 1 FROM chapters c WHERE c.novel_id = n.id AND c.updated_at > b.checked_at)
+				OR EXISTS (SELECT 1 FROM chapter_summaries s WHERE s.novel_id = n.id AND s.generated_at > b.checked_at)
+				OR EXISTS (SELECT 1 FROM novel_synopses a WHERE a.novel_id = n.id AND a.updated_at > b.checked_at))
+		ORDER BY b.checked_at NULLS FIRST
+		LIMIT $2;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, recheckBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list novels to refresh: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan novel to refresh: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate novel to refresh rows: %w", err)
+	}
+	return ids, nil
+}
+
+// LockSweep takes the summary sweep's advisory lock for the current transaction.
+func (r *postgresSummaryRepository) LockSweep(ctx context.Context) (bool, error) {
+	var locked bool
+	err := conn(ctx, r.pool).QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, summarySweepLockKey).Scan(&locked)
+	if err != nil {
+		return false, fmt.Errorf("failed to lock summary sweep: %w", err)
+	}
+	return locked, nil
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// postgresTimelineEventRepository implements the repository.TimelineEventRepository interface.
+type postgresTimelineEventRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewTimelineEventRepository creates a new instance of postgresTimelineEventRepository.
+func NewTimelineEventRepository(pool *pgxpool.Pool) repository.TimelineEventRepository {
+	return &postgresTimelineEventRepository{pool: pool}
+}
+
+// ListByNovelID retrieves the events of a novel in timeline order, with their links.
+func (r *postgresTimelineEventRepository) ListByNovelID(ctx context.Context, novelID uuid.UUID) ([]*domain.TimelineEvent, error) {
+	query := `
+		SELECT e.id, e.novel_id, e.title, COALESCE(e.description, ''), e.event_order, COALESCE(e.event_date_text, ''),
+			e.created_at, e.updated_at, e.created_by_user_id,
+			COALESCE(array_agg(l.entity_type ORDER BY l.entity_type, l.entity_id) FILTER (WHERE l.event_id IS NOT NULL), '{}'),
+			COALESCE(array_agg(l.entity_id ORDER BY l.entity_type, l.entity_id) FILTER (WHERE l.event_id IS NOT NULL), '{}')
+		FROM timeline_events e
+		LEFT JOIN timeline_event_links l ON l.event_id = e.id
+		WHERE e.novel_id = $1
+		GROUP BY e.id
+		ORDER BY e.event_order;`
+
+	rows, err := conn(ctx, r.pool).Query(ctx, query, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list timeline events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*domain.TimelineEvent{}
+	for rows.Next() {
+		e := &domain.TimelineEvent{}
+		var linkTypes []string
+		var linkIDs []uuid.UUID
+		err := rows.Scan(
+			&e.ID, &e.NovelID, &e.Title, &e.Description, &e.EventOrder, &e.EventDateText,
+			&e.CreatedAt, &e.UpdatedAt, &e.CreatedByUserID, &linkTypes, &linkIDs,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan timeline event: %w", err)
+		}
+		for i := range linkTypes {
+			e.Links = append(e.Links, domain.TimelineEventLink{EntityType: linkTypes[i], EntityID: linkIDs[i]})
+		}
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate timeline event rows: %w", err)
+	}
+
+	return events, nil
+}
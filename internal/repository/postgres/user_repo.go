@@ -129,3 +129,18 @@ func (r *postgresUserRepository) FindByEmail(ctx context.Context, email string)
 	return user, nil
 }
 
+// IsAdmin reports whether the user has the global admin role and is active.
+func (r *postgresUserRepository) IsAdmin(ctx context.Context, id string) (bool, error) {
+	query := `SELECT role = 'admin' AND is_active FROM users WHERE id = $1;`
+
+	var admin bool
+	err := r.pool.QueryRow(ctx, query, id).Scan(&admin)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check user role: %w", err)
+	}
+
+	return admin, nil
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+var ErrPrivateNoteNotFound = errors.New("private note not found")
+
+// PrivateNoteFilter narrows a user's private notes. Query is a full-text search over title and content.
+type PrivateNoteFilter struct {
+	UserID  uuid.UUID
+	NovelID *uuid.UUID
+	Query   string
+}
+
+// PrivateNoteRepository always scopes by the author: a note of another user is
+// reported as ErrPrivateNoteNotFound, never returned.
+type PrivateNoteRepository interface {
+	Create(ctx context.Context, note *domain.PrivateNote) (*domain.PrivateNote, error)
+	GetByID(ctx context.Context, userID, id uuid.UUID) (*domain.PrivateNote, error)
+	Update(ctx context.Context, note *domain.PrivateNote) (*domain.PrivateNote, error)
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+	List(ctx context.Context, filter PrivateNoteFilter) ([]*domain.PrivateNote, error)
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+var (
+	ErrPromptTemplateNotFound = errors.New("prompt template not found")
+	// ErrPromptTemplateVersionConflict is returned when another version of the same
+	// template was created concurrently.
+	ErrPromptTemplateVersionConflict = errors.New("prompt template version created concurrently")
+)
+
+// PromptTemplateFilter selects the versions of one template scope.
+type PromptTemplateFilter struct {
+	NovelID *uuid.UUID              // Nil for the global templates
+	Type    domain.AISuggestionType // Empty for all types
+}
+
+type PromptTemplateRepository interface {
+	// Create stores template as the next version of its type and scope, inactive.
+	Create(ctx context.Context, template *domain.PromptTemplate) (*domain.PromptTemplate, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.PromptTemplate, error)
+	// List returns the versions of a scope, newest first.
+	List(ctx context.Context, filter PromptTemplateFilter) ([]*domain.PromptTemplate, error)
+	// Resolve returns the active template of the novel for a type: its override if it has
+	// one, the global template otherwise.
+	Resolve(ctx context.Context, suggestionType domain.AISuggestionType, novelID uuid.UUID) (*domain.PromptTemplate, error)
+	// Activate makes a template the active version of its type and scope. Call it within
+	// a transaction.
+	Activate(ctx context.Context, id uuid.UUID) (*domain.PromptTemplate, error)
+	// Deactivate deactivates the active version of a type and scope, if any.
+	Deactivate(ctx context.Context, suggestionType domain.AISuggestionType, novelID *uuid.UUID) error
+}
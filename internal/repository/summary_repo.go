@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+// ChapterToSummarize is a chapter whose summary is missing or stale, with the user its
+// summary job is attributed to: the chapter's last editor or the novel's owner.
+type ChapterToSummarize struct {
+	ChapterID   uuid.UUID
+	NovelID     uuid.UUID
+	Title       string
+	Content     string
+	RequesterID uuid.UUID
+}
+
+// SummaryRepository stores generated chapter summaries and novel synopses.
+type SummaryRepository interface {
+	// UpsertChapterSummary stores the summary of a chapter in place of its previous one.
+	UpsertChapterSummary(ctx context.Context, summary *domain.ChapterSummary) error
+	ListChapterSummaries(ctx context.Context, novelID uuid.UUID) ([]*domain.ChapterSummary, error)
+	// ListChaptersToSummarize returns chapters with content whose summary is missing or
+	// stale and that were last updated before stableBefore, least recently updated
+	// first. Chapters with an automatic summary in progress, or one that failed after
+	// retryBefore, are left out.
+	ListChaptersToSummarize(ctx context.Context, stableBefore, retryBefore time.Time, limit int) ([]*ChapterToSummarize, error)
+
+	// ListSynopses returns the synopses of a novel, the book first and then by act.
+	ListSynopses(ctx context.Context, novelID uuid.UUID) ([]*domain.Synopsis, error)
+	// SaveSynopsis upserts the synopsis, source, pending hash and suggestion of an act.
+	SaveSynopsis(ctx context.Context, synopsis *domain.Synopsis) error
+	// CompleteSynopsis stores the text generated by suggestionID as the synopsis of the
+	// input it was pending for. It reports false when the suggestion was superseded.
+	CompleteSynopsis(ctx context.Context, suggestionID uuid.UUID, text string) (bool, error)
+	// DeleteActsAfter removes the synopses of acts numbered above act.
+	DeleteActsAfter(ctx context.Context, novelID uuid.UUID, act int) error
+	// MarkChecked records that a novel's synopses were brought up to date.
+	MarkChecked(ctx context.Context, novelID uuid.UUID) error
+	// ListNovelsToRefresh returns novels with chapter summaries whose synopses may be out
+	// of date: their chapters, summaries or synopses changed since they were last
+	// checked, or they were last checked before recheckBefore.
+	ListNovelsToRefresh(ctx context.Context, recheckBefore time.Time, limit int) ([]uuid.UUID, error)
+	// LockSweep takes a transaction-scoped lock that lets a single process enqueue
+	// summaries at a time. It reports false if another process holds the lock. Call it
+	// within a transaction.
+	LockSweep(ctx context.Context) (bool, error)
+}
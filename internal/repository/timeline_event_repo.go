@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+// TimelineEventRepository reads the story timeline of a novel.
+type TimelineEventRepository interface {
+	// ListByNovelID returns the novel's events with their links, in timeline order.
+	ListByNovelID(ctx context.Context, novelID uuid.UUID) ([]*domain.TimelineEvent, error)
+}
@@ -0,0 +1,10 @@
+package repository
+
+import "context"
+
+// Transactor runs a function inside a database transaction. Repositories called
+// with the context passed to fn take part in the same transaction; nested calls
+// join the outer transaction.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
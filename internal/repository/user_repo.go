@@ -22,6 +22,8 @@ type UserRepository interface {
 	// It should populate the User.ID and Timestamps.
 	Create(ctx context.Context, user *domain.User) (*domain.User, error)
 	FindByEmail(ctx context.Context, email string) (*domain.User, error)
+	// IsAdmin reports whether the user is an active platform administrator.
+	IsAdmin(ctx context.Context, id string) (bool, error)
 	// Update modifies an existing user's details.
 	// Update(ctx context.Context, user *domain.User) error
 }
\ No newline at end of file
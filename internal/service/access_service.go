@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// ErrForbidden is returned when the acting user lacks the permission for an action.
+var ErrForbidden = errors.New("forbidden")
+
+// AccessService resolves a user's collaboration role and enforces permissions on novels
+// and on platform administration.
+type AccessService struct {
+	novelRepo        repository.NovelRepository
+	collaboratorRepo repository.CollaboratorRepository
+	userRepo         repository.UserRepository
+}
+
+func NewAccessService(
+	novelRepo repository.NovelRepository,
+	collaboratorRepo repository.CollaboratorRepository,
+	userRepo repository.UserRepository) *AccessService {
+	return &AccessService{
+		novelRepo:        novelRepo,
+		collaboratorRepo: collaboratorRepo,
+		userRepo:         userRepo,
+	}
+}
+
+// Role returns the user's role on the novel. Non-collaborators of public novels are
+// treated as viewers; everyone else gets ErrForbidden.
+func (s *AccessService) Role(ctx context.Context, novelID, userID uuid.UUID) (domain.CollaborationRole, error) {
+	role, err := s.collaboratorRepo.GetRole(ctx, novelID, userID)
+	if err == nil {
+		return role, nil
+	}
+	if !errors.Is(err, repository.ErrCollaboratorNotFound) {
+		return "", err
+	}
+
+	novel, err := s.novelRepo.GetByID(ctx, novelID)
+	if err != nil {
+		return "", err
+	}
+	if novel.Visibility == domain.NovelVisibilityPublic {
+		return domain.CollaborationRoleViewer, nil
+	}
+	return "", ErrForbidden
+}
+
+// Require returns the user's role if it grants the permission, ErrForbidden otherwise.
+func (s *AccessService) Require(ctx context.Context, novelID, userID uuid.UUID, permission domain.Permission) (domain.CollaborationRole, error) {
+	role, err := s.Role(ctx, novelID, userID)
+	if err != nil {
+		return "", err
+	}
+	if !role.Can(permission) {
+		return "", fmt.Errorf("%w: role %s cannot %s", ErrForbidden, role, permission)
+	}
+	return role, nil
+}
+
+// RequireAdmin returns ErrForbidden unless the user is a platform administrator.
+func (s *AccessService) RequireAdmin(ctx context.Context, userID uuid.UUID) error {
+	admin, err := s.userRepo.IsAdmin(ctx, userID.String())
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return fmt.Errorf("%w: administrator role required", ErrForbidden)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// ErrInvalidAnalyticsQuery is returned for a malformed AI analytics query.
+var ErrInvalidAnalyticsQuery = errors.New("invalid ai analytics query")
+
+const (
+	defaultAnalyticsRange = 30 * 24 * time.Hour
+	maxAnalyticsRange     = 366 * 24 * time.Hour
+)
+
+// AIAnalyticsService reports how authors receive AI suggestions, so models and prompt
+// templates can be compared on their outcomes.
+type AIAnalyticsService struct {
+	analyticsRepo repository.AIAnalyticsRepository
+	access        *AccessService
+}
+
+func NewAIAnalyticsService(
+	analyticsRepo repository.AIAnalyticsRepository,
+	access *AccessService) *AIAnalyticsService {
+	return &AIAnalyticsService{
+		analyticsRepo: analyticsRepo,
+		access:        access,
+	}
+}
+
+// AIAnalyticsQuery selects the suggestions requested in [From, To), by default the
+// last 30 days, and the dimensions to break them down by, by default all of them.
+type AIAnalyticsQuery struct {
+	From    time.Time
+	To      time.Time
+	Type    domain.AISuggestionType
+	Model   string
+	NovelID *uuid.UUID
+	GroupBy []string
+}
+
+// Report aggregates the outcomes of the suggestions matching query. It is restricted
+// to administrators: it spans every user's novels.
+func (s *AIAnalyticsService) Report(ctx context.Context, actorID uuid.UUID, query AIAnalyticsQuery) (*domain.AIAnalyticsReport, error) {
+	if err := s.access.RequireAdmin(ctx, actorID); err != nil {
+		return nil, err
+	}
+
+	filter, err := analyticsFilter(query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.analyticsRepo.SuggestionStats(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	filter.GroupBy = nil
+	totals, err := s.analyticsRepo.SuggestionStats(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.AIAnalyticsReport{
+		From:    filter.From,
+		To:      filter.To,
+		GroupBy: analyticsDimensions(query.GroupBy),
+		Totals:  &domain.AISuggestionStats{},
+		Groups:  groups,
+	}
+	if len(totals) > 0 {
+		report.Totals = totals[0]
+	}
+	setRates(report.Totals)
+	for _, stats := range groups {
+		setRates(stats)
+	}
+	return report, nil
+}
+
+// analyticsFilter validates query and fills in its defaults.
+func analyticsFilter(query AIAnalyticsQuery, now time.Time) (repository.AIAnalyticsFilter, error) {
+	filter := repository.AIAnalyticsFilter{
+		From:    query.From,
+		To:      query.To,
+		Type:    query.Type,
+		Model:   query.Model,
+		NovelID: query.NovelID,
+		GroupBy: analyticsDimensions(query.GroupBy),
+	}
+	if filter.To.IsZero() {
+		filter.To = now
+	}
+	if filter.From.IsZero() {
+		filter.From = filter.To.Add(-defaultAnalyticsRange)
+	}
+
+	switch {
+	case !filter.From.Before(filter.To):
+		return filter, fmt.Errorf("%w: from must be before to", ErrInvalidAnalyticsQuery)
+	case filter.To.Sub(filter.From) > maxAnalyticsRange:
+		return filter, fmt.Errorf("%w: the range must not exceed %d days", ErrInvalidAnalyticsQuery, int(maxAnalyticsRange.Hours()/24))
+	case filter.Type != "" && !filter.Type.Valid():
+		return filter, fmt.Errorf("%w: unknown suggestion type %q", ErrInvalidAnalyticsQuery, filter.Type)
+	}
+	for _, dimension := range query.GroupBy {
+		if !slices.Contains(domain.AIAnalyticsDimensions, dimension) {
+			return filter, fmt.Errorf("%w: cannot group by %q, expected one of %v",
+				ErrInvalidAnalyticsQuery, dimension, domain.AIAnalyticsDimensions)
+		}
+	}
+	return filter, nil
+}
+
+// analyticsDimensions returns the requested dimensions in their canonical order, all
+// of them when none were requested.
+func analyticsDimensions(requested []string) []string {
+	if len(requested) == 0 {
+		return domain.AIAnalyticsDimensions
+	}
+	dimensions := []string{}
+	for _, dimension := range domain.AIAnalyticsDimensions {
+		if slices.Contains(requested, dimension) {
+			dimensions = append(dimensions, dimension)
+		}
+	}
+	return dimensions
+}
+
+// setRates derives the rates of stats from its counts.
+func setRates(stats *domain.AISuggestionStats) {
+	stats.AcceptanceRate = rate(stats.Accepted, stats.Accepted+stats.Rejected)
+	stats.EditRate = rate(stats.Edited, stats.Generated)
+	stats.FailureRate = rate(stats.Failed, stats.Generated+stats.Failed)
+}
+
+// rate returns n / of, or nil when of is 0.
+func rate(n, of int) *float64 {
+	if of == 0 {
+		return nil
+	}
+	r := float64(n) / float64(of)
+	return &r
+}
@@ -0,0 +1,684 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/llm"
+	"github.com/khaled2049/server/internal/mq"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+var (
+	// ErrSuggestionNotClaimable is returned when another worker owns the suggestion or it
+	// was already generated; the job is a duplicate and can be dropped.
+	ErrSuggestionNotClaimable = errors.New("ai suggestion is not claimable")
+	// ErrGenerationInterrupted is returned when shutdown interrupted a generation. The
+	// suggestion was released back to pending and its job should be requeued.
+	ErrGenerationInterrupted = errors.New("ai suggestion generation interrupted")
+	// ErrGenerationCancelled is returned when the suggestion was cancelled while it was
+	// generated. Nothing is stored; the job is done.
+	ErrGenerationCancelled = errors.New("ai suggestion generation cancelled")
+
+	errGenerationTimedOut = errors.New("generation timed out")
+)
+
+const (
+	// pendingGrace keeps the sweep away from suggestions whose job is still in flight.
+	pendingGrace = time.Minute
+	// promptOverheadTokens covers the chat formatting around the prompt's messages.
+	promptOverheadTokens = 16
+	// controlResubscribeDelay spaces attempts to follow control commands after the
+	// subscription was lost.
+	controlResubscribeDelay = 5 * time.Second
+)
+
+// AIGenerationOptions tunes the AI worker's generation.
+type AIGenerationOptions struct {
+	MaxOutputTokens int
+	// ContextWindow is used when the model does not report its context window.
+	ContextWindow int
+	// ContextBudget caps the tokens of assembled context; 0 fills the context window.
+	ContextBudget int
+	// Timeout bounds a single generation attempt; timeouts are transient failures.
+	Timeout time.Duration
+	// ClaimTTL is how long a generating suggestion stays claimed before the sweep
+	// hands it to another worker. Defaults to twice Timeout.
+	ClaimTTL time.Duration
+	// Prices (USD per 1,000 tokens) of the estimated cost in the usage ledger
+	PromptCostPer1K     float64
+	CompletionCostPer1K float64
+	// MaxAttempts bounds the attempts at a suggestion that keeps failing with transient
+	// errors; the first retry waits RetryBaseDelay, each next one twice as long up to
+	// RetryMaxDelay. Defaults to a single attempt.
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+// AIGenerationService generates the content of pending AI suggestions. It runs in the
+// AI worker; jobs may be delivered more than once, so every step is keyed by the
+// suggestion ID and guarded by its status. The model's output is broadcast as it
+// arrives (see mq.AISuggestionStreamTopic), and its token usage is recorded in the
+// usage ledger with the result, together with an ai.suggestion.generated event for the
+// requester. Output is screened by the moderation service, if any,
+// before it is streamed and stored, and status changes are pushed to the followers of
+// the novel (see LiveEventService). Automatic summaries (see SummaryService) are
+// applied and accepted with their result, without moderation. Failed attempts are
+// retried with backoff while their errors are transient, and the generation of a
+// suggestion cancelled through the API is stopped (see WatchControl).
+type AIGenerationService struct {
+	transactor     repository.Transactor
+	suggestionRepo repository.AISuggestionRepository
+	summaryRepo    repository.SummaryRepository
+	templateRepo   repository.PromptTemplateRepository
+	usageRepo      repository.AIUsageRepository
+	outboxRepo     repository.OutboxRepository
+	assembler      *ContextAssembler
+	client         llm.Client
+	streams        mq.PubSub          // Nil disables streaming
+	live           *LiveEventService  // Nil disables status events
+	moderation     *ModerationService // Nil disables moderation
+	options        AIGenerationOptions
+
+	modelWindow atomic.Int64 // Context window reported by the model; 0 until known
+
+	mu       sync.Mutex
+	inflight map[uuid.UUID]*inflightGeneration
+}
+
+// inflightGeneration stops a generation running in this process.
+type inflightGeneration struct {
+	cancel context.CancelCauseFunc
+}
+
+func NewAIGenerationService(
+	transactor repository.Transactor,
+	suggestionRepo repository.AISuggestionRepository,
+	summaryRepo repository.SummaryRepository,
+	templateRepo repository.PromptTemplateRepository,
+	usageRepo repository.AIUsageRepository,
+	outboxRepo repository.OutboxRepository,
+	assembler *ContextAssembler,
+	client llm.Client,
+	streams mq.PubSub,
+	live *LiveEventService,
+	moderation *ModerationService,
+	options AIGenerationOptions) *AIGenerationService {
+	if options.ClaimTTL <= 0 {
+		options.ClaimTTL = 2 * options.Timeout
+	}
+	options.MaxAttempts = max(options.MaxAttempts, 1)
+	return &AIGenerationService{
+		transactor:     transactor,
+		suggestionRepo: suggestionRepo,
+		summaryRepo:    summaryRepo,
+		templateRepo:   templateRepo,
+		usageRepo:      usageRepo,
+		outboxRepo:     outboxRepo,
+		assembler:      assembler,
+		client:         client,
+		streams:        streams,
+		live:           live,
+		moderation:     moderation,
+		options:        options,
+		inflight:       make(map[uuid.UUID]*inflightGeneration),
+	}
+}
+
+// Generate claims the suggestion, calls the model and stores the result. A transient
+// failure (rate limit, provider outage, timeout) puts the suggestion back to pending
+// until its retry is due, as long as attempts remain; other failures, and the last
+// attempt, mark it failed. Either way the job is done and nil is returned. Cancelling
+// ctx (shutdown) releases the suggestion and returns ErrGenerationInterrupted;
+// cancelling the suggestion stops the model and returns ErrGenerationCancelled.
+func (s *AIGenerationService) Generate(ctx context.Context, suggestionID uuid.UUID) error {
+	// Tracked before the claim, so a cancellation cannot slip in between
+	genCtx, untrack := s.track(ctx, suggestionID)
+	defer untrack()
+
+	suggestion, err := s.suggestionRepo.Claim(ctx, suggestionID, time.Now().Add(-s.options.ClaimTTL))
+	if err != nil {
+		if errors.Is(err, repository.ErrAISuggestionStatusChanged) {
+			return ErrSuggestionNotClaimable
+		}
+		return err
+	}
+	claimedAt := suggestion.UpdatedAt
+
+	// The outcome must be stored even if shutdown starts while the model answers
+	store := context.WithoutCancel(ctx)
+	s.live.SuggestionChanged(store, nil, suggestion)
+
+	stream := &generationStream{pubsub: s.streams, suggestionID: suggestion.ID}
+	prompt, err := s.buildPrompt(genCtx, suggestion)
+	if err == nil && s.moderation != nil && !suggestion.Automatic {
+		stream.screen, err = s.moderation.Screen(genCtx, suggestion.NovelID)
+	}
+	if err == nil {
+		suggestion.PromptContext = prompt.assembled.Text
+		var resp *llm.Response
+		started := time.Now()
+		stream.send(genCtx, mq.AISuggestionStreamEvent{Type: mq.StreamStart})
+		resp, err = s.complete(genCtx, chatRequest(suggestion, prompt.messages), stream)
+		if err == nil {
+			usage := s.usageEntry(suggestion, resp)
+			suggestion.Status = domain.AISuggestionGenerated
+			suggestion.GeneratedContent = strings.TrimSpace(resp.Text)
+			suggestion.ModelUsed = resp.Model
+			suggestion.GenerationMetadata = map[string]any{
+				"promptTokens":     usage.PromptTokens,
+				"completionTokens": usage.CompletionTokens,
+				"totalTokens":      usage.TotalTokens,
+				"estimatedCost":    usage.EstimatedCost,
+				"finishReason":     resp.FinishReason,
+				"latencyMs":        time.Since(started).Milliseconds(),
+				"context":          prompt.assembled.Metadata(),
+				"template":         templateMetadata(prompt.template),
+			}
+			if suggestion.Automatic && suggestion.GeneratedContent == "" {
+				// Nothing to apply; failing lets the summary be retried
+				suggestion.Status = domain.AISuggestionFailed
+				suggestion.LastError = "the model returned an empty summary"
+				suggestion.LastErrorKind = domain.AIErrorPermanent
+				suggestion.GenerationMetadata["error"] = suggestion.LastError
+			}
+			if stream.screen != nil {
+				outcome := stream.screen.Check(genCtx, suggestion.GeneratedContent)
+				suggestion.SetModeration(outcome)
+				stream.release(genCtx, outcome.Flagged)
+			}
+			return s.finish(store, suggestion, claimedAt, stream, usage)
+		}
+	}
+
+	if ctx.Err() != nil {
+		if err := s.suggestionRepo.Release(store, suggestion.ID, claimedAt); err != nil {
+			log.Printf("Warning: failed to release AI suggestion %s: %v", suggestion.ID, err)
+		}
+		return ErrGenerationInterrupted
+	}
+	if errors.Is(context.Cause(genCtx), ErrGenerationCancelled) {
+		// The suggestion is already cancelled and subscribers were told
+		return ErrGenerationCancelled
+	}
+
+	kind := classifyGenerationError(err)
+	if kind == domain.AIErrorTransient && suggestion.Attempts < s.options.MaxAttempts {
+		return s.scheduleRetry(store, suggestion, claimedAt, stream, err)
+	}
+
+	suggestion.Status = domain.AISuggestionFailed
+	suggestion.LastError = err.Error()
+	suggestion.LastErrorKind = kind
+	suggestion.GenerationMetadata = map[string]any{"error": err.Error()}
+	if prompt.template != nil {
+		suggestion.GenerationMetadata["template"] = templateMetadata(prompt.template)
+	}
+	if prompt.assembled != nil {
+		suggestion.GenerationMetadata["context"] = prompt.assembled.Metadata()
+	}
+	return s.finish(store, suggestion, claimedAt, stream, nil)
+}
+
+// scheduleRetry puts a suggestion whose attempt failed with a transient error back to
+// pending until its retry is due; the sweep then picks it up.
+func (s *AIGenerationService) scheduleRetry(
+	ctx context.Context,
+	suggestion *domain.AISuggestion,
+	claimedAt time.Time,
+	stream *generationStream,
+	cause error,
+) error {
+	retryAt := time.Now().Add(s.retryDelay(suggestion.Attempts))
+	if err := s.suggestionRepo.ScheduleRetry(ctx, suggestion.ID, claimedAt, cause.Error(), retryAt); err != nil {
+		return s.finishError(err)
+	}
+	log.Printf("AI suggestion %s attempt %d of %d failed, retrying at %s: %v",
+		suggestion.ID, suggestion.Attempts, s.options.MaxAttempts, retryAt.Format(time.RFC3339), cause)
+	stream.send(ctx, mq.AISuggestionStreamEvent{Type: mq.StreamRetry, Error: cause.Error(), RetryAt: &retryAt})
+	suggestion.Status = domain.AISuggestionPending
+	s.live.SuggestionChanged(ctx, nil, suggestion)
+	return nil
+}
+
+// retryDelay is the backoff after the given number of failed attempts: RetryBaseDelay,
+// doubled with each further attempt up to RetryMaxDelay.
+func (s *AIGenerationService) retryDelay(attempts int) time.Duration {
+	delay := s.options.RetryBaseDelay
+	for i := 1; i < attempts && (s.options.RetryMaxDelay <= 0 || delay < s.options.RetryMaxDelay); i++ {
+		delay *= 2
+	}
+	if s.options.RetryMaxDelay > 0 {
+		delay = min(delay, s.options.RetryMaxDelay)
+	}
+	return delay
+}
+
+// classifyGenerationError tells apart failures another attempt may get past from those
+// it would repeat. Unknown errors are permanent; a failed suggestion can still be
+// retried through the API.
+func classifyGenerationError(err error) domain.AIErrorKind {
+	var apiErr *llm.APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Temporary() {
+			return domain.AIErrorTransient
+		}
+		return domain.AIErrorPermanent
+	}
+	var netErr net.Error
+	if errors.Is(err, errGenerationTimedOut) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &netErr) {
+		return domain.AIErrorTransient
+	}
+	return domain.AIErrorPermanent
+}
+
+// track registers a generation so that WatchControl can stop it. The returned context
+// is cancelled with ErrGenerationCancelled when the suggestion is cancelled; untrack
+// must be called once the generation is over.
+func (s *AIGenerationService) track(ctx context.Context, suggestionID uuid.UUID) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	generation := &inflightGeneration{cancel: cancel}
+
+	s.mu.Lock()
+	s.inflight[suggestionID] = generation
+	s.mu.Unlock()
+
+	return ctx, func() {
+		s.mu.Lock()
+		// A duplicate job may have replaced the entry
+		if s.inflight[suggestionID] == generation {
+			delete(s.inflight, suggestionID)
+		}
+		s.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// WatchControl follows the control commands the API server broadcasts and stops the
+// generations of cancelled suggestions, until ctx is done. Without streams it returns
+// at once: the result of a cancelled suggestion is then dropped when it is stored.
+func (s *AIGenerationService) WatchControl(ctx context.Context) {
+	if s.streams == nil {
+		return
+	}
+	for ctx.Err() == nil {
+		msgs, err := s.streams.Subscribe(ctx, mq.TopicAISuggestionControl)
+		if err != nil {
+			log.Printf("Warning: failed to follow AI suggestion control commands: %v", err)
+		} else {
+			for msg := range msgs {
+				s.control(msg)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(controlResubscribeDelay):
+		}
+	}
+}
+
+func (s *AIGenerationService) control(msg mq.Message) {
+	var command mq.AISuggestionControl
+	if err := json.Unmarshal(msg.Body, &command); err != nil {
+		log.Printf("Warning: dropping malformed AI suggestion control command: %v", err)
+		return
+	}
+	if command.Command != mq.ControlCancel {
+		return
+	}
+
+	s.mu.Lock()
+	generation := s.inflight[command.SuggestionID]
+	s.mu.Unlock()
+	if generation != nil {
+		log.Printf("AI suggestion %s cancelled, stopping its generation", command.SuggestionID)
+		generation.cancel(ErrGenerationCancelled)
+	}
+}
+
+// finish stores the outcome of a generation, with its usage entry when there is one and
+// the application of an automatic summary or the generated event of any other
+// suggestion, and then tells stream subscribers, who read
+// the stored suggestion when they see it done.
+func (s *AIGenerationService) finish(
+	ctx context.Context,
+	suggestion *domain.AISuggestion,
+	claimedAt time.Time,
+	stream *generationStream,
+	usage *domain.AIUsageEntry,
+) error {
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		finished, err := s.suggestionRepo.FinishGeneration(ctx, suggestion, claimedAt)
+		if err != nil {
+			return err
+		}
+		if usage != nil {
+			if _, err := s.usageRepo.Record(ctx, usage); err != nil {
+				return err
+			}
+		}
+		if finished.Status != domain.AISuggestionGenerated {
+			return nil
+		}
+		if !finished.Automatic {
+			return recordEvent(ctx, s.outboxRepo, domain.OutboxAggregateAISuggestion, finished.ID,
+				mq.TopicAISuggestionGenerated, mq.AISuggestionGeneratedEvent{
+					SuggestionID:   finished.ID,
+					NovelID:        finished.NovelID,
+					UserID:         finished.UserID,
+					Type:           string(finished.Type),
+					VariantGroupID: finished.VariantGroupID(),
+				})
+		}
+		if err := s.applySummary(ctx, finished); err != nil {
+			return err
+		}
+		suggestion.Status = finished.Status
+		return nil
+	})
+	if err != nil {
+		return s.finishError(err)
+	}
+	stream.send(ctx, mq.AISuggestionStreamEvent{Type: mq.StreamDone, Status: string(suggestion.Status)})
+	s.live.SuggestionChanged(ctx, nil, suggestion)
+	return nil
+}
+
+// applySummary stores an automatic summary as the summary of its chapter or, without
+// one, as the synopsis it was generated for, and accepts the suggestion. Call it within
+// a transaction.
+func (s *AIGenerationService) applySummary(ctx context.Context, suggestion *domain.AISuggestion) error {
+	var err error
+	if suggestion.ContextChapterID != nil {
+		err = s.summaryRepo.UpsertChapterSummary(ctx, &domain.ChapterSummary{
+			ChapterID:    *suggestion.ContextChapterID,
+			NovelID:      suggestion.NovelID,
+			Summary:      suggestion.GeneratedContent,
+			ContentHash:  contentHash(suggestion.SelectedText),
+			SuggestionID: &suggestion.ID,
+		})
+	} else {
+		// A synopsis superseded by a newer generation is dropped; the suggestion is done all the same
+		_, err = s.summaryRepo.CompleteSynopsis(ctx, suggestion.ID, suggestion.GeneratedContent)
+	}
+	if err != nil {
+		return err
+	}
+
+	suggestion.Status = domain.AISuggestionAccepted
+	suggestion.Application = &domain.AISuggestionApplication{}
+	_, err = s.suggestionRepo.UpdateReview(ctx, suggestion, domain.AISuggestionGenerated)
+	return err
+}
+
+// ClaimableSuggestions returns suggestions that have no live job: pending ones whose
+// message was lost or whose retry is due, and generating ones whose worker stopped
+// without releasing them.
+func (s *AIGenerationService) ClaimableSuggestions(ctx context.Context, limit int) ([]uuid.UUID, error) {
+	now := time.Now()
+	return s.suggestionRepo.ListClaimable(ctx, now.Add(-pendingGrace), now.Add(-s.options.ClaimTTL), limit)
+}
+
+// chatRequest asks for the suggestion's content with the model and temperature it
+// requested, if any.
+func chatRequest(suggestion *domain.AISuggestion, messages []llm.Message) llm.ChatRequest {
+	req := llm.ChatRequest{Model: suggestion.RequestedModel, Messages: messages}
+	if suggestion.Temperature != nil {
+		req.Temperature = *suggestion.Temperature
+	}
+	return req
+}
+
+func (s *AIGenerationService) complete(ctx context.Context, req llm.ChatRequest, stream *generationStream) (*llm.Response, error) {
+	if s.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.options.Timeout)
+		defer cancel()
+	}
+
+	req.MaxTokens = s.options.MaxOutputTokens
+	resp, err := s.client.ChatStream(ctx, req, func(chunk llm.StreamChunk) error {
+		stream.token(ctx, chunk.Text)
+		return nil
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %s", errGenerationTimedOut, s.options.Timeout)
+	}
+	return resp, err
+}
+
+// usageEntry is the usage ledger entry of a generation.
+func (s *AIGenerationService) usageEntry(suggestion *domain.AISuggestion, resp *llm.Response) *domain.AIUsageEntry {
+	entry := newUsageEntry(suggestion.UserID, suggestion.NovelID, resp, s.options.PromptCostPer1K, s.options.CompletionCostPer1K)
+	entry.SuggestionID = &suggestion.ID
+	return entry
+}
+
+// newUsageEntry is the usage ledger entry of a model response, priced in USD per 1,000
+// tokens. Providers that do not report a total get the sum of prompt and completion
+// tokens.
+func newUsageEntry(userID, novelID uuid.UUID, resp *llm.Response, promptCostPer1K, completionCostPer1K float64) *domain.AIUsageEntry {
+	usage := resp.Usage
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	cost := float64(usage.PromptTokens)/1000*promptCostPer1K +
+		float64(usage.CompletionTokens)/1000*completionCostPer1K
+	return &domain.AIUsageEntry{
+		UserID:           userID,
+		NovelID:          novelID,
+		Model:            resp.Model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		EstimatedCost:    math.Round(cost*1e6) / 1e6,
+	}
+}
+
+// finishError maps a lost claim (another worker reclaimed the suggestion) to a duplicate.
+func (s *AIGenerationService) finishError(err error) error {
+	if errors.Is(err, repository.ErrAISuggestionStatusChanged) {
+		return ErrSuggestionNotClaimable
+	}
+	return err
+}
+
+// generationPrompt is a rendered prompt and what it was built from.
+type generationPrompt struct {
+	messages  []llm.Message
+	assembled *AssembledContext
+	template  *domain.PromptTemplate
+}
+
+// buildPrompt renders the novel's template for the suggestion type, with the context
+// assembled into the room the template leaves in the model's context window. The
+// returned prompt holds what was built so far when it fails.
+func (s *AIGenerationService) buildPrompt(ctx context.Context, suggestion *domain.AISuggestion) (*generationPrompt, error) {
+	prompt := &generationPrompt{}
+	template, err := s.templateRepo.Resolve(ctx, suggestion.Type, suggestion.NovelID)
+	if err != nil {
+		return prompt, fmt.Errorf("failed to resolve prompt template for %s: %w", suggestion.Type, err)
+	}
+	prompt.template = template
+
+	vars := map[string]string{
+		domain.PromptVarSelectedText: suggestion.SelectedText,
+		domain.PromptVarInstructions: suggestion.PromptInstructions,
+	}
+	// Render without context to measure what the template itself takes
+	system, request, err := executePrompt(template, vars)
+	if err != nil {
+		return prompt, err
+	}
+
+	budget := s.contextWindow(ctx) - s.options.MaxOutputTokens - promptOverheadTokens -
+		llm.EstimateTokens(system) - llm.EstimateTokens(request)
+	if s.options.ContextBudget > 0 {
+		budget = min(budget, s.options.ContextBudget)
+	}
+
+	prompt.assembled, err = s.assembler.Assemble(ctx, suggestion, max(budget, 0))
+	if err != nil {
+		return prompt, err
+	}
+
+	vars[domain.PromptVarContext] = prompt.assembled.Text
+	system, user, err := renderPrompt(template, vars)
+	if err != nil {
+		return prompt, err
+	}
+	prompt.messages = []llm.Message{
+		{Role: llm.RoleSystem, Content: system},
+		{Role: llm.RoleUser, Content: user},
+	}
+	return prompt, nil
+}
+
+// templateMetadata identifies the template of a generation, so accept rates can be
+// compared across versions.
+func templateMetadata(t *domain.PromptTemplate) map[string]any {
+	return map[string]any{
+		"id":      t.ID,
+		"version": t.Version,
+		"scope":   t.Scope(),
+	}
+}
+
+// contextWindow returns the model's context window, asking the model once.
+func (s *AIGenerationService) contextWindow(ctx context.Context) int {
+	if window := s.modelWindow.Load(); window > 0 {
+		return int(window)
+	}
+	info, err := s.client.ModelInfo(ctx)
+	if err != nil || info.ContextWindow <= 0 {
+		if err != nil {
+			log.Printf("Warning: failed to get model info, assuming a context window of %d: %v", s.options.ContextWindow, err)
+		}
+		return s.options.ContextWindow
+	}
+	s.modelWindow.Store(int64(info.ContextWindow))
+	return info.ContextWindow
+}
+
+// maxHeldText is how much screened output is held back waiting for the end of a
+// sentence before it is screened up to its last word.
+const maxHeldText = 512
+
+// generationStream broadcasts the progress of one generation. Streaming is best
+// effort: clients fall back to the stored suggestion, so failures are logged once and
+// otherwise ignored. With a screen, text is held back until a sentence or line ends and
+// screened before it is sent; once some is flagged the rest of the output is withheld.
+type generationStream struct {
+	pubsub       mq.PubSub
+	suggestionID uuid.UUID
+	seq          int
+	failed       bool
+
+	screen   *ModerationScreen // Nil sends text as it comes
+	held     string
+	withheld bool
+}
+
+// token streams the next piece of generated text.
+func (st *generationStream) token(ctx context.Context, text string) {
+	if st.screen == nil {
+		st.send(ctx, mq.AISuggestionStreamEvent{Type: mq.StreamToken, Text: text})
+		return
+	}
+	if st.pubsub == nil || st.failed || st.withheld {
+		return
+	}
+
+	st.held += text
+	cut := screenedCut(st.held)
+	if cut == 0 {
+		return
+	}
+	screened := st.held[:cut]
+	st.held = st.held[cut:]
+	if st.screen.Check(ctx, screened).Flagged {
+		st.withhold(ctx)
+		return
+	}
+	st.send(ctx, mq.AISuggestionStreamEvent{Type: mq.StreamToken, Text: screened})
+}
+
+// release ends screening once the whole output was checked: the held text is sent if
+// the output passed, and the stream withheld otherwise.
+func (st *generationStream) release(ctx context.Context, flagged bool) {
+	if flagged {
+		st.withhold(ctx)
+		return
+	}
+	if st.held != "" && !st.withheld {
+		st.send(ctx, mq.AISuggestionStreamEvent{Type: mq.StreamToken, Text: st.held})
+	}
+	st.held = ""
+}
+
+func (st *generationStream) withhold(ctx context.Context) {
+	st.held = ""
+	if !st.withheld {
+		st.withheld = true
+		st.send(ctx, mq.AISuggestionStreamEvent{Type: mq.StreamWithheld})
+	}
+}
+
+// screenedCut returns how much of the held text can be screened: up to its last line
+// break or sentence end, or up to its last space once it grows past maxHeldText.
+// Screened pieces end at whitespace, so no word is split across two of them.
+func screenedCut(held string) int {
+	for i := len(held) - 1; i > 0; i-- {
+		switch {
+		case held[i] == '\n':
+			return i + 1
+		case held[i] == ' ' && strings.IndexByte(".!?\"'", held[i-1]) >= 0:
+			return i + 1
+		}
+	}
+	if len(held) <= maxHeldText {
+		return 0
+	}
+	if i := strings.LastIndexByte(held, ' '); i > 0 {
+		return i + 1
+	}
+	return len(held)
+}
+
+func (st *generationStream) send(ctx context.Context, event mq.AISuggestionStreamEvent) {
+	if st.pubsub == nil || st.failed {
+		return
+	}
+	event.SuggestionID = st.suggestionID
+	if event.Type == mq.StreamToken {
+		st.seq++
+		event.Seq = st.seq
+	}
+
+	body, err := json.Marshal(event)
+	if err == nil {
+		err = st.pubsub.Broadcast(ctx, mq.Message{
+			Topic: mq.AISuggestionStreamTopic(st.suggestionID),
+			Key:   st.suggestionID.String(),
+			Body:  body,
+		})
+	}
+	if err != nil && ctx.Err() == nil {
+		st.failed = true
+		log.Printf("Warning: failed to stream AI suggestion %s, clients will wait for the result: %v", st.suggestionID, err)
+	}
+}
@@ -0,0 +1,858 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/mq"
+	"github.com/khaled2049/server/internal/repository"
+	"github.com/khaled2049/server/internal/util/textdiff"
+)
+
+var (
+	// ErrInvalidSuggestion is returned for malformed AI suggestion input.
+	ErrInvalidSuggestion = errors.New("invalid ai suggestion")
+	// ErrInvalidTransition is returned when the suggestion lifecycle does not allow a status change.
+	ErrInvalidTransition = errors.New("invalid ai suggestion status transition")
+)
+
+const (
+	// maxSuggestionVariants caps the variants of a single request.
+	maxSuggestionVariants = 5
+	// defaultVariantTemperature is the temperature of variants that do not set one, so
+	// that variants of the same model differ.
+	defaultVariantTemperature = 0.8
+)
+
+// AIService handles AI suggestion requests and their review. Generation itself happens
+// asynchronously in the AI worker, which picks up the job recorded in the outbox here.
+// Accepting a suggestion applies it to the manuscript with source ai. Content that
+// moderation flagged is withheld from each reader until the reader reveals it. A request
+// may ask for several variants, generated side by side; accepting one closes the others.
+// Status changes are pushed to the followers of the novel (see LiveEventService).
+type AIService struct {
+	transactor     repository.Transactor
+	suggestionRepo repository.AISuggestionRepository
+	outboxRepo     repository.OutboxRepository
+	chapterRepo    repository.ChapterRepository
+	revisionRepo   repository.ChapterRevisionRepository
+	characterRepo  repository.CharacterRepository
+	placeRepo      repository.PlaceRepository
+	noteRepo       repository.NoteRepository
+	chapterService *ChapterService
+	usage          *AIUsageService
+	streams        mq.PubSub // Nil when no message queue is configured
+	live           *LiveEventService
+	access         *AccessService
+	options        AIOptions
+}
+
+// AIOptions tunes suggestion requests.
+type AIOptions struct {
+	// DefaultModel is the worker's model. AllowedModels are the other models a request
+	// may choose; requests naming any other model are rejected.
+	DefaultModel  string
+	AllowedModels []string
+}
+
+func NewAIService(
+	transactor repository.Transactor,
+	suggestionRepo repository.AISuggestionRepository,
+	outboxRepo repository.OutboxRepository,
+	chapterRepo repository.ChapterRepository,
+	revisionRepo repository.ChapterRevisionRepository,
+	characterRepo repository.CharacterRepository,
+	placeRepo repository.PlaceRepository,
+	noteRepo repository.NoteRepository,
+	chapterService *ChapterService,
+	usage *AIUsageService,
+	streams mq.PubSub,
+	live *LiveEventService,
+	access *AccessService,
+	options AIOptions) *AIService {
+	return &AIService{
+		transactor:     transactor,
+		suggestionRepo: suggestionRepo,
+		outboxRepo:     outboxRepo,
+		chapterRepo:    chapterRepo,
+		revisionRepo:   revisionRepo,
+		characterRepo:  characterRepo,
+		placeRepo:      placeRepo,
+		noteRepo:       noteRepo,
+		chapterService: chapterService,
+		usage:          usage,
+		streams:        streams,
+		live:           live,
+		access:         access,
+		options:        options,
+	}
+}
+
+// SuggestionRequest describes what the user wants generated.
+type SuggestionRequest struct {
+	NovelID            uuid.UUID
+	Type               domain.AISuggestionType
+	ContextChapterID   *uuid.UUID
+	ContextCharacterID *uuid.UUID
+	ContextPlaceID     *uuid.UUID
+	ContextNoteID      *uuid.UUID
+	Instructions       string
+	SelectedText       string // Optional text the user selected (e.g. the passage to rewrite)
+	// Variants is the number of suggestions generated side by side for the request; 0
+	// takes one per VariantOptions entry, or a single one.
+	Variants       int
+	VariantOptions []SuggestionVariant // Per variant, in order; variants past the end use the defaults
+}
+
+// SuggestionVariant overrides the worker's model or temperature for one variant.
+type SuggestionVariant struct {
+	Model       string
+	Temperature *float64
+}
+
+// SuggestionReview carries the optional feedback given when accepting or rejecting.
+type SuggestionReview struct {
+	Feedback *int16 // 1-5 rating
+	Notes    string
+}
+
+// SuggestionPlacement says where an accepted suggestion goes. For chapter suggestions
+// [Start, End) is the rune range to replace (Start == End inserts), relative to
+// BaseRevisionID when given and to the current content otherwise. Without a range a
+// rewrite replaces the selected text and other types are appended. Name overrides the
+// name taken from a character or place idea.
+type SuggestionPlacement struct {
+	BaseRevisionID *int64
+	Start          *int
+	End            *int
+	Name           string
+}
+
+// RequestSuggestion stores a pending suggestion and its generation job in one transaction.
+// It fails with ErrAIQuotaExceeded when the actor or the novel has used up a quota. With
+// several variants each is a suggestion with its own job; the first is returned, with
+// the others in Variants.
+func (s *AIService) RequestSuggestion(ctx context.Context, actorID uuid.UUID, req SuggestionRequest) (*domain.AISuggestion, error) {
+	if !req.Type.Valid() {
+		return nil, fmt.Errorf("%w: unsupported type %q", ErrInvalidSuggestion, req.Type)
+	}
+	variants, err := s.suggestionVariants(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.Require(ctx, req.NovelID, actorID, domain.PermissionEditContent); err != nil {
+		return nil, err
+	}
+	if err := s.usage.CheckQuota(ctx, actorID, req.NovelID); err != nil {
+		return nil, err
+	}
+
+	var suggestion *domain.AISuggestion
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		for i, variant := range variants {
+			created, err := s.suggestionRepo.Create(ctx, &domain.AISuggestion{
+				NovelID:            req.NovelID,
+				UserID:             actorID,
+				Type:               req.Type,
+				Status:             domain.AISuggestionPending,
+				ContextChapterID:   req.ContextChapterID,
+				ContextCharacterID: req.ContextCharacterID,
+				ContextPlaceID:     req.ContextPlaceID,
+				ContextNoteID:      req.ContextNoteID,
+				SelectedText:       strings.TrimSpace(req.SelectedText),
+				PromptInstructions: strings.TrimSpace(req.Instructions),
+				ParentSuggestionID: variant.ParentSuggestionID,
+				VariantIndex:       variant.VariantIndex,
+				RequestedModel:     variant.RequestedModel,
+				Temperature:        variant.Temperature,
+			})
+			if err != nil {
+				return err
+			}
+			if err := recordEvent(ctx, s.outboxRepo, domain.OutboxAggregateAISuggestion, created.ID,
+				mq.TopicAISuggestionRequested, mq.AISuggestionJob{SuggestionID: created.ID}); err != nil {
+				return err
+			}
+
+			if i == 0 {
+				suggestion = created
+				for _, next := range variants[1:] {
+					next.ParentSuggestionID = &created.ID
+				}
+			} else {
+				suggestion.Variants = append(suggestion.Variants, created)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.live.SuggestionChanged(ctx, &actorID, suggestion)
+	for _, variant := range suggestion.Variants {
+		s.live.SuggestionChanged(ctx, &actorID, variant)
+	}
+	return suggestion, nil
+}
+
+// suggestionVariants validates the variants of a request and returns their settings.
+func (s *AIService) suggestionVariants(req SuggestionRequest) ([]*domain.AISuggestion, error) {
+	count := req.Variants
+	if count == 0 {
+		count = max(len(req.VariantOptions), 1)
+	}
+	if count < 1 || count > maxSuggestionVariants {
+		return nil, fmt.Errorf("%w: variants must be between 1 and %d", ErrInvalidSuggestion, maxSuggestionVariants)
+	}
+	if len(req.VariantOptions) > count {
+		return nil, fmt.Errorf("%w: %d variant options for %d variants", ErrInvalidSuggestion, len(req.VariantOptions), count)
+	}
+
+	variants := make([]*domain.AISuggestion, count)
+	for i := range variants {
+		variant := &domain.AISuggestion{}
+		if count > 1 {
+			variant.VariantIndex = i + 1
+		}
+		if i < len(req.VariantOptions) {
+			options := req.VariantOptions[i]
+			model := strings.TrimSpace(options.Model)
+			if model != "" && model != s.options.DefaultModel && !slices.Contains(s.options.AllowedModels, model) {
+				return nil, fmt.Errorf("%w: model %q is not allowed", ErrInvalidSuggestion, model)
+			}
+			if options.Temperature != nil && (*options.Temperature < 0 || *options.Temperature > 2) {
+				return nil, fmt.Errorf("%w: temperature must be between 0 and 2", ErrInvalidSuggestion)
+			}
+			variant.RequestedModel, variant.Temperature = model, options.Temperature
+		}
+		if count > 1 && variant.Temperature == nil {
+			temperature := defaultVariantTemperature
+			variant.Temperature = &temperature
+		}
+		variants[i] = variant
+	}
+	return variants, nil
+}
+
+// GetSuggestion returns a suggestion of a novel the actor can view.
+func (s *AIService) GetSuggestion(ctx context.Context, actorID, suggestionID uuid.UUID) (*domain.AISuggestion, error) {
+	suggestion, err := s.suggestionRepo.GetByID(ctx, suggestionID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.Require(ctx, suggestion.NovelID, actorID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+	return withheld(suggestion, actorID), nil
+}
+
+// ListVariants returns the variants requested together with a suggestion of a novel
+// the actor can view, itself included, in order for comparison. A suggestion requested
+// alone is its only variant.
+func (s *AIService) ListVariants(ctx context.Context, actorID, suggestionID uuid.UUID) ([]*domain.AISuggestion, error) {
+	suggestion, err := s.GetSuggestion(ctx, actorID, suggestionID)
+	if err != nil {
+		return nil, err
+	}
+	groupID := suggestion.VariantGroupID()
+	if groupID == nil {
+		return []*domain.AISuggestion{suggestion}, nil
+	}
+
+	variants, err := s.suggestionRepo.ListVariants(ctx, *groupID)
+	if err != nil {
+		return nil, err
+	}
+	for i, variant := range variants {
+		variants[i] = withheld(variant, actorID)
+	}
+	return variants, nil
+}
+
+// RevealSuggestion returns a suggestion of a novel the actor can view with its content,
+// even if moderation flagged it, and records that the actor chose to see it.
+func (s *AIService) RevealSuggestion(ctx context.Context, actorID, suggestionID uuid.UUID) (*domain.AISuggestion, error) {
+	suggestion, err := s.suggestionRepo.GetByID(ctx, suggestionID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.Require(ctx, suggestion.NovelID, actorID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+	if !suggestion.Withheld(actorID) {
+		return suggestion, nil
+	}
+	return s.suggestionRepo.RecordReveal(ctx, suggestionID, actorID)
+}
+
+// StreamSuggestion returns a suggestion of a novel the actor can view and, while it is
+// being generated, the events the AI worker broadcasts. The channel is nil when the
+// suggestion is no longer in progress or streaming is unavailable; it is closed when
+// ctx is done or the subscription is lost. Events may be missed, so callers should
+// read the stored suggestion once it is done.
+func (s *AIService) StreamSuggestion(
+	ctx context.Context,
+	actorID, suggestionID uuid.UUID,
+) (*domain.AISuggestion, <-chan mq.AISuggestionStreamEvent, error) {
+	suggestion, err := s.GetSuggestion(ctx, actorID, suggestionID)
+	if err != nil || !suggestion.Status.InProgress() || s.streams == nil {
+		return suggestion, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	msgs, err := s.streams.Subscribe(ctx, mq.AISuggestionStreamTopic(suggestionID))
+	if err != nil {
+		cancel()
+		log.Printf("Warning: failed to subscribe to AI suggestion %s: %v", suggestionID, err)
+		return suggestion, nil, nil
+	}
+
+	// The generation may have finished before the subscription started
+	suggestion, err = s.suggestionRepo.GetByID(ctx, suggestionID)
+	if err != nil || !suggestion.Status.InProgress() {
+		cancel()
+		if err != nil {
+			return nil, nil, err
+		}
+		return withheld(suggestion, actorID), nil, nil
+	}
+
+	events := make(chan mq.AISuggestionStreamEvent)
+	go func() {
+		defer close(events)
+		defer cancel()
+		for msg := range msgs {
+			var event mq.AISuggestionStreamEvent
+			if err := json.Unmarshal(msg.Body, &event); err != nil {
+				log.Printf("Warning: dropping malformed stream event of AI suggestion %s: %v", suggestionID, err)
+				continue
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return suggestion, events, nil
+}
+
+// ListSuggestions returns the suggestions of a novel, optionally filtered by status.
+func (s *AIService) ListSuggestions(
+	ctx context.Context,
+	actorID, novelID uuid.UUID,
+	status domain.AISuggestionStatus,
+) ([]*domain.AISuggestion, error) {
+	if status != "" && !status.Valid() {
+		return nil, fmt.Errorf("%w: unsupported status %q", ErrInvalidSuggestion, status)
+	}
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+	suggestions, err := s.suggestionRepo.List(ctx, repository.AISuggestionFilter{NovelID: novelID, Status: status})
+	if err != nil {
+		return nil, err
+	}
+	for i, suggestion := range suggestions {
+		suggestions[i] = withheld(suggestion, actorID)
+	}
+	return suggestions, nil
+}
+
+// AcceptSuggestion marks a generated (or edited) suggestion as accepted and applies it in
+// the same transaction: chapter suggestions become a chapter revision, character and
+// place ideas a character or place, and the other types a note, all with source ai.
+// The suggestion's Application records what was created. Flagged content must have
+// been revealed to the actor. Accepting a variant rejects the other variants of its
+// request that were generated and cancels those still in progress.
+func (s *AIService) AcceptSuggestion(
+	ctx context.Context,
+	actorID, suggestionID uuid.UUID,
+	review SuggestionReview,
+	placement SuggestionPlacement,
+) (*domain.AISuggestion, error) {
+	var accepted *domain.AISuggestion
+	var siblings []*domain.AISuggestion
+	var saved *ChapterUpdateResult
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		accepted, err = s.review(ctx, actorID, suggestionID, domain.AISuggestionAccepted, func(suggestion *domain.AISuggestion) error {
+			if suggestion.Withheld(actorID) {
+				return ErrSuggestionWithheld
+			}
+			if err := applyReview(suggestion, review); err != nil {
+				return err
+			}
+			var err error
+			saved, err = s.applySuggestion(ctx, actorID, suggestion, placement)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if groupID := accepted.VariantGroupID(); groupID != nil {
+			siblings, err = s.suggestionRepo.ResolveVariants(ctx, *groupID, accepted.ID)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if saved != nil {
+		s.chapterService.saved(ctx, actorID, saved.Chapter, saved.Revision)
+	}
+	s.live.SuggestionChanged(ctx, &actorID, accepted)
+	for _, sibling := range siblings {
+		if sibling.Status == domain.AISuggestionCancelled {
+			s.cancelled(ctx, sibling)
+		}
+		s.live.SuggestionChanged(ctx, &actorID, sibling)
+	}
+	return accepted, nil
+}
+
+// RejectSuggestion marks a generated (or edited) suggestion as rejected.
+func (s *AIService) RejectSuggestion(
+	ctx context.Context,
+	actorID, suggestionID uuid.UUID,
+	review SuggestionReview,
+) (*domain.AISuggestion, error) {
+	rejected, err := s.review(ctx, actorID, suggestionID, domain.AISuggestionRejected, func(suggestion *domain.AISuggestion) error {
+		return applyReview(suggestion, review)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.live.SuggestionChanged(ctx, &actorID, rejected)
+	return rejected, nil
+}
+
+// EditSuggestion replaces the generated content before it is accepted. The first edit
+// keeps the model's original text in generation_metadata.originalContent. Flagged
+// content must have been revealed to the actor.
+func (s *AIService) EditSuggestion(
+	ctx context.Context,
+	actorID, suggestionID uuid.UUID,
+	content, notes string,
+) (*domain.AISuggestion, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("%w: content cannot be empty", ErrInvalidSuggestion)
+	}
+
+	edited, err := s.review(ctx, actorID, suggestionID, domain.AISuggestionEdited, func(suggestion *domain.AISuggestion) error {
+		if suggestion.Withheld(actorID) {
+			return ErrSuggestionWithheld
+		}
+		if suggestion.GenerationMetadata == nil {
+			suggestion.GenerationMetadata = map[string]any{}
+		}
+		if _, kept := suggestion.GenerationMetadata["originalContent"]; !kept {
+			suggestion.GenerationMetadata["originalContent"] = suggestion.GeneratedContent
+		}
+		suggestion.GeneratedContent = content
+		if notes != "" {
+			suggestion.UserNotes = strings.TrimSpace(notes)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.live.SuggestionChanged(ctx, &actorID, edited)
+	return edited, nil
+}
+
+// CancelSuggestion stops a suggestion that is still pending or being generated. The
+// worker generating it is told to stop the model, and stream subscribers get done.
+func (s *AIService) CancelSuggestion(ctx context.Context, actorID, suggestionID uuid.UUID) (*domain.AISuggestion, error) {
+	suggestion, err := s.suggestionRepo.GetByID(ctx, suggestionID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.Require(ctx, suggestion.NovelID, actorID, domain.PermissionEditContent); err != nil {
+		return nil, err
+	}
+	if !suggestion.Status.CanTransitionTo(domain.AISuggestionCancelled) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, suggestion.Status, domain.AISuggestionCancelled)
+	}
+
+	cancelled, err := s.suggestionRepo.Cancel(ctx, suggestionID)
+	if errors.Is(err, repository.ErrAISuggestionStatusChanged) {
+		// Generated (or failed) in the meantime
+		return nil, fmt.Errorf("%w: %s -> %s: %v", ErrInvalidTransition, suggestion.Status, domain.AISuggestionCancelled, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.cancelled(ctx, cancelled)
+	s.live.SuggestionChanged(ctx, &actorID, cancelled)
+	return withheld(cancelled, actorID), nil
+}
+
+// cancelled tells the worker generating a cancelled suggestion to stop and its stream
+// subscribers that it is done. Best effort: without the command the worker's result is
+// dropped when it is stored.
+func (s *AIService) cancelled(ctx context.Context, suggestion *domain.AISuggestion) {
+	s.broadcast(ctx, mq.TopicAISuggestionControl, suggestion.ID,
+		mq.AISuggestionControl{SuggestionID: suggestion.ID, Command: mq.ControlCancel})
+	s.broadcast(ctx, mq.AISuggestionStreamTopic(suggestion.ID), suggestion.ID,
+		mq.AISuggestionStreamEvent{SuggestionID: suggestion.ID, Type: mq.StreamDone, Status: string(suggestion.Status)})
+}
+
+// RetrySuggestion requeues a failed suggestion, with a fresh round of attempts. Like a
+// request, it fails with ErrAIQuotaExceeded when the requester or the novel has used up
+// a quota. Automatic summaries are retried by the summary sweep instead.
+func (s *AIService) RetrySuggestion(ctx context.Context, actorID, suggestionID uuid.UUID) (*domain.AISuggestion, error) {
+	suggestion, err := s.suggestionRepo.GetByID(ctx, suggestionID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.Require(ctx, suggestion.NovelID, actorID, domain.PermissionEditContent); err != nil {
+		return nil, err
+	}
+	if suggestion.Automatic {
+		return nil, fmt.Errorf("%w: automatic suggestions are retried by the server", ErrInvalidSuggestion)
+	}
+	if suggestion.Status != domain.AISuggestionFailed {
+		return nil, fmt.Errorf("%w: %s -> %s (only failed suggestions can be retried)",
+			ErrInvalidTransition, suggestion.Status, domain.AISuggestionPending)
+	}
+	// Usage is attributed to the requester
+	if err := s.usage.CheckQuota(ctx, suggestion.UserID, suggestion.NovelID); err != nil {
+		return nil, err
+	}
+
+	var requeued *domain.AISuggestion
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		requeued, err = s.suggestionRepo.Requeue(ctx, suggestionID)
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.outboxRepo, domain.OutboxAggregateAISuggestion, suggestionID,
+			mq.TopicAISuggestionRequested, mq.AISuggestionJob{SuggestionID: suggestionID})
+	})
+	if errors.Is(err, repository.ErrAISuggestionStatusChanged) {
+		return nil, fmt.Errorf("%w: %s -> %s: %v", ErrInvalidTransition, suggestion.Status, domain.AISuggestionPending, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.live.SuggestionChanged(ctx, &actorID, requeued)
+	return withheld(requeued, actorID), nil
+}
+
+// broadcast sends a transient message about a suggestion, if streaming is available.
+func (s *AIService) broadcast(ctx context.Context, topic string, suggestionID uuid.UUID, payload any) {
+	if s.streams == nil {
+		return
+	}
+	body, err := json.Marshal(payload)
+	if err == nil {
+		err = s.streams.Broadcast(ctx, mq.Message{Topic: topic, Key: suggestionID.String(), Body: body})
+	}
+	if err != nil {
+		log.Printf("Warning: failed to broadcast %s for AI suggestion %s: %v", topic, suggestionID, err)
+	}
+}
+
+// review applies a validated lifecycle transition. Reviewing requires edit access to the novel.
+func (s *AIService) review(
+	ctx context.Context,
+	actorID, suggestionID uuid.UUID,
+	next domain.AISuggestionStatus,
+	mutate func(*domain.AISuggestion) error,
+) (*domain.AISuggestion, error) {
+	suggestion, err := s.suggestionRepo.GetByID(ctx, suggestionID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.Require(ctx, suggestion.NovelID, actorID, domain.PermissionEditContent); err != nil {
+		return nil, err
+	}
+
+	current := suggestion.Status
+	if !current.CanTransitionTo(next) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, current, next)
+	}
+	if err := mutate(suggestion); err != nil {
+		return nil, err
+	}
+	suggestion.Status = next
+
+	updated, err := s.suggestionRepo.UpdateReview(ctx, suggestion, current)
+	if errors.Is(err, repository.ErrAISuggestionStatusChanged) {
+		return nil, fmt.Errorf("%w: %s -> %s: %v", ErrInvalidTransition, current, next, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return withheld(updated, actorID), nil
+}
+
+// withheld returns the suggestion as the reader may see it: without its generated and
+// original content when moderation flagged it and the reader has not revealed it.
+func withheld(suggestion *domain.AISuggestion, readerID uuid.UUID) *domain.AISuggestion {
+	if !suggestion.Withheld(readerID) {
+		return suggestion
+	}
+	redacted := *suggestion
+	redacted.GeneratedContent = ""
+	redacted.ContentWithheld = true
+	redacted.GenerationMetadata = make(map[string]any, len(suggestion.GenerationMetadata))
+	for key, value := range suggestion.GenerationMetadata {
+		if key != "originalContent" {
+			redacted.GenerationMetadata[key] = value
+		}
+	}
+	return &redacted
+}
+
+func applyReview(suggestion *domain.AISuggestion, review SuggestionReview) error {
+	if review.Feedback != nil {
+		if *review.Feedback < 1 || *review.Feedback > 5 {
+			return fmt.Errorf("%w: feedback must be between 1 and 5", ErrInvalidSuggestion)
+		}
+		suggestion.UserFeedback = review.Feedback
+	}
+	if notes := strings.TrimSpace(review.Notes); notes != "" {
+		suggestion.UserNotes = notes
+	}
+	return nil
+}
+
+// applySuggestion creates what an accepted suggestion stands for and records it in
+// suggestion.Application. For chapter suggestions it returns the chapter save, whose
+// followers the caller tells once committed. Call it within a transaction.
+func (s *AIService) applySuggestion(
+	ctx context.Context,
+	actorID uuid.UUID,
+	suggestion *domain.AISuggestion,
+	placement SuggestionPlacement,
+) (*ChapterUpdateResult, error) {
+	content := strings.TrimSpace(suggestion.GeneratedContent)
+	if content == "" {
+		return nil, fmt.Errorf("%w: there is no generated content to apply", ErrInvalidSuggestion)
+	}
+	source := string(domain.ContentSourceAI)
+	application := &domain.AISuggestionApplication{}
+	var saved *ChapterUpdateResult
+
+	switch suggestion.Type {
+	case domain.AISuggestionContinuation, domain.AISuggestionDialogue,
+		domain.AISuggestionDescription, domain.AISuggestionRewrite:
+		var start, end int
+		var err error
+		saved, start, end, err = s.applyToChapter(ctx, actorID, suggestion, content, placement)
+		if err != nil {
+			return nil, err
+		}
+		application.RevisionID, application.Start, application.End = &saved.Revision.ID, &start, &end
+
+	case domain.AISuggestionCharacterIdea:
+		name, description, err := splitIdea(content, placement.Name)
+		if err != nil {
+			return nil, err
+		}
+		character, err := s.characterRepo.Create(ctx, &domain.Character{
+			NovelID:         suggestion.NovelID,
+			Name:            name,
+			Description:     description,
+			Source:          source,
+			CreatedByUserID: &actorID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		application.CharacterID = &character.ID
+
+	case domain.AISuggestionPlaceIdea:
+		name, description, err := splitIdea(content, placement.Name)
+		if err != nil {
+			return nil, err
+		}
+		place, err := s.placeRepo.Create(ctx, &domain.Place{
+			NovelID:         suggestion.NovelID,
+			Name:            name,
+			Description:     description,
+			Source:          source,
+			CreatedByUserID: &actorID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		application.PlaceID = &place.ID
+
+	default:
+		noteType := domain.NoteTypeGeneral
+		if suggestion.Type == domain.AISuggestionPlotPoint {
+			noteType = domain.NoteTypePlotPoint
+		}
+		note, err := s.noteRepo.Create(ctx, &domain.Note{
+			NovelID:           suggestion.NovelID,
+			Title:             strings.TrimSpace(placement.Name),
+			Content:           content,
+			Type:              noteType,
+			Source:            source,
+			LinkedChapterID:   suggestion.ContextChapterID,
+			LinkedCharacterID: suggestion.ContextCharacterID,
+			LinkedPlaceID:     suggestion.ContextPlaceID,
+			CreatedByUserID:   &actorID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		application.NoteID = &note.ID
+	}
+
+	suggestion.Application = application
+	return saved, nil
+}
+
+// applyToChapter writes content into the suggestion's context chapter as an ai revision
+// and returns the save and the rune range the content occupies in its revision.
+func (s *AIService) applyToChapter(
+	ctx context.Context,
+	actorID uuid.UUID,
+	suggestion *domain.AISuggestion,
+	content string,
+	placement SuggestionPlacement,
+) (saved *ChapterUpdateResult, start, end int, err error) {
+	if suggestion.ContextChapterID == nil {
+		return nil, 0, 0, fmt.Errorf("%w: %s suggestions need a context chapter to be applied", ErrInvalidSuggestion, suggestion.Type)
+	}
+	chapter, err := s.chapterRepo.LockByID(ctx, *suggestion.ContextChapterID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if err := s.chapterService.requireOnChapter(ctx, chapter, actorID, domain.PermissionEditContent); err != nil {
+		return nil, 0, 0, err
+	}
+
+	start, end, err = s.suggestionRange(ctx, chapter, suggestion, placement)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	text := content
+	if placement.Start == nil && suggestion.Type != domain.AISuggestionRewrite {
+		// Appended as a new paragraph
+		text = paragraphBreak(chapter.Content) + content
+	}
+	runes := []rune(chapter.Content)
+	updated := string(runes[:start]) + text + string(runes[end:])
+
+	notes := fmt.Sprintf("Accepted AI suggestion (%s)", suggestion.Type)
+	saved, err = s.chapterService.saveContent(ctx, chapter, updated, actorID, domain.ContentSourceAI, notes)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if saved.Revision == nil {
+		return nil, 0, 0, fmt.Errorf("%w: applying it would not change the chapter", ErrInvalidSuggestion)
+	}
+	start += utf8.RuneCountInString(text) - utf8.RuneCountInString(content)
+	return saved, start, start + utf8.RuneCountInString(content), nil
+}
+
+// suggestionRange resolves the rune range of the locked chapter's current content that
+// an accepted suggestion replaces. A range given against an older revision is re-based
+// onto the current content and fails with ErrChangeConflict when its text was edited.
+func (s *AIService) suggestionRange(
+	ctx context.Context,
+	chapter *domain.Chapter,
+	suggestion *domain.AISuggestion,
+	placement SuggestionPlacement,
+) (start, end int, err error) {
+	current := []rune(chapter.Content)
+
+	if placement.Start == nil {
+		if suggestion.Type != domain.AISuggestionRewrite {
+			return len(current), len(current), nil
+		}
+		selected := suggestion.SelectedText
+		switch {
+		case selected == "":
+			return 0, 0, fmt.Errorf("%w: a rewrite without selected text needs a start and end", ErrInvalidSuggestion)
+		case strings.Count(chapter.Content, selected) == 0:
+			return 0, 0, fmt.Errorf("%w: the selected text is no longer in the chapter", ErrChangeConflict)
+		case strings.Count(chapter.Content, selected) > 1:
+			return 0, 0, fmt.Errorf("%w: the selected text occurs more than once; pass a start and end", ErrInvalidSuggestion)
+		}
+		start = utf8.RuneCountInString(chapter.Content[:strings.Index(chapter.Content, selected)])
+		return start, start + utf8.RuneCountInString(selected), nil
+	}
+
+	start, end = *placement.Start, *placement.Start
+	if placement.End != nil {
+		end = *placement.End
+	}
+	base := current
+	if placement.BaseRevisionID != nil {
+		chapterID, err := uuid.Parse(chapter.ID)
+		if err != nil {
+			return 0, 0, fmt.Errorf("chapter has invalid ID %q: %w", chapter.ID, err)
+		}
+		revision, err := s.revisionRepo.GetByID(ctx, chapterID, *placement.BaseRevisionID)
+		if err != nil {
+			return 0, 0, err
+		}
+		base = []rune(revision.Content)
+	}
+	if start < 0 || end < start || end > len(base) {
+		return 0, 0, fmt.Errorf("%w: range [%d, %d) is outside the chapter (length %d)", ErrInvalidSuggestion, start, end, len(base))
+	}
+
+	if placement.BaseRevisionID != nil {
+		mapper := textdiff.NewMapper(string(base), chapter.Content)
+		baseStart, baseEnd := start, end
+		var ok bool
+		start, end, ok = mapper.MapRange(baseStart, baseEnd)
+		if !ok || string(current[start:end]) != string(base[baseStart:baseEnd]) {
+			return 0, 0, fmt.Errorf("%w: the text at [%d, %d) of revision %d was edited",
+				ErrChangeConflict, baseStart, baseEnd, *placement.BaseRevisionID)
+		}
+	}
+	return start, end, nil
+}
+
+// paragraphBreak returns the line breaks that start a new paragraph after text.
+func paragraphBreak(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+	trailing := len(text) - len(strings.TrimRight(text, "\n"))
+	return strings.Repeat("\n", max(0, 2-trailing))
+}
+
+// splitIdea takes a character or place name from the first line of a generated idea
+// and uses the rest as its description. An explicit name keeps the whole idea as the
+// description.
+func splitIdea(content, name string) (string, string, error) {
+	if name = strings.TrimSpace(name); name != "" {
+		return name, content, nil
+	}
+
+	first, rest, _ := strings.Cut(content, "\n")
+	first = strings.TrimSpace(strings.Trim(strings.TrimSpace(first), "#*_"))
+	if label, value, found := strings.Cut(first, ":"); found && strings.EqualFold(strings.TrimSpace(label), "name") {
+		first = strings.TrimSpace(strings.Trim(strings.TrimSpace(value), "*_"))
+	}
+	if first == "" || utf8.RuneCountInString(first) > maxIdeaNameLength {
+		return "", "", fmt.Errorf("%w: could not take a name from the generated idea; pass a name", ErrInvalidSuggestion)
+	}
+	return first, strings.TrimSpace(rest), nil
+}
+
+// maxIdeaNameLength bounds names taken from the first line of an idea, so a paragraph
+// is not mistaken for a name.
+const maxIdeaNameLength = 80
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// ErrAIQuotaExceeded is returned when a user or novel has used up an AI token quota.
+var ErrAIQuotaExceeded = errors.New("ai usage quota exceeded")
+
+// AIQuotas caps the tokens AI generation may consume per UTC day and month. 0 disables
+// a quota.
+type AIQuotas struct {
+	UserDailyTokens    int64
+	UserMonthlyTokens  int64
+	NovelDailyTokens   int64
+	NovelMonthlyTokens int64
+}
+
+// AIUsageService reads the AI usage ledger, which the AI worker writes with each
+// generation, to enforce quotas and report consumption.
+type AIUsageService struct {
+	usageRepo repository.AIUsageRepository
+	quotas    AIQuotas
+	access    *AccessService
+}
+
+func NewAIUsageService(
+	usageRepo repository.AIUsageRepository,
+	quotas AIQuotas,
+	access *AccessService) *AIUsageService {
+	return &AIUsageService{
+		usageRepo: usageRepo,
+		quotas:    quotas,
+		access:    access,
+	}
+}
+
+// AIUsagePeriod is the consumption of a day or month, against its quota.
+type AIUsagePeriod struct {
+	domain.AIUsageTotals
+	Start      time.Time `json:"start"`
+	ResetsAt   time.Time `json:"resetsAt"`
+	TokenLimit int64     `json:"tokenLimit,omitempty"` // Omitted when unlimited
+}
+
+// NovelAIUsage is the consumption of a novel by all its users.
+type NovelAIUsage struct {
+	NovelID uuid.UUID     `json:"novelId"`
+	Daily   AIUsagePeriod `json:"daily"`
+	Monthly AIUsagePeriod `json:"monthly"`
+}
+
+// AIUsageReport is a user's consumption, and that of the novels the user generated
+// suggestions for this month.
+type AIUsageReport struct {
+	Daily   AIUsagePeriod   `json:"daily"`
+	Monthly AIUsagePeriod   `json:"monthly"`
+	Novels  []*NovelAIUsage `json:"novels"`
+}
+
+// CheckQuota fails with ErrAIQuotaExceeded when the user or the novel has used up a
+// quota. Suggestions still being generated are not counted yet, so a quota can be
+// overrun by the generations in flight.
+func (s *AIUsageService) CheckQuota(ctx context.Context, userID, novelID uuid.UUID) error {
+	day, month := usagePeriods(time.Now())
+	checks := []struct {
+		scope  string
+		period string
+		limit  int64
+		filter repository.AIUsageFilter
+		resets time.Time
+	}{
+		{"user", "daily", s.quotas.UserDailyTokens, repository.AIUsageFilter{UserID: &userID, Since: day}, day.AddDate(0, 0, 1)},
+		{"user", "monthly", s.quotas.UserMonthlyTokens, repository.AIUsageFilter{UserID: &userID, Since: month}, month.AddDate(0, 1, 0)},
+		{"novel", "daily", s.quotas.NovelDailyTokens, repository.AIUsageFilter{NovelID: &novelID, Since: day}, day.AddDate(0, 0, 1)},
+		{"novel", "monthly", s.quotas.NovelMonthlyTokens, repository.AIUsageFilter{NovelID: &novelID, Since: month}, month.AddDate(0, 1, 0)},
+	}
+
+	for _, check := range checks {
+		if check.limit <= 0 {
+			continue
+		}
+		totals, err := s.usageRepo.Totals(ctx, check.filter)
+		if err != nil {
+			return err
+		}
+		if totals.TotalTokens >= check.limit {
+			return fmt.Errorf("%w: the %s %s quota of %d tokens is used up (%d used); it resets at %s",
+				ErrAIQuotaExceeded, check.period, check.scope, check.limit, totals.TotalTokens,
+				check.resets.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// Report returns the user's consumption of the current day and month and that of the
+// novels the user generated suggestions for this month and can still view.
+func (s *AIUsageService) Report(ctx context.Context, userID uuid.UUID) (*AIUsageReport, error) {
+	day, month := usagePeriods(time.Now())
+	filter := repository.AIUsageFilter{UserID: &userID}
+
+	report := &AIUsageReport{Novels: []*NovelAIUsage{}}
+	var err error
+	if report.Daily, err = s.period(ctx, filter, day, day.AddDate(0, 0, 1), s.quotas.UserDailyTokens); err != nil {
+		return nil, err
+	}
+	if report.Monthly, err = s.period(ctx, filter, month, month.AddDate(0, 1, 0), s.quotas.UserMonthlyTokens); err != nil {
+		return nil, err
+	}
+
+	novelIDs, err := s.usageRepo.NovelsUsedBy(ctx, userID, month)
+	if err != nil {
+		return nil, err
+	}
+	for _, novelID := range novelIDs {
+		if _, err := s.access.Require(ctx, novelID, userID, domain.PermissionView); err != nil {
+			if errors.Is(err, ErrForbidden) || errors.Is(err, repository.ErrNovelNotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		usage := &NovelAIUsage{NovelID: novelID}
+		filter := repository.AIUsageFilter{NovelID: &novelID}
+		if usage.Daily, err = s.period(ctx, filter, day, day.AddDate(0, 0, 1), s.quotas.NovelDailyTokens); err != nil {
+			return nil, err
+		}
+		if usage.Monthly, err = s.period(ctx, filter, month, month.AddDate(0, 1, 0), s.quotas.NovelMonthlyTokens); err != nil {
+			return nil, err
+		}
+		report.Novels = append(report.Novels, usage)
+	}
+
+	return report, nil
+}
+
+func (s *AIUsageService) period(
+	ctx context.Context,
+	filter repository.AIUsageFilter,
+	start, resets time.Time,
+	limit int64,
+) (AIUsagePeriod, error) {
+	filter.Since = start
+	totals, err := s.usageRepo.Totals(ctx, filter)
+	if err != nil {
+		return AIUsagePeriod{}, err
+	}
+	return AIUsagePeriod{AIUsageTotals: *totals, Start: start, ResetsAt: resets, TokenLimit: max(limit, 0)}, nil
+}
+
+// usagePeriods returns the start of the UTC day and month of now.
+func usagePeriods(now time.Time) (day, month time.Time) {
+	now = now.UTC()
+	day = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return day, month
+}
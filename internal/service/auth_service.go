@@ -74,21 +74,19 @@ func (s *AuthService) LoginWithFirebaseToken(ctx context.Context, idToken string
 		// s.userRepo.Update(ctx, user) // Example update logic
 	}
 
-	// 3. Generate Backend Session Token (e.g., JWT)
-	// This token will be used for authenticating subsequent requests to *your* API.
-	// You would typically implement JWT generation/signing logic separately.
-	// backendToken, err := s.jwtGenerator.GenerateToken(user.ID, user.Roles) // Example
-	// if err != nil {
-	// 	 return nil, fmt.Errorf("failed to generate backend token: %w", err)
-	// }
-
-	// For now, let's just return success data without a backend token
+	// 3. Generate Backend Session Token (JWT)
+	// This token is used for authenticating subsequent requests to *your* API.
+	backendToken, err := s.jwtGenerator.GenerateToken(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate backend token: %w", err)
+	}
+
 	log.Printf("User %s (Firebase UID: %s) logged in successfully.", user.ID, user.FirebaseUID)
 	responseData := map[string]interface{}{
-		"message":     "Login successful",
-		"userId":      user.ID, // Your internal DB User ID
-		"firebaseUid": user.FirebaseUID,
-		// "backendToken": backendToken, // Include the generated token
+		"message":      "Login successful",
+		"userId":       user.ID, // Your internal DB User ID
+		"firebaseUid":  user.FirebaseUID,
+		"backendToken": backendToken,
 	}
 
 	return responseData, nil
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+	"github.com/khaled2049/server/internal/util/textdiff"
+)
+
+var (
+	// ErrInvalidChangeSet is returned for malformed change proposals.
+	ErrInvalidChangeSet = errors.New("invalid change set")
+	// ErrChangeConflict is returned when the text a change targets was edited since its base revision.
+	ErrChangeConflict = errors.New("change conflicts with the current chapter content")
+	// ErrChangeNotPending is returned when accepting or rejecting an already resolved change.
+	ErrChangeNotPending = errors.New("change is not pending")
+)
+
+// ChangeSetService implements suggestion (track-changes) mode: commenters and editors
+// propose changes against a chapter revision, and the owner accepts or rejects each one.
+// Accepted changes are re-based onto the current content and saved as a new revision
+// attributed to the proposer.
+type ChangeSetService struct {
+	transactor     repository.Transactor
+	chapterRepo    repository.ChapterRepository
+	revisionRepo   repository.ChapterRevisionRepository
+	changeRepo     repository.ChapterChangeRepository
+	chapterService *ChapterService
+	access         *AccessService
+}
+
+func NewChangeSetService(
+	transactor repository.Transactor,
+	chapterRepo repository.ChapterRepository,
+	revisionRepo repository.ChapterRevisionRepository,
+	changeRepo repository.ChapterChangeRepository,
+	chapterService *ChapterService,
+	access *AccessService) *ChangeSetService {
+	return &ChangeSetService{
+		transactor:     transactor,
+		chapterRepo:    chapterRepo,
+		revisionRepo:   revisionRepo,
+		changeRepo:     changeRepo,
+		chapterService: chapterService,
+		access:         access,
+	}
+}
+
+// ProposedChange replaces the rune range [Start, End) of the base revision with Text.
+type ProposedChange struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// ChangeSetProposal describes a change set. Either Changes or Content (the full proposed
+// text, diffed against the base) must be given. A nil BaseRevisionID proposes against the
+// current chapter content.
+type ChangeSetProposal struct {
+	BaseRevisionID *int64
+	Description    string
+	Changes        []ProposedChange
+	Content        *string
+}
+
+// ChangeResolution is returned when a change is accepted or rejected. Chapter, Revision
+// and OrphanedComments are only set for accepted changes.
+type ChangeResolution struct {
+	Change           *domain.ChapterChange   `json:"change"`
+	Chapter          *domain.Chapter         `json:"chapter,omitempty"`
+	Revision         *domain.ChapterRevision `json:"revision,omitempty"`
+	OrphanedComments []*domain.Comment       `json:"orphanedComments,omitempty"`
+}
+
+// ProposeChangeSet stores pending changes without touching the chapter content.
+func (s *ChangeSetService) ProposeChangeSet(
+	ctx context.Context,
+	actorID, chapterID uuid.UUID,
+	proposal ChangeSetProposal,
+) (*domain.ChapterChangeSet, error) {
+	if (proposal.Content == nil) == (len(proposal.Changes) == 0) {
+		return nil, fmt.Errorf("%w: provide either changes or content", ErrInvalidChangeSet)
+	}
+
+	var created *domain.ChapterChangeSet
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Lock so a baseline snapshot is created at most once
+		chapter, err := s.chapterRepo.LockByID(ctx, chapterID)
+		if err != nil {
+			return err
+		}
+		if err := s.chapterService.requireOnChapter(ctx, chapter, actorID, domain.PermissionComment); err != nil {
+			return err
+		}
+
+		base, err := s.baseRevision(ctx, chapter, chapterID, proposal.BaseRevisionID)
+		if err != nil {
+			return err
+		}
+
+		proposed := proposal.Changes
+		if proposal.Content != nil {
+			proposed = changesFromDiff(base.Content, *proposal.Content)
+		}
+		changes, err := validateChanges(base.Content, proposed)
+		if err != nil {
+			return err
+		}
+
+		novelID, err := uuid.Parse(chapter.NovelID)
+		if err != nil {
+			return fmt.Errorf("chapter %s has invalid novel ID: %w", chapter.ID, err)
+		}
+
+		created, err = s.changeRepo.CreateSet(ctx, &domain.ChapterChangeSet{
+			ChapterID:        chapterID,
+			NovelID:          novelID,
+			BaseRevisionID:   base.ID,
+			ProposedByUserID: actorID,
+			Description:      strings.TrimSpace(proposal.Description),
+			Changes:          changes,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// ListChangeSets returns the change sets of a chapter, optionally only those with changes in status.
+func (s *ChangeSetService) ListChangeSets(
+	ctx context.Context,
+	actorID, chapterID uuid.UUID,
+	status domain.ChapterChangeStatus,
+) ([]*domain.ChapterChangeSet, error) {
+	switch status {
+	case "", domain.ChapterChangePending, domain.ChapterChangeAccepted, domain.ChapterChangeRejected:
+	default:
+		return nil, fmt.Errorf("%w: unsupported status %q", ErrInvalidChangeSet, status)
+	}
+
+	if _, err := s.chapterService.GetChapter(ctx, actorID, chapterID); err != nil {
+		return nil, err
+	}
+	return s.changeRepo.ListSetsByChapter(ctx, chapterID, status)
+}
+
+// GetChangeSet returns a change set the actor can view.
+func (s *ChangeSetService) GetChangeSet(ctx context.Context, actorID, changeSetID uuid.UUID) (*domain.ChapterChangeSet, error) {
+	set, err := s.changeRepo.GetSet(ctx, changeSetID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.Require(ctx, set.NovelID, actorID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// AcceptChange applies a pending change to the current chapter content and records
+// the result as a revision attributed to the proposer. Only the owner may accept.
+func (s *ChangeSetService) AcceptChange(ctx context.Context, actorID, changeID uuid.UUID) (*ChangeResolution, error) {
+	var resolution *ChangeResolution
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		change, set, err := s.lockPendingChange(ctx, actorID, changeID)
+		if err != nil {
+			return err
+		}
+
+		chapter, err := s.chapterRepo.LockByID(ctx, set.ChapterID)
+		if err != nil {
+			return err
+		}
+		base, err := s.revisionRepo.GetByID(ctx, set.ChapterID, set.BaseRevisionID)
+		if err != nil {
+			return err
+		}
+
+		content, err := applyChange(base.Content, chapter.Content, change)
+		if err != nil {
+			return err
+		}
+
+		notes := "Accepted suggested change"
+		if set.Description != "" {
+			notes += ": " + set.Description
+		}
+		saved, err := s.chapterService.saveContent(ctx, chapter, content, set.ProposedByUserID, domain.ContentSourceUser, notes)
+		if err != nil {
+			return err
+		}
+
+		var appliedRevisionID *int64
+		if saved.Revision != nil {
+			appliedRevisionID = &saved.Revision.ID
+		}
+		resolved, err := s.changeRepo.Resolve(ctx, change.ID, domain.ChapterChangeAccepted, actorID, appliedRevisionID)
+		if err != nil {
+			return err
+		}
+
+		resolution = &ChangeResolution{
+			Change:           resolved,
+			Chapter:          saved.Chapter,
+			Revision:         saved.Revision,
+			OrphanedComments: saved.OrphanedComments,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.chapterService.saved(ctx, actorID, resolution.Chapter, resolution.Revision)
+	return resolution, nil
+}
+
+// RejectChange marks a pending change as rejected. Only the owner may reject.
+func (s *ChangeSetService) RejectChange(ctx context.Context, actorID, changeID uuid.UUID) (*ChangeResolution, error) {
+	var resolution *ChangeResolution
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		change, _, err := s.lockPendingChange(ctx, actorID, changeID)
+		if err != nil {
+			return err
+		}
+
+		resolved, err := s.changeRepo.Resolve(ctx, change.ID, domain.ChapterChangeRejected, actorID, nil)
+		if err != nil {
+			return err
+		}
+		resolution = &ChangeResolution{Change: resolved}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resolution, nil
+}
+
+// lockPendingChange locks a change for review and checks that the actor may manage the novel.
+func (s *ChangeSetService) lockPendingChange(
+	ctx context.Context,
+	actorID, changeID uuid.UUID,
+) (*domain.ChapterChange, *domain.ChapterChangeSet, error) {
+	change, err := s.changeRepo.LockChange(ctx, changeID)
+	if err != nil {
+		return nil, nil, err
+	}
+	set, err := s.changeRepo.GetSet(ctx, change.ChangeSetID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.access.Require(ctx, set.NovelID, actorID, domain.PermissionManage); err != nil {
+		return nil, nil, err
+	}
+	if change.Status != domain.ChapterChangePending {
+		return nil, nil, fmt.Errorf("%w: change is already %s", ErrChangeNotPending, change.Status)
+	}
+	return change, set, nil
+}
+
+// baseRevision resolves the revision a proposal is made against. Without an explicit
+// revision the current content is used, snapshotting it first when the latest revision
+// does not match (e.g. chapters saved before revisions were recorded).
+func (s *ChangeSetService) baseRevision(
+	ctx context.Context,
+	chapter *domain.Chapter,
+	chapterID uuid.UUID,
+	revisionID *int64,
+) (*domain.ChapterRevision, error) {
+	if revisionID != nil {
+		return s.revisionRepo.GetByID(ctx, chapterID, *revisionID)
+	}
+
+	latest, err := s.revisionRepo.GetLatest(ctx, chapterID)
+	if err == nil && latest.Content == chapter.Content {
+		return latest, nil
+	}
+	if err != nil && !errors.Is(err, repository.ErrRevisionNotFound) {
+		return nil, err
+	}
+
+	snapshot := &domain.ChapterRevision{
+		ChapterID:     chapterID,
+		Content:       chapter.Content,
+		Source:        domain.ContentSourceUser,
+		RevisionNotes: "Snapshot taken for suggested changes",
+	}
+	if editorID, err := uuid.Parse(chapter.LastEditedByUserID); err == nil {
+		snapshot.EditedByUserID = &editorID
+	}
+	return s.revisionRepo.Create(ctx, snapshot)
+}
+
+// changesFromDiff turns the diff between base and proposed text into changes, merging
+// adjacent deletions and insertions into a single replacement.
+func changesFromDiff(base, proposed string) []ProposedChange {
+	proposedRunes := []rune(proposed)
+
+	var changes []ProposedChange
+	var current *ProposedChange
+	for _, op := range textdiff.Diff(base, proposed) {
+		if op.Kind == textdiff.Equal {
+			if current != nil {
+				changes = append(changes, *current)
+				current = nil
+			}
+			continue
+		}
+		if current == nil {
+			current = &ProposedChange{Start: op.OldStart, End: op.OldStart}
+		}
+		if op.Kind == textdiff.Delete {
+			current.End = op.OldEnd
+		} else {
+			current.Text += string(proposedRunes[op.NewStart:op.NewEnd])
+		}
+	}
+	if current != nil {
+		changes = append(changes, *current)
+	}
+	return changes
+}
+
+// validateChanges checks proposed changes against the base text and fills in the
+// deleted text. Changes are returned in document order and must not overlap.
+func validateChanges(base string, proposed []ProposedChange) ([]*domain.ChapterChange, error) {
+	if len(proposed) == 0 {
+		return nil, fmt.Errorf("%w: no changes", ErrInvalidChangeSet)
+	}
+
+	sorted := append([]ProposedChange(nil), proposed...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	baseRunes := []rune(base)
+	changes := make([]*domain.ChapterChange, 0, len(sorted))
+	for i, p := range sorted {
+		if p.Start < 0 || p.End < p.Start || p.End > len(baseRunes) {
+			return nil, fmt.Errorf("%w: range [%d, %d) is outside the base revision (length %d)",
+				ErrInvalidChangeSet, p.Start, p.End, len(baseRunes))
+		}
+		deleted := string(baseRunes[p.Start:p.End])
+		if deleted == p.Text {
+			return nil, fmt.Errorf("%w: change at [%d, %d) does not change the text", ErrInvalidChangeSet, p.Start, p.End)
+		}
+		if i > 0 {
+			prev := sorted[i-1]
+			if prev.End > p.Start || (prev.Start == p.Start && prev.End == p.Start) {
+				return nil, fmt.Errorf("%w: changes at [%d, %d) and [%d, %d) overlap",
+					ErrInvalidChangeSet, prev.Start, prev.End, p.Start, p.End)
+			}
+		}
+
+		changes = append(changes, &domain.ChapterChange{
+			Start:        p.Start,
+			End:          p.End,
+			DeletedText:  deleted,
+			InsertedText: p.Text,
+		})
+	}
+	return changes, nil
+}
+
+// applyChange re-bases a change from its base revision onto the current content and
+// applies it. It fails with ErrChangeConflict when the targeted text was edited since.
+func applyChange(base, current string, change *domain.ChapterChange) (string, error) {
+	currentRunes := []rune(current)
+
+	// Unchanged content needs no re-basing. The mapper also could not map it when it is
+	// empty, as there is no unchanged text to anchor to.
+	start, end, ok := change.Start, change.End, change.End <= len(currentRunes)
+	if base != current {
+		start, end, ok = textdiff.NewMapper(base, current).MapRange(change.Start, change.End)
+	}
+	if !ok || string(currentRunes[start:end]) != change.DeletedText {
+		return "", fmt.Errorf("%w: the text at [%d, %d) of the base revision was edited",
+			ErrChangeConflict, change.Start, change.End)
+	}
+
+	return string(currentRunes[:start]) + change.InsertedText + string(currentRunes[end:]), nil
+}
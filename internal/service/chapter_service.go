@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/mq"
+	"github.com/khaled2049/server/internal/repository"
+	"github.com/khaled2049/server/internal/util/textdiff"
+)
+
+// ErrInvalidChapter is returned for malformed chapter edits.
+var ErrInvalidChapter = errors.New("invalid chapter")
+
+// ChapterService handles chapter reads and edits. Every content change is stored as a
+// revision and re-maps the anchors of inline comments so they follow the text.
+type ChapterService struct {
+	transactor   repository.Transactor
+	chapterRepo  repository.ChapterRepository
+	revisionRepo repository.ChapterRevisionRepository
+	commentRepo  repository.CommentRepository
+	outboxRepo   repository.OutboxRepository
+	live         *LiveEventService
+	access       *AccessService
+}
+
+func NewChapterService(
+	transactor repository.Transactor,
+	chapterRepo repository.ChapterRepository,
+	revisionRepo repository.ChapterRevisionRepository,
+	commentRepo repository.CommentRepository,
+	outboxRepo repository.OutboxRepository,
+	live *LiveEventService,
+	access *AccessService) *ChapterService {
+	return &ChapterService{
+		transactor:   transactor,
+		chapterRepo:  chapterRepo,
+		revisionRepo: revisionRepo,
+		commentRepo:  commentRepo,
+		outboxRepo:   outboxRepo,
+		live:         live,
+		access:       access,
+	}
+}
+
+// ChapterUpdate describes an edit. A nil Title, Content or Status leaves the field
+// unchanged.
+type ChapterUpdate struct {
+	Title         *string
+	Content       *string
+	Status        *domain.ChapterStatus
+	RevisionNotes string
+}
+
+// ChapterUpdateResult is returned by UpdateChapter. OrphanedComments lists the comments
+// whose anchored text was deleted by this edit.
+type ChapterUpdateResult struct {
+	Chapter          *domain.Chapter         `json:"chapter"`
+	Revision         *domain.ChapterRevision `json:"revision,omitempty"`
+	OrphanedComments []*domain.Comment       `json:"orphanedComments"`
+}
+
+// GetChapter returns a chapter the actor can view.
+func (s *ChapterService) GetChapter(ctx context.Context, actorID, chapterID uuid.UUID) (*domain.Chapter, error) {
+	chapter, err := s.chapterRepo.GetByID(ctx, chapterID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireOnChapter(ctx, chapter, actorID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+	return chapter, nil
+}
+
+// UpdateChapter saves an edit, records a revision when the content changed and
+// re-maps comment anchors, all in one transaction. Changing the status requires manage
+// access; publishing a chapter records a chapter.published event. The followers of the
+// novel are told once it is saved.
+func (s *ChapterService) UpdateChapter(
+	ctx context.Context,
+	actorID, chapterID uuid.UUID,
+	update ChapterUpdate,
+) (*ChapterUpdateResult, error) {
+	var result *ChapterUpdateResult
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Lock the row so concurrent saves remap anchors against the right base text
+		chapter, err := s.chapterRepo.LockByID(ctx, chapterID)
+		if err != nil {
+			return err
+		}
+		if err := s.requireOnChapter(ctx, chapter, actorID, domain.PermissionEditContent); err != nil {
+			return err
+		}
+
+		if update.Title != nil {
+			title := strings.TrimSpace(*update.Title)
+			if title == "" {
+				return fmt.Errorf("%w: title cannot be empty", ErrInvalidChapter)
+			}
+			chapter.Title = title
+		}
+		published := false
+		if update.Status != nil && *update.Status != chapter.Status {
+			if !update.Status.Valid() {
+				return fmt.Errorf("%w: unknown status %q", ErrInvalidChapter, *update.Status)
+			}
+			if err := s.requireOnChapter(ctx, chapter, actorID, domain.PermissionManage); err != nil {
+				return err
+			}
+			chapter.Status = *update.Status
+			published = chapter.Status == domain.ChapterStatusPublished
+		}
+		content := chapter.Content
+		if update.Content != nil {
+			content = *update.Content
+		}
+
+		saved, err := s.saveContent(ctx, chapter, content, actorID, domain.ContentSourceUser, update.RevisionNotes)
+		if err != nil {
+			return err
+		}
+		result = saved
+		if !published {
+			return nil
+		}
+		novelID, err := uuid.Parse(chapter.NovelID)
+		if err != nil {
+			return fmt.Errorf("chapter has invalid novel ID %q: %w", chapter.NovelID, err)
+		}
+		return recordEvent(ctx, s.outboxRepo, domain.OutboxAggregateChapter, chapterID, mq.TopicChapterPublished,
+			mq.ChapterPublishedEvent{ChapterID: chapterID, NovelID: novelID, PublisherID: actorID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.saved(ctx, actorID, result.Chapter, result.Revision)
+	return result, nil
+}
+
+// saved tells the followers of a novel that a chapter was saved. Callers of saveContent
+// call it once their transaction is committed.
+func (s *ChapterService) saved(ctx context.Context, editorID uuid.UUID, chapter *domain.Chapter, revision *domain.ChapterRevision) {
+	var revisionID *int64
+	if revision != nil {
+		revisionID = &revision.ID
+	}
+	s.live.ChapterUpdated(ctx, editorID, chapter, revisionID)
+}
+
+// saveContent writes a locked chapter with new content. When the content changed it also
+// records a revision attributed to editorID and source and re-maps comment anchors.
+// Every save records a chapter.saved event. Call it within a transaction, after LockByID,
+// and call saved once the transaction is committed.
+func (s *ChapterService) saveContent(
+	ctx context.Context,
+	chapter *domain.Chapter,
+	content string,
+	editorID uuid.UUID,
+	source domain.ContentSource,
+	revisionNotes string,
+) (*ChapterUpdateResult, error) {
+	result := &ChapterUpdateResult{Chapter: chapter, OrphanedComments: []*domain.Comment{}}
+
+	chapterID, err := uuid.Parse(chapter.ID)
+	if err != nil {
+		return nil, fmt.Errorf("chapter has invalid ID %q: %w", chapter.ID, err)
+	}
+	novelID, err := uuid.Parse(chapter.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("chapter has invalid novel ID %q: %w", chapter.NovelID, err)
+	}
+
+	oldContent := chapter.Content
+	chapter.Content = content
+	chapter.LastEditedByUserID = editorID.String()
+	if err := s.chapterRepo.Update(ctx, chapter); err != nil {
+		return nil, err
+	}
+
+	event := mq.ChapterSavedEvent{ChapterID: chapterID, NovelID: novelID, EditorID: editorID}
+	if content != oldContent {
+		result.Revision, err = s.revisionRepo.Create(ctx, &domain.ChapterRevision{
+			ChapterID:      chapterID,
+			Content:        content,
+			Source:         source,
+			EditedByUserID: &editorID,
+			RevisionNotes:  revisionNotes,
+		})
+		if err != nil {
+			return nil, err
+		}
+		event.RevisionID = &result.Revision.ID
+
+		orphaned, err := s.remapAnchors(ctx, chapterID, oldContent, content)
+		if err != nil {
+			return nil, err
+		}
+		result.OrphanedComments = append(result.OrphanedComments, orphaned...)
+	}
+
+	if err := recordEvent(ctx, s.outboxRepo, domain.OutboxAggregateChapter, chapterID, mq.TopicChapterSaved, event); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListRevisions returns the revision history of a chapter, newest first.
+func (s *ChapterService) ListRevisions(ctx context.Context, actorID, chapterID uuid.UUID) ([]*domain.ChapterRevision, error) {
+	if _, err := s.GetChapter(ctx, actorID, chapterID); err != nil {
+		return nil, err
+	}
+	return s.revisionRepo.ListByChapterID(ctx, chapterID)
+}
+
+// GetRevision returns a single revision including its content.
+func (s *ChapterService) GetRevision(ctx context.Context, actorID, chapterID uuid.UUID, revisionID int64) (*domain.ChapterRevision, error) {
+	if _, err := s.GetChapter(ctx, actorID, chapterID); err != nil {
+		return nil, err
+	}
+	return s.revisionRepo.GetByID(ctx, chapterID, revisionID)
+}
+
+// remapAnchors moves every anchored comment of the chapter from oldContent to newContent
+// and returns the comments that became orphaned with this edit.
+func (s *ChapterService) remapAnchors(ctx context.Context, chapterID uuid.UUID, oldContent, newContent string) ([]*domain.Comment, error) {
+	comments, err := s.commentRepo.ListAnchoredByChapter(ctx, chapterID)
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, nil
+	}
+
+	mapper := textdiff.NewMapper(oldContent, newContent)
+	newRunes := []rune(newContent)
+
+	var orphaned []*domain.Comment
+	for _, comment := range comments {
+		before := *comment.Anchor
+		after := remapAnchor(mapper, newContent, newRunes, before)
+		if after == before {
+			continue
+		}
+
+		if err := s.commentRepo.UpdateAnchor(ctx, comment.ID, &after); err != nil {
+			return nil, err
+		}
+		comment.Anchor = &after
+		if after.Orphaned && !before.Orphaned {
+			orphaned = append(orphaned, comment)
+		}
+	}
+
+	return orphaned, nil
+}
+
+// remapAnchor computes the anchor after an edit. Live anchors follow the diff and become
+// orphaned when all their text is gone; orphaned anchors re-attach only when their quote
+// occurs exactly once in the new text, so they never jump to an ambiguous passage.
+func remapAnchor(mapper *textdiff.Mapper, newContent string, newRunes []rune, anchor domain.CommentAnchor) domain.CommentAnchor {
+	if !anchor.Orphaned {
+		start, end, ok := mapper.MapRange(anchor.Start, anchor.End)
+		if ok && start < end {
+			anchor.Start, anchor.End = start, end
+			anchor.Quote = string(newRunes[start:end])
+			return anchor
+		}
+		anchor.Orphaned = true
+		// Keep the last quote as the fingerprint; clamp the position to the new text
+		anchor.Start = min(anchor.Start, len(newRunes))
+		anchor.End = anchor.Start
+		return anchor
+	}
+
+	if anchor.Quote == "" || strings.Count(newContent, anchor.Quote) != 1 {
+		return anchor
+	}
+	start := utf8.RuneCountInString(newContent[:strings.Index(newContent, anchor.Quote)])
+	anchor.Start, anchor.End = start, start+utf8.RuneCountInString(anchor.Quote)
+	anchor.Orphaned = false
+	return anchor
+}
+
+// requireOnChapter checks a permission on the novel the chapter belongs to.
+func (s *ChapterService) requireOnChapter(ctx context.Context, chapter *domain.Chapter, actorID uuid.UUID, perm domain.Permission) error {
+	novelID, err := uuid.Parse(chapter.NovelID)
+	if err != nil {
+		return fmt.Errorf("chapter %s has invalid novel ID: %w", chapter.ID, err)
+	}
+	_, err = s.access.Require(ctx, novelID, actorID, perm)
+	return err
+}
@@ -0,0 +1,443 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// ErrInvalidAttributes is returned when character attributes do not match the novel's schema.
+var ErrInvalidAttributes = errors.New("invalid character attributes")
+
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+const attributeDateLayout = "2006-01-02"
+
+type CharacterService struct {
+	novelRepo     repository.NovelRepository
+	characterRepo repository.CharacterRepository
+	attributeRepo repository.CharacterAttributeRepository
+	access        *AccessService
+}
+
+func NewCharacterService(
+	novelRepo repository.NovelRepository,
+	characterRepo repository.CharacterRepository,
+	attributeRepo repository.CharacterAttributeRepository,
+	access *AccessService) *CharacterService {
+	return &CharacterService{
+		novelRepo:     novelRepo,
+		characterRepo: characterRepo,
+		attributeRepo: attributeRepo,
+		access:        access,
+	}
+}
+
+// CreateCharacter validates the character against the novel's attribute schema and stores it.
+// The actor needs to be able to edit the novel's content.
+func (s *CharacterService) CreateCharacter(
+	ctx context.Context,
+	actorID uuid.UUID,
+	novelID uuid.UUID,
+	character *domain.Character,
+) (*domain.Character, error) {
+	// Verify the novel exists
+	_, err := s.novelRepo.GetByID(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("novel not found: %w", err)
+	}
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionEditContent); err != nil {
+		return nil, err
+	}
+
+	character.NovelID = novelID
+	character.CreatedByUserID = &actorID
+	character.Aliases = cleanAliases(character.Aliases, character.Name)
+	if character.Source == "" {
+		character.Source = "user"
+	}
+
+	if err := s.validateAttributes(ctx, novelID, character.Attributes); err != nil {
+		return nil, err
+	}
+
+	return s.characterRepo.Create(ctx, character)
+}
+
+// GetCharacter returns a character of a novel the actor can view.
+func (s *CharacterService) GetCharacter(ctx context.Context, actorID, id uuid.UUID) (*domain.Character, error) {
+	return s.getCharacter(ctx, actorID, id, domain.PermissionView)
+}
+
+// UpdateCharacter re-validates attributes against the current schema before saving.
+func (s *CharacterService) UpdateCharacter(ctx context.Context, actorID uuid.UUID, character *domain.Character) (*domain.Character, error) {
+	existing, err := s.getCharacter(ctx, actorID, character.ID, domain.PermissionEditContent)
+	if err != nil {
+		return nil, err
+	}
+
+	character.NovelID = existing.NovelID
+	character.Source = existing.Source
+	character.CreatedAt = existing.CreatedAt
+	character.CreatedByUserID = existing.CreatedByUserID
+	character.Aliases = cleanAliases(character.Aliases, character.Name)
+
+	if err := s.validateAttributes(ctx, existing.NovelID, character.Attributes); err != nil {
+		return nil, err
+	}
+
+	if err := s.characterRepo.Update(ctx, character); err != nil {
+		return nil, err
+	}
+	return character, nil
+}
+
+func (s *CharacterService) DeleteCharacter(ctx context.Context, actorID, id uuid.UUID) error {
+	if _, err := s.getCharacter(ctx, actorID, id, domain.PermissionEditContent); err != nil {
+		return err
+	}
+	return s.characterRepo.Delete(ctx, id)
+}
+
+// getCharacter loads a character and checks the actor's permission on its novel.
+func (s *CharacterService) getCharacter(ctx context.Context, actorID, id uuid.UUID, perm domain.Permission) (*domain.Character, error) {
+	character, err := s.characterRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.Require(ctx, character.NovelID, actorID, perm); err != nil {
+		return nil, err
+	}
+	return character, nil
+}
+
+// ListCharacters lists a novel's characters. Attribute filters arrive as raw query strings
+// and are converted to the type declared in the schema so JSONB containment matches.
+func (s *CharacterService) ListCharacters(
+	ctx context.Context,
+	actorID uuid.UUID,
+	novelID uuid.UUID,
+	query string,
+	rawAttributes map[string]string,
+) ([]*domain.Character, error) {
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+
+	filter := repository.CharacterFilter{
+		NovelID: novelID,
+		Query:   strings.TrimSpace(query),
+	}
+
+	if len(rawAttributes) > 0 {
+		defs, err := s.attributeRepo.ListByNovelID(ctx, novelID)
+		if err != nil {
+			return nil, err
+		}
+		byKey := definitionsByKey(defs)
+
+		filter.Attributes = domain.Attributes{}
+		for key, raw := range rawAttributes {
+			def, ok := byKey[key]
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown attribute %q", ErrInvalidAttributes, key)
+			}
+			value, err := parseAttributeValue(def, raw)
+			if err != nil {
+				return nil, err
+			}
+			filter.Attributes[key] = value
+		}
+	}
+
+	return s.characterRepo.List(ctx, filter)
+}
+
+// GetAttributeSchema returns the character attribute template of a novel.
+func (s *CharacterService) GetAttributeSchema(ctx context.Context, actorID, novelID uuid.UUID) ([]*domain.CharacterAttributeDefinition, error) {
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+	return s.attributeRepo.ListByNovelID(ctx, novelID)
+}
+
+// ReplaceAttributeSchema validates and stores a novel's attribute template; order is preserved.
+// Like characters, the schema is content: commenters and viewers cannot change it.
+func (s *CharacterService) ReplaceAttributeSchema(
+	ctx context.Context,
+	actorID uuid.UUID,
+	novelID uuid.UUID,
+	defs []*domain.CharacterAttributeDefinition,
+) ([]*domain.CharacterAttributeDefinition, error) {
+	if _, err := s.novelRepo.GetByID(ctx, novelID); err != nil {
+		return nil, fmt.Errorf("novel not found: %w", err)
+	}
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionEditContent); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		if !attributeKeyPattern.MatchString(def.Key) {
+			return nil, fmt.Errorf("%w: key %q must be lowercase letters, digits or underscores", ErrInvalidAttributes, def.Key)
+		}
+		if seen[def.Key] {
+			return nil, fmt.Errorf("%w: duplicate key %q", ErrInvalidAttributes, def.Key)
+		}
+		seen[def.Key] = true
+
+		if def.Label == "" {
+			def.Label = def.Key
+		}
+		switch def.ValueType {
+		case "":
+			def.ValueType = domain.CharacterAttributeText
+		case domain.CharacterAttributeText, domain.CharacterAttributeNumber,
+			domain.CharacterAttributeBoolean, domain.CharacterAttributeDate:
+		case domain.CharacterAttributeEnum:
+			if len(def.Options) == 0 {
+				return nil, fmt.Errorf("%w: enum attribute %q needs options", ErrInvalidAttributes, def.Key)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported type %q for %q", ErrInvalidAttributes, def.ValueType, def.Key)
+		}
+		if def.ValueType != domain.CharacterAttributeEnum {
+			def.Options = nil
+		}
+	}
+
+	return s.attributeRepo.ReplaceForNovel(ctx, novelID, defs)
+}
+
+// DetectMentions finds whole-word, case-insensitive occurrences of character names and
+// aliases in text. Longer names win when matches overlap ("Anna Lee" over "Anna").
+func (s *CharacterService) DetectMentions(ctx context.Context, actorID, novelID uuid.UUID, text string) ([]domain.CharacterMention, error) {
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+	characters, err := s.characterRepo.ListByNovelID(ctx, novelID)
+	if err != nil {
+		return nil, err
+	}
+	return FindCharacterMentions(characters, text), nil
+}
+
+// FindCharacterMentions is the pure matching step behind DetectMentions.
+func FindCharacterMentions(characters []*domain.Character, text string) []domain.CharacterMention {
+	type term struct {
+		character *domain.Character
+		runes     []rune
+	}
+
+	var terms []term
+	for _, character := range characters {
+		for _, name := range append([]string{character.Name}, character.Aliases...) {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			terms = append(terms, term{character: character, runes: []rune(strings.ToLower(name))})
+		}
+	}
+	// Longest terms first so they claim overlapping spans before their prefixes
+	sort.SliceStable(terms, func(i, j int) bool { return len(terms[i].runes) > len(terms[j].runes) })
+
+	source := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(source) {
+		// Case folding changed the length (rare scripts); fall back to the original runes
+		lower = source
+	}
+	claimed := make([]bool, len(source))
+
+	var mentions []domain.CharacterMention
+	for _, t := range terms {
+		n := len(t.runes)
+		for i := 0; i+n <= len(lower); i++ {
+			if !runesEqual(lower[i:i+n], t.runes) || !isWordBoundary(lower, i, i+n) || anyClaimed(claimed, i, i+n) {
+				continue
+			}
+			for k := i; k < i+n; k++ {
+				claimed[k] = true
+			}
+			mentions = append(mentions, domain.CharacterMention{
+				CharacterID: t.character.ID,
+				Name:        t.character.Name,
+				MatchedText: string(source[i : i+n]),
+				Start:       i,
+				End:         i + n,
+			})
+			i += n - 1
+		}
+	}
+
+	sort.Slice(mentions, func(i, j int) bool { return mentions[i].Start < mentions[j].Start })
+	return mentions
+}
+
+func (s *CharacterService) validateAttributes(ctx context.Context, novelID uuid.UUID, attributes domain.Attributes) error {
+	defs, err := s.attributeRepo.ListByNovelID(ctx, novelID)
+	if err != nil {
+		return err
+	}
+	return ValidateAttributes(defs, attributes)
+}
+
+// ValidateAttributes checks values against the schema: no unknown keys, required keys
+// present and every value of the declared type.
+func ValidateAttributes(defs []*domain.CharacterAttributeDefinition, attributes domain.Attributes) error {
+	byKey := definitionsByKey(defs)
+
+	for key, value := range attributes {
+		def, ok := byKey[key]
+		if !ok {
+			return fmt.Errorf("%w: unknown attribute %q", ErrInvalidAttributes, key)
+		}
+		if value == nil {
+			continue
+		}
+		if err := checkAttributeValue(def, value); err != nil {
+			return err
+		}
+	}
+
+	for _, def := range defs {
+		if !def.Required {
+			continue
+		}
+		if value, ok := attributes[def.Key]; !ok || value == nil || value == "" {
+			return fmt.Errorf("%w: %q is required", ErrInvalidAttributes, def.Key)
+		}
+	}
+
+	return nil
+}
+
+func checkAttributeValue(def *domain.CharacterAttributeDefinition, value interface{}) error {
+	switch def.ValueType {
+	case domain.CharacterAttributeNumber:
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%w: %q must be a number", ErrInvalidAttributes, def.Key)
+		}
+	case domain.CharacterAttributeBoolean:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%w: %q must be a boolean", ErrInvalidAttributes, def.Key)
+		}
+	case domain.CharacterAttributeDate:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%w: %q must be a date string", ErrInvalidAttributes, def.Key)
+		}
+		if _, err := time.Parse(attributeDateLayout, str); err != nil {
+			return fmt.Errorf("%w: %q must be formatted YYYY-MM-DD", ErrInvalidAttributes, def.Key)
+		}
+	case domain.CharacterAttributeEnum:
+		str, ok := value.(string)
+		if !ok || !containsString(def.Options, str) {
+			return fmt.Errorf("%w: %q must be one of %s", ErrInvalidAttributes, def.Key, strings.Join(def.Options, ", "))
+		}
+	default:
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%w: %q must be text", ErrInvalidAttributes, def.Key)
+		}
+	}
+	return nil
+}
+
+// parseAttributeValue converts a query-string value to the definition's JSON type.
+func parseAttributeValue(def *domain.CharacterAttributeDefinition, raw string) (interface{}, error) {
+	var value interface{} = raw
+	switch def.ValueType {
+	case domain.CharacterAttributeNumber:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q must be a number", ErrInvalidAttributes, def.Key)
+		}
+		value = n
+	case domain.CharacterAttributeBoolean:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q must be a boolean", ErrInvalidAttributes, def.Key)
+		}
+		value = b
+	}
+	if err := checkAttributeValue(def, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func definitionsByKey(defs []*domain.CharacterAttributeDefinition) map[string]*domain.CharacterAttributeDefinition {
+	byKey := make(map[string]*domain.CharacterAttributeDefinition, len(defs))
+	for _, def := range defs {
+		byKey[def.Key] = def
+	}
+	return byKey
+}
+
+// cleanAliases trims, de-duplicates (case-insensitively) and drops aliases equal to the name.
+func cleanAliases(aliases []string, name string) []string {
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(name)): true}
+	cleaned := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		key := strings.ToLower(alias)
+		if alias == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, alias)
+	}
+	return cleaned
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+func runesEqual(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordBoundary(text []rune, start, end int) bool {
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	if start > 0 && isWord(text[start-1]) {
+		return false
+	}
+	if end < len(text) && isWord(text[end]) {
+		return false
+	}
+	return true
+}
+
+func anyClaimed(claimed []bool, start, end int) bool {
+	for i := start; i < end; i++ {
+		if claimed[i] {
+			return true
+		}
+	}
+	return false
+}
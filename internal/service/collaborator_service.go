@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/mq"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// ErrInvalidRole is returned for roles that cannot be granted through the API.
+var ErrInvalidRole = errors.New("invalid collaboration role")
+
+type CollaboratorService struct {
+	transactor       repository.Transactor
+	collaboratorRepo repository.CollaboratorRepository
+	outboxRepo       repository.OutboxRepository
+	access           *AccessService
+}
+
+func NewCollaboratorService(
+	transactor repository.Transactor,
+	collaboratorRepo repository.CollaboratorRepository,
+	outboxRepo repository.OutboxRepository,
+	access *AccessService) *CollaboratorService {
+	return &CollaboratorService{
+		transactor:       transactor,
+		collaboratorRepo: collaboratorRepo,
+		outboxRepo:       outboxRepo,
+		access:           access,
+	}
+}
+
+// ListCollaborators is available to anyone who can view the novel.
+func (s *CollaboratorService) ListCollaborators(ctx context.Context, novelID, actorID uuid.UUID) ([]*domain.NovelCollaborator, error) {
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+	return s.collaboratorRepo.ListByNovelID(ctx, novelID)
+}
+
+// SetCollaborator adds a collaborator or changes their role. Owner only; ownership itself
+// lives on the novel and cannot be granted here.
+func (s *CollaboratorService) SetCollaborator(
+	ctx context.Context,
+	novelID, actorID, userID uuid.UUID,
+	role domain.CollaborationRole,
+) (*domain.NovelCollaborator, error) {
+	if !role.Valid() || role == domain.CollaborationRoleOwner {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionManage); err != nil {
+		return nil, err
+	}
+	if userID == actorID {
+		return nil, fmt.Errorf("%w: the owner cannot change their own role", ErrInvalidRole)
+	}
+
+	collaborator := &domain.NovelCollaborator{NovelID: novelID, UserID: userID, Role: role}
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		_, err := s.collaboratorRepo.GetRole(ctx, novelID, userID)
+		added := errors.Is(err, repository.ErrCollaboratorNotFound)
+		if err != nil && !added {
+			return err
+		}
+		if err := s.collaboratorRepo.Upsert(ctx, collaborator); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, mq.CollaboratorChangedEvent{
+			NovelID: novelID, UserID: userID, ActorID: actorID, Role: string(role), Added: added,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collaborator, nil
+}
+
+// RemoveCollaborator is allowed for the owner, or for collaborators leaving on their own.
+func (s *CollaboratorService) RemoveCollaborator(ctx context.Context, novelID, actorID, userID uuid.UUID) error {
+	if actorID != userID {
+		if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionManage); err != nil {
+			return err
+		}
+	}
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.collaboratorRepo.Remove(ctx, novelID, userID); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, mq.CollaboratorChangedEvent{
+			NovelID: novelID, UserID: userID, ActorID: actorID, Removed: true,
+		})
+	})
+}
+
+// recordChange records a collaborator.changed event; events are ordered per novel.
+func (s *CollaboratorService) recordChange(ctx context.Context, event mq.CollaboratorChangedEvent) error {
+	return recordEvent(ctx, s.outboxRepo, domain.OutboxAggregateNovel, event.NovelID, mq.TopicCollaboratorChanged, event)
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/mq"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// ErrInvalidComment is returned for malformed comment input.
+var ErrInvalidComment = errors.New("invalid comment")
+
+// CommentService manages threaded comments on chapters and worldbuilding entities.
+//
+// Permissions: viewers can read, commenters/editors/owners can comment and reply,
+// only the author edits a comment, the author or owner deletes it, and the thread
+// author, editors and owners resolve/reopen a thread.
+type CommentService struct {
+	transactor  repository.Transactor
+	commentRepo repository.CommentRepository
+	chapterRepo repository.ChapterRepository
+	outboxRepo  repository.OutboxRepository
+	live        *LiveEventService
+	access      *AccessService
+}
+
+func NewCommentService(
+	transactor repository.Transactor,
+	commentRepo repository.CommentRepository,
+	chapterRepo repository.ChapterRepository,
+	outboxRepo repository.OutboxRepository,
+	live *LiveEventService,
+	access *AccessService) *CommentService {
+	return &CommentService{
+		transactor:  transactor,
+		commentRepo: commentRepo,
+		chapterRepo: chapterRepo,
+		outboxRepo:  outboxRepo,
+		live:        live,
+		access:      access,
+	}
+}
+
+// CreateThread starts a new comment thread on a target. anchor is optional and only
+// valid for chapter targets; its quote is computed from the chapter content.
+func (s *CommentService) CreateThread(
+	ctx context.Context,
+	actorID uuid.UUID,
+	targetType domain.CommentTargetType,
+	targetID uuid.UUID,
+	content string,
+	anchor *domain.CommentAnchor,
+) (*domain.Comment, error) {
+	if !targetType.Valid() {
+		return nil, fmt.Errorf("%w: unsupported target type %q", ErrInvalidComment, targetType)
+	}
+	content, err := cleanCommentContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	novelID, err := s.commentRepo.TargetNovelID(ctx, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionComment); err != nil {
+		return nil, err
+	}
+
+	if anchor != nil && targetType != domain.CommentTargetChapter {
+		return nil, fmt.Errorf("%w: anchors are only supported on chapters", ErrInvalidComment)
+	}
+
+	return s.create(ctx, &domain.Comment{
+		NovelID:    novelID,
+		UserID:     actorID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Anchor:     anchor,
+		Content:    content,
+		Status:     domain.CommentStatusOpen,
+	})
+}
+
+// resolveAnchor validates a requested range against the chapter content and fills in
+// the quote. A quote supplied by the client must match the text at the range, which
+// catches clients anchoring against a stale copy of the chapter.
+func resolveAnchor(content string, requested *domain.CommentAnchor) (*domain.CommentAnchor, error) {
+	runes := []rune(content)
+	if requested.Start < 0 || requested.End <= requested.Start || requested.End > len(runes) {
+		return nil, fmt.Errorf("%w: anchor range [%d, %d) is outside the chapter (length %d)",
+			ErrInvalidComment, requested.Start, requested.End, len(runes))
+	}
+
+	quote := string(runes[requested.Start:requested.End])
+	if requested.Quote != "" && requested.Quote != quote {
+		return nil, fmt.Errorf("%w: anchor quote does not match the chapter text at [%d, %d)",
+			ErrInvalidComment, requested.Start, requested.End)
+	}
+
+	return &domain.CommentAnchor{Start: requested.Start, End: requested.End, Quote: quote}, nil
+}
+
+// Reply adds a reply to a thread. Replying to a reply attaches to the same thread root.
+func (s *CommentService) Reply(ctx context.Context, actorID, parentID uuid.UUID, content string) (*domain.Comment, error) {
+	content, err := cleanCommentContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, err := s.commentRepo.GetByID(ctx, parentID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.Require(ctx, parent.NovelID, actorID, domain.PermissionComment); err != nil {
+		return nil, err
+	}
+
+	rootID := parent.ID
+	if parent.ParentCommentID != nil {
+		rootID = *parent.ParentCommentID
+	}
+
+	return s.create(ctx, &domain.Comment{
+		NovelID:         parent.NovelID,
+		UserID:          actorID,
+		TargetType:      parent.TargetType,
+		TargetID:        parent.TargetID,
+		ParentCommentID: &rootID,
+		Content:         content,
+		Status:          domain.CommentStatusOpen,
+	})
+}
+
+// create stores a comment and its comment.created event in one transaction. Events are
+// ordered per thread. The followers of the novel are told once it is stored. A requested
+// anchor is resolved against the chapter locked in the same transaction, as saves lock
+// it too: a save either commits first or remaps the new anchor with the others.
+func (s *CommentService) create(ctx context.Context, comment *domain.Comment) (*domain.Comment, error) {
+	var created *domain.Comment
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if comment.Anchor != nil {
+			chapter, err := s.chapterRepo.LockByID(ctx, comment.TargetID)
+			if err != nil {
+				return err
+			}
+			if comment.Anchor, err = resolveAnchor(chapter.Content, comment.Anchor); err != nil {
+				return err
+			}
+		}
+		if created, err = s.commentRepo.Create(ctx, comment); err != nil {
+			return err
+		}
+
+		threadID := created.ID
+		if created.ParentCommentID != nil {
+			threadID = *created.ParentCommentID
+		}
+		return recordEvent(ctx, s.outboxRepo, domain.OutboxAggregateCommentThread, threadID, mq.TopicCommentCreated,
+			mq.CommentCreatedEvent{
+				CommentID:       created.ID,
+				NovelID:         created.NovelID,
+				AuthorID:        created.UserID,
+				TargetType:      string(created.TargetType),
+				TargetID:        created.TargetID,
+				ParentCommentID: created.ParentCommentID,
+			})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.live.CommentCreated(ctx, created)
+	return created, nil
+}
+
+// EditComment lets the author change their comment text.
+func (s *CommentService) EditComment(ctx context.Context, actorID, commentID uuid.UUID, content string) (*domain.Comment, error) {
+	content, err := cleanCommentContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := s.commentRepo.GetByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != actorID {
+		return nil, fmt.Errorf("%w: only the author can edit a comment", ErrForbidden)
+	}
+	// The author must still be allowed to comment (e.g. not demoted to viewer)
+	if _, err := s.access.Require(ctx, comment.NovelID, actorID, domain.PermissionComment); err != nil {
+		return nil, err
+	}
+
+	return s.commentRepo.UpdateContent(ctx, commentID, content)
+}
+
+// DeleteComment removes a comment (and its replies when it is a thread root).
+func (s *CommentService) DeleteComment(ctx context.Context, actorID, commentID uuid.UUID) error {
+	comment, err := s.commentRepo.GetByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+
+	// Authors must still be allowed to comment; others' comments take managing the novel
+	permission := domain.PermissionComment
+	if comment.UserID != actorID {
+		permission = domain.PermissionManage
+	}
+	if _, err := s.access.Require(ctx, comment.NovelID, actorID, permission); err != nil {
+		return err
+	}
+
+	return s.commentRepo.Delete(ctx, commentID)
+}
+
+// SetThreadStatus resolves or reopens the thread the comment belongs to.
+func (s *CommentService) SetThreadStatus(
+	ctx context.Context,
+	actorID, commentID uuid.UUID,
+	status domain.CommentStatus,
+) (*domain.Comment, error) {
+	comment, err := s.commentRepo.GetByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.ParentCommentID != nil {
+		if comment, err = s.commentRepo.GetByID(ctx, *comment.ParentCommentID); err != nil {
+			return nil, err
+		}
+	}
+
+	role, err := s.access.Require(ctx, comment.NovelID, actorID, domain.PermissionComment)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != actorID && !role.Can(domain.PermissionEditContent) {
+		return nil, fmt.Errorf("%w: only the thread author, editors and the owner can change thread status", ErrForbidden)
+	}
+
+	return s.commentRepo.UpdateStatus(ctx, comment.ID, status)
+}
+
+// ListThreads returns the threads on a target with their replies nested, oldest first.
+func (s *CommentService) ListThreads(
+	ctx context.Context,
+	actorID uuid.UUID,
+	targetType domain.CommentTargetType,
+	targetID uuid.UUID,
+	status domain.CommentStatus,
+) ([]*domain.Comment, error) {
+	if !targetType.Valid() {
+		return nil, fmt.Errorf("%w: unsupported target type %q", ErrInvalidComment, targetType)
+	}
+	if status != "" && status != domain.CommentStatusOpen && status != domain.CommentStatusResolved {
+		return nil, fmt.Errorf("%w: unsupported status %q", ErrInvalidComment, status)
+	}
+
+	novelID, err := s.commentRepo.TargetNovelID(ctx, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.commentRepo.ListByTarget(ctx, repository.CommentFilter{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Status:     status,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buildThreads(comments), nil
+}
+
+// buildThreads nests replies under their roots, keeping input order.
+func buildThreads(comments []*domain.Comment) []*domain.Comment {
+	roots := make(map[uuid.UUID]*domain.Comment)
+	threads := []*domain.Comment{}
+	for _, comment := range comments {
+		if comment.ParentCommentID == nil {
+			roots[comment.ID] = comment
+			threads = append(threads, comment)
+		}
+	}
+	for _, comment := range comments {
+		if comment.ParentCommentID == nil {
+			continue
+		}
+		if root, ok := roots[*comment.ParentCommentID]; ok {
+			root.Replies = append(root.Replies, comment)
+		}
+	}
+	return threads
+}
+
+func cleanCommentContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", fmt.Errorf("%w: content cannot be empty", ErrInvalidComment)
+	}
+	return content, nil
+}
@@ -0,0 +1,581 @@
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+)
+
+// The rule-based pass of the consistency checker compares a chapter with the story
+// bible through simple text patterns. It favours precision over recall: a descriptor
+// ("green eyes") is only attributed to a character when its sentence, or the sentences
+// before it in the paragraph, name no other character.
+
+// storyBible is what a chapter is checked against.
+type storyBible struct {
+	chapter    *domain.Chapter
+	chapterID  uuid.UUID
+	chapters   map[uuid.UUID]*domain.Chapter // The novel's chapters
+	characters []*domain.Character
+	places     []*domain.Place
+	rules      []*domain.Note          // world_rule and magic_system notes
+	events     []*domain.TimelineEvent // In timeline order
+}
+
+type descriptorCategory string
+
+const (
+	descriptorColor    descriptorCategory = "color"
+	descriptorMaterial descriptorCategory = "material"
+)
+
+// descriptorWords maps the words of comparable descriptors to their category and
+// canonical value, so that synonyms ("gray", "grey") do not contradict each other.
+var descriptorWords = map[string]struct {
+	category descriptorCategory
+	value    string
+}{
+	"amber": {descriptorColor, "amber"}, "auburn": {descriptorColor, "auburn"},
+	"black": {descriptorColor, "black"}, "blond": {descriptorColor, "blonde"},
+	"blonde": {descriptorColor, "blonde"}, "blue": {descriptorColor, "blue"},
+	"brown": {descriptorColor, "brown"}, "ginger": {descriptorColor, "red"},
+	"gold": {descriptorColor, "golden"}, "golden": {descriptorColor, "golden"},
+	"gray": {descriptorColor, "grey"}, "green": {descriptorColor, "green"},
+	"grey": {descriptorColor, "grey"}, "hazel": {descriptorColor, "hazel"},
+	"purple": {descriptorColor, "violet"}, "red": {descriptorColor, "red"},
+	"silver": {descriptorColor, "silver"}, "violet": {descriptorColor, "violet"},
+	"white": {descriptorColor, "white"},
+
+	"brick": {descriptorMaterial, "brick"}, "bronze": {descriptorMaterial, "bronze"},
+	"crystal": {descriptorMaterial, "crystal"}, "glass": {descriptorMaterial, "glass"},
+	"granite": {descriptorMaterial, "granite"}, "iron": {descriptorMaterial, "iron"},
+	"marble": {descriptorMaterial, "marble"}, "sandstone": {descriptorMaterial, "sandstone"},
+	"slate": {descriptorMaterial, "slate"}, "steel": {descriptorMaterial, "steel"},
+	"stone": {descriptorMaterial, "stone"}, "thatch": {descriptorMaterial, "thatch"},
+	"thatched": {descriptorMaterial, "thatch"}, "timber": {descriptorMaterial, "wood"},
+	"wood": {descriptorMaterial, "wood"}, "wooden": {descriptorMaterial, "wood"},
+}
+
+// characterFeatures are the nouns whose color is checked for characters, singular.
+var characterFeatures = []string{"eye", "hair", "beard"}
+
+// maxDescriptorGap is how many words may separate a descriptor from its noun ("grey,
+// tired eyes").
+const maxDescriptorGap = 2
+
+// worldRuleLine matches the lines of world rule and magic system notes that the rules
+// pass enforces, e.g. "Forbidden: teleportation, raising the dead".
+var worldRuleLine = regexp.MustCompile(`(?im)^\s*(?:forbidden|never|not allowed|impossible)\s*:\s*(.+)$`)
+
+// deathWords mark an event as a death when it involves a single character.
+var deathWords = regexp.MustCompile(`(?i)\b(?:dies|died|death|funeral|passes away|passed away|(?:is|was) (?:killed|murdered|slain|executed))\b`)
+
+type textWord struct {
+	start, end int // Rune offsets
+	lower      string
+	sentence   int
+	paragraph  int
+}
+
+// splitWords splits text into words, numbering the sentences and paragraphs they
+// belong to. Apostrophes within a word are kept ("Anna's").
+func splitWords(runes []rune) []textWord {
+	var words []textWord
+	sentence, paragraph := 0, 0
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) ||
+				(runes[i] == '\'' || runes[i] == '’') && i+1 < len(runes) && unicode.IsLetter(runes[i+1])) {
+				i++
+			}
+			words = append(words, textWord{
+				start:     start,
+				end:       i,
+				lower:     strings.ToLower(string(runes[start:i])),
+				sentence:  sentence,
+				paragraph: paragraph,
+			})
+			continue
+		case r == '\n':
+			paragraph++
+			sentence++
+		case r == '.' || r == '!' || r == '?' || r == ';':
+			sentence++
+		}
+		i++
+	}
+	return words
+}
+
+// sentenceAt returns the sentence of the word at a rune offset, or of the next word.
+func sentenceAt(words []textWord, offset int) int {
+	i, _ := slices.BinarySearchFunc(words, offset, func(w textWord, offset int) int { return cmp.Compare(w.end, offset+1) })
+	return words[min(i, len(words)-1)].sentence
+}
+
+// singular strips the plural s of a noun, well enough to compare descriptors.
+func singular(word string) string {
+	if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// descriptor is a color or material describing a noun, e.g. "green eyes".
+type descriptor struct {
+	category   descriptorCategory
+	value      string
+	noun       string // Singular
+	start, end int    // Rune range from the descriptor to the noun
+}
+
+// findDescriptors returns the descriptors of nouns accepted by isNoun: a descriptor
+// word followed in its sentence, at most maxDescriptorGap words later, by the noun.
+func findDescriptors(words []textWord, isNoun func(noun string) bool) []descriptor {
+	var found []descriptor
+	for i, w := range words {
+		d, ok := descriptorWords[w.lower]
+		if !ok {
+			continue
+		}
+		for j := i + 1; j < len(words) && j <= i+1+maxDescriptorGap && words[j].sentence == w.sentence; j++ {
+			if next, ok := descriptorWords[words[j].lower]; ok {
+				if next.category == d.category {
+					break // Left to the next descriptor ("blue and green eyes")
+				}
+				continue // Both describe the noun ("red brick walls")
+			}
+			if noun := singular(words[j].lower); isNoun(noun) {
+				found = append(found, descriptor{d.category, d.value, noun, w.start, words[j].end})
+				break
+			}
+		}
+	}
+	return found
+}
+
+// canon holds the descriptor values of an entity in the story bible, by category and noun.
+type canon map[string][]string
+
+func canonKey(category descriptorCategory, noun string) string {
+	return string(category) + "/" + noun
+}
+
+func (c canon) add(category descriptorCategory, noun, value string) {
+	key := canonKey(category, noun)
+	if !slices.Contains(c[key], value) {
+		c[key] = append(c[key], value)
+	}
+}
+
+// contradicts returns the values of the story bible a descriptor differs from; none when
+// the bible does not describe the noun or agrees.
+func (c canon) contradicts(d descriptor) []string {
+	values := c[canonKey(d.category, d.noun)]
+	if len(values) == 0 || slices.Contains(values, d.value) {
+		return nil
+	}
+	return values
+}
+
+func (c canon) hasNoun(noun string) bool {
+	for key := range c {
+		if strings.HasSuffix(key, "/"+noun) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCharacterFeature(noun string) bool {
+	return slices.Contains(characterFeatures, noun)
+}
+
+// characterCanon collects the feature colors of a character: from attributes whose key
+// names a feature ("eye_color": "green") and from its descriptions.
+func characterCanon(character *domain.Character) canon {
+	c := canon{}
+	for key, value := range character.Attributes {
+		text, ok := value.(string)
+		if !ok {
+			continue
+		}
+		for _, feature := range characterFeatures {
+			if !strings.Contains(strings.ToLower(key), feature) {
+				continue
+			}
+			for _, w := range splitWords([]rune(text)) {
+				if d, ok := descriptorWords[w.lower]; ok && d.category == descriptorColor {
+					c.add(descriptorColor, feature, d.value)
+				}
+			}
+		}
+	}
+	for _, text := range []string{character.PhysicalDescription, character.Description} {
+		for _, d := range findDescriptors(splitWords([]rune(text)), isCharacterFeature) {
+			if d.category == descriptorColor {
+				c.add(d.category, d.noun, d.value)
+			}
+		}
+	}
+	return c
+}
+
+// placeCanon collects the colors and materials a place's descriptions give to things.
+func placeCanon(place *domain.Place) canon {
+	c := canon{}
+	for _, text := range []string{place.Description, place.LocationDetails, place.Atmosphere} {
+		words := splitWords([]rune(text))
+		for _, d := range findDescriptors(words, func(string) bool { return true }) {
+			c.add(d.category, d.noun, d.value)
+		}
+	}
+	return c
+}
+
+// checkConsistency runs the rule-based checks of a chapter.
+func checkConsistency(bible *storyBible) []*domain.ConsistencyFinding {
+	runes := []rune(bible.chapter.Content)
+	words := splitWords(runes)
+	mentions := FindCharacterMentions(bible.characters, bible.chapter.Content)
+
+	var findings []*domain.ConsistencyFinding
+	findings = append(findings, checkCharacterDescriptions(bible, runes, words, mentions)...)
+	findings = append(findings, checkPlaceDescriptions(bible, runes, words)...)
+	findings = append(findings, checkWorldRules(bible, runes)...)
+	findings = append(findings, checkDeaths(bible, runes, mentions)...)
+	findings = append(findings, checkTimelineOrder(bible)...)
+	return findings
+}
+
+// checkCharacterDescriptions reports feature colors that differ from the story bible
+// ("blue eyes" for a character with green eyes).
+func checkCharacterDescriptions(
+	bible *storyBible,
+	runes []rune,
+	words []textWord,
+	mentions []domain.CharacterMention,
+) []*domain.ConsistencyFinding {
+	characters := make(map[uuid.UUID]*domain.Character, len(bible.characters))
+	canons := make(map[uuid.UUID]canon, len(bible.characters))
+	for _, character := range bible.characters {
+		characters[character.ID] = character
+		canons[character.ID] = characterCanon(character)
+	}
+
+	// The characters each sentence names
+	named := map[int][]uuid.UUID{}
+	for _, mention := range mentions {
+		s := sentenceAt(words, mention.Start)
+		if !slices.Contains(named[s], mention.CharacterID) {
+			named[s] = append(named[s], mention.CharacterID)
+		}
+	}
+
+	// The subject of a sentence is the only character it names or, when it names none,
+	// the subject of the previous sentence of its paragraph
+	subjects := map[int]uuid.UUID{}
+	subject, sentence, paragraph := uuid.Nil, -1, -1
+	for _, w := range words {
+		if w.sentence == sentence {
+			continue
+		}
+		if w.paragraph != paragraph {
+			subject = uuid.Nil
+		}
+		sentence, paragraph = w.sentence, w.paragraph
+		switch ids := named[sentence]; len(ids) {
+		case 0:
+		case 1:
+			subject = ids[0]
+		default:
+			subject = uuid.Nil
+		}
+		subjects[sentence] = subject
+	}
+
+	var findings []*domain.ConsistencyFinding
+	for _, d := range findDescriptors(words, isCharacterFeature) {
+		id := subjects[sentenceAt(words, d.start)]
+		if id == uuid.Nil {
+			continue
+		}
+		expected := canons[id].contradicts(d)
+		if expected == nil {
+			continue
+		}
+		character := characters[id]
+		findings = append(findings, ruleFinding(
+			domain.ConsistencyCharacterDescription, domain.ConsistencySeverityWarning, runes, d.start, d.end,
+			fmt.Sprintf("%s has %s %s in the story bible, not %s.", character.Name,
+				strings.Join(expected, " or "), pluralFeature(d.noun), d.value),
+			"character", character.ID, d.noun+"/"+d.value,
+		))
+	}
+	return findings
+}
+
+func pluralFeature(noun string) string {
+	if noun == "eye" {
+		return "eyes"
+	}
+	return noun
+}
+
+// checkPlaceDescriptions reports colors and materials that differ from a place's
+// description, in sentences naming that place alone.
+func checkPlaceDescriptions(bible *storyBible, runes []rune, words []textWord) []*domain.ConsistencyFinding {
+	// Places are matched by name like characters are
+	named := make([]*domain.Character, 0, len(bible.places))
+	places := make(map[uuid.UUID]*domain.Place, len(bible.places))
+	canons := make(map[uuid.UUID]canon, len(bible.places))
+	for _, place := range bible.places {
+		named = append(named, &domain.Character{ID: place.ID, Name: place.Name})
+		places[place.ID] = place
+		canons[place.ID] = placeCanon(place)
+	}
+
+	sentencePlaces := map[int][]uuid.UUID{}
+	for _, mention := range FindCharacterMentions(named, bible.chapter.Content) {
+		s := sentenceAt(words, mention.Start)
+		if !slices.Contains(sentencePlaces[s], mention.CharacterID) {
+			sentencePlaces[s] = append(sentencePlaces[s], mention.CharacterID)
+		}
+	}
+
+	isNoun := func(noun string) bool {
+		return slices.ContainsFunc(bible.places, func(place *domain.Place) bool { return canons[place.ID].hasNoun(noun) })
+	}
+	var findings []*domain.ConsistencyFinding
+	for _, d := range findDescriptors(words, isNoun) {
+		ids := sentencePlaces[sentenceAt(words, d.start)]
+		if len(ids) != 1 {
+			continue
+		}
+		expected := canons[ids[0]].contradicts(d)
+		if expected == nil {
+			continue
+		}
+		place := places[ids[0]]
+		findings = append(findings, ruleFinding(
+			domain.ConsistencyPlaceDescription, domain.ConsistencySeverityWarning, runes, d.start, d.end,
+			fmt.Sprintf("The %s of %s is %s in the story bible, not %s.", d.noun, place.Name,
+				strings.Join(expected, " or "), d.value),
+			"place", place.ID, d.noun+"/"+d.value,
+		))
+	}
+	return findings
+}
+
+// checkWorldRules reports what the "Forbidden:" lines of world rule and magic system
+// notes rule out.
+func checkWorldRules(bible *storyBible, runes []rune) []*domain.ConsistencyFinding {
+	lower := []rune(strings.ToLower(string(runes)))
+	if len(lower) != len(runes) {
+		lower = runes
+	}
+
+	var findings []*domain.ConsistencyFinding
+	for _, note := range bible.rules {
+		title := cmp.Or(note.Title, "Untitled note")
+		for _, line := range worldRuleLine.FindAllStringSubmatch(note.Content, -1) {
+			for _, phrase := range strings.FieldsFunc(line[1], func(r rune) bool { return r == ',' || r == ';' }) {
+				phrase = strings.Trim(strings.TrimSpace(phrase), ".")
+				term := []rune(strings.ToLower(phrase))
+				if len(term) < 3 {
+					continue
+				}
+				for i := 0; i+len(term) <= len(lower); i++ {
+					if !runesEqual(lower[i:i+len(term)], term) || !isWordBoundary(lower, i, i+len(term)) {
+						continue
+					}
+					findings = append(findings, ruleFinding(
+						domain.ConsistencyWorldRule, domain.ConsistencySeverityWarning, runes, i, i+len(term),
+						fmt.Sprintf("%q is ruled out by %q: %s", phrase, title, strings.TrimSpace(line[0])),
+						"note", note.ID, strings.ToLower(phrase),
+					))
+					i += len(term) - 1
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// characterDeath is the timeline event in which a character dies.
+type characterDeath struct {
+	character *domain.Character
+	event     *domain.TimelineEvent
+}
+
+// deaths finds the death of each character in the timeline: the first event linked to
+// the character that names them next to a death ("Anna dies", "the murder of Anna",
+// "Anna's funeral"), or that mentions a death and involves no other character.
+func deaths(bible *storyBible) map[uuid.UUID]*characterDeath {
+	isCharacter := make(map[uuid.UUID]bool, len(bible.characters))
+	for _, character := range bible.characters {
+		isCharacter[character.ID] = true
+	}
+
+	found := map[uuid.UUID]*characterDeath{}
+	for _, character := range bible.characters {
+		names := []string{regexp.QuoteMeta(character.Name)}
+		for _, alias := range character.Aliases {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				names = append(names, regexp.QuoteMeta(alias))
+			}
+		}
+		name := "(?:" + strings.Join(names, "|") + ")"
+		dies := regexp.MustCompile(`(?i)\b(?:(?:kills|killed|murders|murdered|slays|slew|executes|executed|(?:death|funeral|murder|execution) of)\s+` +
+			name + `|` + name + `\s+(?:dies|died|passes away|passed away|(?:is|was) (?:killed|murdered|slain|executed|dead))|` +
+			name + `(?:'s|’s)\s+(?:death|funeral|execution|murder))\b`)
+
+		for _, event := range bible.events {
+			linked, others := false, 0
+			for _, link := range event.Links {
+				switch {
+				case link.EntityID == character.ID:
+					linked = true
+				case isCharacter[link.EntityID]:
+					others++
+				}
+			}
+			text := event.Title + "\n" + event.Description
+			if linked && (dies.MatchString(text) || others == 0 && deathWords.MatchString(text)) {
+				found[character.ID] = &characterDeath{character: character, event: event}
+				break
+			}
+		}
+	}
+	return found
+}
+
+// eventChapters returns the chapters an event is linked to.
+func (b *storyBible) eventChapters(event *domain.TimelineEvent) []*domain.Chapter {
+	var chapters []*domain.Chapter
+	for _, link := range event.Links {
+		if chapter, ok := b.chapters[link.EntityID]; ok {
+			chapters = append(chapters, chapter)
+		}
+	}
+	return chapters
+}
+
+// chapterEvents returns the events linked to the checked chapter.
+func (b *storyBible) chapterEvents() []*domain.TimelineEvent {
+	var events []*domain.TimelineEvent
+	for _, event := range b.events {
+		if slices.ContainsFunc(event.Links, func(link domain.TimelineEventLink) bool { return link.EntityID == b.chapterID }) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// checkDeaths reports characters who appear in a chapter set after their death: the
+// chapter comes after the one their death is linked to, or its timeline events come
+// after the death. Chapters whose events all precede the death (flashbacks) are fine.
+func checkDeaths(bible *storyBible, runes []rune, mentions []domain.CharacterMention) []*domain.ConsistencyFinding {
+	died := deaths(bible)
+	events := bible.chapterEvents()
+
+	var findings []*domain.ConsistencyFinding
+	reported := map[uuid.UUID]bool{}
+	for _, mention := range mentions {
+		death := died[mention.CharacterID]
+		if death == nil || reported[mention.CharacterID] {
+			continue
+		}
+		reported[mention.CharacterID] = true
+
+		before := func(e *domain.TimelineEvent) bool { return e.EventOrder < death.event.EventOrder }
+		if slices.Contains(events, death.event) || len(events) > 0 && !slices.ContainsFunc(events, func(e *domain.TimelineEvent) bool { return !before(e) }) {
+			continue // The death is told here, or the chapter is set before it
+		}
+
+		var where string
+		for _, chapter := range bible.eventChapters(death.event) {
+			if chapter.OrderIndex < bible.chapter.OrderIndex {
+				where = fmt.Sprintf("in chapter %q", chapter.Title)
+				break
+			}
+		}
+		if where == "" {
+			if len(events) == 0 {
+				continue // Neither the chapters nor the timeline place the chapter after the death
+			}
+			where = "earlier in the timeline"
+		}
+		findings = append(findings, ruleFinding(
+			domain.ConsistencyCharacterDeath, domain.ConsistencySeverityWarning, runes, mention.Start, mention.End,
+			fmt.Sprintf("%s appears here but died %s (%q). Check that this is a memory or a mention.",
+				death.character.Name, where, death.event.Title),
+			"character", mention.CharacterID, death.event.ID.String(),
+		))
+	}
+	return findings
+}
+
+// checkTimelineOrder reports the timeline events of a chapter that involve a character
+// after the character's death.
+func checkTimelineOrder(bible *storyBible) []*domain.ConsistencyFinding {
+	died := deaths(bible)
+
+	var findings []*domain.ConsistencyFinding
+	for _, event := range bible.chapterEvents() {
+		for _, link := range event.Links {
+			death := died[link.EntityID]
+			if death == nil || death.event == event || death.event.EventOrder >= event.EventOrder {
+				continue
+			}
+			findings = append(findings, &domain.ConsistencyFinding{
+				Kind:     domain.ConsistencyTimelineOrder,
+				Severity: domain.ConsistencySeverityError,
+				Source:   domain.ConsistencySourceRules,
+				Message: fmt.Sprintf("%s takes part in %q, which the timeline places after their death (%q).",
+					death.character.Name, event.Title, death.event.Title),
+				EntityType:  "timeline_event",
+				EntityID:    &event.ID,
+				Fingerprint: findingFingerprint(domain.ConsistencyTimelineOrder, event.ID, death.event.ID.String()),
+			})
+		}
+	}
+	return findings
+}
+
+// ruleFinding is a finding of the rules pass on the rune range [start, end).
+func ruleFinding(
+	kind domain.ConsistencyFindingKind,
+	severity domain.ConsistencySeverity,
+	runes []rune,
+	start, end int,
+	message, entityType string,
+	entityID uuid.UUID,
+	key string,
+) *domain.ConsistencyFinding {
+	return &domain.ConsistencyFinding{
+		Kind:        kind,
+		Severity:    severity,
+		Source:      domain.ConsistencySourceRules,
+		Range:       &domain.TextRange{Start: start, End: end},
+		Quote:       string(runes[start:end]),
+		Message:     message,
+		EntityType:  entityType,
+		EntityID:    &entityID,
+		Fingerprint: findingFingerprint(kind, entityID, key),
+	}
+}
+
+// findingFingerprint identifies an issue across checks by what was found, not where.
+func findingFingerprint(kind domain.ConsistencyFindingKind, entityID uuid.UUID, key string) string {
+	return contentHash(string(kind) + "|" + entityID.String() + "|" + key)
+}
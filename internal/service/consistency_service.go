@@ -0,0 +1,519 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/llm"
+	"github.com/khaled2049/server/internal/mq"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+var (
+	// ErrInvalidConsistencyCheck is returned for malformed consistency check input.
+	ErrInvalidConsistencyCheck = errors.New("invalid consistency check")
+	// ErrConsistencyCheckNotClaimable is returned when another worker runs the check or
+	// it already ran; the job is a duplicate and can be dropped.
+	ErrConsistencyCheckNotClaimable = errors.New("consistency check is not claimable")
+)
+
+const (
+	// maxBibleRunes bounds the story bible sent to the model with a chapter.
+	maxBibleRunes = 24000
+	// maxResolutionNoteLength bounds the note of a resolved or dismissed finding, in runes.
+	maxResolutionNoteLength = 1000
+)
+
+const consistencyInstructions = `You are the continuity editor of a novel. Compare the chapter with the story bible ` +
+	`and report only where the chapter contradicts it: how characters and places look, characters who are dead, ` +
+	`the rules of the world and its magic, and the order of events. Answer with a JSON array and nothing else. ` +
+	`Each item is {"kind": "character_description", "character_death", "place_description", "world_rule", ` +
+	`"timeline_order" or "other", "severity": "info", "warning" or "error", "quote": the contradicting text, ` +
+	`copied exactly from the chapter, "message": one sentence explaining the contradiction}. ` +
+	`Answer [] when the chapter is consistent.`
+
+// ConsistencyOptions tunes the consistency checker.
+type ConsistencyOptions struct {
+	// LLMEnabled lets checks request an LLM pass after the rule-based one.
+	LLMEnabled      bool
+	MaxOutputTokens int
+	// Timeout bounds the LLM pass of a check.
+	Timeout time.Duration
+	// ClaimTTL is how long a running check stays claimed before the sweep hands it to
+	// another worker. Defaults to twice Timeout, and at least a minute.
+	ClaimTTL time.Duration
+	// Prices (USD per 1,000 tokens) of the estimated cost in the usage ledger
+	PromptCostPer1K     float64
+	CompletionCostPer1K float64
+}
+
+// ConsistencyService checks chapters against their novel's story bible: character
+// descriptions and attributes, place descriptions, world rule and magic system notes
+// and the timeline. Checks are requested through the API and run by the AI worker: a
+// rule-based pass (see consistency_rules.go) and, when requested, an LLM pass whose
+// tokens are recorded in the usage ledger. Each check replaces the open findings of its
+// chapter; resolved findings are kept and dismissed ones are not reported again.
+type ConsistencyService struct {
+	transactor      repository.Transactor
+	consistencyRepo repository.ConsistencyRepository
+	outboxRepo      repository.OutboxRepository
+	chapterRepo     repository.ChapterRepository
+	characterRepo   repository.CharacterRepository
+	placeRepo       repository.PlaceRepository
+	noteRepo        repository.NoteRepository
+	timelineRepo    repository.TimelineEventRepository
+	usageRepo       repository.AIUsageRepository
+	usage           *AIUsageService
+	client          llm.Client // Nil outside the AI worker
+	access          *AccessService
+	options         ConsistencyOptions
+}
+
+func NewConsistencyService(
+	transactor repository.Transactor,
+	consistencyRepo repository.ConsistencyRepository,
+	outboxRepo repository.OutboxRepository,
+	chapterRepo repository.ChapterRepository,
+	characterRepo repository.CharacterRepository,
+	placeRepo repository.PlaceRepository,
+	noteRepo repository.NoteRepository,
+	timelineRepo repository.TimelineEventRepository,
+	usageRepo repository.AIUsageRepository,
+	usage *AIUsageService,
+	client llm.Client,
+	access *AccessService,
+	options ConsistencyOptions) *ConsistencyService {
+	if options.ClaimTTL <= 0 {
+		options.ClaimTTL = max(2*options.Timeout, time.Minute)
+	}
+	return &ConsistencyService{
+		transactor:      transactor,
+		consistencyRepo: consistencyRepo,
+		outboxRepo:      outboxRepo,
+		chapterRepo:     chapterRepo,
+		characterRepo:   characterRepo,
+		placeRepo:       placeRepo,
+		noteRepo:        noteRepo,
+		timelineRepo:    timelineRepo,
+		usageRepo:       usageRepo,
+		usage:           usage,
+		client:          client,
+		access:          access,
+		options:         options,
+	}
+}
+
+// RequestCheck queues a consistency check of a chapter the actor can edit. With useLLM,
+// the check also asks the model, and fails with ErrAIQuotaExceeded when the actor or the
+// novel has used up a quota.
+func (s *ConsistencyService) RequestCheck(ctx context.Context, actorID, chapterID uuid.UUID, useLLM bool) (*domain.ConsistencyCheck, error) {
+	if useLLM && !s.options.LLMEnabled {
+		return nil, fmt.Errorf("%w: the LLM pass is disabled on this server", ErrInvalidConsistencyCheck)
+	}
+	novelID, err := s.chapterNovelID(ctx, chapterID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionEditContent); err != nil {
+		return nil, err
+	}
+	if useLLM {
+		if err := s.usage.CheckQuota(ctx, actorID, novelID); err != nil {
+			return nil, err
+		}
+	}
+
+	var check *domain.ConsistencyCheck
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		check, err = s.consistencyRepo.CreateCheck(ctx, &domain.ConsistencyCheck{
+			NovelID:           novelID,
+			ChapterID:         chapterID,
+			RequestedByUserID: actorID,
+			UseLLM:            useLLM,
+		})
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.outboxRepo, domain.OutboxAggregateConsistencyCheck, check.ID,
+			mq.TopicConsistencyCheckRequested, mq.ConsistencyCheckJob{CheckID: check.ID})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// chapterNovelID returns the novel of a chapter.
+func (s *ConsistencyService) chapterNovelID(ctx context.Context, chapterID uuid.UUID) (uuid.UUID, error) {
+	chapter, err := s.chapterRepo.GetByID(ctx, chapterID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	novelID, err := uuid.Parse(chapter.NovelID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("chapter has invalid novel ID %q: %w", chapter.NovelID, err)
+	}
+	return novelID, nil
+}
+
+// GetCheck returns a check of a novel the actor can view.
+func (s *ConsistencyService) GetCheck(ctx context.Context, actorID, checkID uuid.UUID) (*domain.ConsistencyCheck, error) {
+	check, err := s.consistencyRepo.GetCheck(ctx, checkID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.Require(ctx, check.NovelID, actorID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// ListFindings returns the findings of a chapter the actor can view, optionally of one
+// status, in text order.
+func (s *ConsistencyService) ListFindings(
+	ctx context.Context,
+	actorID, chapterID uuid.UUID,
+	status domain.ConsistencyFindingStatus,
+) ([]*domain.ConsistencyFinding, error) {
+	if status != "" && !status.Valid() {
+		return nil, fmt.Errorf("%w: unsupported status %q", ErrInvalidConsistencyCheck, status)
+	}
+	novelID, err := s.chapterNovelID(ctx, chapterID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+	return s.consistencyRepo.ListFindings(ctx, repository.ConsistencyFindingFilter{ChapterID: chapterID, Status: status})
+}
+
+// SetFindingStatus resolves, dismisses or reopens a finding of a chapter the actor can
+// edit, with an optional note.
+func (s *ConsistencyService) SetFindingStatus(
+	ctx context.Context,
+	actorID, findingID uuid.UUID,
+	status domain.ConsistencyFindingStatus,
+	note string,
+) (*domain.ConsistencyFinding, error) {
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > maxResolutionNoteLength {
+		return nil, fmt.Errorf("%w: note must be at most %d characters", ErrInvalidConsistencyCheck, maxResolutionNoteLength)
+	}
+	finding, err := s.consistencyRepo.GetFinding(ctx, findingID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.access.Require(ctx, finding.NovelID, actorID, domain.PermissionEditContent); err != nil {
+		return nil, err
+	}
+
+	finding.Status, finding.ResolutionNote, finding.ResolvedByUserID = status, note, &actorID
+	if status == domain.ConsistencyFindingOpen {
+		finding.ResolutionNote, finding.ResolvedByUserID = "", nil
+	}
+	return s.consistencyRepo.UpdateFindingStatus(ctx, finding)
+}
+
+// RunCheck claims a check and runs it against the current content of its chapter. A
+// failed LLM pass does not fail the check; its rule-based findings are stored and the
+// reason is recorded in the check's error. Other failures mark the check failed. Either
+// way the job is done and nil is returned, unless ctx is cancelled (the check is
+// reclaimed by the sweep) or the check could not be claimed.
+func (s *ConsistencyService) RunCheck(ctx context.Context, checkID uuid.UUID) error {
+	check, err := s.consistencyRepo.GetCheck(ctx, checkID)
+	if err != nil {
+		return err
+	}
+	chapter, err := s.chapterRepo.GetByID(ctx, check.ChapterID)
+	if err != nil {
+		return err
+	}
+
+	check, err = s.consistencyRepo.ClaimCheck(ctx, checkID, contentHash(chapter.Content), time.Now().Add(-s.options.ClaimTTL))
+	if errors.Is(err, repository.ErrConsistencyCheckStatusChanged) {
+		return ErrConsistencyCheckNotClaimable
+	}
+	if err != nil {
+		return err
+	}
+	startedAt := *check.StartedAt
+
+	findings, err := s.findings(ctx, check, chapter)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("Error: consistency check %s failed: %v", check.ID, err)
+		check.Status, check.Error = domain.ConsistencyCheckFailed, err.Error()
+		_, err = s.consistencyRepo.FinishCheck(ctx, check, startedAt)
+		return s.finishError(err)
+	}
+
+	return s.finishError(s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		stored, err := s.consistencyRepo.ReplaceOpenFindings(ctx, check, findings)
+		if err != nil {
+			return err
+		}
+		check.Status, check.FindingsCount = domain.ConsistencyCheckCompleted, len(stored)
+		_, err = s.consistencyRepo.FinishCheck(ctx, check, startedAt)
+		return err
+	}))
+}
+
+// finishError maps a lost claim (another worker reclaimed the check) to a duplicate.
+func (s *ConsistencyService) finishError(err error) error {
+	if errors.Is(err, repository.ErrConsistencyCheckStatusChanged) {
+		return ErrConsistencyCheckNotClaimable
+	}
+	return err
+}
+
+// ClaimableChecks returns checks that have no live job: pending ones whose message was
+// lost and running ones whose worker stopped.
+func (s *ConsistencyService) ClaimableChecks(ctx context.Context, limit int) ([]uuid.UUID, error) {
+	now := time.Now()
+	return s.consistencyRepo.ListClaimableChecks(ctx, now.Add(-pendingGrace), now.Add(-s.options.ClaimTTL), limit)
+}
+
+// findings runs the passes of a claimed check. Why the LLM pass was skipped or failed
+// is recorded in check.Error.
+func (s *ConsistencyService) findings(
+	ctx context.Context,
+	check *domain.ConsistencyCheck,
+	chapter *domain.Chapter,
+) ([]*domain.ConsistencyFinding, error) {
+	bible, err := s.loadBible(ctx, check, chapter)
+	if err != nil {
+		return nil, err
+	}
+	findings := checkConsistency(bible)
+	if !check.UseLLM {
+		return findings, nil
+	}
+
+	if s.client == nil || !s.options.LLMEnabled {
+		check.Error = "LLM pass skipped: the LLM pass is disabled"
+		return findings, nil
+	}
+	if s.usage != nil {
+		err := s.usage.CheckQuota(ctx, check.RequestedByUserID, check.NovelID)
+		if errors.Is(err, ErrAIQuotaExceeded) {
+			check.Error = "LLM pass skipped: " + err.Error()
+			return findings, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	suggested, err := s.llmFindings(ctx, check, bible)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		log.Printf("Warning: LLM pass of consistency check %s failed: %v", check.ID, err)
+		check.Error = "LLM pass failed: " + err.Error()
+		return findings, nil
+	}
+	for _, f := range suggested {
+		// The rules already reported it
+		duplicate := f.Range != nil && slices.ContainsFunc(findings, func(r *domain.ConsistencyFinding) bool {
+			return r.Kind == f.Kind && r.Range != nil && r.Range.Start < f.Range.End && f.Range.Start < r.Range.End
+		})
+		if !duplicate {
+			findings = append(findings, f)
+		}
+	}
+	return findings, nil
+}
+
+// loadBible loads the story bible of the check's novel.
+func (s *ConsistencyService) loadBible(
+	ctx context.Context,
+	check *domain.ConsistencyCheck,
+	chapter *domain.Chapter,
+) (*storyBible, error) {
+	bible := &storyBible{chapter: chapter, chapterID: check.ChapterID, chapters: map[uuid.UUID]*domain.Chapter{}}
+
+	chapters, err := s.chapterRepo.ListByNovelID(ctx, check.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapters: %w", err)
+	}
+	for _, c := range chapters {
+		if id, err := uuid.Parse(c.ID); err == nil {
+			bible.chapters[id] = c
+		}
+	}
+
+	if bible.characters, err = s.characterRepo.ListByNovelID(ctx, check.NovelID); err != nil {
+		return nil, fmt.Errorf("failed to load characters: %w", err)
+	}
+	if bible.places, err = s.placeRepo.ListByNovelID(ctx, check.NovelID); err != nil {
+		return nil, fmt.Errorf("failed to load places: %w", err)
+	}
+
+	notes, err := s.noteRepo.ListByNovelID(ctx, check.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notes: %w", err)
+	}
+	for _, note := range notes {
+		if note.Type == domain.NoteTypeWorldRule || note.Type == domain.NoteTypeMagicSystem {
+			bible.rules = append(bible.rules, note)
+		}
+	}
+
+	if bible.events, err = s.timelineRepo.ListByNovelID(ctx, check.NovelID); err != nil {
+		return nil, fmt.Errorf("failed to load timeline: %w", err)
+	}
+	slices.SortStableFunc(bible.events, func(a, b *domain.TimelineEvent) int { return cmp.Compare(a.EventOrder, b.EventOrder) })
+	return bible, nil
+}
+
+// llmFinding is an item of the model's answer.
+type llmFinding struct {
+	Kind     string `json:"kind"`
+	Severity string `json:"severity"`
+	Quote    string `json:"quote"`
+	Message  string `json:"message"`
+}
+
+// llmFindings asks the model for the contradictions between the chapter and the story
+// bible, and records the tokens it used.
+func (s *ConsistencyService) llmFindings(
+	ctx context.Context,
+	check *domain.ConsistencyCheck,
+	bible *storyBible,
+) ([]*domain.ConsistencyFinding, error) {
+	if s.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.options.Timeout)
+		defer cancel()
+	}
+
+	resp, err := s.client.Chat(ctx, llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: consistencyInstructions},
+			{Role: llm.RoleUser, Content: fmt.Sprintf("Story bible:\n\n%s\n\nChapter %q:\n\n%s",
+				renderBible(bible), bible.chapter.Title, bible.chapter.Content)},
+		},
+		MaxTokens: s.options.MaxOutputTokens,
+	})
+	if err != nil {
+		return nil, err
+	}
+	entry := newUsageEntry(check.RequestedByUserID, check.NovelID, resp, s.options.PromptCostPer1K, s.options.CompletionCostPer1K)
+	if _, err := s.usageRepo.Record(ctx, entry); err != nil {
+		log.Printf("Warning: failed to record usage of consistency check %s: %v", check.ID, err)
+	}
+
+	answer := resp.Text
+	start, end := strings.Index(answer, "["), strings.LastIndex(answer, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("the model did not answer with a JSON array")
+	}
+	var items []llmFinding
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &items); err != nil {
+		return nil, fmt.Errorf("failed to decode the model's findings: %w", err)
+	}
+
+	runes := []rune(bible.chapter.Content)
+	var findings []*domain.ConsistencyFinding
+	for _, item := range items {
+		message, quote := strings.TrimSpace(item.Message), strings.TrimSpace(item.Quote)
+		if message == "" {
+			continue
+		}
+		kind := domain.ConsistencyFindingKind(item.Kind)
+		if !kind.Valid() {
+			kind = domain.ConsistencyOther
+		}
+		severity := domain.ConsistencySeverity(item.Severity)
+		if !severity.Valid() {
+			severity = domain.ConsistencySeverityWarning
+		}
+		finding := &domain.ConsistencyFinding{
+			Kind:        kind,
+			Severity:    severity,
+			Source:      domain.ConsistencySourceLLM,
+			Quote:       quote,
+			Message:     message,
+			Fingerprint: findingFingerprint(kind, uuid.Nil, strings.ToLower(cmp.Or(quote, message))),
+		}
+		if r := locateQuote(runes, quote); r != nil {
+			finding.Range, finding.Quote = r, string(runes[r.Start:r.End])
+		}
+		findings = append(findings, finding)
+	}
+	return findings, nil
+}
+
+// locateQuote finds the first occurrence of quote in text, ignoring case; nil when the
+// quote is empty or not found.
+func locateQuote(text []rune, quote string) *domain.TextRange {
+	term := []rune(strings.ToLower(quote))
+	lower := []rune(strings.ToLower(string(text)))
+	if len(term) == 0 || len(lower) != len(text) {
+		return nil
+	}
+	for i := 0; i+len(term) <= len(lower); i++ {
+		if runesEqual(lower[i:i+len(term)], term) {
+			return &domain.TextRange{Start: i, End: i + len(term)}
+		}
+	}
+	return nil
+}
+
+// renderBible renders the story bible for the model, cut at maxBibleRunes.
+func renderBible(bible *storyBible) string {
+	var sections []string
+	for _, character := range bible.characters {
+		text := characterCandidate(character).text()
+		keys := make([]string, 0, len(character.Attributes))
+		for key := range character.Attributes {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			text += fmt.Sprintf("\n%s: %v", key, character.Attributes[key])
+		}
+		sections = append(sections, text)
+	}
+	for _, place := range bible.places {
+		sections = append(sections, placeCandidate(place).text())
+	}
+	for _, note := range bible.rules {
+		sections = append(sections, noteCandidate(note).text())
+	}
+	if len(bible.events) > 0 {
+		lines := []string{"Timeline, in order:"}
+		for i, event := range bible.events {
+			line := fmt.Sprintf("%d. %s", i+1, event.Title)
+			for _, chapter := range bible.eventChapters(event) {
+				line += fmt.Sprintf(" (chapter %q)", chapter.Title)
+			}
+			if event.Description != "" {
+				line += ": " + event.Description
+			}
+			lines = append(lines, line)
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	text := []rune(strings.Join(sections, "\n\n"))
+	if len(text) > maxBibleRunes {
+		text = text[:maxBibleRunes]
+	}
+	return string(text)
+}
@@ -0,0 +1,686 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/llm"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// Truncation strategies accepted in ContextOptions.Truncation.
+const (
+	// ContextTruncate cuts the first section that does not fit down to the remaining budget.
+	ContextTruncate = "truncate"
+	// ContextDrop leaves out sections that do not fit; smaller, lower ranked ones may still.
+	ContextDrop = "drop"
+)
+
+// Kinds of context sections.
+const (
+	ContextSectionNovel           = "novel"
+	ContextSectionChapter         = "chapter"
+	ContextSectionPreviousChapter = "previous_chapter"
+	ContextSectionStorySoFar      = "story_so_far"
+	ContextSectionCharacter       = "character"
+	ContextSectionPlace           = "place"
+	ContextSectionNote            = "note"
+	ContextSectionPassage         = "passage"
+	ContextSectionTimelineEvent   = "timeline_event"
+)
+
+const (
+	// chapterShare and previousChapterShare cap the chapter text in the budget, so the
+	// story so far leaves room for the world it happens in; entityShare keeps a single
+	// long character sheet or note from crowding out the rest.
+	chapterShare         = 0.6
+	previousChapterShare = 0.2
+	storySoFarShare      = 0.15
+	entityShare          = 0.1
+	// minSectionTokens is the smallest remainder worth a truncated section.
+	minSectionTokens = 32
+	// maxMentionScore caps the score a section earns from being mentioned.
+	maxMentionScore = 40
+	// mentionWindowTokens bounds the chapter text searched for mentions.
+	mentionWindowTokens = 2000
+	// retrievalQueryTokens bounds the chapter text used as the retrieval query when the
+	// suggestion has no selected text or instructions.
+	retrievalQueryTokens = 200
+)
+
+// Relevance scores of context sections. A section scores its kind's base, the boost of
+// its kind for the suggestion type and the bonuses below.
+const (
+	scoreExplicit      = 100 // The suggestion names it as context
+	scoreLinked        = 30  // Linked to the context chapter, character or place
+	scoreMention       = 10  // Per mention in the selected text, instructions or chapter text
+	scoreLinkedMention = 10  // A timeline event linked to a mentioned character or place
+	scoreRetrieved     = 50  // Scaled by the similarity of a retrieved passage or note
+)
+
+var contextBaseScores = map[string]int{
+	ContextSectionChapter:         50,
+	ContextSectionPreviousChapter: 20,
+	ContextSectionStorySoFar:      20,
+	ContextSectionCharacter:       10,
+	ContextSectionPlace:           10,
+	ContextSectionNote:            5,
+	ContextSectionPassage:         15,
+	ContextSectionTimelineEvent:   5,
+}
+
+// contextTypeBoosts favors what each suggestion type relies on most.
+var contextTypeBoosts = map[domain.AISuggestionType]map[string]int{
+	domain.AISuggestionContinuation:  {ContextSectionChapter: 40, ContextSectionPreviousChapter: 20, ContextSectionStorySoFar: 30},
+	domain.AISuggestionDialogue:      {ContextSectionChapter: 30, ContextSectionCharacter: 15},
+	domain.AISuggestionDescription:   {ContextSectionChapter: 30, ContextSectionPlace: 15},
+	domain.AISuggestionCharacterIdea: {ContextSectionCharacter: 10, ContextSectionNote: 10},
+	domain.AISuggestionPlaceIdea:     {ContextSectionPlace: 10, ContextSectionNote: 10},
+	domain.AISuggestionPlotPoint:     {ContextSectionTimelineEvent: 20, ContextSectionNote: 10, ContextSectionPreviousChapter: 10, ContextSectionPassage: 10, ContextSectionStorySoFar: 20},
+	domain.AISuggestionSummary:       {ContextSectionChapter: 50},
+	domain.AISuggestionRewrite:       {ContextSectionChapter: 20},
+	domain.AISuggestionBrainstorm:    {ContextSectionNote: 15, ContextSectionTimelineEvent: 10, ContextSectionPassage: 10, ContextSectionStorySoFar: 10},
+}
+
+// contextSectionOrder is the reading order of the assembled context: the world first,
+// then the story leading up to the task.
+var contextSectionOrder = []string{
+	ContextSectionNovel, ContextSectionTimelineEvent, ContextSectionCharacter, ContextSectionPlace,
+	ContextSectionNote, ContextSectionPassage, ContextSectionStorySoFar, ContextSectionPreviousChapter, ContextSectionChapter,
+}
+
+// ContextOptions tunes context assembly.
+type ContextOptions struct {
+	Truncation string // ContextTruncate (default) or ContextDrop
+	Passages   int    // Passages of other chapters retrieved by semantic search; 0 for none
+	// ChaptersPerAct groups chapters into acts as SummaryOptions does; 5 when 0
+	ChaptersPerAct int
+}
+
+// ContextSection describes a piece of the assembled context.
+type ContextSection struct {
+	Kind      string `json:"kind"`
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Score     int    `json:"score"`
+	Tokens    int    `json:"tokens"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// AssembledContext is the context of a suggestion packed into a token budget.
+type AssembledContext struct {
+	Text     string
+	Tokens   int
+	Budget   int
+	Sections []ContextSection // Included sections, most relevant first
+	Omitted  int              // Candidate sections left out for lack of budget
+}
+
+// Metadata summarizes the assembly for a suggestion's generation metadata.
+func (a *AssembledContext) Metadata() map[string]any {
+	return map[string]any{
+		"budget":   a.Budget,
+		"tokens":   a.Tokens,
+		"sections": a.Sections,
+		"omitted":  a.Omitted,
+	}
+}
+
+// ContextAssembler gathers what a model needs to know about a novel for a suggestion:
+// the chapter text, the chapter before it, the story so far from the summaries of the
+// earlier chapters, characters, places, shared notes, the timeline and, with semantic
+// search, related passages of other chapters. Candidates are ranked by relevance to the
+// suggestion and packed into a token budget.
+type ContextAssembler struct {
+	novelRepo     repository.NovelRepository
+	chapterRepo   repository.ChapterRepository
+	characterRepo repository.CharacterRepository
+	placeRepo     repository.PlaceRepository
+	noteRepo      repository.NoteRepository
+	timelineRepo  repository.TimelineEventRepository
+	summaryRepo   repository.SummaryRepository // May be nil to go without the story so far
+	embeddings    *EmbeddingService            // May be nil to go without semantic search
+	options       ContextOptions
+}
+
+func NewContextAssembler(
+	novelRepo repository.NovelRepository,
+	chapterRepo repository.ChapterRepository,
+	characterRepo repository.CharacterRepository,
+	placeRepo repository.PlaceRepository,
+	noteRepo repository.NoteRepository,
+	timelineRepo repository.TimelineEventRepository,
+	summaryRepo repository.SummaryRepository,
+	embeddings *EmbeddingService,
+	options ContextOptions) *ContextAssembler {
+	if options.Truncation != ContextDrop {
+		options.Truncation = ContextTruncate
+	}
+	if options.ChaptersPerAct <= 0 {
+		options.ChaptersPerAct = defaultChaptersPerAct
+	}
+	return &ContextAssembler{
+		novelRepo:     novelRepo,
+		chapterRepo:   chapterRepo,
+		characterRepo: characterRepo,
+		placeRepo:     placeRepo,
+		noteRepo:      noteRepo,
+		timelineRepo:  timelineRepo,
+		summaryRepo:   summaryRepo,
+		embeddings:    embeddings,
+		options:       options,
+	}
+}
+
+// contextCandidate is a section before packing.
+type contextCandidate struct {
+	section ContextSection
+	label   string // First line, kept when the body is truncated
+	body    string
+	keepEnd bool    // Truncation keeps the end of the body (story text) instead of the start
+	share   float64 // Largest share of the budget the section may take; 0 for no cap
+	// retrieved reports that semantic search already boosted the section
+	retrieved bool
+}
+
+func (c *contextCandidate) text() string {
+	return c.label + "\n" + c.body
+}
+
+// Assemble builds the context of suggestion within budget tokens. The novel's header
+// is always included; it is all automatic summaries get, as their selected text holds
+// what they summarize.
+func (a *ContextAssembler) Assemble(ctx context.Context, suggestion *domain.AISuggestion, budget int) (*AssembledContext, error) {
+	novel, err := a.novelRepo.GetByID(ctx, suggestion.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load novel context: %w", err)
+	}
+
+	var candidates []*contextCandidate
+	if !suggestion.Automatic {
+		if candidates, err = a.gather(ctx, suggestion); err != nil {
+			return nil, err
+		}
+	}
+
+	boosts := contextTypeBoosts[suggestion.Type]
+	for _, c := range candidates {
+		c.section.Score += contextBaseScores[c.section.Kind] + boosts[c.section.Kind]
+	}
+	slices.SortStableFunc(candidates, func(x, y *contextCandidate) int {
+		return cmp.Compare(y.section.Score, x.section.Score)
+	})
+
+	header := novelHeader(novel)
+	assembled := &AssembledContext{Budget: budget}
+	included := []*contextCandidate{{
+		section: ContextSection{Kind: ContextSectionNovel, ID: novel.ID, Name: novel.Title, Tokens: llm.EstimateTokens(header)},
+		label:   header,
+	}}
+	remaining := budget - included[0].section.Tokens
+
+	for _, c := range candidates {
+		if c.share > 0 {
+			c.fit(int(float64(budget) * c.share))
+		}
+		if c.section.Tokens > remaining {
+			if a.options.Truncation == ContextDrop || remaining < minSectionTokens || !c.fit(remaining) {
+				assembled.Omitted++
+				continue
+			}
+		}
+		included = append(included, c)
+		remaining -= c.section.Tokens
+	}
+
+	for _, c := range included {
+		assembled.Sections = append(assembled.Sections, c.section)
+		assembled.Tokens += c.section.Tokens
+	}
+
+	// Read the world first and the story last, most relevant first within a kind
+	slices.SortStableFunc(included, func(x, y *contextCandidate) int {
+		return cmp.Compare(slices.Index(contextSectionOrder, x.section.Kind), slices.Index(contextSectionOrder, y.section.Kind))
+	})
+	parts := make([]string, 0, len(included))
+	for _, c := range included {
+		if c.body == "" {
+			parts = append(parts, c.label)
+		} else {
+			parts = append(parts, c.text())
+		}
+	}
+	assembled.Text = strings.Join(parts, "\n\n")
+
+	return assembled, nil
+}
+
+// fit truncates the body so the section takes at most maxTokens, and reports whether
+// anything of the body is left.
+func (c *contextCandidate) fit(maxTokens int) bool {
+	if c.section.Tokens <= maxTokens {
+		return true
+	}
+	bodyTokens := maxTokens - llm.EstimateTokens(c.label+"\n")
+	if bodyTokens <= 0 {
+		return false
+	}
+	if c.keepEnd {
+		c.body = "…" + tailTokens(c.body, bodyTokens)
+	} else {
+		c.body = headTokens(c.body, bodyTokens) + "…"
+	}
+	c.section.Tokens = llm.EstimateTokens(c.text())
+	c.section.Truncated = true
+	return true
+}
+
+// gather loads the candidate sections of a suggestion with their relevance bonuses.
+func (a *ContextAssembler) gather(ctx context.Context, suggestion *domain.AISuggestion) ([]*contextCandidate, error) {
+	var candidates []*contextCandidate
+	// focus is what the suggestion is about; mentions in it make entities relevant
+	focus := []string{suggestion.SelectedText, suggestion.PromptInstructions}
+
+	chapters, err := a.chapterRepo.ListByNovelID(ctx, suggestion.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapter context: %w", err)
+	}
+
+	// Without a context chapter the story so far is the latest chapter. Chapters before
+	// the previous one are summarized.
+	var chapter, previous *domain.Chapter
+	summarized := 0
+	if suggestion.ContextChapterID != nil {
+		for i, ch := range chapters {
+			if ch.ID == suggestion.ContextChapterID.String() {
+				chapter = ch
+				if i > 0 {
+					previous, summarized = chapters[i-1], i-1
+				}
+			}
+		}
+	} else if len(chapters) > 0 {
+		previous, summarized = chapters[len(chapters)-1], len(chapters)-1
+	}
+
+	if chapter != nil && chapter.Content != "" {
+		c := storyCandidate(ContextSectionChapter, chapter, fmt.Sprintf("Chapter %q (most recent text):", chapter.Title), chapterShare)
+		c.section.Score += scoreExplicit
+		candidates = append(candidates, c)
+		focus = append(focus, tailTokens(chapter.Content, mentionWindowTokens))
+	}
+	if previous != nil && previous.Content != "" {
+		label := fmt.Sprintf("End of the previous chapter, %q:", previous.Title)
+		if chapter == nil {
+			label = fmt.Sprintf("End of the latest chapter, %q:", previous.Title)
+			focus = append(focus, tailTokens(previous.Content, mentionWindowTokens))
+		}
+		candidates = append(candidates, storyCandidate(ContextSectionPreviousChapter, previous, label, previousChapterShare))
+	}
+	story, err := a.storySoFar(ctx, suggestion.NovelID, chapters[:summarized])
+	if err != nil {
+		return nil, err
+	}
+	if story != nil {
+		candidates = append(candidates, story)
+	}
+
+	mentions := mentionCounter(strings.Join(focus, "\n"))
+	// mentioned characters and places make the timeline events linked to them relevant
+	mentioned := map[uuid.UUID]bool{}
+
+	var chapterCharacters, chapterPlaces []uuid.UUID
+	if chapter != nil {
+		if chapterCharacters, err = a.characterRepo.ListIDsByChapterID(ctx, *suggestion.ContextChapterID); err != nil {
+			return nil, fmt.Errorf("failed to load chapter characters: %w", err)
+		}
+		if chapterPlaces, err = a.placeRepo.ListIDsByChapterID(ctx, *suggestion.ContextChapterID); err != nil {
+			return nil, fmt.Errorf("failed to load chapter places: %w", err)
+		}
+	}
+
+	characters, err := a.characterRepo.ListByNovelID(ctx, suggestion.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load character context: %w", err)
+	}
+	for _, character := range characters {
+		c := characterCandidate(character)
+		c.section.Score += entityScore(character.ID, suggestion.ContextCharacterID, chapterCharacters)
+		n := mentions(append([]string{character.Name}, character.Aliases...)...)
+		c.section.Score += min(n*scoreMention, maxMentionScore)
+		mentioned[character.ID] = n > 0
+		candidates = append(candidates, c)
+	}
+
+	places, err := a.placeRepo.ListByNovelID(ctx, suggestion.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load place context: %w", err)
+	}
+	for _, place := range places {
+		c := placeCandidate(place)
+		c.section.Score += entityScore(place.ID, suggestion.ContextPlaceID, chapterPlaces)
+		n := mentions(place.Name)
+		c.section.Score += min(n*scoreMention, maxMentionScore)
+		mentioned[place.ID] = n > 0
+		candidates = append(candidates, c)
+	}
+
+	notes, err := a.noteRepo.ListByNovelID(ctx, suggestion.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load note context: %w", err)
+	}
+	noteCandidates := make(map[uuid.UUID]*contextCandidate, len(notes))
+	for _, note := range notes {
+		c := noteCandidate(note)
+		noteCandidates[note.ID] = c
+		if isContext(note.ID, suggestion.ContextNoteID) {
+			c.section.Score += scoreExplicit
+		}
+		if isContext(uuidOrNil(note.LinkedChapterID), suggestion.ContextChapterID) ||
+			isContext(uuidOrNil(note.LinkedCharacterID), suggestion.ContextCharacterID) ||
+			isContext(uuidOrNil(note.LinkedPlaceID), suggestion.ContextPlaceID) {
+			c.section.Score += scoreLinked
+		}
+		if note.Title != "" {
+			c.section.Score += min(mentions(note.Title)*scoreMention, maxMentionScore)
+		}
+		candidates = append(candidates, c)
+	}
+
+	events, err := a.timelineRepo.ListByNovelID(ctx, suggestion.NovelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timeline context: %w", err)
+	}
+	for _, event := range events {
+		c := timelineCandidate(event)
+		for _, link := range event.Links {
+			switch {
+			case isContext(link.EntityID, suggestion.ContextChapterID),
+				isContext(link.EntityID, suggestion.ContextCharacterID),
+				isContext(link.EntityID, suggestion.ContextPlaceID):
+				c.section.Score += scoreLinked
+			case mentioned[link.EntityID]:
+				c.section.Score += scoreLinkedMention
+			}
+		}
+		c.section.Score += min(mentions(event.Title)*scoreMention, maxMentionScore)
+		candidates = append(candidates, c)
+	}
+
+	passages := a.retrieve(ctx, suggestion, cmp.Or(chapter, previous))
+	included := 0
+	for _, passage := range passages {
+		bonus := int(passage.Score * scoreRetrieved)
+		switch passage.SourceType {
+		case domain.EmbeddingSourceNote:
+			// A note matches with its best passage
+			if c := noteCandidates[passage.SourceID]; c != nil && !c.retrieved {
+				c.section.Score += bonus
+				c.retrieved = true
+			}
+		case domain.EmbeddingSourceChapter:
+			id := passage.SourceID.String()
+			if included == a.options.Passages || (chapter != nil && id == chapter.ID) || (previous != nil && id == previous.ID) {
+				continue
+			}
+			c := passageCandidate(passage)
+			c.section.Score += bonus
+			candidates = append(candidates, c)
+			included++
+		}
+	}
+
+	return candidates, nil
+}
+
+// retrieve finds the passages of the novel related to what the suggestion is about:
+// its selected text and instructions or, without them, the end of the story so far.
+// Semantic search is an aid, so failures are logged rather than returned.
+func (a *ContextAssembler) retrieve(ctx context.Context, suggestion *domain.AISuggestion, story *domain.Chapter) []*SemanticSearchResult {
+	if a.embeddings == nil || a.options.Passages <= 0 {
+		return nil
+	}
+	query := strings.TrimSpace(suggestion.SelectedText + "\n" + suggestion.PromptInstructions)
+	if query == "" && story != nil {
+		query = tailTokens(story.Content, retrievalQueryTokens)
+	}
+	if query == "" {
+		return nil
+	}
+
+	// Leave room for the notes and the chapters already in context among the matches
+	passages, err := a.embeddings.Retrieve(ctx, suggestion.NovelID, query, a.options.Passages*3)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Warning: semantic retrieval for AI suggestion %s failed: %v", suggestion.ID, err)
+		}
+		return nil
+	}
+	return passages
+}
+
+// storySoFar condenses chapters into the synopses of the acts they complete and the
+// summaries of the rest, or returns nil when none of them is summarized yet. Stale
+// summaries still tell most of the story and are used as they are.
+func (a *ContextAssembler) storySoFar(ctx context.Context, novelID uuid.UUID, chapters []*domain.Chapter) (*contextCandidate, error) {
+	if a.summaryRepo == nil || len(chapters) == 0 {
+		return nil, nil
+	}
+	summaries, err := a.summaryRepo.ListChapterSummaries(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chapter summaries: %w", err)
+	}
+	synopses, err := a.summaryRepo.ListSynopses(ctx, novelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load novel synopses: %w", err)
+	}
+
+	var parts []string
+	outline := newSynopsisOutline(chapters, summaries, synopses, a.options.ChaptersPerAct)
+	for _, act := range outline.acts {
+		first, last := act.chapters[0], act.chapters[len(act.chapters)-1]
+		if synopsis := outline.synopses[act.number]; synopsis != nil && synopsis.Synopsis != "" &&
+			len(act.chapters) == a.options.ChaptersPerAct {
+			parts = append(parts, fmt.Sprintf("Act %d (chapters %d to %d): %s", act.number, first.position, last.position, synopsis.Synopsis))
+			continue
+		}
+		for _, c := range act.chapters {
+			if c.summary != nil {
+				parts = append(parts, fmt.Sprintf("Chapter %d, %q: %s", c.position, c.chapter.Title, c.summary.Summary))
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+
+	c := &contextCandidate{
+		section: ContextSection{Kind: ContextSectionStorySoFar, Name: "Story so far"},
+		label:   "The story so far, in summary:",
+		body:    strings.Join(parts, "\n\n"),
+		keepEnd: true,
+		share:   storySoFarShare,
+	}
+	c.section.Tokens = llm.EstimateTokens(c.text())
+	return c, nil
+}
+
+func novelHeader(novel *domain.Novel) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Novel: %s", novel.Title)
+	if novel.Genre != "" {
+		fmt.Fprintf(&b, "\nGenre: %s", novel.Genre)
+	}
+	if novel.Logline != "" {
+		fmt.Fprintf(&b, "\nLogline: %s", novel.Logline)
+	}
+	return b.String()
+}
+
+func characterCandidate(character *domain.Character) *contextCandidate {
+	fields := []labeledValue{
+		{"Also known as", strings.Join(character.Aliases, ", ")},
+		{"Description", character.Description},
+		{"Appearance", character.PhysicalDescription},
+		{"Motivations", character.Motivations},
+		{"Backstory", character.Backstory},
+	}
+	return entityCandidate(ContextSectionCharacter, character.ID, character.Name, "Character: "+character.Name, fields)
+}
+
+func placeCandidate(place *domain.Place) *contextCandidate {
+	fields := []labeledValue{
+		{"Description", place.Description},
+		{"Location", place.LocationDetails},
+		{"Atmosphere", place.Atmosphere},
+	}
+	return entityCandidate(ContextSectionPlace, place.ID, place.Name, "Place: "+place.Name, fields)
+}
+
+func noteCandidate(note *domain.Note) *contextCandidate {
+	name := cmp.Or(note.Title, "Untitled note")
+	label := fmt.Sprintf("Note (%s): %s", strings.ReplaceAll(string(note.Type), "_", " "), name)
+	return entityCandidate(ContextSectionNote, note.ID, name, label, []labeledValue{{"", note.Content}})
+}
+
+func timelineCandidate(event *domain.TimelineEvent) *contextCandidate {
+	label := "Timeline event: " + event.Title
+	if event.EventDateText != "" {
+		label += " (" + event.EventDateText + ")"
+	}
+	return entityCandidate(ContextSectionTimelineEvent, event.ID, event.Title, label, []labeledValue{{"", event.Description}})
+}
+
+func passageCandidate(passage *SemanticSearchResult) *contextCandidate {
+	c := &contextCandidate{
+		section: ContextSection{Kind: ContextSectionPassage, ID: passage.SourceID.String(), Name: passage.Title},
+		label:   fmt.Sprintf("Related passage from chapter %q:", passage.Title),
+		body:    passage.Text,
+		share:   entityShare,
+	}
+	c.section.Tokens = llm.EstimateTokens(c.text())
+	return c
+}
+
+func storyCandidate(kind string, chapter *domain.Chapter, label string, share float64) *contextCandidate {
+	c := &contextCandidate{
+		section: ContextSection{Kind: kind, ID: chapter.ID, Name: chapter.Title},
+		label:   label,
+		body:    chapter.Content,
+		keepEnd: true,
+		share:   share,
+	}
+	c.section.Tokens = llm.EstimateTokens(c.text())
+	return c
+}
+
+type labeledValue struct{ label, value string }
+
+func entityCandidate(kind string, id uuid.UUID, name, label string, fields []labeledValue) *contextCandidate {
+	lines := make([]string, 0, len(fields))
+	for _, field := range fields {
+		switch {
+		case field.value == "":
+		case field.label == "":
+			lines = append(lines, field.value)
+		default:
+			lines = append(lines, field.label+": "+field.value)
+		}
+	}
+
+	c := &contextCandidate{
+		section: ContextSection{Kind: kind, ID: id.String(), Name: name},
+		label:   label,
+		body:    strings.Join(lines, "\n"),
+		share:   entityShare,
+	}
+	c.section.Tokens = llm.EstimateTokens(c.text())
+	return c
+}
+
+// entityScore scores a character or place by whether it is the context entity or
+// linked to the context chapter.
+func entityScore(id uuid.UUID, contextID *uuid.UUID, chapterIDs []uuid.UUID) int {
+	score := 0
+	if isContext(id, contextID) {
+		score += scoreExplicit
+	}
+	if slices.Contains(chapterIDs, id) {
+		score += scoreLinked
+	}
+	return score
+}
+
+func isContext(id uuid.UUID, contextID *uuid.UUID) bool {
+	return contextID != nil && id != uuid.Nil && id == *contextID
+}
+
+func uuidOrNil(id *uuid.UUID) uuid.UUID {
+	if id == nil {
+		return uuid.Nil
+	}
+	return *id
+}
+
+// mentionCounter returns a function counting the whole-word, case-insensitive
+// occurrences of any of its names in text.
+func mentionCounter(text string) func(names ...string) int {
+	return func(names ...string) int {
+		if text == "" {
+			return 0
+		}
+		count := 0
+		for _, name := range names {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			// \b only knows ASCII word characters; names may not be ASCII
+			pattern := regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}_])` + regexp.QuoteMeta(name) + `(?:$|[^\p{L}\p{N}_])`)
+			count += len(pattern.FindAllStringIndex(text, -1))
+		}
+		return count
+	}
+}
+
+// tailTokens keeps the end of text within roughly maxTokens, starting at a paragraph
+// or word boundary.
+func tailTokens(text string, maxTokens int) string {
+	if llm.EstimateTokens(text) <= maxTokens {
+		return text
+	}
+	// Estimates run at about four characters per token
+	tail := text[len(text)-min(len(text), maxTokens*4):]
+	for llm.EstimateTokens(tail) > maxTokens {
+		tail = tail[len(tail)/10:]
+	}
+	if i := strings.Index(tail, "\n\n"); i >= 0 && i < len(tail)/2 {
+		return tail[i+2:]
+	}
+	if i := strings.IndexAny(tail, " \n"); i >= 0 {
+		return tail[i+1:]
+	}
+	return tail
+}
+
+// headTokens keeps the start of text within roughly maxTokens, ending at a word boundary.
+func headTokens(text string, maxTokens int) string {
+	if llm.EstimateTokens(text) <= maxTokens {
+		return text
+	}
+	head := text[:min(len(text), maxTokens*4)]
+	for llm.EstimateTokens(head) > maxTokens {
+		head = head[:len(head)-len(head)/10-1]
+	}
+	if i := strings.LastIndexAny(head, " \n"); i > len(head)/2 {
+		return head[:i]
+	}
+	return strings.ToValidUTF8(head, "")
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/llm"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// ErrInvalidSearch is returned for malformed semantic search input.
+var ErrInvalidSearch = errors.New("invalid search")
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+	// maxSearchQueryLength bounds the query in runes; it is embedded as a single input.
+	maxSearchQueryLength = 1000
+	// embedBatchSize is the number of passages embedded per provider call.
+	embedBatchSize = 32
+)
+
+// EmbeddingOptions tunes how sources are split into passages.
+type EmbeddingOptions struct {
+	ChunkTokens        int // Passage size; 256 when 0
+	ChunkOverlapTokens int // Text shared by consecutive passages, at most half a passage
+}
+
+// EmbeddingService maintains the semantic search index of chapters and shared notes
+// and searches it. Sources are split into passages of about ChunkTokens, each embedded
+// with the source's title; vectors are scored in Go, by cosine similarity.
+type EmbeddingService struct {
+	transactor    repository.Transactor
+	embeddingRepo repository.EmbeddingRepository
+	chapterRepo   repository.ChapterRepository
+	noteRepo      repository.NoteRepository
+	embedder      llm.Embedder
+	access        *AccessService
+	options       EmbeddingOptions
+}
+
+func NewEmbeddingService(
+	transactor repository.Transactor,
+	embeddingRepo repository.EmbeddingRepository,
+	chapterRepo repository.ChapterRepository,
+	noteRepo repository.NoteRepository,
+	embedder llm.Embedder,
+	access *AccessService,
+	options EmbeddingOptions) *EmbeddingService {
+	if options.ChunkTokens <= 0 {
+		options.ChunkTokens = 256
+	}
+	options.ChunkOverlapTokens = min(max(options.ChunkOverlapTokens, 0), options.ChunkTokens/2)
+	return &EmbeddingService{
+		transactor:    transactor,
+		embeddingRepo: embeddingRepo,
+		chapterRepo:   chapterRepo,
+		noteRepo:      noteRepo,
+		embedder:      embedder,
+		access:        access,
+		options:       options,
+	}
+}
+
+// SemanticSearchResult is a passage matching a search, with the rune range of its
+// text in the source.
+type SemanticSearchResult struct {
+	SourceType domain.EmbeddingSourceType `json:"sourceType"`
+	SourceID   uuid.UUID                  `json:"sourceId"`
+	Title      string                     `json:"title"`
+	ChunkIndex int                        `json:"chunkIndex"`
+	Start      int                        `json:"start"`
+	End        int                        `json:"end"`
+	Text       string                     `json:"text"`
+	Score      float64                    `json:"score"` // Cosine similarity to the query
+}
+
+// Search returns the passages of a novel's chapters and notes closest in meaning to q.
+func (s *EmbeddingService) Search(ctx context.Context, actorID, novelID uuid.UUID, q string, limit int) ([]*SemanticSearchResult, error) {
+	q = strings.TrimSpace(q)
+	switch {
+	case q == "":
+		return nil, fmt.Errorf("%w: q is required", ErrInvalidSearch)
+	case utf8.RuneCountInString(q) > maxSearchQueryLength:
+		return nil, fmt.Errorf("%w: q must be at most %d characters", ErrInvalidSearch, maxSearchQueryLength)
+	case limit == 0:
+		limit = defaultSearchLimit
+	case limit < 0 || limit > maxSearchLimit:
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidSearch, maxSearchLimit)
+	}
+
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+	return s.Retrieve(ctx, novelID, q, limit)
+}
+
+// Retrieve returns the limit passages of a novel closest in meaning to query, without
+// access checks. Passages not yet indexed are not found.
+func (s *EmbeddingService) Retrieve(ctx context.Context, novelID uuid.UUID, query string, limit int) ([]*SemanticSearchResult, error) {
+	results := []*SemanticSearchResult{}
+	chunks, err := s.embeddingRepo.ListChunksByNovel(ctx, novelID, s.embedder.Model())
+	if err != nil || len(chunks) == 0 {
+		return results, err
+	}
+
+	vectors, err := s.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed search query: %w", err)
+	}
+	for _, chunk := range chunks {
+		score := llm.CosineSimilarity(vectors[0], chunk.Embedding)
+		if score <= 0 {
+			continue
+		}
+		results = append(results, &SemanticSearchResult{
+			SourceType: chunk.SourceType,
+			SourceID:   chunk.SourceID,
+			Title:      chunk.Title,
+			ChunkIndex: chunk.Index,
+			Start:      chunk.Start,
+			End:        chunk.End,
+			Text:       chunk.Content,
+			Score:      score,
+		})
+	}
+
+	slices.SortStableFunc(results, func(x, y *SemanticSearchResult) int {
+		return cmp.Compare(y.Score, x.Score)
+	})
+	return results[:min(len(results), limit)], nil
+}
+
+// IndexSource brings the index of a chapter or note up to date. Passages whose text
+// and title did not change keep their vectors; only the others are embedded. A deleted
+// source is skipped; PruneOrphans drops its index.
+func (s *EmbeddingService) IndexSource(ctx context.Context, sourceType domain.EmbeddingSourceType, id uuid.UUID) error {
+	source, text, err := s.loadSource(ctx, sourceType, id)
+	if err != nil || source == nil {
+		return err
+	}
+
+	reusable := map[string][]float32{}
+	previous, err := s.embeddingRepo.GetSource(ctx, sourceType, id)
+	switch {
+	case errors.Is(err, repository.ErrEmbeddingSourceNotFound):
+	case err != nil:
+		return err
+	case previous.Model != source.Model:
+	case !previous.UpdatedAt.Before(source.UpdatedAt):
+		return nil // Already up to date
+	case previous.Title == source.Title:
+		chunks, err := s.embeddingRepo.ListChunksBySource(ctx, sourceType, id)
+		if err != nil {
+			return err
+		}
+		for _, chunk := range chunks {
+			reusable[chunk.Content] = chunk.Embedding
+		}
+	}
+
+	runes := []rune(text)
+	var chunks, pending []*domain.EmbeddingChunk
+	for i, span := range chunkText(runes, s.options.ChunkTokens, s.options.ChunkOverlapTokens) {
+		chunk := &domain.EmbeddingChunk{
+			SourceType: sourceType,
+			SourceID:   id,
+			Index:      i,
+			Start:      span.start,
+			End:        span.end,
+			Content:    string(runes[span.start:span.end]),
+		}
+		if vector, ok := reusable[chunk.Content]; ok {
+			chunk.Embedding = vector
+		} else {
+			pending = append(pending, chunk)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	for batch := range slices.Chunk(pending, embedBatchSize) {
+		inputs := make([]string, len(batch))
+		for i, chunk := range batch {
+			inputs[i] = embeddingInput(source.Title, chunk.Content)
+		}
+		vectors, err := s.embedder.Embed(ctx, inputs)
+		if err != nil {
+			return fmt.Errorf("failed to embed %s %s: %w", sourceType, id, err)
+		}
+		for i, chunk := range batch {
+			chunk.Embedding = vectors[i]
+		}
+	}
+
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// A concurrent run may have indexed a newer version meanwhile; keep it
+		_, err := s.embeddingRepo.ReplaceSource(ctx, source, chunks)
+		return err
+	})
+}
+
+// StaleSources lists up to limit chapters and notes whose index is missing, out of
+// date or made with another model.
+func (s *EmbeddingService) StaleSources(ctx context.Context, limit int) ([]*domain.EmbeddingSource, error) {
+	return s.embeddingRepo.ListStale(ctx, s.embedder.Model(), limit)
+}
+
+// PruneOrphans drops the index of deleted chapters and notes.
+func (s *EmbeddingService) PruneOrphans(ctx context.Context) (int64, error) {
+	return s.embeddingRepo.DeleteOrphans(ctx)
+}
+
+// loadSource returns the index entry to store for a chapter or note and its text, or
+// nil when the source does not exist.
+func (s *EmbeddingService) loadSource(ctx context.Context, sourceType domain.EmbeddingSourceType, id uuid.UUID) (*domain.EmbeddingSource, string, error) {
+	source := &domain.EmbeddingSource{Type: sourceType, ID: id, Model: s.embedder.Model()}
+	switch sourceType {
+	case domain.EmbeddingSourceChapter:
+		chapter, err := s.chapterRepo.GetByID(ctx, id)
+		if errors.Is(err, repository.ErrChapterNotFound) {
+			return nil, "", nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if source.NovelID, err = uuid.Parse(chapter.NovelID); err != nil {
+			return nil, "", fmt.Errorf("chapter has invalid novel ID %q: %w", chapter.NovelID, err)
+		}
+		source.Title, source.UpdatedAt = chapter.Title, chapter.UpdatedAt
+		return source, chapter.Content, nil
+	case domain.EmbeddingSourceNote:
+		note, err := s.noteRepo.GetByID(ctx, id)
+		if errors.Is(err, repository.ErrNoteNotFound) {
+			return nil, "", nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		source.NovelID, source.Title, source.UpdatedAt = note.NovelID, note.Title, note.UpdatedAt
+		return source, note.Content, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported embedding source type %q", sourceType)
+	}
+}
+
+// embeddingInput is the text embedded for a passage: the title places passages like
+// "she refused it" in the chapter or note they belong to.
+func embeddingInput(title, content string) string {
+	if title == "" {
+		return content
+	}
+	return title + "\n\n" + content
+}
+
+// textSpan is the rune range [start, end) of a passage.
+type textSpan struct {
+	start, end int
+	tokens     int
+}
+
+// chunkText splits text into passages of at most about maxTokens that end at sentence
+// or line breaks where possible. Each passage after the first starts with up to
+// overlapTokens of the previous one, so a sentence cut from its context is still
+// found next to it.
+func chunkText(text []rune, maxTokens, overlapTokens int) []textSpan {
+	units := sentenceSpans(text, maxTokens)
+
+	var chunks []textSpan
+	for i := 0; i < len(units); {
+		j, tokens := i, 0
+		for j < len(units) && (j == i || tokens+units[j].tokens <= maxTokens) {
+			tokens += units[j].tokens
+			j++
+		}
+		chunks = append(chunks, textSpan{start: units[i].start, end: units[j-1].end, tokens: tokens})
+		if j == len(units) {
+			break
+		}
+
+		// Step back over whole sentences within the overlap, leaving room for the next
+		// sentence and always moving forward
+		next, shared := j, 0
+		for next-1 > i && shared+units[next-1].tokens <= min(overlapTokens, maxTokens-units[j].tokens) {
+			next--
+			shared += units[next].tokens
+		}
+		i = next
+	}
+	return chunks
+}
+
+// sentenceSpans splits text into sentences, lines and, for longer ones, runs of words
+// of at most maxTokens. Spans start and end with non-space characters.
+func sentenceSpans(text []rune, maxTokens int) []textSpan {
+	var spans []textSpan
+	var current *textSpan
+	flush := func() {
+		if current != nil {
+			spans = append(spans, *current)
+			current = nil
+		}
+	}
+
+	for i := 0; i < len(text); {
+		if unicode.IsSpace(text[i]) {
+			if text[i] == '\n' {
+				flush()
+			}
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(text) && !unicode.IsSpace(text[j]) {
+			j++
+		}
+		tokens := llm.EstimateTokens(string(text[i:j]))
+		if current != nil && current.tokens+tokens > maxTokens {
+			flush()
+		}
+		if current == nil {
+			current = &textSpan{start: i}
+		}
+		current.end = j
+		current.tokens += tokens
+		if endsSentence(text[i:j]) {
+			flush()
+		}
+		i = j
+	}
+	flush()
+	return spans
+}
+
+// endsSentence reports whether a word ends a sentence, allowing closing quotes and
+// brackets after the punctuation.
+func endsSentence(word []rune) bool {
+	for len(word) > 0 && strings.ContainsRune(`"'”’»)]`, word[len(word)-1]) {
+		word = word[:len(word)-1]
+	}
+	return len(word) > 0 && strings.ContainsRune(".!?…", word[len(word)-1])
+}
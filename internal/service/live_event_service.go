@@ -0,0 +1,519 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/mq"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// ErrInvalidEventStream is returned for malformed event stream requests.
+var ErrInvalidEventStream = errors.New("invalid event stream")
+
+const (
+	// maxStreamNovels caps the novels a single stream follows.
+	maxStreamNovels = 50
+	// liveReplayLimit caps the events replayed to a reconnecting client; one further
+	// behind is told to reset instead.
+	liveReplayLimit = 500
+	// liveSubscriberBuffer is the number of events a client may lag behind before it is
+	// dropped, to resume from the store when it reconnects.
+	liveSubscriberBuffer = 64
+	// livePollBatch is the number of stored events read at a time without a queue.
+	livePollBatch = 200
+	// liveResubscribeDelay is the pause before following the queue again after the
+	// subscription was lost.
+	liveResubscribeDelay = 2 * time.Second
+	// liveGapTimeout is how long polling waits for a missing event ID: concurrent inserts
+	// may commit out of ID order, and a failed insert leaves an ID unused for good.
+	liveGapTimeout = 10 * time.Second
+	// liveMaxGaps caps the missing IDs polling waits for at a time.
+	liveMaxGaps = 1000
+)
+
+// LiveEventService pushes events to the clients connected to this API server: new
+// notifications, chapter saves, comments and AI suggestion status changes. Publish is
+// called once a change is committed, by whichever process made it; the event is stored,
+// so reconnecting clients can catch up, and broadcast on the message queue, which Follow
+// listens to in every API server. Without a queue Follow polls the store instead.
+// Publishing is best effort: a failure is logged and never fails the change.
+type LiveEventService struct {
+	liveEventRepo repository.LiveEventRepository
+	pubsub        mq.PubSub // Nil when no message queue is configured
+	access        *AccessService
+	options       LiveEventOptions
+
+	mu          sync.Mutex
+	subscribers map[*LiveSubscription]struct{}
+}
+
+// LiveEventOptions tunes the live event stream.
+type LiveEventOptions struct {
+	// Retention is how long events are kept for reconnecting clients (default 24h).
+	Retention time.Duration
+	// PollInterval is how often Follow reads the store without a message queue
+	// (default 1s).
+	PollInterval time.Duration
+}
+
+func NewLiveEventService(
+	liveEventRepo repository.LiveEventRepository,
+	pubsub mq.PubSub,
+	access *AccessService,
+	options LiveEventOptions) *LiveEventService {
+	if options.Retention <= 0 {
+		options.Retention = 24 * time.Hour
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = time.Second
+	}
+	return &LiveEventService{
+		liveEventRepo: liveEventRepo,
+		pubsub:        pubsub,
+		access:        access,
+		options:       options,
+		subscribers:   make(map[*LiveSubscription]struct{}),
+	}
+}
+
+// LiveSubscription is a client's stream. Replay holds the stored events it missed since
+// the event ID it resumed from, oldest first; when those are gone or too many, Replay is
+// empty and Reset tells the client to reload what it shows instead. LastEventID is the
+// ID the client is caught up to after either. Events delivers the later events and is
+// closed when the client falls behind or the server loses the message queue; the client
+// then reconnects and resumes.
+type LiveSubscription struct {
+	UserID      uuid.UUID
+	NovelIDs    []uuid.UUID
+	Replay      []*domain.LiveEvent
+	Reset       bool
+	LastEventID int64
+	Events      <-chan *domain.LiveEvent
+
+	events    chan *domain.LiveEvent
+	novels    map[uuid.UUID]bool
+	replaying bool                // Events are held in pending until the replay is read
+	pending   []*domain.LiveEvent // Guarded by the service's mutex, like the fields above
+	closed    bool
+}
+
+// wants reports whether the event is for the subscriber: events for a user go to that
+// user only, novel events to the followers of the novel other than their actor.
+func (sub *LiveSubscription) wants(event *domain.LiveEvent) bool {
+	if event.UserID != nil {
+		return *event.UserID == sub.UserID
+	}
+	if event.NovelID == nil || !sub.novels[*event.NovelID] {
+		return false
+	}
+	return event.ActorUserID == nil || *event.ActorUserID != sub.UserID
+}
+
+// Publish stores an event and broadcasts it. Call it after the change it reports is
+// committed. It is safe on a nil service, which drops the event.
+func (s *LiveEventService) Publish(ctx context.Context, event *domain.LiveEvent) {
+	if s == nil {
+		return
+	}
+	if err := s.liveEventRepo.Create(ctx, event); err != nil {
+		log.Printf("Warning: failed to store %s live event: %v", event.Type, err)
+		return
+	}
+	if s.pubsub == nil {
+		// Follow picks it up from the store
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err == nil {
+		err = s.pubsub.Broadcast(ctx, mq.Message{Topic: mq.TopicLiveEvents, Key: liveEventKey(event), Body: body})
+	}
+	if err != nil {
+		// Clients get it when they next reconnect
+		log.Printf("Warning: failed to broadcast live event %d: %v", event.ID, err)
+	}
+}
+
+func liveEventKey(event *domain.LiveEvent) string {
+	if event.UserID != nil {
+		return event.UserID.String()
+	}
+	if event.NovelID != nil {
+		return event.NovelID.String()
+	}
+	return ""
+}
+
+// NotificationCreated pushes a new notification to its recipient.
+func (s *LiveEventService) NotificationCreated(ctx context.Context, notification *domain.Notification) {
+	s.publish(ctx, domain.LiveEventNotification, notification.NovelID, &notification.UserID,
+		notification.ActorUserID, notification)
+}
+
+// ChapterUpdated tells the followers of a novel that a chapter was saved, with the
+// revision recorded when the content changed. The content is left out; clients fetch
+// the chapter, or the revision, when they need it.
+func (s *LiveEventService) ChapterUpdated(ctx context.Context, editorID uuid.UUID, chapter *domain.Chapter, revisionID *int64) {
+	if s == nil {
+		return
+	}
+	novelID, err := uuid.Parse(chapter.NovelID)
+	if err != nil {
+		log.Printf("Warning: chapter %s has invalid novel ID %q", chapter.ID, chapter.NovelID)
+		return
+	}
+	data := map[string]any{
+		"chapterId": chapter.ID,
+		"novelId":   novelID,
+		"title":     chapter.Title,
+		"status":    chapter.Status,
+		"wordCount": chapter.WordCount,
+		"editorId":  editorID,
+		"updatedAt": chapter.UpdatedAt,
+	}
+	if revisionID != nil {
+		data["revisionId"] = *revisionID
+	}
+	s.publish(ctx, domain.LiveEventChapterUpdated, &novelID, nil, &editorID, data)
+}
+
+// CommentCreated tells the followers of a novel about a new thread or reply.
+func (s *LiveEventService) CommentCreated(ctx context.Context, comment *domain.Comment) {
+	s.publish(ctx, domain.LiveEventCommentCreated, &comment.NovelID, nil, &comment.UserID, comment)
+}
+
+// SuggestionChanged tells the followers of a novel that an AI suggestion changed
+// status. actorID is nil for changes made by the AI worker. Automatic summaries are not
+// reported. The content is left out, as it may be withheld from some readers.
+func (s *LiveEventService) SuggestionChanged(ctx context.Context, actorID *uuid.UUID, suggestion *domain.AISuggestion) {
+	if suggestion.Automatic {
+		return
+	}
+	data := map[string]any{
+		"suggestionId": suggestion.ID,
+		"novelId":      suggestion.NovelID,
+		"userId":       suggestion.UserID,
+		"type":         suggestion.Type,
+		"status":       suggestion.Status,
+	}
+	if groupID := suggestion.VariantGroupID(); groupID != nil {
+		data["variantGroupId"] = groupID
+	}
+	s.publish(ctx, domain.LiveEventSuggestionStatus, &suggestion.NovelID, nil, actorID, data)
+}
+
+func (s *LiveEventService) publish(
+	ctx context.Context,
+	eventType domain.LiveEventType,
+	novelID, userID, actorID *uuid.UUID,
+	payload any,
+) {
+	if s == nil {
+		return
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Warning: failed to encode %s live event: %v", eventType, err)
+		return
+	}
+	s.Publish(ctx, &domain.LiveEvent{
+		Type:        eventType,
+		NovelID:     novelID,
+		UserID:      userID,
+		ActorUserID: actorID,
+		Data:        data,
+	})
+}
+
+// Subscribe opens a stream of the actor's own events and of the events of the given
+// novels, which the actor must be able to view. With a lastEventID the stored events
+// since are replayed first. Close the subscription when done.
+func (s *LiveEventService) Subscribe(
+	ctx context.Context,
+	actorID uuid.UUID,
+	novelIDs []uuid.UUID,
+	lastEventID int64,
+) (*LiveSubscription, error) {
+	if len(novelIDs) > maxStreamNovels {
+		return nil, fmt.Errorf("%w: at most %d novels per stream", ErrInvalidEventStream, maxStreamNovels)
+	}
+	if lastEventID < 0 {
+		return nil, fmt.Errorf("%w: invalid last event ID %d", ErrInvalidEventStream, lastEventID)
+	}
+
+	events := make(chan *domain.LiveEvent, liveSubscriberBuffer)
+	sub := &LiveSubscription{
+		UserID:    actorID,
+		Events:    events,
+		events:    events,
+		novels:    make(map[uuid.UUID]bool, len(novelIDs)),
+		replaying: lastEventID > 0,
+	}
+	for _, novelID := range novelIDs {
+		if !sub.novels[novelID] {
+			sub.novels[novelID] = true
+			sub.NovelIDs = append(sub.NovelIDs, novelID)
+		}
+	}
+	if err := s.Authorize(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	// Registered before the replay is read, so nothing falls in between
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+
+	if lastEventID > 0 {
+		if err := s.replay(ctx, sub, lastEventID); err != nil {
+			s.Close(sub)
+			return nil, err
+		}
+	}
+	return sub, nil
+}
+
+// replay reads the events a resuming client missed and then releases those held back
+// while reading. Events are stored in ID order but may commit out of it, so an event
+// held back is released unless it was replayed, even when its ID is lower than the last
+// one replayed. An event still uncommitted when the client resumes, with an ID lower than
+// the client's, is not replayed: it reaches the client live if it is broadcast or polled
+// after the client subscribed.
+func (s *LiveEventService) replay(ctx context.Context, sub *LiveSubscription, lastEventID int64) error {
+	oldest, latest, err := s.liveEventRepo.Bounds(ctx)
+	if err != nil {
+		return err
+	}
+
+	sub.LastEventID = lastEventID
+	replayed := map[int64]bool{}
+	// Events were pruned since, or the store does not know the ID at all
+	gone := lastEventID+1 < oldest || lastEventID > latest
+	if !gone {
+		stored, err := s.liveEventRepo.ListForUser(ctx, sub.UserID, sub.NovelIDs, lastEventID, liveReplayLimit+1)
+		if err != nil {
+			return err
+		}
+		gone = len(stored) > liveReplayLimit
+		if !gone {
+			for _, event := range stored {
+				if sub.wants(event) {
+					sub.Replay = append(sub.Replay, event)
+				}
+				replayed[event.ID] = true
+				sub.LastEventID = event.ID
+			}
+		}
+	}
+	if gone {
+		sub.Reset, sub.Replay, sub.LastEventID = true, nil, latest
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub.replaying = false
+	for _, event := range sub.pending {
+		if event.ID > lastEventID && !replayed[event.ID] {
+			s.deliver(sub, event)
+		}
+	}
+	sub.pending = nil
+	return nil
+}
+
+// Authorize checks that the subscriber can still view the novels it follows. Streams
+// are long-lived, so callers recheck from time to time.
+func (s *LiveEventService) Authorize(ctx context.Context, sub *LiveSubscription) error {
+	for _, novelID := range sub.NovelIDs {
+		if _, err := s.access.Require(ctx, novelID, sub.UserID, domain.PermissionView); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close ends a subscription and closes its Events channel, if it is still open.
+func (s *LiveEventService) Close(sub *LiveSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drop(sub)
+}
+
+// Dispatch hands an event to the local subscribers it is for.
+func (s *LiveEventService) Dispatch(event *domain.LiveEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers {
+		if sub.wants(event) {
+			s.deliver(sub, event)
+		}
+	}
+}
+
+// deliver queues an event for a subscriber, dropping the subscriber when it lags
+// behind. Call it with the mutex held.
+func (s *LiveEventService) deliver(sub *LiveSubscription, event *domain.LiveEvent) {
+	if sub.replaying {
+		if len(sub.pending) < liveSubscriberBuffer {
+			sub.pending = append(sub.pending, event)
+			return
+		}
+	} else {
+		select {
+		case sub.events <- event:
+			return
+		default:
+		}
+	}
+	log.Printf("Live event subscriber %s fell behind; dropping it", sub.UserID)
+	s.drop(sub)
+}
+
+// drop unregisters a subscriber and closes its channel. Call it with the mutex held.
+func (s *LiveEventService) drop(sub *LiveSubscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(s.subscribers, sub)
+	close(sub.events)
+}
+
+// dropAll drops every subscriber, so the clients resume from the store.
+func (s *LiveEventService) dropAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers {
+		s.drop(sub)
+	}
+}
+
+// Follow dispatches the events published by every process to the local subscribers
+// until ctx is done: from the message queue when there is one, by polling the store
+// otherwise. Events broadcast while the queue subscription is lost are missed, so the
+// subscribers are then dropped and resume from the store when they reconnect.
+func (s *LiveEventService) Follow(ctx context.Context) {
+	if s.pubsub == nil {
+		s.poll(ctx)
+		return
+	}
+	for ctx.Err() == nil {
+		msgs, err := s.pubsub.Subscribe(ctx, mq.TopicLiveEvents)
+		if err != nil {
+			log.Printf("Warning: failed to follow live events: %v", err)
+		} else {
+			for msg := range msgs {
+				var event domain.LiveEvent
+				if err := json.Unmarshal(msg.Body, &event); err != nil {
+					log.Printf("Warning: dropping malformed live event: %v", err)
+					continue
+				}
+				s.Dispatch(&event)
+			}
+		}
+		s.dropAll()
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(liveResubscribeDelay):
+		}
+	}
+}
+
+// poll dispatches the events stored after Follow started, every PollInterval. It reads
+// again from below the IDs it has not seen yet, for up to liveGapTimeout, so events whose
+// insert commits after a later one are still dispatched, once. Events committing later
+// than that, or stored before polling started, are missed.
+func (s *LiveEventService) poll(ctx context.Context) {
+	var cursor *liveCursor
+	ticker := time.NewTicker(s.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if cursor == nil {
+			_, latest, err := s.liveEventRepo.Bounds(ctx)
+			if err == nil {
+				cursor = &liveCursor{position: latest, gaps: map[int64]time.Time{}}
+			} else if ctx.Err() == nil {
+				log.Printf("Warning: failed to read live events: %v", err)
+			}
+		}
+		for after := cursor.from(time.Now()); cursor != nil && ctx.Err() == nil; {
+			events, err := s.liveEventRepo.ListAfter(ctx, after, livePollBatch)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Warning: failed to read live events: %v", err)
+				}
+				break
+			}
+			for _, event := range events {
+				if cursor.advance(event.ID, time.Now()) {
+					s.Dispatch(event)
+				}
+				after = event.ID
+			}
+			if len(events) < livePollBatch {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// liveCursor is the position of polling in the stored events, with the IDs below it
+// that were not seen yet.
+type liveCursor struct {
+	position int64               // Highest ID seen
+	gaps     map[int64]time.Time // Missing IDs, with when to stop waiting for them
+}
+
+// from returns the ID to read after: below the lowest missing ID still waited for. It is
+// safe on a nil cursor.
+func (c *liveCursor) from(now time.Time) int64 {
+	if c == nil {
+		return 0
+	}
+	from := c.position
+	for id, deadline := range c.gaps {
+		if now.After(deadline) {
+			delete(c.gaps, id)
+		} else if id-1 < from {
+			from = id - 1
+		}
+	}
+	return from
+}
+
+// advance records an event ID read and reports whether it was not seen before.
+func (c *liveCursor) advance(id int64, now time.Time) bool {
+	if id <= c.position {
+		if _, missing := c.gaps[id]; !missing {
+			return false
+		}
+		delete(c.gaps, id)
+		return true
+	}
+	for missing := max(c.position+1, id-liveMaxGaps); missing < id; missing++ {
+		c.gaps[missing] = now.Add(liveGapTimeout)
+	}
+	c.position = id
+	return true
+}
+
+// Prune deletes the events older than the retention window and returns how many.
+func (s *LiveEventService) Prune(ctx context.Context, now time.Time) (int64, error) {
+	return s.liveEventRepo.DeleteBefore(ctx, now.Add(-s.options.Retention))
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/moderation"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+var (
+	// ErrInvalidModerationSettings is returned for malformed moderation settings.
+	ErrInvalidModerationSettings = errors.New("invalid moderation settings")
+	// ErrSuggestionWithheld is returned when acting on flagged output the actor has not revealed.
+	ErrSuggestionWithheld = errors.New("flagged ai suggestion content must be revealed first")
+)
+
+// ModerationService holds the per-novel moderation settings and screens AI output
+// against them. The AI worker records the outcome with each generation; flagged output
+// is withheld until revealed (see AIService.RevealSuggestion).
+type ModerationService struct {
+	classifier    moderation.Classifier // Nil disables moderation
+	settingsRepo  repository.ModerationSettingsRepository
+	access        *AccessService
+	defaultRating domain.ContentRating
+}
+
+func NewModerationService(
+	classifier moderation.Classifier,
+	settingsRepo repository.ModerationSettingsRepository,
+	access *AccessService,
+	defaultRating domain.ContentRating) *ModerationService {
+	if !defaultRating.Valid() {
+		defaultRating = domain.ContentRatingGeneral
+	}
+	return &ModerationService{
+		classifier:    classifier,
+		settingsRepo:  settingsRepo,
+		access:        access,
+		defaultRating: defaultRating,
+	}
+}
+
+// GetSettings returns the moderation settings of a novel the actor can view, the
+// defaults when it has none.
+func (s *ModerationService) GetSettings(ctx context.Context, actorID, novelID uuid.UUID) (*domain.NovelModerationSettings, error) {
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+	return s.settings(ctx, novelID)
+}
+
+// UpdateSettings replaces the moderation settings of a novel. It requires manage access.
+func (s *ModerationService) UpdateSettings(
+	ctx context.Context,
+	actorID, novelID uuid.UUID,
+	rating domain.ContentRating,
+	allowedCategories []string,
+) (*domain.NovelModerationSettings, error) {
+	if !rating.Valid() {
+		return nil, fmt.Errorf("%w: unknown content rating %q", ErrInvalidModerationSettings, rating)
+	}
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionManage); err != nil {
+		return nil, err
+	}
+
+	categories := []string{}
+	for _, category := range allowedCategories {
+		category = strings.ToLower(strings.TrimSpace(category))
+		if category != "" && !slices.Contains(categories, category) {
+			categories = append(categories, category)
+		}
+	}
+	return s.settingsRepo.Upsert(ctx, &domain.NovelModerationSettings{
+		NovelID:           novelID,
+		ContentRating:     rating,
+		AllowedCategories: categories,
+		UpdatedByUserID:   &actorID,
+	})
+}
+
+func (s *ModerationService) settings(ctx context.Context, novelID uuid.UUID) (*domain.NovelModerationSettings, error) {
+	settings, err := s.settingsRepo.Get(ctx, novelID)
+	if errors.Is(err, repository.ErrModerationSettingsNotFound) {
+		return &domain.NovelModerationSettings{
+			NovelID:           novelID,
+			ContentRating:     s.defaultRating,
+			AllowedCategories: []string{},
+		}, nil
+	}
+	return settings, err
+}
+
+// Screen returns the screen of a novel's AI output, or nil when moderation is disabled.
+func (s *ModerationService) Screen(ctx context.Context, novelID uuid.UUID) (*ModerationScreen, error) {
+	if s.classifier == nil {
+		return nil, nil
+	}
+	settings, err := s.settings(ctx, novelID)
+	if err != nil {
+		return nil, err
+	}
+	return &ModerationScreen{classifier: s.classifier, settings: settings}, nil
+}
+
+// ModerationScreen checks texts against the moderation settings of a novel.
+type ModerationScreen struct {
+	classifier moderation.Classifier
+	settings   *domain.NovelModerationSettings
+}
+
+// Check classifies text and flags it when the novel's settings do not allow a finding.
+// A text the classifier fails on is flagged as unchecked, so it still needs a reveal.
+func (sc *ModerationScreen) Check(ctx context.Context, text string) *domain.AIModeration {
+	outcome := &domain.AIModeration{
+		Classifier:    sc.classifier.Name(),
+		ContentRating: sc.settings.ContentRating,
+	}
+	findings, err := sc.classifier.Classify(ctx, text)
+	if err != nil {
+		findings = []domain.ModerationFinding{{Category: "unchecked", Rule: "classifier-error", Detail: err.Error()}}
+	}
+	for _, finding := range findings {
+		if !sc.settings.Allows(finding) {
+			outcome.Reasons = append(outcome.Reasons, finding)
+		}
+	}
+	outcome.Flagged = len(outcome.Reasons) > 0
+	return outcome
+}
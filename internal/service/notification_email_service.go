@@ -0,0 +1,529 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/email"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+var (
+	// ErrInvalidNotificationPreference is returned for malformed notification preferences.
+	ErrInvalidNotificationPreference = errors.New("invalid notification preference")
+	// ErrInvalidUnsubscribeLink is returned for unsubscribe links that were tampered with.
+	ErrInvalidUnsubscribeLink = errors.New("invalid unsubscribe link")
+)
+
+const (
+	defaultNotificationEmailMaxAge   = 24 * time.Hour
+	defaultNotificationEmailClaimTTL = 5 * time.Minute
+)
+
+// NotificationEmailOptions configures the email channel of notifications.
+type NotificationEmailOptions struct {
+	// DefaultMode applies to the notifications no preference of the user covers.
+	DefaultMode domain.NotificationEmailMode
+	// AppURL is the base of the links into the app; PublicAPIURL that of the
+	// unsubscribe links, which this API serves.
+	AppURL       string
+	PublicAPIURL string
+	DigestHour   int // UTC hour the daily digests go out
+	MaxAttempts  int // Sends of an email before giving up; 5 when 0
+	// MaxAge skips the instant emails of older notifications, e.g. those made while the
+	// channel was disabled. 24 hours when 0.
+	MaxAge time.Duration
+	// ClaimTTL is how long claimed emails stay claimed before another run takes them
+	// over. 5 minutes when 0.
+	ClaimTTL time.Duration
+}
+
+// NotificationEmailService emails notifications to their recipients, at once or in a
+// daily digest as each user prefers, and keeps those preferences. Every email carries
+// signed unsubscribe links that work without signing in.
+type NotificationEmailService struct {
+	notificationRepo repository.NotificationRepository
+	preferenceRepo   repository.NotificationPreferenceRepository
+	novelRepo        repository.NovelRepository
+	userRepo         repository.UserRepository
+	sender           email.Sender // nil when the email channel is disabled
+	signer           *email.UnsubscribeSigner
+	access           *AccessService
+	opts             NotificationEmailOptions
+}
+
+func NewNotificationEmailService(
+	notificationRepo repository.NotificationRepository,
+	preferenceRepo repository.NotificationPreferenceRepository,
+	novelRepo repository.NovelRepository,
+	userRepo repository.UserRepository,
+	sender email.Sender,
+	signer *email.UnsubscribeSigner,
+	access *AccessService,
+	opts NotificationEmailOptions) *NotificationEmailService {
+	if !opts.DefaultMode.Valid() {
+		opts.DefaultMode = domain.NotificationEmailInstant
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = defaultNotificationEmailMaxAge
+	}
+	if opts.ClaimTTL <= 0 {
+		opts.ClaimTTL = defaultNotificationEmailClaimTTL
+	}
+	opts.AppURL = strings.TrimRight(opts.AppURL, "/")
+	opts.PublicAPIURL = strings.TrimRight(opts.PublicAPIURL, "/")
+	return &NotificationEmailService{
+		notificationRepo: notificationRepo,
+		preferenceRepo:   preferenceRepo,
+		novelRepo:        novelRepo,
+		userRepo:         userRepo,
+		sender:           sender,
+		signer:           signer,
+		access:           access,
+		opts:             opts,
+	}
+}
+
+// Enabled reports whether emails are sent.
+func (s *NotificationEmailService) Enabled() bool {
+	return s.sender != nil
+}
+
+// DefaultMode returns the email mode of notifications no preference covers.
+func (s *NotificationEmailService) DefaultMode() domain.NotificationEmailMode {
+	return s.opts.DefaultMode
+}
+
+// NotificationPreferenceInput selects the scope of a preference: a novel, a type, both,
+// or all notifications when neither is set.
+type NotificationPreferenceInput struct {
+	NovelID   *uuid.UUID
+	Type      *domain.NotificationType
+	EmailMode domain.NotificationEmailMode
+}
+
+// ListPreferences returns the actor's notification preferences.
+func (s *NotificationEmailService) ListPreferences(ctx context.Context, actorID uuid.UUID) ([]*domain.NotificationPreference, error) {
+	return s.preferenceRepo.ListByUser(ctx, actorID)
+}
+
+// SetPreference sets the email mode of a scope of the actor's notifications. Novel
+// scopes require access to the novel.
+func (s *NotificationEmailService) SetPreference(
+	ctx context.Context,
+	actorID uuid.UUID,
+	input NotificationPreferenceInput,
+) (*domain.NotificationPreference, error) {
+	if !input.EmailMode.Valid() {
+		return nil, fmt.Errorf("%w: email mode must be instant, daily or off", ErrInvalidNotificationPreference)
+	}
+	if err := s.validateScope(ctx, actorID, input.NovelID, input.Type); err != nil {
+		return nil, err
+	}
+
+	preference := &domain.NotificationPreference{
+		UserID:    actorID,
+		NovelID:   input.NovelID,
+		Type:      input.Type,
+		EmailMode: input.EmailMode,
+	}
+	if err := s.preferenceRepo.Upsert(ctx, preference); err != nil {
+		return nil, err
+	}
+	return preference, nil
+}
+
+// DeletePreference removes the actor's preference of a scope; its notifications follow
+// the broader preferences again.
+func (s *NotificationEmailService) DeletePreference(
+	ctx context.Context,
+	actorID uuid.UUID,
+	novelID *uuid.UUID,
+	notificationType *domain.NotificationType,
+) error {
+	if notificationType != nil && !notificationType.Valid() {
+		return fmt.Errorf("%w: unknown notification type %q", ErrInvalidNotificationPreference, *notificationType)
+	}
+	return s.preferenceRepo.Delete(ctx, actorID, novelID, notificationType)
+}
+
+// Unsubscribe turns off the emails of the scope of an unsubscribe link.
+func (s *NotificationEmailService) Unsubscribe(ctx context.Context, token string) (*domain.NotificationPreference, error) {
+	preference, err := s.UnsubscribePreview(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.preferenceRepo.Upsert(ctx, preference); err != nil {
+		return nil, err
+	}
+	return preference, nil
+}
+
+// UnsubscribePreview checks an unsubscribe link and returns the preference Unsubscribe
+// would save, without saving it.
+func (s *NotificationEmailService) UnsubscribePreview(ctx context.Context, token string) (*domain.NotificationPreference, error) {
+	scope, err := s.signer.Verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidUnsubscribeLink, err)
+	}
+	if _, err := s.userRepo.FindByID(ctx, scope.UserID.String()); err != nil {
+		return nil, err
+	}
+	if scope.NovelID != nil {
+		// The user may have left the novel since; unsubscribing needs no access
+		if _, err := s.novelRepo.GetByID(ctx, *scope.NovelID); err != nil {
+			return nil, err
+		}
+	}
+
+	preference := &domain.NotificationPreference{
+		UserID:    scope.UserID,
+		NovelID:   scope.NovelID,
+		EmailMode: domain.NotificationEmailOff,
+	}
+	if scope.Type != "" {
+		if !scope.Type.Valid() {
+			return nil, fmt.Errorf("%w: unknown notification type", ErrInvalidUnsubscribeLink)
+		}
+		preference.Type = &scope.Type
+	}
+	return preference, nil
+}
+
+// SendPending handles up to limit notifications not yet handled by the email channel:
+// emails those the recipient gets instantly, keeps those for the daily digest and skips
+// the others. It returns how many notifications it handled and how many were emailed.
+// Failed emails are retried on later runs, up to MaxAttempts.
+func (s *NotificationEmailService) SendPending(ctx context.Context, limit int) (int, int, error) {
+	if s.sender == nil {
+		return 0, 0, nil
+	}
+	claimed, err := s.notificationRepo.ClaimEmails(ctx, limit, time.Now().Add(-s.opts.ClaimTTL))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	titles := map[uuid.UUID]string{}
+	sent := 0
+	for _, notification := range claimed {
+		ok, err := s.sendInstant(ctx, notification, titles)
+		if err != nil {
+			return len(claimed), sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+	return len(claimed), sent, nil
+}
+
+// SendDigests sends up to limit of the digests due at now: each covers the
+// notifications kept for the recipient's digest before the last digest hour. It returns
+// how many recipients it handled and how many digests were sent. Failed digests are
+// retried on later runs, up to MaxAttempts.
+func (s *NotificationEmailService) SendDigests(ctx context.Context, now time.Time, limit int) (int, int, error) {
+	if s.sender == nil {
+		return 0, 0, nil
+	}
+	cutoff := digestCutoff(now, s.opts.DigestHour)
+	recipients, err := s.notificationRepo.ListDigestRecipients(ctx, cutoff, limit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	titles := map[uuid.UUID]string{}
+	sent := 0
+	for _, userID := range recipients {
+		ok, err := s.sendDigest(ctx, userID, cutoff, titles)
+		if err != nil {
+			return len(recipients), sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+	return len(recipients), sent, nil
+}
+
+// sendInstant settles the email of a claimed notification and reports whether it was
+// sent.
+func (s *NotificationEmailService) sendInstant(
+	ctx context.Context,
+	notification *domain.Notification,
+	titles map[uuid.UUID]string,
+) (bool, error) {
+	ids := []uuid.UUID{notification.ID}
+	mode, err := s.emailMode(ctx, notification)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case mode == domain.NotificationEmailDaily:
+		return false, s.notificationRepo.SetEmailStatus(ctx, ids, domain.NotificationEmailDigest)
+	case mode == domain.NotificationEmailOff, time.Since(notification.CreatedAt) > s.opts.MaxAge:
+		return false, s.notificationRepo.SetEmailStatus(ctx, ids, domain.NotificationEmailSkipped)
+	}
+
+	recipient, err := s.recipient(ctx, notification.UserID)
+	if err != nil || recipient == nil {
+		if err == nil {
+			err = s.notificationRepo.SetEmailStatus(ctx, ids, domain.NotificationEmailSkipped)
+		}
+		return false, err
+	}
+
+	msg, err := s.notificationMessage(ctx, recipient, notification, titles)
+	if err == nil {
+		err = s.sender.Send(ctx, msg)
+	}
+	if err != nil {
+		log.Printf("Warning: failed to email notification %s: %v", notification.ID, err)
+		return false, s.notificationRepo.FailEmails(ctx, ids, domain.NotificationEmailPending, s.opts.MaxAttempts, err.Error())
+	}
+	return true, s.notificationRepo.SetEmailStatus(ctx, ids, domain.NotificationEmailSent)
+}
+
+// sendDigest sends a user's digest and reports whether it was sent. Notifications whose
+// emails were turned off since they were kept are skipped.
+func (s *NotificationEmailService) sendDigest(
+	ctx context.Context,
+	userID uuid.UUID,
+	cutoff time.Time,
+	titles map[uuid.UUID]string,
+) (bool, error) {
+	claimed, err := s.notificationRepo.ClaimDigest(ctx, userID, cutoff)
+	if err != nil || len(claimed) == 0 {
+		return false, err
+	}
+
+	var included, skipped []uuid.UUID
+	items := make([]email.DigestItem, 0, len(claimed))
+	for _, notification := range claimed {
+		mode, err := s.emailMode(ctx, notification)
+		if err != nil {
+			return false, err
+		}
+		if mode == domain.NotificationEmailOff {
+			skipped = append(skipped, notification.ID)
+			continue
+		}
+		title, err := s.novelTitle(ctx, notification.NovelID, titles)
+		if err != nil {
+			return false, err
+		}
+		included = append(included, notification.ID)
+		items = append(items, email.DigestItem{
+			Message:    notification.Message,
+			NovelTitle: title,
+			Link:       s.notificationLink(notification),
+			CreatedAt:  notification.CreatedAt,
+		})
+	}
+	if err := s.notificationRepo.SetEmailStatus(ctx, skipped, domain.NotificationEmailSkipped); err != nil {
+		return false, err
+	}
+	if len(included) == 0 {
+		return false, nil
+	}
+
+	recipient, err := s.recipient(ctx, userID)
+	if err != nil || recipient == nil {
+		if err == nil {
+			err = s.notificationRepo.SetEmailStatus(ctx, included, domain.NotificationEmailSkipped)
+		}
+		return false, err
+	}
+
+	msg, err := s.digestMessage(recipient, userID, items)
+	if err == nil {
+		err = s.sender.Send(ctx, msg)
+	}
+	if err != nil {
+		log.Printf("Warning: failed to email notification digest of user %s: %v", userID, err)
+		return false, s.notificationRepo.FailEmails(ctx, included, domain.NotificationEmailDigest, s.opts.MaxAttempts, err.Error())
+	}
+	return true, s.notificationRepo.SetEmailStatus(ctx, included, domain.NotificationEmailSent)
+}
+
+// notificationMessage renders the email of a notification with its unsubscribe links.
+func (s *NotificationEmailService) notificationMessage(
+	ctx context.Context,
+	recipient *domain.User,
+	notification *domain.Notification,
+	titles map[uuid.UUID]string,
+) (*email.Message, error) {
+	title, err := s.novelTitle(ctx, notification.NovelID, titles)
+	if err != nil {
+		return nil, err
+	}
+	unsubscribeURL, err := s.unsubscribeURL(email.UnsubscribeScope{UserID: notification.UserID, Type: notification.Type})
+	if err != nil {
+		return nil, err
+	}
+	var muteNovelURL string
+	if notification.NovelID != nil {
+		if muteNovelURL, err = s.unsubscribeURL(email.UnsubscribeScope{UserID: notification.UserID, NovelID: notification.NovelID}); err != nil {
+			return nil, err
+		}
+	}
+
+	msg, err := email.RenderNotification(notification.Type, email.NotificationData{
+		RecipientName:  recipientName(recipient),
+		Message:        notification.Message,
+		NovelTitle:     title,
+		Link:           s.notificationLink(notification),
+		UnsubscribeURL: unsubscribeURL,
+		MuteNovelURL:   muteNovelURL,
+		PreferencesURL: s.opts.AppURL + "/settings/notifications",
+	})
+	if err != nil {
+		return nil, err
+	}
+	msg.To = recipient.Email
+	msg.Headers = unsubscribeHeaders(unsubscribeURL)
+	return msg, nil
+}
+
+// digestMessage renders a digest email; its unsubscribe link turns off all emails.
+func (s *NotificationEmailService) digestMessage(recipient *domain.User, userID uuid.UUID, items []email.DigestItem) (*email.Message, error) {
+	unsubscribeURL, err := s.unsubscribeURL(email.UnsubscribeScope{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	msg, err := email.RenderDigest(email.DigestData{
+		RecipientName:  recipientName(recipient),
+		Items:          items,
+		UnsubscribeURL: unsubscribeURL,
+		PreferencesURL: s.opts.AppURL + "/settings/notifications",
+	})
+	if err != nil {
+		return nil, err
+	}
+	msg.To = recipient.Email
+	msg.Headers = unsubscribeHeaders(unsubscribeURL)
+	return msg, nil
+}
+
+// emailMode resolves the recipient's email mode of a notification.
+func (s *NotificationEmailService) emailMode(ctx context.Context, notification *domain.Notification) (domain.NotificationEmailMode, error) {
+	mode, err := s.preferenceRepo.Resolve(ctx, notification.UserID, notification.NovelID, notification.Type)
+	if errors.Is(err, repository.ErrNotificationPreferenceNotFound) {
+		return s.opts.DefaultMode, nil
+	}
+	return mode, err
+}
+
+// recipient returns the user to email, or nil when the user is gone or has no address.
+func (s *NotificationEmailService) recipient(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID.String())
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(user.Email) == "" {
+		return nil, nil
+	}
+	return user, nil
+}
+
+// novelTitle returns the title of a novel, caching it in titles for the run.
+func (s *NotificationEmailService) novelTitle(ctx context.Context, novelID *uuid.UUID, titles map[uuid.UUID]string) (string, error) {
+	if novelID == nil {
+		return "", nil
+	}
+	if title, ok := titles[*novelID]; ok {
+		return title, nil
+	}
+	novel, err := s.novelRepo.GetByID(ctx, *novelID)
+	if err != nil && !errors.Is(err, repository.ErrNovelNotFound) {
+		return "", err
+	}
+	title := "your novel"
+	if err == nil {
+		title = novel.Title
+	}
+	titles[*novelID] = title
+	return title, nil
+}
+
+// notificationLink opens what a notification is about in the app.
+func (s *NotificationEmailService) notificationLink(notification *domain.Notification) string {
+	if notification.NovelID == nil {
+		return s.opts.AppURL + "/notifications"
+	}
+	novel := s.opts.AppURL + "/novels/" + notification.NovelID.String()
+	switch notification.SubjectType {
+	case "chapter":
+		return novel + "/chapters/" + notification.SubjectID.String()
+	case "comment":
+		return novel + "/comments/" + notification.SubjectID.String()
+	case "ai_suggestion":
+		return novel + "/suggestions/" + notification.SubjectID.String()
+	}
+	return novel
+}
+
+// unsubscribeURL returns the signed unsubscribe link of a scope.
+func (s *NotificationEmailService) unsubscribeURL(scope email.UnsubscribeScope) (string, error) {
+	token, err := s.signer.Sign(scope)
+	if err != nil {
+		return "", err
+	}
+	return s.opts.PublicAPIURL + "/notifications/unsubscribe?token=" + url.QueryEscape(token), nil
+}
+
+// validateScope checks the scope of a preference of the actor.
+func (s *NotificationEmailService) validateScope(
+	ctx context.Context,
+	actorID uuid.UUID,
+	novelID *uuid.UUID,
+	notificationType *domain.NotificationType,
+) error {
+	if notificationType != nil && !notificationType.Valid() {
+		return fmt.Errorf("%w: unknown notification type %q", ErrInvalidNotificationPreference, *notificationType)
+	}
+	if novelID != nil {
+		if _, err := s.access.Require(ctx, *novelID, actorID, domain.PermissionView); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unsubscribeHeaders lets mail clients offer one-click unsubscribing (RFC 8058).
+func unsubscribeHeaders(unsubscribeURL string) map[string]string {
+	return map[string]string{
+		"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+}
+
+// recipientName returns how an email greets its recipient.
+func recipientName(user *domain.User) string {
+	if name := strings.TrimSpace(user.FullName); name != "" {
+		return name
+	}
+	return "there"
+}
+
+// digestCutoff returns the last digest hour at or before now.
+func digestCutoff(now time.Time, hour int) time.Time {
+	now = now.UTC()
+	cutoff := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if cutoff.After(now) {
+		cutoff = cutoff.AddDate(0, 0, -1)
+	}
+	return cutoff
+}
@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/mq"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// ErrInvalidNotificationQuery is returned for malformed notification listings.
+var ErrInvalidNotificationQuery = errors.New("invalid notification query")
+
+const (
+	defaultNotificationLimit = 20
+	maxNotificationLimit     = 100
+)
+
+// NotificationService keeps the users' notification inboxes. The notifier turns domain
+// events into notifications with the Notify methods; events may be delivered more than
+// once, so each notification is keyed by the event it comes from and stored at most
+// once per user. Users only ever see their own notifications, which are also pushed to
+// them as they arrive.
+type NotificationService struct {
+	notificationRepo repository.NotificationRepository
+	commentRepo      repository.CommentRepository
+	collaboratorRepo repository.CollaboratorRepository
+	novelRepo        repository.NovelRepository
+	chapterRepo      repository.ChapterRepository
+	userRepo         repository.UserRepository
+	live             *LiveEventService
+}
+
+func NewNotificationService(
+	notificationRepo repository.NotificationRepository,
+	commentRepo repository.CommentRepository,
+	collaboratorRepo repository.CollaboratorRepository,
+	novelRepo repository.NovelRepository,
+	chapterRepo repository.ChapterRepository,
+	userRepo repository.UserRepository,
+	live *LiveEventService) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		commentRepo:      commentRepo,
+		collaboratorRepo: collaboratorRepo,
+		novelRepo:        novelRepo,
+		chapterRepo:      chapterRepo,
+		userRepo:         userRepo,
+		live:             live,
+	}
+}
+
+// NotificationQuery selects a page of the inbox. Before is the createdAt of the last
+// notification of the previous page.
+type NotificationQuery struct {
+	UnreadOnly bool
+	Before     *time.Time
+	Limit      int // 0 for the default
+}
+
+// ListNotifications returns a page of the actor's notifications, newest first.
+func (s *NotificationService) ListNotifications(
+	ctx context.Context,
+	actorID uuid.UUID,
+	query NotificationQuery,
+) ([]*domain.Notification, error) {
+	switch {
+	case query.Limit == 0:
+		query.Limit = defaultNotificationLimit
+	case query.Limit < 0 || query.Limit > maxNotificationLimit:
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidNotificationQuery, maxNotificationLimit)
+	}
+	return s.notificationRepo.ListByUser(ctx, actorID, repository.NotificationFilter{
+		UnreadOnly: query.UnreadOnly,
+		Before:     query.Before,
+		Limit:      query.Limit,
+	})
+}
+
+// MarkRead marks one of the actor's notifications read.
+func (s *NotificationService) MarkRead(ctx context.Context, actorID, notificationID uuid.UUID) (*domain.Notification, error) {
+	return s.notificationRepo.MarkRead(ctx, actorID, notificationID)
+}
+
+// MarkAllRead marks all of the actor's notifications read and returns how many were unread.
+func (s *NotificationService) MarkAllRead(ctx context.Context, actorID uuid.UUID) (int64, error) {
+	return s.notificationRepo.MarkAllRead(ctx, actorID)
+}
+
+// UnreadCount returns the number of the actor's unread notifications.
+func (s *NotificationService) UnreadCount(ctx context.Context, actorID uuid.UUID) (int, error) {
+	return s.notificationRepo.CountUnread(ctx, actorID)
+}
+
+// NotifyCommentCreated notifies the members of the novel mentioned in a comment, as
+// "@Full Name" or "@email", and, for a reply, the other participants of its thread.
+// A user mentioned in a reply gets the mention only.
+func (s *NotificationService) NotifyCommentCreated(ctx context.Context, event mq.CommentCreatedEvent) (int, error) {
+	comment, err := s.commentRepo.GetByID(ctx, event.CommentID)
+	if err != nil {
+		return 0, err
+	}
+	members, err := s.members(ctx, comment.NovelID)
+	if err != nil {
+		return 0, err
+	}
+
+	actor := s.userName(ctx, comment.UserID)
+	base := domain.Notification{
+		NovelID:     &comment.NovelID,
+		ActorUserID: &comment.UserID,
+		SubjectType: "comment",
+		SubjectID:   comment.ID,
+		Data: map[string]any{
+			"commentId":  comment.ID,
+			"targetType": comment.TargetType,
+			"targetId":   comment.TargetID,
+		},
+		DedupeKey: "comment:" + comment.ID.String(),
+	}
+	if comment.ParentCommentID != nil {
+		base.Data["threadId"] = *comment.ParentCommentID
+	}
+
+	mention := base
+	mention.Type = domain.NotificationMention
+	mention.Message = actor + " mentioned you in a comment"
+	notified, err := s.notify(ctx, findUserMentions(members, comment.Content), &mention)
+	if err != nil || comment.ParentCommentID == nil {
+		return notified, err
+	}
+
+	participants, err := s.threadParticipants(ctx, comment)
+	if err != nil {
+		return notified, err
+	}
+	reply := base
+	reply.Type = domain.NotificationCommentReply
+	reply.Message = actor + " replied to a thread you are in"
+	// Mentioned users already have a notification under the same key
+	replied, err := s.notify(ctx, participants, &reply)
+	return notified + replied, err
+}
+
+// NotifyCollaboratorChanged notifies a user added to a novel. Role changes and removals
+// are not notified. eventID identifies the event, so a user invited again is notified
+// again.
+func (s *NotificationService) NotifyCollaboratorChanged(
+	ctx context.Context,
+	event mq.CollaboratorChangedEvent,
+	eventID string,
+) (int, error) {
+	if !event.Added || event.Removed {
+		return 0, nil
+	}
+	novel, err := s.novelRepo.GetByID(ctx, event.NovelID)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.notify(ctx, []uuid.UUID{event.UserID}, &domain.Notification{
+		NovelID:     &event.NovelID,
+		ActorUserID: &event.ActorID,
+		Type:        domain.NotificationCollaboratorInvite,
+		SubjectType: "novel",
+		SubjectID:   event.NovelID,
+		Message:     fmt.Sprintf("%s added you to %q as %s", s.userName(ctx, event.ActorID), novel.Title, event.Role),
+		Data:        map[string]any{"novelId": event.NovelID, "role": event.Role},
+		DedupeKey:   eventKey("collaborator_invite", event.NovelID, eventID),
+	})
+}
+
+// NotifySuggestionGenerated tells the requester that a suggestion is ready. Variants
+// requested together make a single notification, about the first one generated.
+func (s *NotificationService) NotifySuggestionGenerated(ctx context.Context, event mq.AISuggestionGeneratedEvent) (int, error) {
+	subjectID := event.SuggestionID
+	message := fmt.Sprintf("Your %s suggestion is ready", strings.ReplaceAll(event.Type, "_", " "))
+	if event.VariantGroupID != nil {
+		subjectID = *event.VariantGroupID
+		message = fmt.Sprintf("Your %s suggestions are ready", strings.ReplaceAll(event.Type, "_", " "))
+	}
+
+	return s.notify(ctx, []uuid.UUID{event.UserID}, &domain.Notification{
+		NovelID:     &event.NovelID,
+		Type:        domain.NotificationAISuggestionGenerated,
+		SubjectType: "ai_suggestion",
+		SubjectID:   subjectID,
+		Message:     message,
+		Data:        map[string]any{"suggestionId": event.SuggestionID, "type": event.Type},
+		DedupeKey:   "ai_suggestion:" + subjectID.String(),
+	})
+}
+
+// NotifyChapterPublished notifies the owner and collaborators of the novel, except the
+// publisher. eventID identifies the event, so a chapter published again is notified
+// again.
+func (s *NotificationService) NotifyChapterPublished(
+	ctx context.Context,
+	event mq.ChapterPublishedEvent,
+	eventID string,
+) (int, error) {
+	chapter, err := s.chapterRepo.GetByID(ctx, event.ChapterID)
+	if err != nil {
+		return 0, err
+	}
+	novel, err := s.novelRepo.GetByID(ctx, event.NovelID)
+	if err != nil {
+		return 0, err
+	}
+	members, err := s.members(ctx, event.NovelID)
+	if err != nil {
+		return 0, err
+	}
+
+	// The publisher is the notification's actor, so notify skips them
+	recipients := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		if memberID, err := uuid.Parse(member.ID); err == nil {
+			recipients = append(recipients, memberID)
+		}
+	}
+	return s.notify(ctx, recipients, &domain.Notification{
+		NovelID:     &event.NovelID,
+		ActorUserID: &event.PublisherID,
+		Type:        domain.NotificationChapterPublished,
+		SubjectType: "chapter",
+		SubjectID:   event.ChapterID,
+		Message:     fmt.Sprintf("%s published %q in %q", s.userName(ctx, event.PublisherID), chapter.Title, novel.Title),
+		Data:        map[string]any{"chapterId": event.ChapterID, "novelId": event.NovelID},
+		DedupeKey:   eventKey("chapter_published", event.ChapterID, eventID),
+	})
+}
+
+// notify stores a copy of notification for each recipient other than its actor and
+// returns how many were new.
+func (s *NotificationService) notify(ctx context.Context, recipients []uuid.UUID, notification *domain.Notification) (int, error) {
+	created := 0
+	seen := make(map[uuid.UUID]bool, len(recipients))
+	for _, userID := range recipients {
+		if seen[userID] || (notification.ActorUserID != nil && userID == *notification.ActorUserID) {
+			continue
+		}
+		seen[userID] = true
+
+		entry := *notification
+		entry.UserID = userID
+		ok, err := s.notificationRepo.Create(ctx, &entry)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+			s.live.NotificationCreated(ctx, &entry)
+		}
+	}
+	return created, nil
+}
+
+// members returns the owner and the collaborators of a novel.
+func (s *NotificationService) members(ctx context.Context, novelID uuid.UUID) ([]*domain.User, error) {
+	novel, err := s.novelRepo.GetByID(ctx, novelID)
+	if err != nil {
+		return nil, err
+	}
+	collaborators, err := s.collaboratorRepo.ListByNovelID(ctx, novelID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{novel.OwnerUserID}
+	for _, collaborator := range collaborators {
+		ids = append(ids, collaborator.UserID.String())
+	}
+
+	members := make([]*domain.User, 0, len(ids))
+	for _, id := range ids {
+		user, err := s.userRepo.FindByID(ctx, id)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, user)
+	}
+	return members, nil
+}
+
+// threadParticipants returns the authors of the thread a reply belongs to.
+func (s *NotificationService) threadParticipants(ctx context.Context, reply *domain.Comment) ([]uuid.UUID, error) {
+	comments, err := s.commentRepo.ListByTarget(ctx, repository.CommentFilter{
+		TargetType: reply.TargetType,
+		TargetID:   reply.TargetID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var participants []uuid.UUID
+	for _, comment := range comments {
+		inThread := comment.ID == *reply.ParentCommentID ||
+			(comment.ParentCommentID != nil && *comment.ParentCommentID == *reply.ParentCommentID)
+		if inThread {
+			participants = append(participants, comment.UserID)
+		}
+	}
+	return participants, nil
+}
+
+// userName returns how a user is named in messages.
+func (s *NotificationService) userName(ctx context.Context, userID uuid.UUID) string {
+	user, err := s.userRepo.FindByID(ctx, userID.String())
+	switch {
+	case err != nil:
+		return "Someone"
+	case strings.TrimSpace(user.FullName) != "":
+		return strings.TrimSpace(user.FullName)
+	}
+	return user.Email
+}
+
+// eventKey is the dedupe key of a notification made from a single outbox event.
+func eventKey(kind string, subjectID uuid.UUID, eventID string) string {
+	return kind + ":" + subjectID.String() + ":" + eventID
+}
+
+// findUserMentions returns the IDs of the users mentioned in text as "@" followed by
+// their full name or email, case-insensitively and as whole words.
+func findUserMentions(users []*domain.User, text string) []uuid.UUID {
+	type term struct {
+		userID uuid.UUID
+		runes  []rune
+	}
+
+	var terms []term
+	for _, user := range users {
+		userID, err := uuid.Parse(user.ID)
+		if err != nil {
+			continue
+		}
+		for _, name := range []string{user.FullName, user.Email} {
+			if name = strings.TrimSpace(name); name != "" {
+				terms = append(terms, term{userID: userID, runes: []rune("@" + strings.ToLower(name))})
+			}
+		}
+	}
+
+	lower := []rune(strings.ToLower(text))
+	var mentioned []uuid.UUID
+	for _, t := range terms {
+		n := len(t.runes)
+		for i := 0; i+n <= len(lower); i++ {
+			// "@bob" does not mention Bob in "@bob@example.com"
+			if runesEqual(lower[i:i+n], t.runes) && isWordBoundary(lower, i, i+n) && (i+n == len(lower) || lower[i+n] != '@') {
+				mentioned = append(mentioned, t.userID)
+				break
+			}
+		}
+	}
+	return mentioned
+}
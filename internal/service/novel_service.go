@@ -10,19 +10,16 @@ import (
 )
 
 type NovelService struct {
-	novelRepo     repository.NovelRepository
-	chapterRepo   repository.ChapterRepository
-	characterRepo repository.CharacterRepository
+	novelRepo   repository.NovelRepository
+	chapterRepo repository.ChapterRepository
 }
 
 func NewNovelService(
 	novelRepo repository.NovelRepository,
-	chapterRepo repository.ChapterRepository,
-	characterRepo repository.CharacterRepository) *NovelService {
+	chapterRepo repository.ChapterRepository) *NovelService {
 	return &NovelService{
-		novelRepo:     novelRepo,
-		chapterRepo:   chapterRepo,
-		characterRepo: characterRepo,
+		novelRepo:   novelRepo,
+		chapterRepo: chapterRepo,
 	}
 }
 
@@ -119,21 +116,3 @@ func (s *NovelService) AddChapterToNovel(
 
 	return s.chapterRepo.Create(ctx, chapter)
 }
-
-// CreateCharacter creates a new character and associates it with a novel
-func (s *NovelService) CreateCharacter(
-	ctx context.Context,
-	novelID uuid.UUID,
-	character *domain.Character,
-) (*domain.Character, error) {
-	// Verify the novel exists
-	_, err := s.novelRepo.GetByID(ctx, novelID)
-	if err != nil {
-		return nil, fmt.Errorf("novel not found: %w", err)
-	}
-
-	character.NovelID = novelID
-	character.Source = "user"
-
-	return s.characterRepo.Create(ctx, character)
-}
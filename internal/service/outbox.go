@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// recordEvent adds a domain event to the outbox. Call it inside the transaction of the
+// change the event describes, so the event is published if and only if it commits.
+func recordEvent(
+	ctx context.Context,
+	outboxRepo repository.OutboxRepository,
+	aggregateType string,
+	aggregateID uuid.UUID,
+	topic string,
+	payload any,
+) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", topic, err)
+	}
+	return outboxRepo.Add(ctx, &domain.OutboxEvent{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Topic:         topic,
+		Payload:       body,
+	})
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// ErrInvalidPrivateNote is returned for malformed private note input.
+var ErrInvalidPrivateNote = errors.New("invalid private note")
+
+// PrivateNoteService manages a user's personal notes. Notes are only ever read or
+// changed by their author; no role (novel owner included) grants access to them.
+type PrivateNoteService struct {
+	noteRepo repository.PrivateNoteRepository
+	access   *AccessService
+}
+
+func NewPrivateNoteService(
+	noteRepo repository.PrivateNoteRepository,
+	access *AccessService) *PrivateNoteService {
+	return &PrivateNoteService{
+		noteRepo: noteRepo,
+		access:   access,
+	}
+}
+
+// PrivateNoteInput holds the editable fields of a private note.
+type PrivateNoteInput struct {
+	NovelID *uuid.UUID
+	Title   string
+	Content string
+}
+
+// CreateNote stores a note for the actor. A novel context requires view access to the novel.
+func (s *PrivateNoteService) CreateNote(ctx context.Context, actorID uuid.UUID, input PrivateNoteInput) (*domain.PrivateNote, error) {
+	note, err := s.buildNote(ctx, actorID, input)
+	if err != nil {
+		return nil, err
+	}
+	return s.noteRepo.Create(ctx, note)
+}
+
+// GetNote returns one of the actor's notes.
+func (s *PrivateNoteService) GetNote(ctx context.Context, actorID, noteID uuid.UUID) (*domain.PrivateNote, error) {
+	return s.noteRepo.GetByID(ctx, actorID, noteID)
+}
+
+// UpdateNote replaces the fields of one of the actor's notes.
+func (s *PrivateNoteService) UpdateNote(
+	ctx context.Context,
+	actorID, noteID uuid.UUID,
+	input PrivateNoteInput,
+) (*domain.PrivateNote, error) {
+	note, err := s.buildNote(ctx, actorID, input)
+	if err != nil {
+		return nil, err
+	}
+	note.ID = noteID
+	return s.noteRepo.Update(ctx, note)
+}
+
+// DeleteNote removes one of the actor's notes.
+func (s *PrivateNoteService) DeleteNote(ctx context.Context, actorID, noteID uuid.UUID) error {
+	return s.noteRepo.Delete(ctx, actorID, noteID)
+}
+
+// ListNotes returns the actor's notes, optionally within a novel and matching a search query.
+func (s *PrivateNoteService) ListNotes(
+	ctx context.Context,
+	actorID uuid.UUID,
+	novelID *uuid.UUID,
+	query string,
+) ([]*domain.PrivateNote, error) {
+	return s.noteRepo.List(ctx, repository.PrivateNoteFilter{
+		UserID:  actorID,
+		NovelID: novelID,
+		Query:   strings.TrimSpace(query),
+	})
+}
+
+func (s *PrivateNoteService) buildNote(ctx context.Context, actorID uuid.UUID, input PrivateNoteInput) (*domain.PrivateNote, error) {
+	content := strings.TrimSpace(input.Content)
+	if content == "" {
+		return nil, fmt.Errorf("%w: content cannot be empty", ErrInvalidPrivateNote)
+	}
+
+	// Notes may only reference novels the author can see
+	if input.NovelID != nil {
+		if _, err := s.access.Require(ctx, *input.NovelID, actorID, domain.PermissionView); err != nil {
+			return nil, err
+		}
+	}
+
+	return &domain.PrivateNote{
+		UserID:  actorID,
+		NovelID: input.NovelID,
+		Title:   strings.TrimSpace(input.Title),
+		Content: content,
+	}, nil
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+var (
+	ErrInvalidPromptTemplate = errors.New("invalid prompt template")
+	// ErrPromptVariableMissing is returned when rendering a template whose required
+	// variable is empty.
+	ErrPromptVariableMissing = errors.New("required prompt variable is empty")
+)
+
+// PromptTemplateService manages the versioned prompt templates of the AI worker. Global
+// templates are managed by administrators, a novel's overrides by its managers.
+type PromptTemplateService struct {
+	transactor   repository.Transactor
+	templateRepo repository.PromptTemplateRepository
+	access       *AccessService
+}
+
+func NewPromptTemplateService(
+	transactor repository.Transactor,
+	templateRepo repository.PromptTemplateRepository,
+	access *AccessService) *PromptTemplateService {
+	return &PromptTemplateService{
+		transactor:   transactor,
+		templateRepo: templateRepo,
+		access:       access,
+	}
+}
+
+// PromptTemplateInput describes a new template version.
+type PromptTemplateInput struct {
+	NovelID           *uuid.UUID // Nil for a global template
+	Type              domain.AISuggestionType
+	SystemPrompt      string
+	UserPrompt        string
+	RequiredVariables []string
+	Notes             string
+	Activate          bool // Make it the active version right away
+}
+
+// CreateTemplate validates and stores a new version of a template.
+func (s *PromptTemplateService) CreateTemplate(ctx context.Context, actorID uuid.UUID, input PromptTemplateInput) (*domain.PromptTemplate, error) {
+	if err := s.authorize(ctx, actorID, input.NovelID, domain.PermissionManage); err != nil {
+		return nil, err
+	}
+
+	template := &domain.PromptTemplate{
+		SuggestionType:    input.Type,
+		NovelID:           input.NovelID,
+		SystemPrompt:      strings.TrimSpace(input.SystemPrompt),
+		UserPrompt:        strings.TrimSpace(input.UserPrompt),
+		RequiredVariables: input.RequiredVariables,
+		Notes:             strings.TrimSpace(input.Notes),
+		CreatedByUserID:   &actorID,
+	}
+	if err := validatePromptTemplate(template); err != nil {
+		return nil, err
+	}
+
+	var created *domain.PromptTemplate
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = s.templateRepo.Create(ctx, template); err != nil {
+			return err
+		}
+		if input.Activate {
+			created, err = s.templateRepo.Activate(ctx, created.ID)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetTemplate returns a template version.
+func (s *PromptTemplateService) GetTemplate(ctx context.Context, actorID, id uuid.UUID) (*domain.PromptTemplate, error) {
+	template, err := s.templateRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, actorID, template.NovelID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// ListTemplates returns the versions of the global templates or of a novel's overrides.
+func (s *PromptTemplateService) ListTemplates(
+	ctx context.Context,
+	actorID uuid.UUID,
+	filter repository.PromptTemplateFilter,
+) ([]*domain.PromptTemplate, error) {
+	if filter.Type != "" && !filter.Type.Valid() {
+		return nil, fmt.Errorf("%w: unsupported type %q", ErrInvalidPromptTemplate, filter.Type)
+	}
+	if err := s.authorize(ctx, actorID, filter.NovelID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+	return s.templateRepo.List(ctx, filter)
+}
+
+// EffectiveTemplates returns the template each suggestion type of the novel uses.
+func (s *PromptTemplateService) EffectiveTemplates(ctx context.Context, actorID, novelID uuid.UUID) ([]*domain.PromptTemplate, error) {
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+
+	templates := []*domain.PromptTemplate{}
+	for _, suggestionType := range domain.AISuggestionTypes {
+		template, err := s.templateRepo.Resolve(ctx, suggestionType, novelID)
+		if errors.Is(err, repository.ErrPromptTemplateNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	return templates, nil
+}
+
+// ActivateTemplate makes a version the active one of its type and scope, e.g. to roll
+// back to an earlier version.
+func (s *PromptTemplateService) ActivateTemplate(ctx context.Context, actorID, id uuid.UUID) (*domain.PromptTemplate, error) {
+	template, err := s.templateRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, actorID, template.NovelID, domain.PermissionManage); err != nil {
+		return nil, err
+	}
+
+	var activated *domain.PromptTemplate
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		activated, err = s.templateRepo.Activate(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return activated, nil
+}
+
+// ResetOverride deactivates a novel's override of a type, so the global template applies
+// again. Its versions are kept.
+func (s *PromptTemplateService) ResetOverride(
+	ctx context.Context,
+	actorID, novelID uuid.UUID,
+	suggestionType domain.AISuggestionType,
+) error {
+	if !suggestionType.Valid() {
+		return fmt.Errorf("%w: unsupported type %q", ErrInvalidPromptTemplate, suggestionType)
+	}
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionManage); err != nil {
+		return err
+	}
+	return s.templateRepo.Deactivate(ctx, suggestionType, &novelID)
+}
+
+// authorize checks access to a template scope: global templates are readable by every
+// user and writable by administrators, novel overrides follow the novel's permissions.
+func (s *PromptTemplateService) authorize(ctx context.Context, actorID uuid.UUID, novelID *uuid.UUID, permission domain.Permission) error {
+	if novelID != nil {
+		_, err := s.access.Require(ctx, *novelID, actorID, permission)
+		return err
+	}
+	if permission == domain.PermissionView {
+		return nil
+	}
+	return s.access.RequireAdmin(ctx, actorID)
+}
+
+// validatePromptTemplate checks that a template parses and only uses known variables.
+func validatePromptTemplate(t *domain.PromptTemplate) error {
+	if !t.SuggestionType.Valid() {
+		return fmt.Errorf("%w: unsupported type %q", ErrInvalidPromptTemplate, t.SuggestionType)
+	}
+	if t.SystemPrompt == "" || t.UserPrompt == "" {
+		return fmt.Errorf("%w: system and user prompts are required", ErrInvalidPromptTemplate)
+	}
+	for _, name := range t.RequiredVariables {
+		if !slices.Contains(domain.PromptVariables, name) {
+			return fmt.Errorf("%w: unknown required variable %q", ErrInvalidPromptTemplate, name)
+		}
+	}
+
+	for _, prompt := range []struct{ name, text string }{{"system", t.SystemPrompt}, {"user", t.UserPrompt}} {
+		parsed, err := parsePrompt(prompt.name, prompt.text)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPromptTemplate, err)
+		}
+		for _, name := range promptFields(parsed.Tree.Root) {
+			if !slices.Contains(domain.PromptVariables, name) {
+				return fmt.Errorf("%w: %s prompt uses unknown variable %q (available: %s)",
+					ErrInvalidPromptTemplate, prompt.name, name, strings.Join(domain.PromptVariables, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// renderPrompt renders the system and user prompts of a template. Required variables
+// must not be empty in vars.
+func renderPrompt(t *domain.PromptTemplate, vars map[string]string) (system, user string, err error) {
+	for _, name := range t.RequiredVariables {
+		if strings.TrimSpace(vars[name]) == "" {
+			return "", "", fmt.Errorf("%w: %s (template v%d)", ErrPromptVariableMissing, name, t.Version)
+		}
+	}
+	return executePrompt(t, vars)
+}
+
+// executePrompt renders a template without checking its required variables.
+func executePrompt(t *domain.PromptTemplate, vars map[string]string) (system, user string, err error) {
+	data := make(map[string]string, len(domain.PromptVariables))
+	for _, name := range domain.PromptVariables {
+		data[name] = vars[name]
+	}
+
+	rendered := make([]string, 2)
+	for i, text := range []string{t.SystemPrompt, t.UserPrompt} {
+		parsed, err := parsePrompt("prompt", text)
+		if err != nil {
+			return "", "", fmt.Errorf("%w: %v", ErrInvalidPromptTemplate, err)
+		}
+		var b strings.Builder
+		if err := parsed.Execute(&b, data); err != nil {
+			return "", "", fmt.Errorf("failed to render prompt template v%d: %w", t.Version, err)
+		}
+		rendered[i] = strings.TrimSpace(b.String())
+	}
+	return rendered[0], rendered[1], nil
+}
+
+func parsePrompt(name, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Parse(text)
+}
+
+// promptFields lists the top-level fields ({{.name}}) a template references.
+func promptFields(node parse.Node) []string {
+	var fields []string
+	var walk func(parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			fields = append(fields, n.Ident[0])
+		case *parse.ChainNode:
+			walk(n.Node)
+		case *parse.IfNode:
+			walk(&n.BranchNode)
+		case *parse.RangeNode:
+			walk(&n.BranchNode)
+		case *parse.WithNode:
+			walk(&n.BranchNode)
+		case *parse.BranchNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		}
+	}
+	walk(node)
+	return fields
+}
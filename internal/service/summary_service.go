@@ -0,0 +1,494 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/mq"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+const (
+	defaultChaptersPerAct = 5
+	// summarySweepBatch bounds the chapters summarized and the novels refreshed per sweep.
+	summarySweepBatch = 20
+)
+
+// Instructions of the automatic summary suggestions; the text to summarize is their
+// selected text.
+const (
+	chapterSummaryInstructions = "This is the chapter %q. Summarize it in one paragraph of at most 150 words: " +
+		"what happens, who takes part and what changes for them. Use the present tense and add no commentary."
+	actSynopsisInstructions = "These are the summaries of the chapters of act %d. Write a synopsis of the act " +
+		"in at most 250 words, keeping its turning points and how the characters change."
+	bookSynopsisInstructions = "These are the synopses of the acts of the novel. Write a synopsis of the whole " +
+		"story in at most 400 words, keeping the main plot lines and character arcs."
+)
+
+// SummaryOptions tunes automatic summaries.
+type SummaryOptions struct {
+	StableAfter    time.Duration // How long a chapter must go unedited before it is summarized
+	RetryAfter     time.Duration // Wait before a failed summary or synopsis is generated again
+	ChaptersPerAct int           // Consecutive chapters making up an act of the synopsis; 5 when 0
+}
+
+// SummaryService keeps chapter summaries and the hierarchical synopsis of each novel up
+// to date. A chapter is summarized once its content has been left alone for
+// StableAfter; an act's synopsis summarizes the summaries of its chapters and the
+// book's synopsis those of its acts. Each is regenerated only when its input changed.
+// Generation happens in the AI worker through automatic summary suggestions, which
+// AIGenerationService applies itself.
+type SummaryService struct {
+	transactor     repository.Transactor
+	summaryRepo    repository.SummaryRepository
+	suggestionRepo repository.AISuggestionRepository
+	outboxRepo     repository.OutboxRepository
+	novelRepo      repository.NovelRepository
+	chapterRepo    repository.ChapterRepository
+	usage          *AIUsageService
+	access         *AccessService
+	options        SummaryOptions
+}
+
+func NewSummaryService(
+	transactor repository.Transactor,
+	summaryRepo repository.SummaryRepository,
+	suggestionRepo repository.AISuggestionRepository,
+	outboxRepo repository.OutboxRepository,
+	novelRepo repository.NovelRepository,
+	chapterRepo repository.ChapterRepository,
+	usage *AIUsageService,
+	access *AccessService,
+	options SummaryOptions) *SummaryService {
+	if options.ChaptersPerAct <= 0 {
+		options.ChaptersPerAct = defaultChaptersPerAct
+	}
+	return &SummaryService{
+		transactor:     transactor,
+		summaryRepo:    summaryRepo,
+		suggestionRepo: suggestionRepo,
+		outboxRepo:     outboxRepo,
+		novelRepo:      novelRepo,
+		chapterRepo:    chapterRepo,
+		usage:          usage,
+		access:         access,
+		options:        options,
+	}
+}
+
+// NovelSynopsis is the synopsis of a novel with those of its acts and the summaries of
+// their chapters. Stale parts are being brought up to date.
+type NovelSynopsis struct {
+	NovelID     uuid.UUID      `json:"novelId"`
+	Synopsis    string         `json:"synopsis"` // Whole book; empty until generated
+	GeneratedAt *time.Time     `json:"generatedAt,omitempty"`
+	Stale       bool           `json:"stale"`
+	Acts        []*SynopsisAct `json:"acts"`
+}
+
+// SynopsisAct is the synopsis of consecutive chapters of a novel.
+type SynopsisAct struct {
+	Act         int                `json:"act"`
+	Synopsis    string             `json:"synopsis"`
+	GeneratedAt *time.Time         `json:"generatedAt,omitempty"`
+	Stale       bool               `json:"stale"`
+	Chapters    []*SynopsisChapter `json:"chapters"`
+}
+
+// SynopsisChapter is the summary of a chapter. It is stale when the chapter changed
+// since it was summarized, or has not been summarized yet.
+type SynopsisChapter struct {
+	ChapterID    string     `json:"chapterId"`
+	Title        string     `json:"title"`
+	OrderIndex   int        `json:"orderIndex"`
+	Summary      string     `json:"summary,omitempty"`
+	SummarizedAt *time.Time `json:"summarizedAt,omitempty"`
+	Stale        bool       `json:"stale"`
+}
+
+// GetSynopsis returns the synopsis of a novel the actor can view.
+func (s *SummaryService) GetSynopsis(ctx context.Context, actorID, novelID uuid.UUID) (*NovelSynopsis, error) {
+	if _, err := s.access.Require(ctx, novelID, actorID, domain.PermissionView); err != nil {
+		return nil, err
+	}
+	outline, err := s.outline(ctx, novelID)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &NovelSynopsis{NovelID: novelID, Acts: []*SynopsisAct{}}
+	for _, act := range outline.acts {
+		input := act.input()
+		synopsis := outline.synopsis(novelID, act.number)
+		actView := &SynopsisAct{
+			Act:         act.number,
+			Synopsis:    synopsis.Synopsis,
+			GeneratedAt: synopsis.GeneratedAt,
+			Stale:       input.stale(synopsis),
+			Chapters:    make([]*SynopsisChapter, 0, len(act.chapters)),
+		}
+		for _, c := range act.chapters {
+			chapterView := &SynopsisChapter{
+				ChapterID:  c.chapter.ID,
+				Title:      c.chapter.Title,
+				OrderIndex: c.chapter.OrderIndex,
+				Stale:      !c.current(),
+			}
+			if c.summary != nil {
+				chapterView.Summary, chapterView.SummarizedAt = c.summary.Summary, &c.summary.GeneratedAt
+			}
+			actView.Chapters = append(actView.Chapters, chapterView)
+		}
+		view.Acts = append(view.Acts, actView)
+	}
+
+	book := outline.synopsis(novelID, domain.SynopsisBook)
+	input := outline.book(novelID)
+	view.Synopsis, view.GeneratedAt = book.Synopsis, book.GeneratedAt
+	view.Stale = input.stale(book)
+	return view, nil
+}
+
+// Sweep enqueues the summaries of chapters that became stable and brings the synopses
+// of novels with new summaries up to date. It returns the number of generations
+// enqueued. A single process sweeps at a time; the others return at once.
+func (s *SummaryService) Sweep(ctx context.Context) (int, error) {
+	enqueued := 0
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		locked, err := s.summaryRepo.LockSweep(ctx)
+		if err != nil || !locked {
+			return err
+		}
+
+		now := time.Now()
+		chapters, err := s.summaryRepo.ListChaptersToSummarize(ctx,
+			now.Add(-s.options.StableAfter), now.Add(-s.options.RetryAfter), summarySweepBatch)
+		if err != nil {
+			return err
+		}
+		for _, chapter := range chapters {
+			suggestion, err := s.enqueue(ctx, chapter.NovelID, chapter.RequesterID, &chapter.ChapterID,
+				chapter.Content, fmt.Sprintf(chapterSummaryInstructions, chapter.Title))
+			if err != nil {
+				return err
+			}
+			if suggestion != nil {
+				enqueued++
+			}
+		}
+
+		// Novels are rechecked after RetryAfter to retry failed synopses
+		novels, err := s.summaryRepo.ListNovelsToRefresh(ctx, now.Add(-s.options.RetryAfter), summarySweepBatch)
+		if err != nil {
+			return err
+		}
+		for _, novelID := range novels {
+			n, err := s.refreshSynopsis(ctx, novelID)
+			if err != nil {
+				return err
+			}
+			enqueued += n
+		}
+		return nil
+	})
+	return enqueued, err
+}
+
+// refreshSynopsis brings the synopses of a novel's acts up to date with their chapter
+// summaries and, once all of them are, the book's. Acts with chapters still to be
+// summarized wait for them. It returns the number of generations enqueued.
+func (s *SummaryService) refreshSynopsis(ctx context.Context, novelID uuid.UUID) (int, error) {
+	novel, err := s.novelRepo.GetByID(ctx, novelID)
+	if err != nil {
+		return 0, err
+	}
+	ownerID, err := uuid.Parse(novel.OwnerUserID)
+	if err != nil {
+		return 0, fmt.Errorf("novel has invalid owner ID %q: %w", novel.OwnerUserID, err)
+	}
+	outline, err := s.outline(ctx, novelID)
+	if err != nil {
+		return 0, err
+	}
+
+	enqueued := 0
+	for _, act := range outline.acts {
+		input := act.input()
+		if input.text == "" || !input.ready {
+			continue
+		}
+		queued, err := s.refresh(ctx, outline.synopsis(novelID, act.number), input, ownerID,
+			fmt.Sprintf(actSynopsisInstructions, act.number))
+		if err != nil {
+			return 0, err
+		}
+		if queued {
+			enqueued++
+		}
+	}
+	if err := s.summaryRepo.DeleteActsAfter(ctx, novelID, len(outline.acts)); err != nil {
+		return 0, err
+	}
+
+	if input := outline.book(novelID); input.text != "" && input.ready {
+		queued, err := s.refresh(ctx, outline.synopsis(novelID, domain.SynopsisBook), input, ownerID, bookSynopsisInstructions)
+		if err != nil {
+			return 0, err
+		}
+		if queued {
+			enqueued++
+		}
+	}
+	return enqueued, s.summaryRepo.MarkChecked(ctx, novelID)
+}
+
+// refresh brings a synopsis up to date with its input: a single part is taken as it
+// is, anything else is summarized by an automatic suggestion unless one is already
+// under way or failed less than RetryAfter ago. It reports whether it enqueued one.
+func (s *SummaryService) refresh(
+	ctx context.Context,
+	synopsis *domain.Synopsis,
+	input synopsisInput,
+	requesterID uuid.UUID,
+	instructions string,
+) (bool, error) {
+	switch {
+	case input.summarizedBy(synopsis):
+		if synopsis.PendingHash == "" {
+			return false, nil
+		}
+		// The input changed back while an update was under way; let it go
+		synopsis.PendingHash = ""
+		return false, s.summaryRepo.SaveSynopsis(ctx, synopsis)
+
+	case input.single != "":
+		now := time.Now()
+		synopsis.Synopsis, synopsis.SourceHash, synopsis.GeneratedAt = input.single, input.hash, &now
+		synopsis.PendingHash, synopsis.SuggestionID = "", nil
+		return false, s.summaryRepo.SaveSynopsis(ctx, synopsis)
+
+	case synopsis.PendingHash == input.hash && synopsis.SuggestionID != nil:
+		waiting, err := s.awaiting(ctx, *synopsis.SuggestionID)
+		if err != nil || waiting {
+			return false, err
+		}
+	}
+
+	suggestion, err := s.enqueue(ctx, synopsis.NovelID, requesterID, nil, input.text, instructions)
+	if err != nil || suggestion == nil {
+		return false, err
+	}
+	synopsis.PendingHash, synopsis.SuggestionID = input.hash, &suggestion.ID
+	return true, s.summaryRepo.SaveSynopsis(ctx, synopsis)
+}
+
+// awaiting reports whether a synopsis generation is still under way, or failed too
+// recently to be retried.
+func (s *SummaryService) awaiting(ctx context.Context, suggestionID uuid.UUID) (bool, error) {
+	suggestion, err := s.suggestionRepo.GetByID(ctx, suggestionID)
+	if errors.Is(err, repository.ErrAISuggestionNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case suggestion.Status.InProgress():
+		return true, nil
+	case suggestion.Status == domain.AISuggestionFailed:
+		return suggestion.UpdatedAt.After(time.Now().Add(-s.options.RetryAfter)), nil
+	default:
+		return false, nil
+	}
+}
+
+// enqueue records an automatic summary of text and its generation job, attributed to
+// requesterID. It returns nil, enqueuing nothing, when the requester or the novel has
+// used up an AI quota; the summary is retried on a later sweep.
+func (s *SummaryService) enqueue(
+	ctx context.Context,
+	novelID, requesterID uuid.UUID,
+	chapterID *uuid.UUID,
+	text, instructions string,
+) (*domain.AISuggestion, error) {
+	if err := s.usage.CheckQuota(ctx, requesterID, novelID); err != nil {
+		if errors.Is(err, ErrAIQuotaExceeded) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// The text is stored as it is: a chapter summary's content hash is taken from it
+	suggestion, err := s.suggestionRepo.Create(ctx, &domain.AISuggestion{
+		NovelID:            novelID,
+		UserID:             requesterID,
+		Type:               domain.AISuggestionSummary,
+		Status:             domain.AISuggestionPending,
+		Automatic:          true,
+		ContextChapterID:   chapterID,
+		SelectedText:       text,
+		PromptInstructions: instructions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return suggestion, recordEvent(ctx, s.outboxRepo, domain.OutboxAggregateAISuggestion, suggestion.ID,
+		mq.TopicAISuggestionRequested, mq.AISuggestionJob{SuggestionID: suggestion.ID})
+}
+
+func (s *SummaryService) outline(ctx context.Context, novelID uuid.UUID) (*synopsisOutline, error) {
+	chapters, err := s.chapterRepo.ListByNovelID(ctx, novelID)
+	if err != nil {
+		return nil, err
+	}
+	summaries, err := s.summaryRepo.ListChapterSummaries(ctx, novelID)
+	if err != nil {
+		return nil, err
+	}
+	synopses, err := s.summaryRepo.ListSynopses(ctx, novelID)
+	if err != nil {
+		return nil, err
+	}
+	return newSynopsisOutline(chapters, summaries, synopses, s.options.ChaptersPerAct), nil
+}
+
+// synopsisOutline is a novel's chapters grouped into acts, with their summaries and
+// the stored synopses.
+type synopsisOutline struct {
+	acts     []*outlineAct
+	synopses map[int]*domain.Synopsis // By act; domain.SynopsisBook for the book
+}
+
+type outlineAct struct {
+	number   int // From 1
+	chapters []*outlineChapter
+}
+
+type outlineChapter struct {
+	chapter  *domain.Chapter
+	position int                    // From 1, in reading order
+	summary  *domain.ChapterSummary // Nil until summarized
+}
+
+// newSynopsisOutline groups chapters, in reading order, into acts of perAct chapters.
+func newSynopsisOutline(
+	chapters []*domain.Chapter,
+	summaries []*domain.ChapterSummary,
+	synopses []*domain.Synopsis,
+	perAct int,
+) *synopsisOutline {
+	byChapter := make(map[string]*domain.ChapterSummary, len(summaries))
+	for _, summary := range summaries {
+		byChapter[summary.ChapterID.String()] = summary
+	}
+	outline := &synopsisOutline{synopses: make(map[int]*domain.Synopsis, len(synopses))}
+	for _, synopsis := range synopses {
+		outline.synopses[synopsis.Act] = synopsis
+	}
+
+	for i, chapter := range chapters {
+		if i%perAct == 0 {
+			outline.acts = append(outline.acts, &outlineAct{number: i/perAct + 1})
+		}
+		act := outline.acts[len(outline.acts)-1]
+		act.chapters = append(act.chapters, &outlineChapter{chapter: chapter, position: i + 1, summary: byChapter[chapter.ID]})
+	}
+	return outline
+}
+
+// synopsis returns the stored synopsis of an act, or a new empty one.
+func (o *synopsisOutline) synopsis(novelID uuid.UUID, act int) *domain.Synopsis {
+	if synopsis, ok := o.synopses[act]; ok {
+		return synopsis
+	}
+	synopsis := &domain.Synopsis{NovelID: novelID, Act: act}
+	o.synopses[act] = synopsis
+	return synopsis
+}
+
+// book returns the input of the book's synopsis: the synopses of the acts with
+// something to summarize, ready once all of them are current.
+func (o *synopsisOutline) book(novelID uuid.UUID) synopsisInput {
+	var parts []synopsisPart
+	ready := true
+	for _, act := range o.acts {
+		input := act.input()
+		if input.text == "" {
+			continue
+		}
+		synopsis := o.synopsis(novelID, act.number)
+		ready = ready && input.ready && input.summarizedBy(synopsis)
+		parts = append(parts, synopsisPart{heading: fmt.Sprintf("Act %d", act.number), text: synopsis.Synopsis})
+	}
+	return newSynopsisInput(parts, ready)
+}
+
+// input returns the input of the act's synopsis: the summaries of its chapters, ready
+// once all chapters with content have a current one.
+func (a *outlineAct) input() synopsisInput {
+	var parts []synopsisPart
+	ready := true
+	for _, c := range a.chapters {
+		ready = ready && c.current()
+		if c.summary != nil && strings.TrimSpace(c.chapter.Content) != "" {
+			heading := fmt.Sprintf("Chapter %d, %q", c.position, c.chapter.Title)
+			parts = append(parts, synopsisPart{heading: heading, text: c.summary.Summary})
+		}
+	}
+	return newSynopsisInput(parts, ready)
+}
+
+// current reports whether the chapter's summary is up to date; empty chapters need none.
+func (c *outlineChapter) current() bool {
+	if strings.TrimSpace(c.chapter.Content) == "" {
+		return true
+	}
+	return c.summary != nil && c.summary.ContentHash == contentHash(c.chapter.Content)
+}
+
+type synopsisPart struct{ heading, text string }
+
+// synopsisInput is the text a synopsis is generated from.
+type synopsisInput struct {
+	text   string // Empty when there is nothing to summarize
+	hash   string
+	single string // The text of a single part, which is its own synopsis
+	ready  bool   // Every part is up to date
+}
+
+func newSynopsisInput(parts []synopsisPart, ready bool) synopsisInput {
+	texts := make([]string, len(parts))
+	for i, part := range parts {
+		texts[i] = part.heading + ":\n" + part.text
+	}
+	input := synopsisInput{text: strings.Join(texts, "\n\n"), ready: ready}
+	input.hash = contentHash(input.text)
+	if len(parts) == 1 {
+		input.single = parts[0].text
+	}
+	return input
+}
+
+// summarizedBy reports whether synopsis was generated from this input.
+func (in synopsisInput) summarizedBy(synopsis *domain.Synopsis) bool {
+	return synopsis.SourceHash == in.hash
+}
+
+// stale reports whether synopsis lags behind its input or the input behind its parts.
+func (in synopsisInput) stale(synopsis *domain.Synopsis) bool {
+	return !in.ready || (in.text != "" && !in.summarizedBy(synopsis))
+}
+
+// contentHash identifies summarized text. It matches md5() in the database, which
+// finds the chapters whose summary is stale.
+func contentHash(text string) string {
+	sum := md5.Sum([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,495 @@
+// File: internal/transport/http/handlers/ai_handler.go
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/mq"
+	"github.com/khaled2049/server/internal/service"
+	"github.com/khaled2049/server/internal/transport/http/request"
+)
+
+type AIHandler struct {
+	aiService        *service.AIService
+	usageService     *service.AIUsageService
+	analyticsService *service.AIAnalyticsService
+}
+
+func NewAIHandler(
+	aiService *service.AIService,
+	usageService *service.AIUsageService,
+	analyticsService *service.AIAnalyticsService) *AIHandler {
+	return &AIHandler{
+		aiService:        aiService,
+		usageService:     usageService,
+		analyticsService: analyticsService,
+	}
+}
+
+// RegisterRoutes registers AI suggestion routes on an authenticated router group.
+func (h *AIHandler) RegisterRoutes(router *gin.RouterGroup) {
+	suggestionGroup := router.Group("/ai/suggestions")
+	{
+		suggestionGroup.POST("", h.CreateSuggestionHandler)
+		suggestionGroup.GET("/:suggestionID", h.GetSuggestionHandler)
+		suggestionGroup.GET("/:suggestionID/stream", h.StreamSuggestionHandler)
+		suggestionGroup.GET("/:suggestionID/variants", h.ListVariantsHandler)
+		suggestionGroup.POST("/:suggestionID/reveal", h.RevealSuggestionHandler)
+		suggestionGroup.PUT("/:suggestionID/accept", h.AcceptSuggestionHandler)
+		suggestionGroup.PUT("/:suggestionID/reject", h.RejectSuggestionHandler)
+		suggestionGroup.POST("/:suggestionID/edit", h.EditSuggestionHandler)
+		suggestionGroup.POST("/:suggestionID/cancel", h.CancelSuggestionHandler)
+		suggestionGroup.POST("/:suggestionID/retry", h.RetrySuggestionHandler)
+	}
+
+	router.GET("/novels/:novelID/ai/suggestions", h.ListNovelSuggestionsHandler)
+	router.GET("/me/ai/usage", h.GetUsageHandler)
+	router.GET("/ai/analytics", h.GetAnalyticsHandler)
+}
+
+// CreateSuggestionHandler handles POST /ai/suggestions. Generation is asynchronous,
+// so the pending suggestion is returned with 202 Accepted, with the other variants in
+// variants when several were requested.
+func (h *AIHandler) CreateSuggestionHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.CreateAISuggestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for AI suggestion", "details": err.Error()})
+		return
+	}
+
+	suggestion, err := h.aiService.RequestSuggestion(c.Request.Context(), userID, service.SuggestionRequest{
+		NovelID:            uuid.MustParse(req.NovelID),
+		Type:               domain.AISuggestionType(req.Type),
+		ContextChapterID:   optionalUUID(req.ContextChapterID),
+		ContextCharacterID: optionalUUID(req.ContextCharacterID),
+		ContextPlaceID:     optionalUUID(req.ContextPlaceID),
+		ContextNoteID:      optionalUUID(req.ContextNoteID),
+		Instructions:       req.Instructions,
+		SelectedText:       cmp.Or(req.SelectedText, req.PromptContext),
+		Variants:           req.Variants,
+		VariantOptions:     variantOptions(req.VariantOptions),
+	})
+	if err != nil {
+		respondServiceError(c, err, "Failed to request AI suggestion")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, suggestion)
+}
+
+func variantOptions(requests []request.AISuggestionVariantRequest) []service.SuggestionVariant {
+	options := make([]service.SuggestionVariant, len(requests))
+	for i, req := range requests {
+		options[i] = service.SuggestionVariant{Model: req.Model, Temperature: req.Temperature}
+	}
+	return options
+}
+
+// GetSuggestionHandler handles GET /ai/suggestions/:suggestionID.
+func (h *AIHandler) GetSuggestionHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	suggestionID, ok := parseUUIDParam(c, "suggestionID", "suggestion")
+	if !ok {
+		return
+	}
+
+	suggestion, err := h.aiService.GetSuggestion(c.Request.Context(), userID, suggestionID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve AI suggestion")
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// ListVariantsHandler handles GET /ai/suggestions/:suggestionID/variants: every variant
+// requested together with the suggestion, itself included, in order.
+func (h *AIHandler) ListVariantsHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	suggestionID, ok := parseUUIDParam(c, "suggestionID", "suggestion")
+	if !ok {
+		return
+	}
+
+	variants, err := h.aiService.ListVariants(c.Request.Context(), userID, suggestionID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve AI suggestion variants")
+		return
+	}
+
+	c.JSON(http.StatusOK, variants)
+}
+
+// RevealSuggestionHandler handles POST /ai/suggestions/:suggestionID/reveal: the
+// suggestion with its content even if moderation flagged it. The reveal is recorded.
+func (h *AIHandler) RevealSuggestionHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	suggestionID, ok := parseUUIDParam(c, "suggestionID", "suggestion")
+	if !ok {
+		return
+	}
+
+	suggestion, err := h.aiService.RevealSuggestion(c.Request.Context(), userID, suggestionID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to reveal AI suggestion")
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// streamHeartbeat is how often an idle suggestion stream is kept alive and the stored
+// suggestion checked, in case its done event was missed.
+const streamHeartbeat = 15 * time.Second
+
+// keepStreamOpen lifts the server's read and write timeouts, which bound the whole
+// request and response and would otherwise cut a stream.
+func keepStreamOpen(c *gin.Context) {
+	controller := http.NewResponseController(c.Writer)
+	if err := controller.SetReadDeadline(time.Time{}); err != nil {
+		c.Error(err)
+	}
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		c.Error(err)
+	}
+}
+
+// StreamSuggestionHandler handles GET /ai/suggestions/:suggestionID/stream. It sends
+// Server-Sent Events: "start" when a worker starts generating (discard earlier
+// tokens), "token" for each piece of generated text, "withheld" when moderation flags
+// it, "retry" when an attempt failed and will be retried, and finally "done" with the
+// stored suggestion, whose generatedContent is authoritative, also when cancelled. A
+// client connecting after completion gets "done" right away.
+func (h *AIHandler) StreamSuggestionHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	suggestionID, ok := parseUUIDParam(c, "suggestionID", "suggestion")
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	suggestion, events, err := h.aiService.StreamSuggestion(ctx, userID, suggestionID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to stream AI suggestion")
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // Keep proxies from buffering the stream
+	if !suggestion.Status.InProgress() {
+		c.SSEvent(mq.StreamDone, suggestion)
+		return
+	}
+	keepStreamOpen(c)
+	// Send the headers now; the first event may take a while
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// Subscription lost; keep going on heartbeats
+				events = nil
+				return true
+			}
+			if event.Type != mq.StreamDone {
+				c.SSEvent(event.Type, event)
+				return true
+			}
+		case <-heartbeat.C:
+		case <-ctx.Done():
+			return false
+		}
+
+		suggestion, err := h.aiService.GetSuggestion(ctx, userID, suggestionID)
+		if err != nil {
+			if ctx.Err() == nil {
+				c.SSEvent("error", gin.H{"error": "Failed to retrieve AI suggestion", "details": err.Error()})
+			}
+			return false
+		}
+		if suggestion.Status.InProgress() {
+			fmt.Fprint(w, ": keep-alive\n\n")
+			return true
+		}
+		c.SSEvent(mq.StreamDone, suggestion)
+		return false
+	})
+}
+
+// ListNovelSuggestionsHandler handles GET /novels/:novelID/ai/suggestions?status=.
+func (h *AIHandler) ListNovelSuggestionsHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	novelID, ok := parseUUIDParam(c, "novelID", "novel")
+	if !ok {
+		return
+	}
+
+	suggestions, err := h.aiService.ListSuggestions(
+		c.Request.Context(), userID, novelID, domain.AISuggestionStatus(c.Query("status")),
+	)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve AI suggestions")
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestions)
+}
+
+// AcceptSuggestionHandler handles PUT /ai/suggestions/:suggestionID/accept, which also
+// applies the suggestion. The body is optional.
+func (h *AIHandler) AcceptSuggestionHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	suggestionID, ok := parseUUIDParam(c, "suggestionID", "suggestion")
+	if !ok {
+		return
+	}
+
+	var req request.AcceptAISuggestionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for AI suggestion review", "details": err.Error()})
+			return
+		}
+	}
+	if req.End != nil && req.Start == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end requires start"})
+		return
+	}
+
+	suggestion, err := h.aiService.AcceptSuggestion(c.Request.Context(), userID, suggestionID,
+		service.SuggestionReview{Feedback: req.Feedback, Notes: req.Notes},
+		service.SuggestionPlacement{
+			BaseRevisionID: req.BaseRevisionID,
+			Start:          req.Start,
+			End:            req.End,
+			Name:           req.Name,
+		})
+	if err != nil {
+		respondServiceError(c, err, "Failed to accept AI suggestion")
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// RejectSuggestionHandler handles PUT /ai/suggestions/:suggestionID/reject.
+func (h *AIHandler) RejectSuggestionHandler(c *gin.Context) {
+	h.reviewSuggestion(c, h.aiService.RejectSuggestion, "Failed to reject AI suggestion")
+}
+
+// EditSuggestionHandler handles POST /ai/suggestions/:suggestionID/edit.
+func (h *AIHandler) EditSuggestionHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	suggestionID, ok := parseUUIDParam(c, "suggestionID", "suggestion")
+	if !ok {
+		return
+	}
+
+	var req request.EditAISuggestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for AI suggestion edit", "details": err.Error()})
+		return
+	}
+
+	suggestion, err := h.aiService.EditSuggestion(c.Request.Context(), userID, suggestionID, req.Content, req.Notes)
+	if err != nil {
+		respondServiceError(c, err, "Failed to edit AI suggestion")
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// CancelSuggestionHandler handles POST /ai/suggestions/:suggestionID/cancel for pending
+// and generating suggestions.
+func (h *AIHandler) CancelSuggestionHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	suggestionID, ok := parseUUIDParam(c, "suggestionID", "suggestion")
+	if !ok {
+		return
+	}
+
+	suggestion, err := h.aiService.CancelSuggestion(c.Request.Context(), userID, suggestionID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to cancel AI suggestion")
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// RetrySuggestionHandler handles POST /ai/suggestions/:suggestionID/retry for failed
+// suggestions. Generation is asynchronous, so the pending suggestion is returned with
+// 202 Accepted.
+func (h *AIHandler) RetrySuggestionHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	suggestionID, ok := parseUUIDParam(c, "suggestionID", "suggestion")
+	if !ok {
+		return
+	}
+
+	suggestion, err := h.aiService.RetrySuggestion(c.Request.Context(), userID, suggestionID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retry AI suggestion")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, suggestion)
+}
+
+type reviewFunc func(ctx context.Context, actorID, suggestionID uuid.UUID, review service.SuggestionReview) (*domain.AISuggestion, error)
+
+// reviewSuggestion handles reject; the feedback body is optional.
+func (h *AIHandler) reviewSuggestion(c *gin.Context, review reviewFunc, message string) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	suggestionID, ok := parseUUIDParam(c, "suggestionID", "suggestion")
+	if !ok {
+		return
+	}
+
+	var req request.ReviewAISuggestionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for AI suggestion review", "details": err.Error()})
+			return
+		}
+	}
+
+	suggestion, err := review(c.Request.Context(), userID, suggestionID, service.SuggestionReview{
+		Feedback: req.Feedback,
+		Notes:    req.Notes,
+	})
+	if err != nil {
+		respondServiceError(c, err, message)
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// GetUsageHandler handles GET /me/ai/usage: the current user's AI consumption of the
+// day and month against the quotas.
+func (h *AIHandler) GetUsageHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.usageService.Report(c.Request.Context(), userID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve AI usage")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetAnalyticsHandler handles GET /ai/analytics?from=&to=&type=&model=&novelId=&groupBy=:
+// acceptance and edit rates, ratings, latency and token usage of the suggestions
+// requested in [from, to), broken down by the comma-separated groupBy dimensions.
+// Restricted to administrators.
+func (h *AIHandler) GetAnalyticsHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	query := service.AIAnalyticsQuery{
+		Type:  domain.AISuggestionType(c.Query("type")),
+		Model: c.Query("model"),
+	}
+	if !parseTimeQuery(c, "from", &query.From) || !parseTimeQuery(c, "to", &query.To) {
+		return
+	}
+	if raw := c.Query("novelId"); raw != "" {
+		novelID, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID", "details": err.Error()})
+			return
+		}
+		query.NovelID = &novelID
+	}
+	if raw := c.Query("groupBy"); raw != "" {
+		for _, dimension := range strings.Split(raw, ",") {
+			query.GroupBy = append(query.GroupBy, strings.TrimSpace(dimension))
+		}
+	}
+
+	report, err := h.analyticsService.Report(c.Request.Context(), userID, query)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve AI analytics")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// parseTimeQuery parses the optional query parameter name, an RFC 3339 time or a UTC
+// date (2006-01-02), into t. It responds 400 and returns false when malformed.
+func parseTimeQuery(c *gin.Context, name string, t *time.Time) bool {
+	raw := c.Query(name)
+	if raw == "" {
+		return true
+	}
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		if parsed, err = time.Parse(time.DateOnly, raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid " + name,
+				"details": "expected an RFC 3339 time or a date (YYYY-MM-DD)",
+			})
+			return false
+		}
+	}
+	*t = parsed
+	return true
+}
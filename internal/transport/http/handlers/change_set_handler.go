@@ -0,0 +1,155 @@
+// File: internal/transport/http/handlers/change_set_handler.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/service"
+	"github.com/khaled2049/server/internal/transport/http/request"
+)
+
+type ChangeSetHandler struct {
+	changeSetService *service.ChangeSetService
+}
+
+func NewChangeSetHandler(changeSetService *service.ChangeSetService) *ChangeSetHandler {
+	return &ChangeSetHandler{
+		changeSetService: changeSetService,
+	}
+}
+
+// RegisterRoutes registers suggestion-mode routes on an authenticated router group.
+func (h *ChangeSetHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/chapters/:chapterID/change-sets", h.ProposeChangeSetHandler)
+	router.GET("/chapters/:chapterID/change-sets", h.ListChangeSetsHandler)
+	router.GET("/change-sets/:changeSetID", h.GetChangeSetHandler)
+
+	changeGroup := router.Group("/chapter-changes/:changeID")
+	{
+		changeGroup.POST("/accept", h.AcceptChangeHandler)
+		changeGroup.POST("/reject", h.RejectChangeHandler)
+	}
+}
+
+// ProposeChangeSetHandler handles POST /chapters/:chapterID/change-sets.
+func (h *ChangeSetHandler) ProposeChangeSetHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	chapterID, ok := parseUUIDParam(c, "chapterID", "chapter")
+	if !ok {
+		return
+	}
+
+	var req request.ProposeChangeSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for change set", "details": err.Error()})
+		return
+	}
+
+	proposal := service.ChangeSetProposal{
+		BaseRevisionID: req.BaseRevisionID,
+		Description:    req.Description,
+		Content:        req.Content,
+	}
+	for _, change := range req.Changes {
+		proposal.Changes = append(proposal.Changes, service.ProposedChange{
+			Start: change.Start,
+			End:   change.End,
+			Text:  change.Text,
+		})
+	}
+
+	set, err := h.changeSetService.ProposeChangeSet(c.Request.Context(), userID, chapterID, proposal)
+	if err != nil {
+		respondServiceError(c, err, "Failed to propose changes")
+		return
+	}
+
+	c.JSON(http.StatusCreated, set)
+}
+
+// ListChangeSetsHandler handles GET /chapters/:chapterID/change-sets?status=.
+func (h *ChangeSetHandler) ListChangeSetsHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	chapterID, ok := parseUUIDParam(c, "chapterID", "chapter")
+	if !ok {
+		return
+	}
+
+	sets, err := h.changeSetService.ListChangeSets(
+		c.Request.Context(), userID, chapterID, domain.ChapterChangeStatus(c.Query("status")),
+	)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve change sets")
+		return
+	}
+
+	c.JSON(http.StatusOK, sets)
+}
+
+// GetChangeSetHandler handles GET /change-sets/:changeSetID.
+func (h *ChangeSetHandler) GetChangeSetHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	changeSetID, ok := parseUUIDParam(c, "changeSetID", "change set")
+	if !ok {
+		return
+	}
+
+	set, err := h.changeSetService.GetChangeSet(c.Request.Context(), userID, changeSetID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve change set")
+		return
+	}
+
+	c.JSON(http.StatusOK, set)
+}
+
+// AcceptChangeHandler handles POST /chapter-changes/:changeID/accept.
+func (h *ChangeSetHandler) AcceptChangeHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	changeID, ok := parseUUIDParam(c, "changeID", "change")
+	if !ok {
+		return
+	}
+
+	resolution, err := h.changeSetService.AcceptChange(c.Request.Context(), userID, changeID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to accept change")
+		return
+	}
+
+	c.JSON(http.StatusOK, resolution)
+}
+
+// RejectChangeHandler handles POST /chapter-changes/:changeID/reject.
+func (h *ChangeSetHandler) RejectChangeHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	changeID, ok := parseUUIDParam(c, "changeID", "change")
+	if !ok {
+		return
+	}
+
+	resolution, err := h.changeSetService.RejectChange(c.Request.Context(), userID, changeID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to reject change")
+		return
+	}
+
+	c.JSON(http.StatusOK, resolution)
+}
@@ -0,0 +1,135 @@
+// File: internal/transport/http/handlers/chapter_handler.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/service"
+	"github.com/khaled2049/server/internal/transport/http/request"
+)
+
+type ChapterHandler struct {
+	chapterService *service.ChapterService
+}
+
+func NewChapterHandler(chapterService *service.ChapterService) *ChapterHandler {
+	return &ChapterHandler{
+		chapterService: chapterService,
+	}
+}
+
+// RegisterRoutes registers chapter routes on an authenticated router group.
+func (h *ChapterHandler) RegisterRoutes(router *gin.RouterGroup) {
+	chapterGroup := router.Group("/chapters/:chapterID")
+	{
+		chapterGroup.GET("", h.GetChapterHandler)
+		chapterGroup.PUT("", h.UpdateChapterHandler)
+		chapterGroup.GET("/revisions", h.ListRevisionsHandler)
+		chapterGroup.GET("/revisions/:revisionID", h.GetRevisionHandler)
+	}
+}
+
+// GetChapterHandler handles GET /chapters/:chapterID.
+func (h *ChapterHandler) GetChapterHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	chapterID, ok := parseUUIDParam(c, "chapterID", "chapter")
+	if !ok {
+		return
+	}
+
+	chapter, err := h.chapterService.GetChapter(c.Request.Context(), userID, chapterID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve chapter")
+		return
+	}
+
+	c.JSON(http.StatusOK, chapter)
+}
+
+// UpdateChapterHandler handles PUT /chapters/:chapterID. The response lists comments
+// whose anchored text was deleted by the edit.
+func (h *ChapterHandler) UpdateChapterHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	chapterID, ok := parseUUIDParam(c, "chapterID", "chapter")
+	if !ok {
+		return
+	}
+
+	var req request.UpdateChapterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for chapter", "details": err.Error()})
+		return
+	}
+
+	update := service.ChapterUpdate{
+		Title:         req.Title,
+		Content:       req.Content,
+		RevisionNotes: req.RevisionNotes,
+	}
+	if req.Status != nil {
+		status := domain.ChapterStatus(*req.Status)
+		update.Status = &status
+	}
+
+	result, err := h.chapterService.UpdateChapter(c.Request.Context(), userID, chapterID, update)
+	if err != nil {
+		respondServiceError(c, err, "Failed to update chapter")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ListRevisionsHandler handles GET /chapters/:chapterID/revisions.
+func (h *ChapterHandler) ListRevisionsHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	chapterID, ok := parseUUIDParam(c, "chapterID", "chapter")
+	if !ok {
+		return
+	}
+
+	revisions, err := h.chapterService.ListRevisions(c.Request.Context(), userID, chapterID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve revisions")
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetRevisionHandler handles GET /chapters/:chapterID/revisions/:revisionID.
+func (h *ChapterHandler) GetRevisionHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	chapterID, ok := parseUUIDParam(c, "chapterID", "chapter")
+	if !ok {
+		return
+	}
+	revisionID, err := strconv.ParseInt(c.Param("revisionID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision ID", "details": err.Error()})
+		return
+	}
+
+	revision, err := h.chapterService.GetRevision(c.Request.Context(), userID, chapterID, revisionID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve revision")
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
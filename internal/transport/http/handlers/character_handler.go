@@ -0,0 +1,293 @@
+// File: internal/transport/http/handlers/character_handler.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/service"
+	"github.com/khaled2049/server/internal/transport/http/request"
+)
+
+type CharacterHandler struct {
+	characterService *service.CharacterService
+}
+
+func NewCharacterHandler(characterService *service.CharacterService) *CharacterHandler {
+	return &CharacterHandler{
+		characterService: characterService,
+	}
+}
+
+// RegisterRoutes registers the character routes on an authenticated router group.
+func (h *CharacterHandler) RegisterRoutes(router *gin.RouterGroup) {
+	novelGroup := router.Group("/novels/:novelID")
+	{
+		novelGroup.POST("/characters", h.CreateCharacterHandler)
+		novelGroup.GET("/characters", h.ListCharactersHandler)
+		novelGroup.POST("/characters/mentions", h.DetectMentionsHandler)
+		novelGroup.GET("/character-attributes", h.GetAttributeSchemaHandler)
+		novelGroup.PUT("/character-attributes", h.ReplaceAttributeSchemaHandler)
+	}
+
+	characterGroup := router.Group("/characters")
+	{
+		characterGroup.GET("/:characterID", h.GetCharacterHandler)
+		characterGroup.PUT("/:characterID", h.UpdateCharacterHandler)
+		characterGroup.DELETE("/:characterID", h.DeleteCharacterHandler)
+	}
+}
+
+// CreateCharacterHandler handles POST /novels/:novelID/characters.
+func (h *CharacterHandler) CreateCharacterHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	parsedNovelID, err := uuid.Parse(c.Param("novelID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID", "details": err.Error()})
+		return
+	}
+
+	var req request.CreateCharacterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for character", "details": err.Error()})
+		return
+	}
+
+	character := &domain.Character{
+		NovelID:             parsedNovelID,
+		Name:                req.Name,
+		Description:         req.Description,
+		Backstory:           req.Backstory,
+		Motivations:         req.Motivations,
+		PhysicalDescription: req.PhysicalDescription,
+		ImageURL:            req.ImageURL,
+		Aliases:             req.Aliases,
+		Attributes:          req.Attributes,
+	}
+
+	createdCharacter, err := h.characterService.CreateCharacter(ctx, userID, parsedNovelID, character)
+	if err != nil {
+		respondServiceError(c, err, "Failed to add character to novel")
+		return
+	}
+
+	c.JSON(http.StatusCreated, createdCharacter)
+}
+
+// ListCharactersHandler handles GET /novels/:novelID/characters.
+// Supports ?q= (name or alias) and ?attr[key]=value filters, e.g. ?attr[eye_color]=green.
+func (h *CharacterHandler) ListCharactersHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	parsedNovelID, err := uuid.Parse(c.Param("novelID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID", "details": err.Error()})
+		return
+	}
+
+	characters, err := h.characterService.ListCharacters(ctx, userID, parsedNovelID, c.Query("q"), c.QueryMap("attr"))
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch characters")
+		return
+	}
+
+	if characters == nil {
+		characters = []*domain.Character{}
+	}
+	c.JSON(http.StatusOK, characters)
+}
+
+// DetectMentionsHandler returns the character names/aliases found in the posted text.
+func (h *CharacterHandler) DetectMentionsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	parsedNovelID, err := uuid.Parse(c.Param("novelID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID", "details": err.Error()})
+		return
+	}
+
+	var req request.DetectMentionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		return
+	}
+
+	mentions, err := h.characterService.DetectMentions(ctx, userID, parsedNovelID, req.Text)
+	if err != nil {
+		respondServiceError(c, err, "Failed to detect mentions")
+		return
+	}
+
+	if mentions == nil {
+		mentions = []domain.CharacterMention{}
+	}
+	c.JSON(http.StatusOK, mentions)
+}
+
+// GetAttributeSchemaHandler handles GET /novels/:novelID/character-attributes.
+func (h *CharacterHandler) GetAttributeSchemaHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	parsedNovelID, err := uuid.Parse(c.Param("novelID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID", "details": err.Error()})
+		return
+	}
+
+	defs, err := h.characterService.GetAttributeSchema(ctx, userID, parsedNovelID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch character attribute schema")
+		return
+	}
+
+	if defs == nil {
+		defs = []*domain.CharacterAttributeDefinition{}
+	}
+	c.JSON(http.StatusOK, defs)
+}
+
+// ReplaceAttributeSchemaHandler handles PUT /novels/:novelID/character-attributes.
+func (h *CharacterHandler) ReplaceAttributeSchemaHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	parsedNovelID, err := uuid.Parse(c.Param("novelID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID", "details": err.Error()})
+		return
+	}
+
+	var req request.ReplaceCharacterAttributeSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for attribute schema", "details": err.Error()})
+		return
+	}
+
+	defs := make([]*domain.CharacterAttributeDefinition, 0, len(req.Attributes))
+	for _, attr := range req.Attributes {
+		defs = append(defs, &domain.CharacterAttributeDefinition{
+			Key:       attr.Key,
+			Label:     attr.Label,
+			ValueType: domain.CharacterAttributeType(attr.ValueType),
+			Required:  attr.Required,
+			Options:   attr.Options,
+		})
+	}
+
+	saved, err := h.characterService.ReplaceAttributeSchema(ctx, userID, parsedNovelID, defs)
+	if err != nil {
+		respondServiceError(c, err, "Failed to save character attribute schema")
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// GetCharacterHandler handles GET /characters/:characterID.
+func (h *CharacterHandler) GetCharacterHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	parsedCharacterID, err := uuid.Parse(c.Param("characterID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid character ID", "details": err.Error()})
+		return
+	}
+
+	character, err := h.characterService.GetCharacter(ctx, userID, parsedCharacterID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch character")
+		return
+	}
+
+	c.JSON(http.StatusOK, character)
+}
+
+// UpdateCharacterHandler handles PUT /characters/:characterID.
+func (h *CharacterHandler) UpdateCharacterHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	parsedCharacterID, err := uuid.Parse(c.Param("characterID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid character ID", "details": err.Error()})
+		return
+	}
+
+	var req request.UpdateCharacterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for character", "details": err.Error()})
+		return
+	}
+
+	character := &domain.Character{
+		ID:                  parsedCharacterID,
+		Name:                req.Name,
+		Description:         req.Description,
+		Backstory:           req.Backstory,
+		Motivations:         req.Motivations,
+		PhysicalDescription: req.PhysicalDescription,
+		ImageURL:            req.ImageURL,
+		Aliases:             req.Aliases,
+		Attributes:          req.Attributes,
+	}
+
+	updated, err := h.characterService.UpdateCharacter(ctx, userID, character)
+	if err != nil {
+		respondServiceError(c, err, "Failed to update character")
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteCharacterHandler handles DELETE /characters/:characterID.
+func (h *CharacterHandler) DeleteCharacterHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	parsedCharacterID, err := uuid.Parse(c.Param("characterID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid character ID", "details": err.Error()})
+		return
+	}
+
+	if err := h.characterService.DeleteCharacter(ctx, userID, parsedCharacterID); err != nil {
+		respondServiceError(c, err, "Failed to delete character")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
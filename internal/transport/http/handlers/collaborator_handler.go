@@ -0,0 +1,106 @@
+// File: internal/transport/http/handlers/collaborator_handler.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/service"
+	"github.com/khaled2049/server/internal/transport/http/request"
+)
+
+type CollaboratorHandler struct {
+	collaboratorService *service.CollaboratorService
+}
+
+func NewCollaboratorHandler(collaboratorService *service.CollaboratorService) *CollaboratorHandler {
+	return &CollaboratorHandler{
+		collaboratorService: collaboratorService,
+	}
+}
+
+// RegisterRoutes registers collaborator routes on an authenticated router group.
+func (h *CollaboratorHandler) RegisterRoutes(router *gin.RouterGroup) {
+	collaboratorGroup := router.Group("/novels/:novelID/collaborators")
+	{
+		collaboratorGroup.GET("", h.ListCollaboratorsHandler)
+		collaboratorGroup.POST("", h.SetCollaboratorHandler)
+		collaboratorGroup.DELETE("/:userID", h.RemoveCollaboratorHandler)
+	}
+}
+
+// ListCollaboratorsHandler handles GET /novels/:novelID/collaborators.
+func (h *CollaboratorHandler) ListCollaboratorsHandler(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	novelID, ok := parseUUIDParam(c, "novelID", "novel")
+	if !ok {
+		return
+	}
+
+	collaborators, err := h.collaboratorService.ListCollaborators(c.Request.Context(), novelID, actorID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch collaborators")
+		return
+	}
+
+	if collaborators == nil {
+		collaborators = []*domain.NovelCollaborator{}
+	}
+	c.JSON(http.StatusOK, collaborators)
+}
+
+// SetCollaboratorHandler handles POST /novels/:novelID/collaborators (add or change role).
+func (h *CollaboratorHandler) SetCollaboratorHandler(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	novelID, ok := parseUUIDParam(c, "novelID", "novel")
+	if !ok {
+		return
+	}
+
+	var req request.SetCollaboratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for collaborator", "details": err.Error()})
+		return
+	}
+
+	collaborator, err := h.collaboratorService.SetCollaborator(
+		c.Request.Context(), novelID, actorID, uuid.MustParse(req.UserID), domain.CollaborationRole(req.Role),
+	)
+	if err != nil {
+		respondServiceError(c, err, "Failed to save collaborator")
+		return
+	}
+
+	c.JSON(http.StatusOK, collaborator)
+}
+
+// RemoveCollaboratorHandler handles DELETE /novels/:novelID/collaborators/:userID.
+func (h *CollaboratorHandler) RemoveCollaboratorHandler(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	novelID, ok := parseUUIDParam(c, "novelID", "novel")
+	if !ok {
+		return
+	}
+	userID, ok := parseUUIDParam(c, "userID", "user")
+	if !ok {
+		return
+	}
+
+	if err := h.collaboratorService.RemoveCollaborator(c.Request.Context(), novelID, actorID, userID); err != nil {
+		respondServiceError(c, err, "Failed to remove collaborator")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
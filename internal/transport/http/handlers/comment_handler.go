@@ -0,0 +1,235 @@
+// File: internal/transport/http/handlers/comment_handler.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/service"
+	"github.com/khaled2049/server/internal/transport/http/request"
+)
+
+type CommentHandler struct {
+	commentService *service.CommentService
+}
+
+func NewCommentHandler(commentService *service.CommentService) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+	}
+}
+
+// RegisterRoutes registers comment routes on an authenticated router group.
+func (h *CommentHandler) RegisterRoutes(router *gin.RouterGroup) {
+	commentGroup := router.Group("/comments")
+	{
+		commentGroup.POST("", h.CreateCommentHandler)
+		commentGroup.GET("", h.ListCommentsHandler)
+		commentGroup.POST("/:commentID/replies", h.ReplyHandler)
+		commentGroup.PUT("/:commentID", h.EditCommentHandler)
+		commentGroup.DELETE("/:commentID", h.DeleteCommentHandler)
+		commentGroup.POST("/:commentID/resolve", h.ResolveHandler)
+		commentGroup.POST("/:commentID/reopen", h.ReopenHandler)
+	}
+
+	// Chapter shortcuts for the most common target
+	router.POST("/chapters/:chapterID/comments", h.CreateChapterCommentHandler)
+	router.GET("/chapters/:chapterID/comments", h.ListChapterCommentsHandler)
+}
+
+// CreateCommentHandler handles POST /comments.
+func (h *CommentHandler) CreateCommentHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for comment", "details": err.Error()})
+		return
+	}
+
+	comment, err := h.commentService.CreateThread(
+		c.Request.Context(), userID,
+		domain.CommentTargetType(req.TargetType), uuid.MustParse(req.TargetID), req.Content, req.Anchor.ToDomain(),
+	)
+	if err != nil {
+		respondServiceError(c, err, "Failed to create comment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// ListCommentsHandler handles GET /comments?target_type=&target_id=&status=.
+func (h *CommentHandler) ListCommentsHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Query("target_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target ID", "details": err.Error()})
+		return
+	}
+
+	h.listThreads(c, userID, domain.CommentTargetType(c.Query("target_type")), targetID)
+}
+
+// CreateChapterCommentHandler handles POST /chapters/:chapterID/comments.
+func (h *CommentHandler) CreateChapterCommentHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	chapterID, ok := parseUUIDParam(c, "chapterID", "chapter")
+	if !ok {
+		return
+	}
+
+	var req request.CreateChapterCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for comment", "details": err.Error()})
+		return
+	}
+
+	comment, err := h.commentService.CreateThread(
+		c.Request.Context(), userID, domain.CommentTargetChapter, chapterID, req.Content, req.Anchor.ToDomain(),
+	)
+	if err != nil {
+		respondServiceError(c, err, "Failed to create comment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// ListChapterCommentsHandler handles GET /chapters/:chapterID/comments.
+func (h *CommentHandler) ListChapterCommentsHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	chapterID, ok := parseUUIDParam(c, "chapterID", "chapter")
+	if !ok {
+		return
+	}
+
+	h.listThreads(c, userID, domain.CommentTargetChapter, chapterID)
+}
+
+// ReplyHandler handles POST /comments/:commentID/replies.
+func (h *CommentHandler) ReplyHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	commentID, ok := parseUUIDParam(c, "commentID", "comment")
+	if !ok {
+		return
+	}
+
+	var req request.CommentContentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for reply", "details": err.Error()})
+		return
+	}
+
+	reply, err := h.commentService.Reply(c.Request.Context(), userID, commentID, req.Content)
+	if err != nil {
+		respondServiceError(c, err, "Failed to reply to comment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, reply)
+}
+
+// EditCommentHandler handles PUT /comments/:commentID.
+func (h *CommentHandler) EditCommentHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	commentID, ok := parseUUIDParam(c, "commentID", "comment")
+	if !ok {
+		return
+	}
+
+	var req request.CommentContentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for comment", "details": err.Error()})
+		return
+	}
+
+	comment, err := h.commentService.EditComment(c.Request.Context(), userID, commentID, req.Content)
+	if err != nil {
+		respondServiceError(c, err, "Failed to edit comment")
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteCommentHandler handles DELETE /comments/:commentID.
+func (h *CommentHandler) DeleteCommentHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	commentID, ok := parseUUIDParam(c, "commentID", "comment")
+	if !ok {
+		return
+	}
+
+	if err := h.commentService.DeleteComment(c.Request.Context(), userID, commentID); err != nil {
+		respondServiceError(c, err, "Failed to delete comment")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ResolveHandler handles POST /comments/:commentID/resolve.
+func (h *CommentHandler) ResolveHandler(c *gin.Context) {
+	h.setThreadStatus(c, domain.CommentStatusResolved)
+}
+
+// ReopenHandler handles POST /comments/:commentID/reopen.
+func (h *CommentHandler) ReopenHandler(c *gin.Context) {
+	h.setThreadStatus(c, domain.CommentStatusOpen)
+}
+
+func (h *CommentHandler) setThreadStatus(c *gin.Context, status domain.CommentStatus) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	commentID, ok := parseUUIDParam(c, "commentID", "comment")
+	if !ok {
+		return
+	}
+
+	comment, err := h.commentService.SetThreadStatus(c.Request.Context(), userID, commentID, status)
+	if err != nil {
+		respondServiceError(c, err, "Failed to update comment status")
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+func (h *CommentHandler) listThreads(c *gin.Context, userID uuid.UUID, targetType domain.CommentTargetType, targetID uuid.UUID) {
+	threads, err := h.commentService.ListThreads(
+		c.Request.Context(), userID, targetType, targetID, domain.CommentStatus(c.Query("status")),
+	)
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch comments")
+		return
+	}
+
+	c.JSON(http.StatusOK, threads)
+}
@@ -0,0 +1,149 @@
+// File: internal/transport/http/handlers/consistency_handler.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/service"
+	"github.com/khaled2049/server/internal/transport/http/request"
+)
+
+type ConsistencyHandler struct {
+	consistencyService *service.ConsistencyService
+}
+
+func NewConsistencyHandler(consistencyService *service.ConsistencyService) *ConsistencyHandler {
+	return &ConsistencyHandler{
+		consistencyService: consistencyService,
+	}
+}
+
+// RegisterRoutes registers consistency check routes on an authenticated router group.
+func (h *ConsistencyHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/chapters/:chapterID/consistency-checks", h.CreateCheckHandler)
+	router.GET("/chapters/:chapterID/consistency-findings", h.ListFindingsHandler)
+	router.GET("/consistency-checks/:checkID", h.GetCheckHandler)
+
+	findingGroup := router.Group("/consistency-findings/:findingID")
+	{
+		findingGroup.POST("/resolve", h.ResolveFindingHandler)
+		findingGroup.POST("/dismiss", h.DismissFindingHandler)
+		findingGroup.POST("/reopen", h.ReopenFindingHandler)
+	}
+}
+
+// CreateCheckHandler handles POST /chapters/:chapterID/consistency-checks. The check
+// runs in the AI worker; poll it with GET /consistency-checks/:checkID.
+func (h *ConsistencyHandler) CreateCheckHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	chapterID, ok := parseUUIDParam(c, "chapterID", "chapter")
+	if !ok {
+		return
+	}
+
+	var req request.CreateConsistencyCheckRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for consistency check", "details": err.Error()})
+			return
+		}
+	}
+
+	check, err := h.consistencyService.RequestCheck(c.Request.Context(), userID, chapterID, req.UseLLM)
+	if err != nil {
+		respondServiceError(c, err, "Failed to request consistency check")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, check)
+}
+
+// GetCheckHandler handles GET /consistency-checks/:checkID.
+func (h *ConsistencyHandler) GetCheckHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	checkID, ok := parseUUIDParam(c, "checkID", "consistency check")
+	if !ok {
+		return
+	}
+
+	check, err := h.consistencyService.GetCheck(c.Request.Context(), userID, checkID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get consistency check")
+		return
+	}
+
+	c.JSON(http.StatusOK, check)
+}
+
+// ListFindingsHandler handles GET /chapters/:chapterID/consistency-findings, optionally
+// filtered with ?status=open|resolved|dismissed.
+func (h *ConsistencyHandler) ListFindingsHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	chapterID, ok := parseUUIDParam(c, "chapterID", "chapter")
+	if !ok {
+		return
+	}
+
+	findings, err := h.consistencyService.ListFindings(
+		c.Request.Context(), userID, chapterID, domain.ConsistencyFindingStatus(c.Query("status")),
+	)
+	if err != nil {
+		respondServiceError(c, err, "Failed to fetch consistency findings")
+		return
+	}
+
+	c.JSON(http.StatusOK, findings)
+}
+
+// ResolveFindingHandler handles POST /consistency-findings/:findingID/resolve.
+func (h *ConsistencyHandler) ResolveFindingHandler(c *gin.Context) {
+	h.setFindingStatus(c, domain.ConsistencyFindingResolved)
+}
+
+// DismissFindingHandler handles POST /consistency-findings/:findingID/dismiss.
+func (h *ConsistencyHandler) DismissFindingHandler(c *gin.Context) {
+	h.setFindingStatus(c, domain.ConsistencyFindingDismissed)
+}
+
+// ReopenFindingHandler handles POST /consistency-findings/:findingID/reopen.
+func (h *ConsistencyHandler) ReopenFindingHandler(c *gin.Context) {
+	h.setFindingStatus(c, domain.ConsistencyFindingOpen)
+}
+
+func (h *ConsistencyHandler) setFindingStatus(c *gin.Context, status domain.ConsistencyFindingStatus) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	findingID, ok := parseUUIDParam(c, "findingID", "consistency finding")
+	if !ok {
+		return
+	}
+
+	var req request.ConsistencyFindingStatusRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for consistency finding", "details": err.Error()})
+			return
+		}
+	}
+
+	finding, err := h.consistencyService.SetFindingStatus(c.Request.Context(), userID, findingID, status, req.Note)
+	if err != nil {
+		respondServiceError(c, err, "Failed to update consistency finding status")
+		return
+	}
+
+	c.JSON(http.StatusOK, finding)
+}
@@ -0,0 +1,40 @@
+// File: internal/transport/http/handlers/context.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/transport/http/middleware"
+)
+
+// currentUserID returns the authenticated user set by middleware.AuthMiddleware.
+// It writes a 401 response and returns false when there is none.
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(c.GetString(middleware.UserIDKey))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// parseUUIDParam parses a UUID path parameter, writing a 400 response on failure.
+func parseUUIDParam(c *gin.Context, name, label string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + label + " ID", "details": err.Error()})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// optionalUUID converts an optional request field that binding already validated as a UUID.
+func optionalUUID(value *string) *uuid.UUID {
+	if value == nil {
+		return nil
+	}
+	id := uuid.MustParse(*value)
+	return &id
+}
@@ -0,0 +1,83 @@
+// File: internal/transport/http/handlers/errors.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khaled2049/server/internal/repository"
+	"github.com/khaled2049/server/internal/service"
+)
+
+// respondServiceError maps domain errors returned by services to HTTP status codes.
+// message is used for errors that are not recognized (500).
+func respondServiceError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden", "details": err.Error()})
+	case errors.Is(err, service.ErrInvalidAttributes),
+		errors.Is(err, service.ErrInvalidRole),
+		errors.Is(err, service.ErrInvalidComment),
+		errors.Is(err, service.ErrInvalidChapter),
+		errors.Is(err, service.ErrInvalidChangeSet),
+		errors.Is(err, service.ErrInvalidPrivateNote),
+		errors.Is(err, service.ErrInvalidSuggestion),
+		errors.Is(err, service.ErrInvalidPromptTemplate),
+		errors.Is(err, service.ErrInvalidSearch),
+		errors.Is(err, service.ErrInvalidAnalyticsQuery),
+		errors.Is(err, service.ErrInvalidModerationSettings),
+		errors.Is(err, service.ErrInvalidConsistencyCheck),
+		errors.Is(err, service.ErrInvalidNotificationQuery),
+		errors.Is(err, service.ErrInvalidNotificationPreference),
+		errors.Is(err, service.ErrInvalidUnsubscribeLink),
+		errors.Is(err, service.ErrInvalidEventStream):
+		c.JSON(http.StatusBadRequest, gin.H{"error": message, "details": err.Error()})
+	case errors.Is(err, service.ErrChangeConflict),
+		errors.Is(err, service.ErrChangeNotPending),
+		errors.Is(err, service.ErrInvalidTransition),
+		errors.Is(err, service.ErrSuggestionWithheld),
+		errors.Is(err, repository.ErrPromptTemplateVersionConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": message, "details": err.Error()})
+	case errors.Is(err, service.ErrAIQuotaExceeded):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "AI usage quota exceeded", "details": err.Error()})
+	case errors.Is(err, repository.ErrNovelNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Novel not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrCharacterNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Character not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrChapterNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chapter not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrRevisionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrChangeSetNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Change set not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrChangeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Change not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrPrivateNoteNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Note not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrAISuggestionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "AI suggestion not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrAISuggestionContextNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "AI suggestion context not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrPromptTemplateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prompt template not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrCollaboratorNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collaborator not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrCommentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrCommentTargetNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment target not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrConsistencyCheckNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Consistency check not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrConsistencyFindingNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Consistency finding not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrNotificationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found", "details": err.Error()})
+	case errors.Is(err, repository.ErrNotificationPreferenceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification preference not found", "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
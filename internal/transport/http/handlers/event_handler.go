@@ -0,0 +1,220 @@
+// File: internal/transport/http/handlers/event_handler.go
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/service"
+	"golang.org/x/net/websocket"
+)
+
+// webSocketWriteTimeout bounds a write to a WebSocket client.
+const webSocketWriteTimeout = 10 * time.Second
+
+type EventHandler struct {
+	live *service.LiveEventService
+}
+
+func NewEventHandler(live *service.LiveEventService) *EventHandler {
+	return &EventHandler{
+		live: live,
+	}
+}
+
+// RegisterRoutes registers the live event stream on an authenticated router group.
+func (h *EventHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/events", h.StreamEventsHandler)
+}
+
+// StreamEventsHandler handles GET /events?novelId=&novelId=&lastEventId=. It pushes the
+// user's notifications and the events of the given novels: chapter.updated,
+// comment.created and suggestion.status, except those the user caused. The stream is
+// Server-Sent Events, or WebSocket when the request asks for an upgrade, with each
+// event's data being the domain.LiveEvent. A client resumes with the Last-Event-ID
+// header (or lastEventId) and first gets the events it missed; "reset" instead tells it
+// to reload what it shows because too much was missed. "ready" follows once the client
+// is caught up. The stream ends when the client lags behind or loses access to a novel;
+// it then reconnects.
+func (h *EventHandler) StreamEventsHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var novelIDs []uuid.UUID
+	for _, raw := range c.QueryArray("novelId") {
+		for _, part := range strings.Split(raw, ",") {
+			novelID, err := uuid.Parse(strings.TrimSpace(part))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID", "details": err.Error()})
+				return
+			}
+			novelIDs = append(novelIDs, novelID)
+		}
+	}
+	var lastEventID int64
+	if raw := cmp.Or(c.GetHeader("Last-Event-ID"), c.Query("lastEventId")); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid last event ID", "details": err.Error()})
+			return
+		}
+		lastEventID = parsed
+	}
+
+	ctx := c.Request.Context()
+	sub, err := h.live.Subscribe(ctx, userID, novelIDs, lastEventID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to open event stream")
+		return
+	}
+	defer h.live.Close(sub)
+
+	keepStreamOpen(c)
+	if strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+		h.streamWebSocket(c, sub)
+		return
+	}
+	h.streamServerSentEvents(c, sub)
+}
+
+// streamServerSentEvents writes the stream as Server-Sent Events. Unlike c.SSEvent each
+// event carries its ID, which the browser sends back as Last-Event-ID when it reconnects.
+func (h *EventHandler) streamServerSentEvents(c *gin.Context, sub *service.LiveSubscription) {
+	ctx := c.Request.Context()
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // Keep proxies from buffering the stream
+	c.Status(http.StatusOK)
+
+	if sub.Reset {
+		writeServerSentEvent(c.Writer, strconv.FormatInt(sub.LastEventID, 10), "reset", gin.H{"lastEventId": sub.LastEventID})
+	}
+	for _, event := range sub.Replay {
+		writeLiveEvent(c.Writer, event)
+	}
+	writeServerSentEvent(c.Writer, "", "ready", gin.H{"novelIds": subscribedNovels(sub)})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Dropped; the browser reconnects with Last-Event-ID
+				return false
+			}
+			writeLiveEvent(w, event)
+			return true
+		case <-heartbeat.C:
+			if err := h.live.Authorize(ctx, sub); err != nil {
+				if ctx.Err() == nil {
+					writeServerSentEvent(w, "", "error", gin.H{"error": "Event stream closed", "details": err.Error()})
+				}
+				return false
+			}
+			fmt.Fprint(w, ": keep-alive\n\n")
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+}
+
+func writeLiveEvent(w io.Writer, event *domain.LiveEvent) {
+	writeServerSentEvent(w, strconv.FormatInt(event.ID, 10), string(event.Type), event)
+}
+
+// writeServerSentEvent writes an event with an optional ID. JSON data never spans lines.
+func writeServerSentEvent(w io.Writer, id, eventType string, data any) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		body, _ = json.Marshal(gin.H{"error": err.Error()})
+	}
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, body)
+}
+
+// streamWebSocket writes the stream over a WebSocket, one JSON message per event: the
+// domain.LiveEvent itself, or {"type": "reset" | "ready" | "error" | "keep-alive", ...}.
+// A client resumes by reconnecting with the lastEventId query parameter.
+func (h *EventHandler) streamWebSocket(c *gin.Context, sub *service.LiveSubscription) {
+	server := websocket.Server{
+		// Clients authenticate with a token, not cookies, so any origin may connect
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			// The hijacked connection may still carry the server's read deadline
+			ws.SetReadDeadline(time.Time{})
+			ctx, cancel := context.WithCancel(c.Request.Context())
+			defer cancel()
+			go func() {
+				// Nothing is expected from the client; a failed read means it is gone
+				defer cancel()
+				var discard []byte
+				for websocket.Message.Receive(ws, &discard) == nil {
+				}
+			}()
+
+			send := func(message any) bool {
+				ws.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+				return websocket.JSON.Send(ws, message) == nil
+			}
+
+			if sub.Reset && !send(gin.H{"type": "reset", "lastEventId": sub.LastEventID}) {
+				return
+			}
+			for _, event := range sub.Replay {
+				if !send(event) {
+					return
+				}
+			}
+			if !send(gin.H{"type": "ready", "novelIds": subscribedNovels(sub)}) {
+				return
+			}
+
+			heartbeat := time.NewTicker(streamHeartbeat)
+			defer heartbeat.Stop()
+			for {
+				select {
+				case event, ok := <-sub.Events:
+					if !ok || !send(event) {
+						return
+					}
+				case <-heartbeat.C:
+					if err := h.live.Authorize(ctx, sub); err != nil {
+						if ctx.Err() == nil {
+							send(gin.H{"type": "error", "error": "Event stream closed", "details": err.Error()})
+						}
+						return
+					}
+					if !send(gin.H{"type": "keep-alive"}) {
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func subscribedNovels(sub *service.LiveSubscription) []uuid.UUID {
+	if sub.NovelIDs == nil {
+		return []uuid.UUID{}
+	}
+	return sub.NovelIDs
+}
@@ -0,0 +1,75 @@
+// File: internal/transport/http/handlers/moderation_handler.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/service"
+	"github.com/khaled2049/server/internal/transport/http/request"
+)
+
+type ModerationHandler struct {
+	moderationService *service.ModerationService
+}
+
+func NewModerationHandler(moderationService *service.ModerationService) *ModerationHandler {
+	return &ModerationHandler{
+		moderationService: moderationService,
+	}
+}
+
+// RegisterRoutes registers moderation settings routes on an authenticated router group.
+func (h *ModerationHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/novels/:novelID/ai/moderation", h.GetSettingsHandler)
+	router.PUT("/novels/:novelID/ai/moderation", h.UpdateSettingsHandler)
+}
+
+// GetSettingsHandler handles GET /novels/:novelID/ai/moderation.
+func (h *ModerationHandler) GetSettingsHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	novelID, ok := parseUUIDParam(c, "novelID", "novel")
+	if !ok {
+		return
+	}
+
+	settings, err := h.moderationService.GetSettings(c.Request.Context(), userID, novelID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get moderation settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettingsHandler handles PUT /novels/:novelID/ai/moderation.
+func (h *ModerationHandler) UpdateSettingsHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	novelID, ok := parseUUIDParam(c, "novelID", "novel")
+	if !ok {
+		return
+	}
+
+	var req request.UpdateModerationSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for moderation settings", "details": err.Error()})
+		return
+	}
+
+	settings, err := h.moderationService.UpdateSettings(
+		c.Request.Context(), userID, novelID, domain.ContentRating(req.ContentRating), req.AllowedCategories,
+	)
+	if err != nil {
+		respondServiceError(c, err, "Failed to update moderation settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,126 @@
+// File: internal/transport/http/handlers/notification_handler.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khaled2049/server/internal/service"
+)
+
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// RegisterRoutes registers the current user's notification routes on an authenticated router group.
+func (h *NotificationHandler) RegisterRoutes(router *gin.RouterGroup) {
+	notificationGroup := router.Group("/me/notifications")
+	{
+		notificationGroup.GET("", h.ListNotificationsHandler)
+		notificationGroup.GET("/unread-count", h.UnreadCountHandler)
+		notificationGroup.POST("/read-all", h.MarkAllReadHandler)
+		notificationGroup.POST("/:notificationID/read", h.MarkReadHandler)
+	}
+}
+
+// ListNotificationsHandler handles GET /me/notifications?unread=&before=&limit=.
+func (h *NotificationHandler) ListNotificationsHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var query service.NotificationQuery
+	if raw := c.Query("unread"); raw != "" {
+		unread, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid unread filter", "details": err.Error()})
+			return
+		}
+		query.UnreadOnly = unread
+	}
+	if raw := c.Query("before"); raw != "" {
+		before, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before time", "details": err.Error()})
+			return
+		}
+		query.Before = &before
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit", "details": err.Error()})
+			return
+		}
+		query.Limit = limit
+	}
+
+	notifications, err := h.notificationService.ListNotifications(c.Request.Context(), userID, query)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve notifications")
+		return
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+// UnreadCountHandler handles GET /me/notifications/unread-count.
+func (h *NotificationHandler) UnreadCountHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.notificationService.UnreadCount(c.Request.Context(), userID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to count unread notifications")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread": count})
+}
+
+// MarkReadHandler handles POST /me/notifications/:notificationID/read.
+func (h *NotificationHandler) MarkReadHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	notificationID, ok := parseUUIDParam(c, "notificationID", "notification")
+	if !ok {
+		return
+	}
+
+	notification, err := h.notificationService.MarkRead(c.Request.Context(), userID, notificationID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to mark notification read")
+		return
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllReadHandler handles POST /me/notifications/read-all.
+func (h *NotificationHandler) MarkAllReadHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	marked, err := h.notificationService.MarkAllRead(c.Request.Context(), userID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to mark notifications read")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"marked": marked})
+}
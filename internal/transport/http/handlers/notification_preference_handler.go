@@ -0,0 +1,209 @@
+// File: internal/transport/http/handlers/notification_preference_handler.go
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/service"
+	"github.com/khaled2049/server/internal/transport/http/request"
+)
+
+// unsubscribeConfirmPage asks to confirm an unsubscribe link opened in a browser. Only
+// its form unsubscribes: link scanners and prefetchers of mail providers open the link
+// too, but do not submit forms.
+var unsubscribeConfirmPage = template.Must(template.New("unsubscribe-confirm").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>Unsubscribe</title></head>
+<body style="font-family: sans-serif; line-height: 1.5; color: #222;">
+<h1>Unsubscribe?</h1>
+<p>You will no longer get {{.Description}}.</p>
+<form method="post" action="?token={{.Token}}">
+<button type="submit">Unsubscribe</button>
+</form>
+</body>
+</html>
+`))
+
+// unsubscribePage confirms an unsubscribe made from the confirmation page.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribed</title></head>
+<body style="font-family: sans-serif; line-height: 1.5; color: #222;">
+<h1>You are unsubscribed</h1>
+<p>You will no longer get {{.}}.</p>
+<p>You can change this any time in your NovelCraft notification settings.</p>
+</body>
+</html>
+`))
+
+type NotificationPreferenceHandler struct {
+	emailService *service.NotificationEmailService
+}
+
+func NewNotificationPreferenceHandler(emailService *service.NotificationEmailService) *NotificationPreferenceHandler {
+	return &NotificationPreferenceHandler{
+		emailService: emailService,
+	}
+}
+
+// RegisterRoutes registers the current user's notification preference routes on an
+// authenticated router group.
+func (h *NotificationPreferenceHandler) RegisterRoutes(router *gin.RouterGroup) {
+	preferenceGroup := router.Group("/me/notification-preferences")
+	{
+		preferenceGroup.GET("", h.ListPreferencesHandler)
+		preferenceGroup.PUT("", h.SetPreferenceHandler)
+		preferenceGroup.DELETE("", h.DeletePreferenceHandler)
+	}
+}
+
+// RegisterPublicRoutes registers the unsubscribe links of notification emails, which
+// work without signing in.
+func (h *NotificationPreferenceHandler) RegisterPublicRoutes(router *gin.Engine) {
+	router.GET("/notifications/unsubscribe", h.UnsubscribePageHandler)
+	router.POST("/notifications/unsubscribe", h.UnsubscribeHandler)
+}
+
+// ListPreferencesHandler handles GET /me/notification-preferences.
+func (h *NotificationPreferenceHandler) ListPreferencesHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	preferences, err := h.emailService.ListPreferences(c.Request.Context(), userID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve notification preferences")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"emailEnabled":     h.emailService.Enabled(),
+		"defaultEmailMode": h.emailService.DefaultMode(),
+		"preferences":      preferences,
+	})
+}
+
+// SetPreferenceHandler handles PUT /me/notification-preferences.
+func (h *NotificationPreferenceHandler) SetPreferenceHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.NotificationPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for notification preference", "details": err.Error()})
+		return
+	}
+
+	input := service.NotificationPreferenceInput{EmailMode: domain.NotificationEmailMode(req.EmailMode)}
+	if req.NovelID != nil {
+		novelID, err := uuid.Parse(*req.NovelID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID", "details": err.Error()})
+			return
+		}
+		input.NovelID = &novelID
+	}
+	if req.Type != nil {
+		notificationType := domain.NotificationType(*req.Type)
+		input.Type = &notificationType
+	}
+
+	preference, err := h.emailService.SetPreference(c.Request.Context(), userID, input)
+	if err != nil {
+		respondServiceError(c, err, "Failed to save notification preference")
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
+
+// DeletePreferenceHandler handles DELETE /me/notification-preferences?novelId=&type=.
+func (h *NotificationPreferenceHandler) DeletePreferenceHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var novelID *uuid.UUID
+	if raw := c.Query("novelId"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID", "details": err.Error()})
+			return
+		}
+		novelID = &parsed
+	}
+	var notificationType *domain.NotificationType
+	if raw := c.Query("type"); raw != "" {
+		parsed := domain.NotificationType(raw)
+		notificationType = &parsed
+	}
+
+	if err := h.emailService.DeletePreference(c.Request.Context(), userID, novelID, notificationType); err != nil {
+		respondServiceError(c, err, "Failed to delete notification preference")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// UnsubscribePageHandler handles GET /notifications/unsubscribe?token=, the link of
+// notification emails. It changes nothing and answers with a page whose form posts to
+// UnsubscribeHandler.
+func (h *NotificationPreferenceHandler) UnsubscribePageHandler(c *gin.Context) {
+	token := c.Query("token")
+	preference, err := h.emailService.UnsubscribePreview(c.Request.Context(), token)
+	if err != nil {
+		respondServiceError(c, err, "Failed to unsubscribe")
+		return
+	}
+
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	page := struct{ Token, Description string }{token, unsubscribeDescription(preference)}
+	if err := unsubscribeConfirmPage.Execute(c.Writer, page); err != nil {
+		c.Error(err)
+	}
+}
+
+// UnsubscribeHandler handles POST /notifications/unsubscribe?token=, the one-click
+// unsubscribe of mail clients (RFC 8058) and the form of the confirmation page. Browsers
+// get a page, other clients the preference.
+func (h *NotificationPreferenceHandler) UnsubscribeHandler(c *gin.Context) {
+	preference, err := h.emailService.Unsubscribe(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		respondServiceError(c, err, "Failed to unsubscribe")
+		return
+	}
+
+	if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) != gin.MIMEHTML {
+		c.JSON(http.StatusOK, preference)
+		return
+	}
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := unsubscribePage.Execute(c.Writer, unsubscribeDescription(preference)); err != nil {
+		c.Error(err)
+	}
+}
+
+// unsubscribeDescription describes the emails an unsubscribe turns off.
+func unsubscribeDescription(preference *domain.NotificationPreference) string {
+	switch {
+	case preference.NovelID != nil && preference.Type != nil:
+		return "emails of this kind about this novel"
+	case preference.NovelID != nil:
+		return "emails about this novel"
+	case preference.Type != nil:
+		return "emails of this kind"
+	}
+	return "notification emails"
+}
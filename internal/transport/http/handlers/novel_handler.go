@@ -29,7 +29,6 @@ func (h *NovelHandler) RegisterRoutes(router *gin.Engine) {
 		novelGroup.GET("", h.GetAllNovelsHandler)
 		novelGroup.GET("/:novelID", h.GetNovelByIDHandler)
 		novelGroup.POST("/with-first-chapter", h.CreateNovelWithFirstChapterHandler)
-		novelGroup.POST("/:novelID/characters", h.CreateCharacterForNovelHandler)
 
 		// Routes for chapters specifically related to a novel
 		novelChaptersGroup := novelGroup.Group("/:novelID/chapters")
@@ -177,44 +176,3 @@ func (h *NovelHandler) AddChapterToNovelHandler(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, createdChapter)
 }
-
-func (h *NovelHandler) CreateCharacterForNovelHandler(c *gin.Context) {
-	ctx := c.Request.Context()
-	novelID := c.Param("novelID")
-
-	var reqCharacter request.CreateCharacterRequest
-	if err := c.ShouldBindJSON(&reqCharacter); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for character", "details": err.Error()})
-		return
-	}
-
-	// convert novelID from string to uuid.UUID
-	parsedNovelID, err := uuid.Parse(novelID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID", "details": err.Error()})
-		return
-	}
-
-	character := &domain.Character{
-		NovelID:             parsedNovelID,
-		Name:                reqCharacter.Name,
-		Description:         reqCharacter.Description,
-		Backstory:           reqCharacter.Backstory,
-		Motivations:         reqCharacter.Motivations,
-		PhysicalDescription: reqCharacter.PhysicalDescription,
-		ImageURL:            reqCharacter.ImageURL,
-	}
-
-	createdCharacter, err := h.novelService.CreateCharacter(ctx, parsedNovelID, character)
-	if err != nil {
-		// Check if it's a "novel not found" type of error
-		if err.Error() == fmt.Sprintf("novel not found: %s", novelID) { // This check is brittle; better to use custom errors
-			c.JSON(http.StatusNotFound, gin.H{"error": "Novel not found", "details": err.Error()})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add character to novel", "details": err.Error()})
-		}
-		return
-	}
-
-	c.JSON(http.StatusCreated, createdCharacter)
-}
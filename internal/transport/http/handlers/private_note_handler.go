@@ -0,0 +1,151 @@
+// File: internal/transport/http/handlers/private_note_handler.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/service"
+	"github.com/khaled2049/server/internal/transport/http/request"
+)
+
+type PrivateNoteHandler struct {
+	privateNoteService *service.PrivateNoteService
+}
+
+func NewPrivateNoteHandler(privateNoteService *service.PrivateNoteService) *PrivateNoteHandler {
+	return &PrivateNoteHandler{
+		privateNoteService: privateNoteService,
+	}
+}
+
+// RegisterRoutes registers the current user's private note routes on an authenticated router group.
+func (h *PrivateNoteHandler) RegisterRoutes(router *gin.RouterGroup) {
+	noteGroup := router.Group("/me/notes")
+	{
+		noteGroup.POST("", h.CreateNoteHandler)
+		noteGroup.GET("", h.ListNotesHandler)
+		noteGroup.GET("/:noteID", h.GetNoteHandler)
+		noteGroup.PUT("/:noteID", h.UpdateNoteHandler)
+		noteGroup.DELETE("/:noteID", h.DeleteNoteHandler)
+	}
+}
+
+// CreateNoteHandler handles POST /me/notes.
+func (h *PrivateNoteHandler) CreateNoteHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.PrivateNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for note", "details": err.Error()})
+		return
+	}
+
+	note, err := h.privateNoteService.CreateNote(c.Request.Context(), userID, privateNoteInput(req))
+	if err != nil {
+		respondServiceError(c, err, "Failed to create note")
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
+
+// ListNotesHandler handles GET /me/notes?novel_id=&q=.
+func (h *PrivateNoteHandler) ListNotesHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var novelID *uuid.UUID
+	if raw := c.Query("novel_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID", "details": err.Error()})
+			return
+		}
+		novelID = &parsed
+	}
+
+	notes, err := h.privateNoteService.ListNotes(c.Request.Context(), userID, novelID, c.Query("q"))
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve notes")
+		return
+	}
+
+	c.JSON(http.StatusOK, notes)
+}
+
+// GetNoteHandler handles GET /me/notes/:noteID.
+func (h *PrivateNoteHandler) GetNoteHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	noteID, ok := parseUUIDParam(c, "noteID", "note")
+	if !ok {
+		return
+	}
+
+	note, err := h.privateNoteService.GetNote(c.Request.Context(), userID, noteID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve note")
+		return
+	}
+
+	c.JSON(http.StatusOK, note)
+}
+
+// UpdateNoteHandler handles PUT /me/notes/:noteID.
+func (h *PrivateNoteHandler) UpdateNoteHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	noteID, ok := parseUUIDParam(c, "noteID", "note")
+	if !ok {
+		return
+	}
+
+	var req request.PrivateNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for note", "details": err.Error()})
+		return
+	}
+
+	note, err := h.privateNoteService.UpdateNote(c.Request.Context(), userID, noteID, privateNoteInput(req))
+	if err != nil {
+		respondServiceError(c, err, "Failed to update note")
+		return
+	}
+
+	c.JSON(http.StatusOK, note)
+}
+
+// DeleteNoteHandler handles DELETE /me/notes/:noteID.
+func (h *PrivateNoteHandler) DeleteNoteHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	noteID, ok := parseUUIDParam(c, "noteID", "note")
+	if !ok {
+		return
+	}
+
+	if err := h.privateNoteService.DeleteNote(c.Request.Context(), userID, noteID); err != nil {
+		respondServiceError(c, err, "Failed to delete note")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// privateNoteInput converts a validated request; novel_id is already checked to be a UUID.
+func privateNoteInput(req request.PrivateNoteRequest) service.PrivateNoteInput {
+	return service.PrivateNoteInput{NovelID: optionalUUID(req.NovelID), Title: req.Title, Content: req.Content}
+}
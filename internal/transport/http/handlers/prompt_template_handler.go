@@ -0,0 +1,198 @@
+// File: internal/transport/http/handlers/prompt_template_handler.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/repository"
+	"github.com/khaled2049/server/internal/service"
+	"github.com/khaled2049/server/internal/transport/http/request"
+)
+
+type PromptTemplateHandler struct {
+	templateService *service.PromptTemplateService
+}
+
+func NewPromptTemplateHandler(templateService *service.PromptTemplateService) *PromptTemplateHandler {
+	return &PromptTemplateHandler{
+		templateService: templateService,
+	}
+}
+
+// RegisterRoutes registers prompt template routes on an authenticated router group.
+func (h *PromptTemplateHandler) RegisterRoutes(router *gin.RouterGroup) {
+	templateGroup := router.Group("/ai/prompt-templates")
+	{
+		templateGroup.GET("", h.ListGlobalTemplatesHandler)
+		templateGroup.POST("", h.CreateGlobalTemplateHandler)
+		templateGroup.GET("/:templateID", h.GetTemplateHandler)
+		templateGroup.PUT("/:templateID/activate", h.ActivateTemplateHandler)
+	}
+
+	novelGroup := router.Group("/novels/:novelID/ai/prompt-templates")
+	{
+		novelGroup.GET("", h.ListNovelTemplatesHandler)
+		novelGroup.POST("", h.CreateNovelTemplateHandler)
+		novelGroup.GET("/effective", h.EffectiveTemplatesHandler)
+		novelGroup.DELETE("/:type", h.ResetNovelTemplateHandler)
+	}
+}
+
+// ListGlobalTemplatesHandler handles GET /ai/prompt-templates?type=.
+func (h *PromptTemplateHandler) ListGlobalTemplatesHandler(c *gin.Context) {
+	h.listTemplates(c, nil)
+}
+
+// ListNovelTemplatesHandler handles GET /novels/:novelID/ai/prompt-templates?type=.
+func (h *PromptTemplateHandler) ListNovelTemplatesHandler(c *gin.Context) {
+	novelID, ok := parseUUIDParam(c, "novelID", "novel")
+	if !ok {
+		return
+	}
+	h.listTemplates(c, &novelID)
+}
+
+func (h *PromptTemplateHandler) listTemplates(c *gin.Context, novelID *uuid.UUID) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	templates, err := h.templateService.ListTemplates(c.Request.Context(), userID, repository.PromptTemplateFilter{
+		NovelID: novelID,
+		Type:    domain.AISuggestionType(c.Query("type")),
+	})
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve prompt templates")
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// CreateGlobalTemplateHandler handles POST /ai/prompt-templates (administrators only).
+func (h *PromptTemplateHandler) CreateGlobalTemplateHandler(c *gin.Context) {
+	h.createTemplate(c, nil)
+}
+
+// CreateNovelTemplateHandler handles POST /novels/:novelID/ai/prompt-templates.
+func (h *PromptTemplateHandler) CreateNovelTemplateHandler(c *gin.Context) {
+	novelID, ok := parseUUIDParam(c, "novelID", "novel")
+	if !ok {
+		return
+	}
+	h.createTemplate(c, &novelID)
+}
+
+func (h *PromptTemplateHandler) createTemplate(c *gin.Context, novelID *uuid.UUID) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req request.CreatePromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input for prompt template", "details": err.Error()})
+		return
+	}
+
+	template, err := h.templateService.CreateTemplate(c.Request.Context(), userID, service.PromptTemplateInput{
+		NovelID:           novelID,
+		Type:              domain.AISuggestionType(req.Type),
+		SystemPrompt:      req.SystemPrompt,
+		UserPrompt:        req.UserPrompt,
+		RequiredVariables: req.RequiredVariables,
+		Notes:             req.Notes,
+		Activate:          req.Activate,
+	})
+	if err != nil {
+		respondServiceError(c, err, "Failed to create prompt template")
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// GetTemplateHandler handles GET /ai/prompt-templates/:templateID.
+func (h *PromptTemplateHandler) GetTemplateHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	templateID, ok := parseUUIDParam(c, "templateID", "prompt template")
+	if !ok {
+		return
+	}
+
+	template, err := h.templateService.GetTemplate(c.Request.Context(), userID, templateID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve prompt template")
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// ActivateTemplateHandler handles PUT /ai/prompt-templates/:templateID/activate.
+func (h *PromptTemplateHandler) ActivateTemplateHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	templateID, ok := parseUUIDParam(c, "templateID", "prompt template")
+	if !ok {
+		return
+	}
+
+	template, err := h.templateService.ActivateTemplate(c.Request.Context(), userID, templateID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to activate prompt template")
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// EffectiveTemplatesHandler handles GET /novels/:novelID/ai/prompt-templates/effective.
+func (h *PromptTemplateHandler) EffectiveTemplatesHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	novelID, ok := parseUUIDParam(c, "novelID", "novel")
+	if !ok {
+		return
+	}
+
+	templates, err := h.templateService.EffectiveTemplates(c.Request.Context(), userID, novelID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to retrieve prompt templates")
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// ResetNovelTemplateHandler handles DELETE /novels/:novelID/ai/prompt-templates/:type,
+// which returns the type to the global template.
+func (h *PromptTemplateHandler) ResetNovelTemplateHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	novelID, ok := parseUUIDParam(c, "novelID", "novel")
+	if !ok {
+		return
+	}
+
+	err := h.templateService.ResetOverride(c.Request.Context(), userID, novelID, domain.AISuggestionType(c.Param("type")))
+	if err != nil {
+		respondServiceError(c, err, "Failed to reset prompt template")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
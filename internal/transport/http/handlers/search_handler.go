@@ -0,0 +1,55 @@
+// File: internal/transport/http/handlers/search_handler.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khaled2049/server/internal/service"
+)
+
+type SearchHandler struct {
+	embeddingService *service.EmbeddingService
+}
+
+func NewSearchHandler(embeddingService *service.EmbeddingService) *SearchHandler {
+	return &SearchHandler{
+		embeddingService: embeddingService,
+	}
+}
+
+// RegisterRoutes registers search routes on an authenticated router group.
+func (h *SearchHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/novels/:novelID/semantic-search", h.SemanticSearchHandler)
+}
+
+// SemanticSearchHandler handles GET /novels/:novelID/semantic-search?q=&limit=: the
+// passages of the novel's chapters and notes closest in meaning to q, best first.
+func (h *SearchHandler) SemanticSearchHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	novelID, ok := parseUUIDParam(c, "novelID", "novel")
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit", "details": err.Error()})
+			return
+		}
+	}
+
+	results, err := h.embeddingService.Search(c.Request.Context(), userID, novelID, c.Query("q"), limit)
+	if err != nil {
+		respondServiceError(c, err, "Failed to search novel")
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
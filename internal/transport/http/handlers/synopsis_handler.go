@@ -0,0 +1,45 @@
+// File: internal/transport/http/handlers/synopsis_handler.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khaled2049/server/internal/service"
+)
+
+type SynopsisHandler struct {
+	summaryService *service.SummaryService
+}
+
+func NewSynopsisHandler(summaryService *service.SummaryService) *SynopsisHandler {
+	return &SynopsisHandler{
+		summaryService: summaryService,
+	}
+}
+
+// RegisterRoutes registers synopsis routes on an authenticated router group.
+func (h *SynopsisHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/novels/:novelID/synopsis", h.GetSynopsisHandler)
+}
+
+// GetSynopsisHandler handles GET /novels/:novelID/synopsis: the synopsis of the whole
+// novel, of each act and the summaries of its chapters, as generated so far.
+func (h *SynopsisHandler) GetSynopsisHandler(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	novelID, ok := parseUUIDParam(c, "novelID", "novel")
+	if !ok {
+		return
+	}
+
+	synopsis, err := h.summaryService.GetSynopsis(c.Request.Context(), userID, novelID)
+	if err != nil {
+		respondServiceError(c, err, "Failed to get synopsis")
+		return
+	}
+
+	c.JSON(http.StatusOK, synopsis)
+}
@@ -0,0 +1,51 @@
+// File: internal/transport/http/middleware/auth_middleware.go
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/khaled2049/server/internal/util/jwt"
+)
+
+// UserIDKey is the gin context key holding the authenticated user's ID.
+const UserIDKey = "userID"
+
+// TokenValidator validates backend session tokens; implemented by *jwt.Generator.
+type TokenValidator interface {
+	ValidateToken(tokenString string) (*jwt.Claims, error)
+}
+
+// AuthMiddleware requires a valid "Authorization: Bearer <token>" header and
+// stores the user ID from its claims under UserIDKey. Browsers cannot set headers on
+// EventSource and WebSocket connections, so streaming requests may pass the token in
+// the access_token query parameter instead.
+func AuthMiddleware(validator TokenValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		tokenString, found := strings.CutPrefix(header, "Bearer ")
+		if !found && isStreamRequest(c.Request) {
+			tokenString, found = c.Query("access_token"), true
+		}
+		if !found || tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing bearer token"})
+			return
+		}
+
+		claims, err := validator.ValidateToken(tokenString)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			return
+		}
+
+		c.Set(UserIDKey, claims.UserID)
+		c.Next()
+	}
+}
+
+// isStreamRequest reports whether a request opens an event stream or a WebSocket.
+func isStreamRequest(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream") ||
+		strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
@@ -0,0 +1,63 @@
+package request
+
+// CreateAISuggestionRequest defines the payload for requesting AI assistance.
+type CreateAISuggestionRequest struct {
+	NovelID            string  `json:"novel_id" binding:"required,uuid"`
+	Type               string  `json:"type" binding:"required"` // continuation, dialogue, description, ...
+	ContextChapterID   *string `json:"context_chapter_id" binding:"omitempty,uuid"`
+	ContextCharacterID *string `json:"context_character_id" binding:"omitempty,uuid"`
+	ContextPlaceID     *string `json:"context_place_id" binding:"omitempty,uuid"`
+	ContextNoteID      *string `json:"context_note_id" binding:"omitempty,uuid"`
+	Instructions       string  `json:"instructions"`
+	SelectedText       string  `json:"selected_text"`  // Selected text the suggestion is about
+	PromptContext      string  `json:"prompt_context"` // Deprecated: use selected_text
+	// Variants generated side by side (default one per variant option, or one)
+	Variants       int                          `json:"variants" binding:"omitempty,min=1,max=5"`
+	VariantOptions []AISuggestionVariantRequest `json:"variant_options" binding:"omitempty,max=5,dive"`
+}
+
+// AISuggestionVariantRequest overrides the model or temperature of one variant.
+type AISuggestionVariantRequest struct {
+	Model       string   `json:"model"`
+	Temperature *float64 `json:"temperature" binding:"omitempty,min=0,max=2"`
+}
+
+// ReviewAISuggestionRequest defines the optional payload for accepting or rejecting a suggestion.
+type ReviewAISuggestionRequest struct {
+	Feedback *int16 `json:"feedback" binding:"omitempty,min=1,max=5"`
+	Notes    string `json:"notes"`
+}
+
+// AcceptAISuggestionRequest defines the optional payload for accepting a suggestion.
+// Start and end are rune offsets into the context chapter, relative to base_revision_id
+// when given; without them a rewrite replaces the selected text and other chapter
+// suggestions are appended. Name names a character or place created from an idea.
+type AcceptAISuggestionRequest struct {
+	ReviewAISuggestionRequest
+	Start          *int   `json:"start" binding:"omitempty,min=0"`
+	End            *int   `json:"end" binding:"omitempty,min=0"`
+	BaseRevisionID *int64 `json:"base_revision_id"`
+	Name           string `json:"name"`
+}
+
+// EditAISuggestionRequest defines the payload for editing generated content before accepting it.
+type EditAISuggestionRequest struct {
+	Content string `json:"content" binding:"required"`
+	Notes   string `json:"notes"`
+}
+
+// UpdateModerationSettingsRequest defines the payload for a novel's moderation settings.
+type UpdateModerationSettingsRequest struct {
+	ContentRating     string   `json:"content_rating" binding:"required"` // general, teen or mature
+	AllowedCategories []string `json:"allowed_categories"`
+}
+
+// CreatePromptTemplateRequest defines the payload for a new prompt template version.
+type CreatePromptTemplateRequest struct {
+	Type              string   `json:"type" binding:"required"`
+	SystemPrompt      string   `json:"system_prompt" binding:"required"`
+	UserPrompt        string   `json:"user_prompt" binding:"required"` // Go text/template, e.g. {{.context}}
+	RequiredVariables []string `json:"required_variables"`
+	Notes             string   `json:"notes"`
+	Activate          bool     `json:"activate"`
+}
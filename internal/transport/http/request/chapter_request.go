@@ -0,0 +1,25 @@
+package request
+
+// UpdateChapterRequest defines the payload for saving a chapter. Omitted fields are left unchanged.
+type UpdateChapterRequest struct {
+	Title         *string `json:"title"`
+	Content       *string `json:"content"`
+	Status        *string `json:"status"` // draft, published or archived
+	RevisionNotes string  `json:"revision_notes"`
+}
+
+// ProposeChangeSetRequest defines the payload for suggesting changes to a chapter.
+// Provide either changes (rune ranges of the base revision) or the full proposed content.
+type ProposeChangeSetRequest struct {
+	BaseRevisionID *int64                  `json:"base_revision_id"` // Defaults to the current content
+	Description    string                  `json:"description"`
+	Changes        []ProposedChangeRequest `json:"changes" binding:"omitempty,dive"`
+	Content        *string                 `json:"content"`
+}
+
+// ProposedChangeRequest replaces [start, end) of the base revision with text.
+type ProposedChangeRequest struct {
+	Start int    `json:"start" binding:"min=0"`
+	End   int    `json:"end" binding:"min=0"`
+	Text  string `json:"text"`
+}
@@ -0,0 +1,47 @@
+package request
+
+import "github.com/khaled2049/server/internal/domain"
+
+type CreateCharacterRequest struct {
+	Name                string            `json:"name" binding:"required"`
+	Description         string            `json:"description,omitempty"`
+	Backstory           string            `json:"backstory,omitempty"`
+	Motivations         string            `json:"motivations,omitempty"`
+	PhysicalDescription string            `json:"physical_description,omitempty"`
+	ImageURL            string            `json:"image_url,omitempty"`
+	Aliases             []string          `json:"aliases,omitempty"`
+	Attributes          domain.Attributes `json:"attributes,omitempty"` // Validated against the novel's attribute schema
+
+	// NovelID will be taken from the URL path parameter.
+	// ID, CreatedAt, UpdatedAt, Source (defaults to 'user'), and CreatedByUserID (from auth context)
+	// should be handled by the server.
+}
+
+// UpdateCharacterRequest replaces all editable fields of a character.
+type UpdateCharacterRequest struct {
+	Name                string            `json:"name" binding:"required"`
+	Description         string            `json:"description"`
+	Backstory           string            `json:"backstory"`
+	Motivations         string            `json:"motivations"`
+	PhysicalDescription string            `json:"physical_description"`
+	ImageURL            string            `json:"image_url"`
+	Aliases             []string          `json:"aliases"`
+	Attributes          domain.Attributes `json:"attributes"`
+}
+
+type CharacterAttributeDefinitionRequest struct {
+	Key       string   `json:"key" binding:"required"`
+	Label     string   `json:"label"`
+	ValueType string   `json:"value_type"` // text (default), number, boolean, date, enum
+	Required  bool     `json:"required"`
+	Options   []string `json:"options,omitempty"`
+}
+
+// ReplaceCharacterAttributeSchemaRequest sets the whole attribute template; array order is display order.
+type ReplaceCharacterAttributeSchemaRequest struct {
+	Attributes []CharacterAttributeDefinitionRequest `json:"attributes" binding:"dive"`
+}
+
+type DetectMentionsRequest struct {
+	Text string `json:"text" binding:"required"`
+}
@@ -0,0 +1,6 @@
+package request
+
+type SetCollaboratorRequest struct {
+	UserID string `json:"user_id" binding:"required,uuid"`
+	Role   string `json:"role" binding:"required"` // editor, commenter or viewer
+}
@@ -0,0 +1,37 @@
+package request
+
+import "github.com/khaled2049/server/internal/domain"
+
+type CreateCommentRequest struct {
+	TargetType string                `json:"target_type" binding:"required"` // chapter, character, place, note, timeline_event
+	TargetID   string                `json:"target_id" binding:"required,uuid"`
+	Content    string                `json:"content" binding:"required"`
+	Anchor     *CommentAnchorRequest `json:"anchor"` // chapter targets only
+}
+
+// CommentAnchorRequest anchors a comment to the rune range [start, end) of a chapter.
+// quote is optional; when given it must equal the chapter text at the range.
+type CommentAnchorRequest struct {
+	Start int    `json:"start" binding:"min=0"`
+	End   int    `json:"end" binding:"min=0"`
+	Quote string `json:"quote"`
+}
+
+// CreateChapterCommentRequest is used for comments created through the chapter shortcut.
+type CreateChapterCommentRequest struct {
+	Content string                `json:"content" binding:"required"`
+	Anchor  *CommentAnchorRequest `json:"anchor"`
+}
+
+// CommentContentRequest is used for replies, chapter comments and edits.
+type CommentContentRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// ToDomain converts the request into an anchor; a nil request yields nil.
+func (r *CommentAnchorRequest) ToDomain() *domain.CommentAnchor {
+	if r == nil {
+		return nil
+	}
+	return &domain.CommentAnchor{Start: r.Start, End: r.End, Quote: r.Quote}
+}
@@ -0,0 +1,12 @@
+package request
+
+// CreateConsistencyCheckRequest defines the optional payload for checking a chapter.
+type CreateConsistencyCheckRequest struct {
+	UseLLM bool `json:"use_llm"` // Also ask the model, after the rule-based checks
+}
+
+// ConsistencyFindingStatusRequest defines the optional payload for resolving,
+// dismissing or reopening a consistency finding.
+type ConsistencyFindingStatusRequest struct {
+	Note string `json:"note"`
+}
@@ -0,0 +1,9 @@
+package request
+
+// NotificationPreferenceRequest defines the payload for setting how a scope of the
+// user's notifications is emailed. Omit novel_id and type to cover all notifications.
+type NotificationPreferenceRequest struct {
+	NovelID   *string `json:"novel_id"`
+	Type      *string `json:"type"`
+	EmailMode string  `json:"email_mode" binding:"required"`
+}
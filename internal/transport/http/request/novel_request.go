@@ -22,16 +22,3 @@ type AutosaveChapterRequest struct {
 	Content string `json:"content"`
 	UserID  string `json:"user_id" binding:"required"` // Or get from auth context
 }
-
-type CreateCharacterRequest struct {
-	Name                string `json:"name" binding:"required"`
-	Description         string `json:"description,omitempty"`
-	Backstory           string `json:"backstory,omitempty"`
-	Motivations         string `json:"motivations,omitempty"`
-	PhysicalDescription string `json:"physical_description,omitempty"`
-	ImageURL            string `json:"image_url,omitempty"`
-
-	// NovelID will be taken from the URL path parameter.
-	// ID, CreatedAt, UpdatedAt, Source (defaults to 'user'), and CreatedByUserID (from auth context)
-	// should be handled by the server.
-}
@@ -0,0 +1,8 @@
+package request
+
+// PrivateNoteRequest defines the payload for creating or replacing a private note.
+type PrivateNoteRequest struct {
+	NovelID *string `json:"novel_id" binding:"omitempty,uuid"` // Optional novel context
+	Title   string  `json:"title"`
+	Content string  `json:"content" binding:"required"`
+}
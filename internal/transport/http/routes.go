@@ -15,11 +15,46 @@ func RegisterAllRoutes(
 	authHandler *handlers.AuthHandler, 
 	helloHandler *handlers.HelloHandler,
 	novelHandler *handlers.NovelHandler, 
+	characterHandler *handlers.CharacterHandler,
+	commentHandler *handlers.CommentHandler,
+	collaboratorHandler *handlers.CollaboratorHandler,
+	chapterHandler *handlers.ChapterHandler,
+	changeSetHandler *handlers.ChangeSetHandler,
+	privateNoteHandler *handlers.PrivateNoteHandler,
+	aiHandler *handlers.AIHandler,
+	promptTemplateHandler *handlers.PromptTemplateHandler,
+	searchHandler *handlers.SearchHandler,
+	synopsisHandler *handlers.SynopsisHandler,
+	moderationHandler *handlers.ModerationHandler,
+	consistencyHandler *handlers.ConsistencyHandler,
+	notificationHandler *handlers.NotificationHandler,
+	notificationPreferenceHandler *handlers.NotificationPreferenceHandler,
+	eventHandler *handlers.EventHandler,
+	authMiddleware gin.HandlerFunc,
 ) {
 	// Initialize handlers
 	helloHandler.RegisterRoutes(router) 
 	authHandler.RegisterRoutes(router)  
 	novelHandler.RegisterRoutes(router)
+	notificationPreferenceHandler.RegisterPublicRoutes(router)
+
+	// Routes below require a valid backend token
+	authed := router.Group("", authMiddleware)
+	characterHandler.RegisterRoutes(authed)
+	commentHandler.RegisterRoutes(authed)
+	collaboratorHandler.RegisterRoutes(authed)
+	chapterHandler.RegisterRoutes(authed)
+	changeSetHandler.RegisterRoutes(authed)
+	privateNoteHandler.RegisterRoutes(authed)
+	aiHandler.RegisterRoutes(authed)
+	promptTemplateHandler.RegisterRoutes(authed)
+	searchHandler.RegisterRoutes(authed)
+	synopsisHandler.RegisterRoutes(authed)
+	moderationHandler.RegisterRoutes(authed)
+	consistencyHandler.RegisterRoutes(authed)
+	notificationHandler.RegisterRoutes(authed)
+	notificationPreferenceHandler.RegisterRoutes(authed)
+	eventHandler.RegisterRoutes(authed)
 
 
 	// Add health check endpoint (common practice)
@@ -28,6 +28,21 @@ type Server struct {
 	authHandler  *handlers.AuthHandler
 	helloHandler *handlers.HelloHandler
 	novelHandler *handlers.NovelHandler
+	characterHandler *handlers.CharacterHandler
+	commentHandler *handlers.CommentHandler
+	collaboratorHandler *handlers.CollaboratorHandler
+	chapterHandler *handlers.ChapterHandler
+	changeSetHandler *handlers.ChangeSetHandler
+	privateNoteHandler *handlers.PrivateNoteHandler
+	aiHandler *handlers.AIHandler
+	promptTemplateHandler *handlers.PromptTemplateHandler
+	searchHandler *handlers.SearchHandler
+	synopsisHandler *handlers.SynopsisHandler
+	moderationHandler *handlers.ModerationHandler
+	consistencyHandler *handlers.ConsistencyHandler
+	notificationHandler *handlers.NotificationHandler
+	notificationPreferenceHandler *handlers.NotificationPreferenceHandler
+	eventHandler *handlers.EventHandler
 }
 
 // NewServer creates and configures a new HTTP server instance.
@@ -37,6 +52,22 @@ func NewServer(
 	authHandler *handlers.AuthHandler,
 	helloHandler *handlers.HelloHandler,
 	novelHandler *handlers.NovelHandler,
+	characterHandler *handlers.CharacterHandler,
+	commentHandler *handlers.CommentHandler,
+	collaboratorHandler *handlers.CollaboratorHandler,
+	chapterHandler *handlers.ChapterHandler,
+	changeSetHandler *handlers.ChangeSetHandler,
+	privateNoteHandler *handlers.PrivateNoteHandler,
+	aiHandler *handlers.AIHandler,
+	promptTemplateHandler *handlers.PromptTemplateHandler,
+	searchHandler *handlers.SearchHandler,
+	synopsisHandler *handlers.SynopsisHandler,
+	moderationHandler *handlers.ModerationHandler,
+	consistencyHandler *handlers.ConsistencyHandler,
+	notificationHandler *handlers.NotificationHandler,
+	notificationPreferenceHandler *handlers.NotificationPreferenceHandler,
+	eventHandler *handlers.EventHandler,
+	authMiddleware gin.HandlerFunc,
 
 ) *Server {
 	// Set Gin mode (e.g., debug, release, test)
@@ -53,11 +84,29 @@ func NewServer(
 		authHandler:  authHandler,
 		helloHandler: helloHandler,
 		novelHandler: novelHandler,
+		characterHandler: characterHandler,
+		commentHandler: commentHandler,
+		collaboratorHandler: collaboratorHandler,
+		chapterHandler: chapterHandler,
+		changeSetHandler: changeSetHandler,
+		privateNoteHandler: privateNoteHandler,
+		aiHandler: aiHandler,
+		promptTemplateHandler: promptTemplateHandler,
+		searchHandler: searchHandler,
+		synopsisHandler: synopsisHandler,
+		moderationHandler: moderationHandler,
+		consistencyHandler: consistencyHandler,
+		notificationHandler: notificationHandler,
+		notificationPreferenceHandler: notificationPreferenceHandler,
+		eventHandler: eventHandler,
 	}
 
 	// --- Register Routes ---
 	// Pass the engine and handlers to the central registration function
-	RegisterAllRoutes(engine, authHandler, helloHandler, novelHandler)
+	RegisterAllRoutes(engine, authHandler, helloHandler, novelHandler, characterHandler,
+		commentHandler, collaboratorHandler, chapterHandler, changeSetHandler, privateNoteHandler, aiHandler, promptTemplateHandler,
+		searchHandler, synopsisHandler, moderationHandler, consistencyHandler, notificationHandler,
+		notificationPreferenceHandler, eventHandler, authMiddleware)
 
 	return server
 }
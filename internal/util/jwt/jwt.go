@@ -61,5 +61,18 @@ func (g *Generator) GenerateToken(userID string) (string, error) {
 	return tokenString, nil
 }
 
-// --- Add a corresponding ValidateToken function here later ---
-// func (g *Generator) ValidateToken(tokenString string) (*Claims, error) { ... }
\ No newline at end of file
+// ValidateToken parses a token issued by GenerateToken and returns its claims.
+// Expired, not-yet-valid or foreign-signed tokens are rejected.
+func (g *Generator) ValidateToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return g.secretKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	if !token.Valid || claims.UserID == "" {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
\ No newline at end of file
@@ -0,0 +1,232 @@
+// Package textdiff computes token-level diffs between two texts and maps
+// rune offsets in the old text to offsets in the new text.
+package textdiff
+
+import (
+	"unicode"
+)
+
+// OpKind is the kind of a diff segment.
+type OpKind int
+
+const (
+	Equal OpKind = iota
+	Insert
+	Delete
+)
+
+// Op is a contiguous diff segment. Offsets are rune offsets; OldStart/OldEnd are
+// empty for Insert and NewStart/NewEnd are empty for Delete.
+type Op struct {
+	Kind     OpKind
+	OldStart int
+	OldEnd   int
+	NewStart int
+	NewEnd   int
+}
+
+// maxEditDistance bounds the Myers search; beyond it the differing middle is
+// reported as one delete+insert, which is still a correct (if coarse) diff. The search
+// keeps about maxEditDistance²/2 offsets (4 MB), and a rewrite that far apart has
+// little left worth aligning.
+const maxEditDistance = 1000
+
+type token struct {
+	text  string
+	start int // Rune offset in the source text
+	end   int
+}
+
+// Diff returns the segments that turn oldText into newText.
+func Diff(oldText, newText string) []Op {
+	a, b := tokenize(oldText), tokenize(newText)
+
+	// Common prefix and suffix are cheap to strip and cover most edits
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix].text == b[prefix].text {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix].text == b[len(b)-1-suffix].text {
+		suffix++
+	}
+
+	var kinds []OpKind
+	for i := 0; i < prefix; i++ {
+		kinds = append(kinds, Equal)
+	}
+	kinds = append(kinds, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for i := 0; i < suffix; i++ {
+		kinds = append(kinds, Equal)
+	}
+
+	return buildOps(kinds, a, b, len([]rune(oldText)), len([]rune(newText)))
+}
+
+// buildOps walks per-token edit kinds and merges them into rune-offset segments.
+func buildOps(kinds []OpKind, a, b []token, oldLen, newLen int) []Op {
+	var ops []Op
+	ai, bi := 0, 0
+	oldPos, newPos := 0, 0
+
+	emit := func(kind OpKind, oldEnd, newEnd int) {
+		if oldEnd == oldPos && newEnd == newPos {
+			return
+		}
+		if n := len(ops); n > 0 && ops[n-1].Kind == kind {
+			ops[n-1].OldEnd, ops[n-1].NewEnd = oldEnd, newEnd
+		} else {
+			ops = append(ops, Op{Kind: kind, OldStart: oldPos, OldEnd: oldEnd, NewStart: newPos, NewEnd: newEnd})
+		}
+		oldPos, newPos = oldEnd, newEnd
+	}
+
+	for _, kind := range kinds {
+		switch kind {
+		case Equal:
+			emit(Equal, a[ai].end, b[bi].end)
+			ai++
+			bi++
+		case Delete:
+			emit(Delete, a[ai].end, newPos)
+			ai++
+		case Insert:
+			emit(Insert, oldPos, b[bi].end)
+			bi++
+		}
+	}
+	// Tokens cover the whole text, but guard against trailing gaps
+	if oldPos < oldLen {
+		emit(Delete, oldLen, newPos)
+	}
+	if newPos < newLen {
+		emit(Insert, oldPos, newLen)
+	}
+	return ops
+}
+
+// myers returns the per-token edit script between a and b (Myers' O(ND) algorithm).
+func myers(a, b []token) []OpKind {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(n, m)
+	}
+
+	maxD := min(n+m, maxEditDistance)
+	// frontiers[d] holds the furthest x reached on each diagonal k = -d, -d+2, ..., d
+	// with d edits, at index (k+d)/2: only the live diagonals are kept
+	var frontiers [][]int
+	reached := func(d, k int) int { return frontiers[d][(k+d)/2] }
+	// down reports whether the path to diagonal k in round d comes from k+1 (insertion)
+	// rather than k-1 (deletion)
+	down := func(d, k int) bool {
+		return k == -d || (k != d && reached(d-1, k-1) < reached(d-1, k+1))
+	}
+
+	found := false
+	for d := 0; d <= maxD && !found; d++ {
+		frontier := make([]int, d+1)
+		frontiers = append(frontiers, frontier)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			switch {
+			case d == 0:
+			case down(d, k):
+				x = reached(d-1, k+1) // Move down: insertion
+			default:
+				x = reached(d-1, k-1) + 1 // Move right: deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x].text == b[y].text {
+				x++
+				y++
+			}
+			frontier[(k+d)/2] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		return replaceAll(n, m)
+	}
+
+	// Backtrack through the saved frontiers to recover the path
+	var reversed []OpKind
+	x, y := n, m
+	for d := len(frontiers) - 1; d > 0; d-- {
+		k := x - y
+		prevK := k - 1
+		if down(d, k) {
+			prevK = k + 1
+		}
+		prevX := reached(d-1, prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, Equal)
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, Insert)
+		} else {
+			reversed = append(reversed, Delete)
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, Equal)
+		x--
+		y--
+	}
+
+	kinds := make([]OpKind, len(reversed))
+	for i, kind := range reversed {
+		kinds[len(reversed)-1-i] = kind
+	}
+	return kinds
+}
+
+func replaceAll(n, m int) []OpKind {
+	kinds := make([]OpKind, 0, n+m)
+	for i := 0; i < n; i++ {
+		kinds = append(kinds, Delete)
+	}
+	for i := 0; i < m; i++ {
+		kinds = append(kinds, Insert)
+	}
+	return kinds
+}
+
+// tokenize splits text into words, whitespace runs and single other runes so
+// diffs align on word boundaries.
+func tokenize(text string) []token {
+	runes := []rune(text)
+	var tokens []token
+
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' || r == '’':
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		default:
+			return 3
+		}
+	}
+
+	for i := 0; i < len(runes); {
+		c := class(runes[i])
+		j := i + 1
+		if c != 3 {
+			for j < len(runes) && class(runes[j]) == c {
+				j++
+			}
+		}
+		tokens = append(tokens, token{text: string(runes[i:j]), start: i, end: j})
+		i = j
+	}
+	return tokens
+}
@@ -0,0 +1,66 @@
+package textdiff
+
+import "sort"
+
+// Mapper translates rune offsets of the old text into offsets of the new text.
+type Mapper struct {
+	equal []Op // Equal segments in order
+}
+
+// NewMapper builds a Mapper from the diff of oldText and newText.
+func NewMapper(oldText, newText string) *Mapper {
+	return NewMapperFromOps(Diff(oldText, newText))
+}
+
+// NewMapperFromOps builds a Mapper from an existing diff.
+func NewMapperFromOps(ops []Op) *Mapper {
+	m := &Mapper{}
+	for _, op := range ops {
+		if op.Kind == Equal {
+			m.equal = append(m.equal, op)
+		}
+	}
+	return m
+}
+
+// MapRange maps the old range [start, end) to the new text. The result spans the
+// surviving (unchanged) characters of the range and anything inserted between them.
+// ok is false when every character of the range was deleted or replaced.
+func (m *Mapper) MapRange(start, end int) (newStart, newEnd int, ok bool) {
+	if start >= end {
+		// Collapsed range (a caret position): follow it if it sits in unchanged text
+		if pos, found := m.mapPoint(start); found {
+			return pos, pos, true
+		}
+		return 0, 0, false
+	}
+
+	first, last := -1, -1
+	// First equal segment ending after start
+	i := sort.Search(len(m.equal), func(i int) bool { return m.equal[i].OldEnd > start })
+	for ; i < len(m.equal) && m.equal[i].OldStart < end; i++ {
+		seg := m.equal[i]
+		lo, hi := max(start, seg.OldStart), min(end, seg.OldEnd)
+		if lo >= hi {
+			continue
+		}
+		if first < 0 {
+			first = seg.NewStart + (lo - seg.OldStart)
+		}
+		last = seg.NewStart + (hi - seg.OldStart)
+	}
+
+	if first < 0 {
+		return 0, 0, false
+	}
+	return first, last, true
+}
+
+// mapPoint maps a single offset that lies in unchanged text (or at its end).
+func (m *Mapper) mapPoint(pos int) (int, bool) {
+	i := sort.Search(len(m.equal), func(i int) bool { return m.equal[i].OldEnd >= pos })
+	if i < len(m.equal) && m.equal[i].OldStart <= pos {
+		return m.equal[i].NewStart + (pos - m.equal[i].OldStart), true
+	}
+	return 0, false
+}
@@ -0,0 +1,190 @@
+// File: internal/worker/ai_worker.go
+
+// Package worker holds the long-running consumers behind the cmd/ worker binaries.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/mq"
+	"github.com/khaled2049/server/internal/repository"
+	"github.com/khaled2049/server/internal/service"
+)
+
+// aiJob is a suggestion to generate. delivery is nil for jobs found by the sweep.
+type aiJob struct {
+	suggestionID uuid.UUID
+	delivery     *mq.Delivery
+}
+
+// AIWorker consumes AI suggestion jobs and generates them with bounded concurrency.
+// Besides the queue it periodically sweeps the database for suggestions whose message
+// was lost, whose retry is due or whose worker died, so a broker outage only delays
+// generation. It also follows the control commands that cancel generations.
+type AIWorker struct {
+	consumer      mq.Consumer // May be nil to run on the sweep alone
+	generator     *service.AIGenerationService
+	concurrency   int
+	sweepInterval time.Duration
+}
+
+func NewAIWorker(
+	consumer mq.Consumer,
+	generator *service.AIGenerationService,
+	concurrency int,
+	sweepInterval time.Duration) *AIWorker {
+	return &AIWorker{
+		consumer:      consumer,
+		generator:     generator,
+		concurrency:   max(concurrency, 1),
+		sweepInterval: sweepInterval,
+	}
+}
+
+// Run processes jobs until ctx is cancelled. In-flight generations are then
+// interrupted, their suggestions released and their messages requeued; Run returns
+// once every job has settled.
+func (w *AIWorker) Run(ctx context.Context) error {
+	var deliveries <-chan mq.Delivery
+	if w.consumer != nil {
+		var err error
+		deliveries, err = w.consumer.Consume(ctx, mq.TopicAISuggestionRequested)
+		if err != nil {
+			return fmt.Errorf("failed to consume ai suggestion jobs: %w", err)
+		}
+	}
+
+	// Cancellations are followed until the last job has settled
+	controlCtx, stopControl := context.WithCancel(ctx)
+	controlDone := make(chan struct{})
+	go func() {
+		defer close(controlDone)
+		w.generator.WatchControl(controlCtx)
+	}()
+
+	jobs := make(chan aiJob)
+	var wg sync.WaitGroup
+	for i := 0; i < w.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				w.process(ctx, job)
+			}
+		}()
+	}
+
+	err := w.dispatch(ctx, deliveries, jobs)
+	close(jobs)
+	wg.Wait()
+	stopControl()
+	<-controlDone
+	return err
+}
+
+// dispatch feeds jobs from the queue and the sweep to the workers.
+func (w *AIWorker) dispatch(ctx context.Context, deliveries <-chan mq.Delivery, jobs chan<- aiJob) error {
+	sweep := time.NewTicker(w.sweepInterval)
+	defer sweep.Stop()
+
+	// Sweep once at startup to pick up what a previous run left behind
+	w.sweep(ctx, jobs)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case delivery, ok := <-deliveries:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return errors.New("ai suggestion job delivery stopped")
+			}
+			w.dispatchDelivery(ctx, delivery, jobs)
+		case <-sweep.C:
+			w.sweep(ctx, jobs)
+		}
+	}
+}
+
+func (w *AIWorker) dispatchDelivery(ctx context.Context, delivery mq.Delivery, jobs chan<- aiJob) {
+	var payload mq.AISuggestionJob
+	if err := json.Unmarshal(delivery.Body, &payload); err != nil || payload.SuggestionID == uuid.Nil {
+		log.Printf("Dropping malformed AI suggestion job %q: %v", delivery.Key, err)
+		settle(delivery.Nack(false))
+		return
+	}
+
+	select {
+	case jobs <- aiJob{suggestionID: payload.SuggestionID, delivery: &delivery}:
+	case <-ctx.Done():
+		settle(delivery.Nack(true))
+	}
+}
+
+func (w *AIWorker) sweep(ctx context.Context, jobs chan<- aiJob) {
+	ids, err := w.generator.ClaimableSuggestions(ctx, w.concurrency)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Warning: AI suggestion sweep failed: %v", err)
+		}
+		return
+	}
+
+	for _, id := range ids {
+		select {
+		case jobs <- aiJob{suggestionID: id}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// process generates one suggestion and settles its message.
+func (w *AIWorker) process(ctx context.Context, job aiJob) {
+	err := w.generator.Generate(ctx, job.suggestionID)
+	switch {
+	case err == nil:
+		log.Printf("AI suggestion %s processed", job.suggestionID)
+	case errors.Is(err, service.ErrSuggestionNotClaimable),
+		errors.Is(err, repository.ErrAISuggestionNotFound):
+		// Duplicate delivery, or the suggestion was deleted with its novel
+		err = nil
+	case errors.Is(err, service.ErrGenerationCancelled):
+		log.Printf("AI suggestion %s cancelled", job.suggestionID)
+		err = nil
+	case errors.Is(err, service.ErrGenerationInterrupted):
+		log.Printf("AI suggestion %s interrupted; requeueing", job.suggestionID)
+	default:
+		log.Printf("Error: AI suggestion %s: %v", job.suggestionID, err)
+	}
+
+	if job.delivery == nil {
+		return
+	}
+	if err == nil {
+		settle(job.delivery.Ack())
+		return
+	}
+	if ctx.Err() != nil || errors.Is(err, service.ErrGenerationInterrupted) {
+		settle(job.delivery.Nack(true))
+		return
+	}
+	// Infrastructure errors are retried with backoff; past the retry budget the message is
+	// dead-lettered and the sweep still picks the suggestion up
+	settle(job.delivery.Retry())
+}
+
+func settle(err error) {
+	if err != nil {
+		log.Printf("Warning: failed to settle AI suggestion job: %v", err)
+	}
+}
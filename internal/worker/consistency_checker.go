@@ -0,0 +1,126 @@
+// File: internal/worker/consistency_checker.go
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/mq"
+	"github.com/khaled2049/server/internal/repository"
+	"github.com/khaled2049/server/internal/service"
+)
+
+// checkSweepBatch is the number of lost or stalled checks run per sweep.
+const checkSweepBatch = 20
+
+// ConsistencyChecker runs the consistency checks requested through the API as their
+// jobs arrive, and periodically sweeps the database for checks whose job was lost or
+// whose worker stopped.
+type ConsistencyChecker struct {
+	consumer      mq.Consumer // May be nil to run on the sweep alone
+	checks        *service.ConsistencyService
+	sweepInterval time.Duration
+}
+
+func NewConsistencyChecker(
+	consumer mq.Consumer,
+	checks *service.ConsistencyService,
+	sweepInterval time.Duration) *ConsistencyChecker {
+	return &ConsistencyChecker{
+		consumer:      consumer,
+		checks:        checks,
+		sweepInterval: sweepInterval,
+	}
+}
+
+// Run checks until ctx is cancelled, one chapter at a time.
+func (w *ConsistencyChecker) Run(ctx context.Context) error {
+	var deliveries <-chan mq.Delivery
+	if w.consumer != nil {
+		var err error
+		deliveries, err = w.consumer.Consume(ctx, mq.TopicConsistencyCheckRequested)
+		if err != nil {
+			return fmt.Errorf("failed to consume consistency check jobs: %w", err)
+		}
+	}
+
+	sweep := time.NewTicker(w.sweepInterval)
+	defer sweep.Stop()
+
+	w.sweep(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case delivery, ok := <-deliveries:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return errors.New("consistency check job delivery stopped")
+			}
+			w.process(ctx, delivery)
+		case <-sweep.C:
+			w.sweep(ctx)
+		}
+	}
+}
+
+// process runs the check of a job and settles its message.
+func (w *ConsistencyChecker) process(ctx context.Context, delivery mq.Delivery) {
+	var job mq.ConsistencyCheckJob
+	if err := json.Unmarshal(delivery.Body, &job); err != nil || job.CheckID == uuid.Nil {
+		log.Printf("Dropping malformed consistency check job %q: %v", delivery.Key, err)
+		settleCheck(delivery.Nack(false))
+		return
+	}
+
+	err := w.checks.RunCheck(ctx, job.CheckID)
+	switch {
+	case err == nil,
+		errors.Is(err, service.ErrConsistencyCheckNotClaimable),
+		errors.Is(err, repository.ErrConsistencyCheckNotFound),
+		errors.Is(err, repository.ErrChapterNotFound):
+		settleCheck(delivery.Ack())
+	case ctx.Err() != nil:
+		settleCheck(delivery.Nack(true))
+	default:
+		// Past the retry budget the message is dead-lettered; the sweep still runs the check
+		log.Printf("Error: consistency check %s: %v", job.CheckID, err)
+		settleCheck(delivery.Retry())
+	}
+}
+
+// sweep runs a batch of checks that have no live job.
+func (w *ConsistencyChecker) sweep(ctx context.Context) {
+	ids, err := w.checks.ClaimableChecks(ctx, checkSweepBatch)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Warning: consistency check sweep failed: %v", err)
+		}
+		return
+	}
+
+	for _, id := range ids {
+		err := w.checks.RunCheck(ctx, id)
+		switch {
+		case err == nil, errors.Is(err, service.ErrConsistencyCheckNotClaimable):
+		case ctx.Err() != nil:
+			return
+		default:
+			log.Printf("Warning: consistency check %s: %v", id, err)
+		}
+	}
+}
+
+func settleCheck(err error) {
+	if err != nil {
+		log.Printf("Warning: failed to settle consistency check job: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// File: internal/worker/email_dispatcher.go
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/khaled2049/server/internal/service"
+)
+
+// emailBatch is the number of notifications claimed at a time for the email channel.
+const emailBatch = 50
+
+// EmailDispatcher emails notifications: every interval it handles the notifications
+// written since the last run and sends the daily digests that are due. Dispatchers in
+// several processes share the work.
+type EmailDispatcher struct {
+	emails   *service.NotificationEmailService
+	interval time.Duration
+}
+
+func NewEmailDispatcher(emails *service.NotificationEmailService, interval time.Duration) *EmailDispatcher {
+	return &EmailDispatcher{
+		emails:   emails,
+		interval: interval,
+	}
+}
+
+// Run dispatches emails until ctx is cancelled.
+func (w *EmailDispatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.dispatch(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			w.dispatch(ctx)
+		}
+	}
+}
+
+// dispatch handles the pending notifications, batch by batch, then sends the digests due.
+func (w *EmailDispatcher) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		claimed, sent, err := w.emails.SendPending(ctx, emailBatch)
+		if sent > 0 {
+			log.Printf("Emailed %d notifications", sent)
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Error: emailing notifications: %v", err)
+			}
+			return
+		}
+		if claimed < emailBatch {
+			break
+		}
+	}
+
+	for ctx.Err() == nil {
+		recipients, sent, err := w.emails.SendDigests(ctx, time.Now(), emailBatch)
+		if sent > 0 {
+			log.Printf("Sent %d notification digests", sent)
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Error: sending notification digests: %v", err)
+			}
+			return
+		}
+		if recipients < emailBatch {
+			return
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// File: internal/worker/embedding_indexer.go
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/mq"
+	"github.com/khaled2049/server/internal/service"
+)
+
+// indexSweepBatch is the number of stale sources indexed per sweep.
+const indexSweepBatch = 50
+
+// EmbeddingIndexer keeps the semantic search index up to date. It reindexes chapters
+// as their save events arrive and periodically sweeps the database for what the events
+// do not cover: notes, sources indexed with another model and events that were lost.
+type EmbeddingIndexer struct {
+	consumer      mq.Consumer // May be nil to run on the sweep alone
+	indexer       *service.EmbeddingService
+	sweepInterval time.Duration
+}
+
+func NewEmbeddingIndexer(
+	consumer mq.Consumer,
+	indexer *service.EmbeddingService,
+	sweepInterval time.Duration) *EmbeddingIndexer {
+	return &EmbeddingIndexer{
+		consumer:      consumer,
+		indexer:       indexer,
+		sweepInterval: sweepInterval,
+	}
+}
+
+// Run indexes until ctx is cancelled. Sources are indexed one at a time, so saves do
+// not compete with the AI worker for the embedding provider.
+func (w *EmbeddingIndexer) Run(ctx context.Context) error {
+	var deliveries <-chan mq.Delivery
+	if w.consumer != nil {
+		var err error
+		deliveries, err = w.consumer.Consume(ctx, mq.TopicChapterSaved)
+		if err != nil {
+			return fmt.Errorf("failed to consume chapter saved events: %w", err)
+		}
+	}
+
+	sweep := time.NewTicker(w.sweepInterval)
+	defer sweep.Stop()
+
+	// Sweep once at startup to index what changed while no indexer was running
+	w.sweep(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case delivery, ok := <-deliveries:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return errors.New("chapter saved event delivery stopped")
+			}
+			w.process(ctx, delivery)
+		case <-sweep.C:
+			w.sweep(ctx)
+		}
+	}
+}
+
+// process reindexes the chapter of a save event and settles its message.
+func (w *EmbeddingIndexer) process(ctx context.Context, delivery mq.Delivery) {
+	var event mq.ChapterSavedEvent
+	if err := json.Unmarshal(delivery.Body, &event); err != nil || event.ChapterID == uuid.Nil {
+		log.Printf("Dropping malformed chapter saved event %q: %v", delivery.Key, err)
+		settleIndex(delivery.Nack(false))
+		return
+	}
+
+	err := w.indexer.IndexSource(ctx, domain.EmbeddingSourceChapter, event.ChapterID)
+	switch {
+	case err == nil:
+		settleIndex(delivery.Ack())
+	case ctx.Err() != nil:
+		settleIndex(delivery.Nack(true))
+	default:
+		// Past the retry budget the message is dead-lettered; the sweep still reindexes
+		log.Printf("Error: indexing chapter %s: %v", event.ChapterID, err)
+		settleIndex(delivery.Retry())
+	}
+}
+
+// sweep indexes a batch of stale sources and drops the index of deleted ones.
+func (w *EmbeddingIndexer) sweep(ctx context.Context) {
+	sources, err := w.indexer.StaleSources(ctx, indexSweepBatch)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Warning: embedding index sweep failed: %v", err)
+		}
+		return
+	}
+
+	for _, source := range sources {
+		if err := w.indexer.IndexSource(ctx, source.Type, source.ID); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Warning: indexing %s %s: %v", source.Type, source.ID, err)
+		}
+	}
+
+	pruned, err := w.indexer.PruneOrphans(ctx)
+	switch {
+	case err != nil && ctx.Err() == nil:
+		log.Printf("Warning: pruning the embedding index failed: %v", err)
+	case pruned > 0:
+		log.Printf("Dropped the embedding index of %d deleted chapters and notes", pruned)
+	}
+}
+
+func settleIndex(err error) {
+	if err != nil {
+		log.Printf("Warning: failed to settle chapter saved event: %v", err)
+	}
+}
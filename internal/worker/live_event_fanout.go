@@ -0,0 +1,54 @@
+// File: internal/worker/live_event_fanout.go
+package worker
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/khaled2049/server/internal/service"
+)
+
+// LiveEventFanout runs in every API server: it hands the live events published by any
+// process to the clients connected here (see LiveEventService.Follow) and prunes the
+// events past their retention every hour.
+type LiveEventFanout struct {
+	live *service.LiveEventService
+}
+
+func NewLiveEventFanout(live *service.LiveEventService) *LiveEventFanout {
+	return &LiveEventFanout{
+		live: live,
+	}
+}
+
+// Run fans out events until ctx is cancelled.
+func (w *LiveEventFanout) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.live.Follow(ctx)
+	}()
+	defer wg.Wait()
+
+	prune := time.NewTicker(time.Hour)
+	defer prune.Stop()
+
+	w.prune(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-prune.C:
+			w.prune(ctx)
+		}
+	}
+}
+
+func (w *LiveEventFanout) prune(ctx context.Context) {
+	if _, err := w.live.Prune(ctx, time.Now()); err != nil && ctx.Err() == nil {
+		log.Printf("Warning: failed to prune live events: %v", err)
+	}
+}
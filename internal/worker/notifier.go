@@ -0,0 +1,149 @@
+// File: internal/worker/notifier.go
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/khaled2049/server/internal/mq"
+	"github.com/khaled2049/server/internal/repository"
+	"github.com/khaled2049/server/internal/service"
+)
+
+// notifierTopics are the domain events the notifier turns into notifications.
+var notifierTopics = []string{
+	mq.TopicCommentCreated,
+	mq.TopicCollaboratorChanged,
+	mq.TopicAISuggestionGenerated,
+	mq.TopicChapterPublished,
+}
+
+// Notifier fills the users' notification inboxes from domain events. Each topic is
+// consumed on its own, one event at a time.
+type Notifier struct {
+	consumer      mq.Consumer
+	notifications *service.NotificationService
+}
+
+func NewNotifier(consumer mq.Consumer, notifications *service.NotificationService) *Notifier {
+	return &Notifier{
+		consumer:      consumer,
+		notifications: notifications,
+	}
+}
+
+// Run notifies until ctx is cancelled or the delivery of a topic stops.
+func (w *Notifier) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	subscriptions := make(map[string]<-chan mq.Delivery, len(notifierTopics))
+	for _, topic := range notifierTopics {
+		deliveries, err := w.consumer.Consume(ctx, topic)
+		if err != nil {
+			return fmt.Errorf("failed to consume %s events: %w", topic, err)
+		}
+		subscriptions[topic] = deliveries
+	}
+
+	var (
+		wg      sync.WaitGroup
+		once    sync.Once
+		stopErr error
+	)
+	for topic, deliveries := range subscriptions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := w.drain(ctx, topic, deliveries); err != nil {
+				once.Do(func() { stopErr = err })
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	return stopErr
+}
+
+// drain processes the deliveries of a topic until ctx is cancelled.
+func (w *Notifier) drain(ctx context.Context, topic string, deliveries <-chan mq.Delivery) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case delivery, ok := <-deliveries:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("%s event delivery stopped", topic)
+			}
+			w.process(ctx, topic, delivery)
+		}
+	}
+}
+
+// process notifies the recipients of an event and settles its message.
+func (w *Notifier) process(ctx context.Context, topic string, delivery mq.Delivery) {
+	eventID := delivery.Headers[outboxIDHeader]
+
+	var (
+		notified int
+		err      error
+	)
+	switch topic {
+	case mq.TopicCommentCreated:
+		var event mq.CommentCreatedEvent
+		if err = json.Unmarshal(delivery.Body, &event); err == nil {
+			notified, err = w.notifications.NotifyCommentCreated(ctx, event)
+		}
+	case mq.TopicCollaboratorChanged:
+		var event mq.CollaboratorChangedEvent
+		if err = json.Unmarshal(delivery.Body, &event); err == nil {
+			notified, err = w.notifications.NotifyCollaboratorChanged(ctx, event, eventID)
+		}
+	case mq.TopicAISuggestionGenerated:
+		var event mq.AISuggestionGeneratedEvent
+		if err = json.Unmarshal(delivery.Body, &event); err == nil {
+			notified, err = w.notifications.NotifySuggestionGenerated(ctx, event)
+		}
+	case mq.TopicChapterPublished:
+		var event mq.ChapterPublishedEvent
+		if err = json.Unmarshal(delivery.Body, &event); err == nil {
+			notified, err = w.notifications.NotifyChapterPublished(ctx, event, eventID)
+		}
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		log.Printf("Dropping malformed %s event %q: %v", topic, delivery.Key, err)
+		settleNotification(delivery.Nack(false))
+	case err == nil:
+		if notified > 0 {
+			log.Printf("Sent %d notifications for %s event %q", notified, topic, delivery.Key)
+		}
+		settleNotification(delivery.Ack())
+	case errors.Is(err, repository.ErrCommentNotFound),
+		errors.Is(err, repository.ErrNovelNotFound),
+		errors.Is(err, repository.ErrChapterNotFound):
+		// Deleted since; there is nothing left to notify about
+		settleNotification(delivery.Ack())
+	case ctx.Err() != nil:
+		settleNotification(delivery.Nack(true))
+	default:
+		log.Printf("Error: notifying %s event %q: %v", topic, delivery.Key, err)
+		settleNotification(delivery.Retry())
+	}
+}
+
+func settleNotification(err error) {
+	if err != nil {
+		log.Printf("Warning: failed to settle notification event: %v", err)
+	}
+}
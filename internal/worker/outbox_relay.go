@@ -0,0 +1,155 @@
+// File: internal/worker/outbox_relay.go
+package worker
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khaled2049/server/internal/domain"
+	"github.com/khaled2049/server/internal/mq"
+	"github.com/khaled2049/server/internal/repository"
+)
+
+// outboxIDHeader lets consumers de-duplicate events the relay published twice.
+const outboxIDHeader = "outbox-id"
+
+// OutboxRelay publishes outbox events through the message queue. One relay publishes
+// at a time across all processes (see OutboxRepository.LockRelay), in outbox order;
+// when an event fails, later events of the same aggregate wait for the next round so
+// per-aggregate order holds. An event that failed maxAttempts times is parked: it stays
+// in the outbox but is no longer retried, so it cannot hold back newer events. Events
+// may be published more than once.
+type OutboxRelay struct {
+	transactor   repository.Transactor
+	outboxRepo   repository.OutboxRepository
+	producer     mq.Producer
+	pollInterval time.Duration
+	batchSize    int
+	maxAttempts  int
+	retention    time.Duration
+}
+
+func NewOutboxRelay(
+	transactor repository.Transactor,
+	outboxRepo repository.OutboxRepository,
+	producer mq.Producer,
+	pollInterval time.Duration,
+	batchSize int,
+	maxAttempts int,
+	retention time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		transactor:   transactor,
+		outboxRepo:   outboxRepo,
+		producer:     producer,
+		pollInterval: pollInterval,
+		batchSize:    max(batchSize, 1),
+		maxAttempts:  max(maxAttempts, 1),
+		retention:    retention,
+	}
+}
+
+// Run relays events until ctx is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	poll := time.NewTicker(r.pollInterval)
+	defer poll.Stop()
+	prune := time.NewTicker(time.Hour)
+	defer prune.Stop()
+
+	for {
+		// Drain a backlog without waiting for the next tick
+		for {
+			sent, full, err := r.relayBatch(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Warning: outbox relay failed: %v", err)
+			}
+			if err != nil || !full || sent == 0 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		case <-prune.C:
+			r.prune(ctx)
+		}
+	}
+}
+
+// relayBatch publishes one batch of pending events. full reports that the batch was
+// full, so more events may be waiting.
+func (r *OutboxRelay) relayBatch(ctx context.Context) (sent int, full bool, err error) {
+	err = r.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		locked, err := r.outboxRepo.LockRelay(ctx)
+		if err != nil || !locked {
+			return err
+		}
+
+		events, err := r.outboxRepo.ListPending(ctx, r.maxAttempts, r.batchSize)
+		if err != nil {
+			return err
+		}
+		full = len(events) == r.batchSize
+
+		type aggregate struct {
+			kind string
+			id   uuid.UUID
+		}
+		blocked := map[aggregate]bool{}
+		sentIDs := make([]int64, 0, len(events))
+		for _, event := range events {
+			key := aggregate{event.AggregateType, event.AggregateID}
+			if blocked[key] {
+				continue
+			}
+			if err := r.producer.Publish(ctx, toMessage(event)); err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				blocked[key] = true
+				if event.Attempts+1 >= r.maxAttempts {
+					log.Printf("Error: parking outbox event %d (%s) after %d failed attempts: %v",
+						event.ID, event.Topic, event.Attempts+1, err)
+				}
+				if err := r.outboxRepo.MarkFailed(ctx, event.ID, err.Error()); err != nil {
+					return err
+				}
+				continue
+			}
+			sentIDs = append(sentIDs, event.ID)
+		}
+
+		sent = len(sentIDs)
+		// Store progress even if shutdown interrupted the batch
+		return r.outboxRepo.MarkSent(context.WithoutCancel(ctx), sentIDs)
+	})
+	return sent, full, err
+}
+
+func (r *OutboxRelay) prune(ctx context.Context) {
+	if r.retention <= 0 {
+		return
+	}
+	if _, err := r.outboxRepo.DeleteSentBefore(ctx, time.Now().Add(-r.retention)); err != nil {
+		log.Printf("Warning: failed to prune outbox: %v", err)
+	}
+}
+
+func toMessage(event *domain.OutboxEvent) mq.Message {
+	headers := make(map[string]string, len(event.Headers)+1)
+	for k, v := range event.Headers {
+		headers[k] = v
+	}
+	headers[outboxIDHeader] = strconv.FormatInt(event.ID, 10)
+
+	return mq.Message{
+		Topic:   event.Topic,
+		Key:     event.AggregateID.String(),
+		Body:    event.Payload,
+		Headers: headers,
+	}
+}
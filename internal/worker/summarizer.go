@@ -0,0 +1,50 @@
+// File: internal/worker/summarizer.go
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/khaled2049/server/internal/service"
+)
+
+// Summarizer periodically enqueues the summaries of chapters that stopped changing and
+// keeps novel synopses up to date with them. The summaries themselves are generated by
+// the AI worker.
+type Summarizer struct {
+	summaries *service.SummaryService
+	interval  time.Duration
+}
+
+func NewSummarizer(summaries *service.SummaryService, interval time.Duration) *Summarizer {
+	return &Summarizer{
+		summaries: summaries,
+		interval:  interval,
+	}
+}
+
+// Run sweeps until ctx is cancelled.
+func (w *Summarizer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.sweep(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Summarizer) sweep(ctx context.Context) {
+	enqueued, err := w.summaries.Sweep(ctx)
+	switch {
+	case err != nil && ctx.Err() == nil:
+		log.Printf("Warning: summary sweep failed: %v", err)
+	case enqueued > 0:
+		log.Printf("Enqueued %d automatic summaries", enqueued)
+	}
+}
@@ -0,0 +1,12 @@
+-- File: migrations/000002_character_aliases_attributes.down.sql
+
+DROP TRIGGER IF EXISTS update_character_attribute_definitions_updated_at ON character_attribute_definitions;
+DROP TABLE IF EXISTS character_attribute_definitions;
+
+DROP INDEX IF EXISTS idx_characters_attributes;
+DROP INDEX IF EXISTS idx_characters_aliases;
+ALTER TABLE characters
+    DROP COLUMN IF EXISTS attributes,
+    DROP COLUMN IF EXISTS aliases;
+
+DROP TYPE IF EXISTS character_attribute_type;
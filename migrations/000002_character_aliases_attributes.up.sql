@@ -0,0 +1,31 @@
+-- File: migrations/000002_character_aliases_attributes.up.sql
+
+-- Value types a per-novel character attribute can hold
+CREATE TYPE character_attribute_type AS ENUM ('text', 'number', 'boolean', 'date', 'enum');
+
+-- Aliases/nicknames are used for search and mention detection,
+-- attributes hold the values of the novel's attribute schema (keyed by definition key)
+ALTER TABLE characters
+    ADD COLUMN aliases TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN attributes JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+CREATE INDEX idx_characters_aliases ON characters USING gin (aliases);
+CREATE INDEX idx_characters_attributes ON characters USING gin (attributes jsonb_path_ops); -- Supports @> filtering
+
+-- Schema template: the typed attribute fields a novel's characters may carry
+CREATE TABLE character_attribute_definitions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    novel_id UUID NOT NULL REFERENCES novels(id) ON DELETE CASCADE,
+    key VARCHAR(64) NOT NULL, -- Stable key used inside characters.attributes
+    label TEXT NOT NULL,
+    value_type character_attribute_type NOT NULL DEFAULT 'text',
+    required BOOLEAN NOT NULL DEFAULT false,
+    options TEXT[] NOT NULL DEFAULT '{}', -- Allowed values when value_type = 'enum'
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (novel_id, key)
+);
+CREATE INDEX idx_character_attribute_definitions_novel_id ON character_attribute_definitions(novel_id, position);
+
+CREATE TRIGGER update_character_attribute_definitions_updated_at BEFORE UPDATE ON character_attribute_definitions FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
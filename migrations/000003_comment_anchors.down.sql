@@ -0,0 +1,9 @@
+-- File: migrations/000003_comment_anchors.down.sql
+
+DROP INDEX IF EXISTS idx_comments_anchored_chapter;
+ALTER TABLE comments
+    DROP CONSTRAINT IF EXISTS comments_anchor_range_check,
+    DROP COLUMN IF EXISTS anchor_orphaned,
+    DROP COLUMN IF EXISTS anchor_quote,
+    DROP COLUMN IF EXISTS anchor_end,
+    DROP COLUMN IF EXISTS anchor_start;
@@ -0,0 +1,16 @@
+-- File: migrations/000003_comment_anchors.up.sql
+
+-- Inline chapter comments anchor to a rune range of chapters.content.
+-- anchor_quote is the text the range covered when last mapped (fingerprint);
+-- anchors whose text is deleted by an edit are flagged orphaned instead of moved.
+ALTER TABLE comments
+    ADD COLUMN anchor_start INTEGER,
+    ADD COLUMN anchor_end INTEGER,
+    ADD COLUMN anchor_quote TEXT,
+    ADD COLUMN anchor_orphaned BOOLEAN NOT NULL DEFAULT false,
+    ADD CONSTRAINT comments_anchor_range_check CHECK (
+        (anchor_start IS NULL AND anchor_end IS NULL)
+        OR (anchor_start >= 0 AND anchor_end >= anchor_start AND target_chapter_id IS NOT NULL)
+    );
+
+CREATE INDEX idx_comments_anchored_chapter ON comments(target_chapter_id) WHERE anchor_start IS NOT NULL;
@@ -0,0 +1,5 @@
+-- File: migrations/000004_chapter_change_sets.down.sql
+
+DROP TABLE IF EXISTS chapter_changes;
+DROP TABLE IF EXISTS chapter_change_sets;
+DROP TYPE IF EXISTS chapter_change_status;
@@ -0,0 +1,41 @@
+-- File: migrations/000004_chapter_change_sets.up.sql
+
+-- Track-changes: proposed edits are stored against a chapter revision and never touch
+-- chapters.content until the owner accepts them one by one.
+CREATE TYPE chapter_change_status AS ENUM ('pending', 'accepted', 'rejected');
+
+CREATE TABLE chapter_change_sets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    chapter_id UUID NOT NULL REFERENCES chapters(id) ON DELETE CASCADE,
+    novel_id UUID NOT NULL REFERENCES novels(id) ON DELETE CASCADE, -- Denormalized for access checks
+    base_revision_id BIGINT NOT NULL REFERENCES chapter_revisions(id) ON DELETE CASCADE,
+    proposed_by_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_chapter_change_sets_chapter_id ON chapter_change_sets(chapter_id, created_at DESC);
+CREATE INDEX idx_chapter_change_sets_proposed_by ON chapter_change_sets(proposed_by_user_id);
+
+-- A change replaces the rune range [base_start, base_end) of the base revision with inserted_text.
+-- Pure insertions have base_start = base_end, pure deletions an empty inserted_text.
+CREATE TABLE chapter_changes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    change_set_id UUID NOT NULL REFERENCES chapter_change_sets(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL, -- Order within the set
+    base_start INTEGER NOT NULL,
+    base_end INTEGER NOT NULL,
+    deleted_text TEXT NOT NULL DEFAULT '', -- Base text being replaced, used to detect conflicts
+    inserted_text TEXT NOT NULL DEFAULT '',
+    status chapter_change_status NOT NULL DEFAULT 'pending',
+    resolved_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMPTZ,
+    applied_revision_id BIGINT REFERENCES chapter_revisions(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (base_start >= 0 AND base_end >= base_start),
+    CHECK (base_end > base_start OR inserted_text <> ''),
+    UNIQUE (change_set_id, position)
+);
+CREATE INDEX idx_chapter_changes_pending ON chapter_changes(change_set_id) WHERE status = 'pending';
+
+CREATE TRIGGER update_chapter_changes_updated_at BEFORE UPDATE ON chapter_changes FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
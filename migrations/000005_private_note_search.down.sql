@@ -0,0 +1,3 @@
+-- File: migrations/000005_private_note_search.down.sql
+
+DROP INDEX IF EXISTS idx_user_private_notes_search;
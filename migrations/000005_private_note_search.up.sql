@@ -0,0 +1,5 @@
+-- File: migrations/000005_private_note_search.up.sql
+
+-- Full-text search over a user's private notes (GET /me/notes?q=)
+CREATE INDEX idx_user_private_notes_search ON user_private_notes
+    USING gin (to_tsvector('simple', COALESCE(title, '') || ' ' || content));
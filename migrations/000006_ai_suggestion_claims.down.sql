@@ -0,0 +1,3 @@
+-- File: migrations/000006_ai_suggestion_claims.down.sql
+
+DROP INDEX IF EXISTS idx_ai_suggestions_claimable;
@@ -0,0 +1,6 @@
+-- File: migrations/000006_ai_suggestion_claims.up.sql
+
+-- The AI worker sweeps for pending suggestions whose job was lost and for generating
+-- suggestions whose claim went stale (updated_at is the claim time)
+CREATE INDEX idx_ai_suggestions_claimable ON ai_suggestions(updated_at)
+    WHERE status IN ('pending', 'generating');
@@ -0,0 +1,3 @@
+-- File: migrations/000007_outbox.down.sql
+
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,19 @@
+-- File: migrations/000007_outbox.up.sql
+
+-- Transactional outbox: domain events are written in the transaction of the change
+-- they describe and published to the message queue by the relay afterwards
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY, -- Publishing order
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    topic VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    headers JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMPTZ,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE INDEX idx_outbox_pending ON outbox(id) WHERE sent_at IS NULL;
+CREATE INDEX idx_outbox_sent_at ON outbox(sent_at) WHERE sent_at IS NOT NULL;
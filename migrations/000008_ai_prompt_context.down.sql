@@ -0,0 +1,5 @@
+-- File: migrations/000008_ai_prompt_context.down.sql
+
+UPDATE ai_suggestions SET prompt_context = selected_text;
+
+ALTER TABLE ai_suggestions DROP COLUMN IF EXISTS selected_text;
@@ -0,0 +1,8 @@
+-- File: migrations/000008_ai_prompt_context.up.sql
+
+-- prompt_context now holds the context the worker assembled and sent to the model;
+-- the text the author selected moves to its own column
+ALTER TABLE ai_suggestions ADD COLUMN selected_text TEXT;
+
+UPDATE ai_suggestions SET selected_text = prompt_context;
+UPDATE ai_suggestions SET prompt_context = NULL WHERE status IN ('pending', 'generating');
@@ -0,0 +1,3 @@
+-- File: migrations/000009_prompt_templates.down.sql
+
+DROP TABLE IF EXISTS prompt_templates;
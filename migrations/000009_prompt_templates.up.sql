@@ -0,0 +1,46 @@
+-- File: migrations/000009_prompt_templates.up.sql
+
+-- Prompt templates per suggestion type. Templates are never edited: a change is a new
+-- version, and one version per type and scope is active. novel_id scopes a novel's
+-- override; NULL is the global template.
+CREATE TABLE prompt_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    suggestion_type ai_suggestion_type NOT NULL,
+    novel_id UUID REFERENCES novels(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    system_prompt TEXT NOT NULL,
+    user_prompt TEXT NOT NULL, -- Go text/template over the prompt variables
+    required_variables TEXT[] NOT NULL DEFAULT '{}',
+    is_active BOOLEAN NOT NULL DEFAULT false,
+    notes TEXT,
+    created_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE UNIQUE INDEX idx_prompt_templates_version
+    ON prompt_templates(suggestion_type, COALESCE(novel_id, '00000000-0000-0000-0000-000000000000'), version);
+CREATE UNIQUE INDEX idx_prompt_templates_active
+    ON prompt_templates(suggestion_type, COALESCE(novel_id, '00000000-0000-0000-0000-000000000000'))
+    WHERE is_active;
+CREATE INDEX idx_prompt_templates_novel_id ON prompt_templates(novel_id) WHERE novel_id IS NOT NULL;
+
+-- Version 1 of the global templates reproduces the prompts the worker was built with
+INSERT INTO prompt_templates (suggestion_type, version, system_prompt, user_prompt, required_variables, is_active, notes)
+VALUES
+    ('continuation', 1, 'You are a writing assistant helping an author with their novel. Match the novel''s tone and style. Reply with the requested text only, without commentary.',
+     E'{{.context}}\n\n{{if .selected_text}}Selected text:\n{{.selected_text}}\n\n{{end}}{{if .instructions}}Author''s instructions: {{.instructions}}\n\n{{end}}Task: Continue the chapter from where the text ends.', '{}', true, 'Initial template'),
+    ('dialogue', 1, 'You are a writing assistant helping an author with their novel. Match the novel''s tone and style. Reply with the requested text only, without commentary.',
+     E'{{.context}}\n\n{{if .selected_text}}Selected text:\n{{.selected_text}}\n\n{{end}}{{if .instructions}}Author''s instructions: {{.instructions}}\n\n{{end}}Task: Write dialogue for the scene.', '{}', true, 'Initial template'),
+    ('description', 1, 'You are a writing assistant helping an author with their novel. Match the novel''s tone and style. Reply with the requested text only, without commentary.',
+     E'{{.context}}\n\n{{if .selected_text}}Selected text:\n{{.selected_text}}\n\n{{end}}{{if .instructions}}Author''s instructions: {{.instructions}}\n\n{{end}}Task: Write a vivid description for the scene.', '{}', true, 'Initial template'),
+    ('character_idea', 1, 'You are a writing assistant helping an author with their novel. Match the novel''s tone and style. Reply with the requested text only, without commentary.',
+     E'{{.context}}\n\n{{if .selected_text}}Selected text:\n{{.selected_text}}\n\n{{end}}{{if .instructions}}Author''s instructions: {{.instructions}}\n\n{{end}}Task: Propose a new character who fits the story, with a name and a short description.', '{}', true, 'Initial template'),
+    ('place_idea', 1, 'You are a writing assistant helping an author with their novel. Match the novel''s tone and style. Reply with the requested text only, without commentary.',
+     E'{{.context}}\n\n{{if .selected_text}}Selected text:\n{{.selected_text}}\n\n{{end}}{{if .instructions}}Author''s instructions: {{.instructions}}\n\n{{end}}Task: Propose a new place that fits the story, with a name and a short description.', '{}', true, 'Initial template'),
+    ('plot_point', 1, 'You are a writing assistant helping an author with their novel. Match the novel''s tone and style. Reply with the requested text only, without commentary.',
+     E'{{.context}}\n\n{{if .selected_text}}Selected text:\n{{.selected_text}}\n\n{{end}}{{if .instructions}}Author''s instructions: {{.instructions}}\n\n{{end}}Task: Propose the next plot point.', '{}', true, 'Initial template'),
+    ('summary', 1, 'You are a writing assistant helping an author with their novel. Match the novel''s tone and style. Reply with the requested text only, without commentary.',
+     E'{{.context}}\n\n{{if .selected_text}}Selected text:\n{{.selected_text}}\n\n{{end}}{{if .instructions}}Author''s instructions: {{.instructions}}\n\n{{end}}Task: Summarize the text.', '{}', true, 'Initial template'),
+    ('rewrite', 1, 'You are a writing assistant helping an author with their novel. Match the novel''s tone and style. Reply with the requested text only, without commentary.',
+     E'{{.context}}\n\n{{if .selected_text}}Selected text:\n{{.selected_text}}\n\n{{end}}{{if .instructions}}Author''s instructions: {{.instructions}}\n\n{{end}}Task: Rewrite the selected text.', '{selected_text}', true, 'Initial template'),
+    ('brainstorm', 1, 'You are a writing assistant helping an author with their novel. Match the novel''s tone and style. Reply with the requested text only, without commentary.',
+     E'{{.context}}\n\n{{if .selected_text}}Selected text:\n{{.selected_text}}\n\n{{end}}{{if .instructions}}Author''s instructions: {{.instructions}}\n\n{{end}}Task: Brainstorm a few ideas the author could develop.', '{}', true, 'Initial template');
@@ -0,0 +1,13 @@
+-- File: migrations/000010_ai_suggestion_application.down.sql
+
+DROP INDEX IF EXISTS idx_ai_suggestions_applied_revision;
+
+ALTER TABLE ai_suggestions
+    DROP CONSTRAINT IF EXISTS ai_suggestions_applied_range,
+    DROP COLUMN IF EXISTS applied_note_id,
+    DROP COLUMN IF EXISTS applied_place_id,
+    DROP COLUMN IF EXISTS applied_character_id,
+    DROP COLUMN IF EXISTS applied_end,
+    DROP COLUMN IF EXISTS applied_start,
+    DROP COLUMN IF EXISTS applied_revision_id,
+    DROP COLUMN IF EXISTS applied_at;
@@ -0,0 +1,15 @@
+-- File: migrations/000010_ai_suggestion_application.up.sql
+
+-- Where an accepted suggestion's text went: a rune range of the chapter revision it
+-- created, or the character, place or note created from it
+ALTER TABLE ai_suggestions
+    ADD COLUMN applied_at TIMESTAMPTZ,
+    ADD COLUMN applied_revision_id BIGINT REFERENCES chapter_revisions(id) ON DELETE SET NULL,
+    ADD COLUMN applied_start INTEGER,
+    ADD COLUMN applied_end INTEGER,
+    ADD COLUMN applied_character_id UUID REFERENCES characters(id) ON DELETE SET NULL,
+    ADD COLUMN applied_place_id UUID REFERENCES places(id) ON DELETE SET NULL,
+    ADD COLUMN applied_note_id UUID REFERENCES notes(id) ON DELETE SET NULL,
+    ADD CONSTRAINT ai_suggestions_applied_range CHECK (applied_start IS NULL OR (applied_start >= 0 AND applied_end >= applied_start));
+
+CREATE INDEX idx_ai_suggestions_applied_revision ON ai_suggestions(applied_revision_id) WHERE applied_revision_id IS NOT NULL;
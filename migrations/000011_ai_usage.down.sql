@@ -0,0 +1,3 @@
+-- File: migrations/000011_ai_usage.down.sql
+
+DROP TABLE IF EXISTS ai_usage;
@@ -0,0 +1,20 @@
+-- File: migrations/000011_ai_usage.up.sql
+
+-- AI usage ledger: one row per completed generation, written with its result. Quotas
+-- and usage reports sum it per user or novel over a period.
+CREATE TABLE ai_usage (
+    id BIGSERIAL PRIMARY KEY,
+    suggestion_id UUID REFERENCES ai_suggestions(id) ON DELETE SET NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    novel_id UUID NOT NULL REFERENCES novels(id) ON DELETE CASCADE,
+    model VARCHAR(100) NOT NULL DEFAULT '',
+    prompt_tokens INT NOT NULL DEFAULT 0 CHECK (prompt_tokens >= 0),
+    completion_tokens INT NOT NULL DEFAULT 0 CHECK (completion_tokens >= 0),
+    total_tokens INT NOT NULL DEFAULT 0 CHECK (total_tokens >= 0),
+    estimated_cost NUMERIC(12, 6) NOT NULL DEFAULT 0, -- USD, from the configured prices
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_ai_usage_user_recorded_at ON ai_usage(user_id, recorded_at);
+CREATE INDEX idx_ai_usage_novel_recorded_at ON ai_usage(novel_id, recorded_at);
+CREATE INDEX idx_ai_usage_suggestion_id ON ai_usage(suggestion_id);
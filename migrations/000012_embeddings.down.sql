@@ -0,0 +1,4 @@
+-- File: migrations/000012_embeddings.down.sql
+
+DROP TABLE IF EXISTS embedding_chunks;
+DROP TABLE IF EXISTS embedding_sources;
@@ -0,0 +1,32 @@
+-- File: migrations/000012_embeddings.up.sql
+
+-- Semantic search index: chapters and shared notes are split into passages whose
+-- embeddings are stored as plain REAL arrays and scored in the application, so no
+-- database extension is needed.
+CREATE TABLE embedding_sources (
+    source_type VARCHAR(20) NOT NULL CHECK (source_type IN ('chapter', 'note')),
+    source_id UUID NOT NULL, -- A chapter or note; rows of deleted sources are pruned by the indexer
+    novel_id UUID NOT NULL REFERENCES novels(id) ON DELETE CASCADE,
+    model VARCHAR(100) NOT NULL, -- Vectors of different models are not comparable
+    title TEXT NOT NULL DEFAULT '', -- Title the passages were embedded with
+    source_updated_at TIMESTAMPTZ NOT NULL, -- updated_at of the indexed version of the source
+    indexed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (source_type, source_id)
+);
+
+CREATE TABLE embedding_chunks (
+    source_type VARCHAR(20) NOT NULL,
+    source_id UUID NOT NULL,
+    chunk_index INT NOT NULL,
+    novel_id UUID NOT NULL REFERENCES novels(id) ON DELETE CASCADE,
+    start_offset INT NOT NULL, -- Rune range of the passage in the source text
+    end_offset INT NOT NULL,
+    content TEXT NOT NULL,
+    embedding REAL[] NOT NULL,
+    PRIMARY KEY (source_type, source_id, chunk_index),
+    FOREIGN KEY (source_type, source_id) REFERENCES embedding_sources(source_type, source_id) ON DELETE CASCADE,
+    CONSTRAINT embedding_chunks_range CHECK (start_offset >= 0 AND end_offset >= start_offset)
+);
+
+CREATE INDEX idx_embedding_sources_novel_id ON embedding_sources(novel_id);
+CREATE INDEX idx_embedding_chunks_novel_id ON embedding_chunks(novel_id);
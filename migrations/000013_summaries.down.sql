@@ -0,0 +1,6 @@
+-- File: migrations/000013_summaries.down.sql
+
+DROP TABLE IF EXISTS novel_synopses;
+DROP TABLE IF EXISTS chapter_summaries;
+DROP INDEX IF EXISTS idx_ai_suggestions_automatic_chapter;
+ALTER TABLE ai_suggestions DROP COLUMN IF EXISTS automatic;
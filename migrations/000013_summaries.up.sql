@@ -0,0 +1,38 @@
+-- File: migrations/000013_summaries.up.sql
+
+-- Summaries generated in the background are ordinary summary suggestions flagged
+-- automatic; the worker applies them itself instead of waiting for a review.
+ALTER TABLE ai_suggestions ADD COLUMN automatic BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_ai_suggestions_automatic_chapter ON ai_suggestions(context_chapter_id, status) WHERE automatic;
+
+-- Kept apart from chapters so storing a summary does not touch the chapter's updated_at.
+CREATE TABLE chapter_summaries (
+    chapter_id UUID PRIMARY KEY REFERENCES chapters(id) ON DELETE CASCADE,
+    novel_id UUID NOT NULL REFERENCES novels(id) ON DELETE CASCADE,
+    summary TEXT NOT NULL,
+    content_hash TEXT NOT NULL, -- md5 of the summarized chapter content; a summary is stale once it differs
+    suggestion_id UUID REFERENCES ai_suggestions(id) ON DELETE SET NULL,
+    generated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_chapter_summaries_novel_id ON chapter_summaries(novel_id);
+
+-- Hierarchical synopsis of a novel: one row per act (consecutive groups of chapters,
+-- numbered from 1) summarizing its chapter summaries, and act 0 for the whole book,
+-- summarizing the act synopses. A synopsis is regenerated when the hash of its input
+-- changes; pending_hash is the input of the generation under way.
+CREATE TABLE novel_synopses (
+    novel_id UUID NOT NULL REFERENCES novels(id) ON DELETE CASCADE,
+    act INT NOT NULL CHECK (act >= 0),
+    synopsis TEXT NOT NULL DEFAULT '',
+    source_hash TEXT NOT NULL DEFAULT '',
+    pending_hash TEXT,
+    suggestion_id UUID REFERENCES ai_suggestions(id) ON DELETE SET NULL,
+    generated_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    checked_at TIMESTAMPTZ, -- Act 0 only: when the sweep last brought the novel up to date
+    PRIMARY KEY (novel_id, act)
+);
+
+CREATE INDEX idx_novel_synopses_suggestion_id ON novel_synopses(suggestion_id);
@@ -0,0 +1,3 @@
+-- File: migrations/000014_ai_analytics.down.sql
+
+DROP INDEX IF EXISTS idx_ai_suggestions_requested_at;
@@ -0,0 +1,4 @@
+-- File: migrations/000014_ai_analytics.up.sql
+
+-- Suggestion analytics aggregate the suggestions authors requested over a time range.
+CREATE INDEX idx_ai_suggestions_requested_at ON ai_suggestions(requested_at) WHERE NOT automatic;
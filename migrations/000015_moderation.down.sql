@@ -0,0 +1,3 @@
+-- File: migrations/000015_moderation.down.sql
+
+DROP TABLE IF EXISTS novel_moderation_settings;
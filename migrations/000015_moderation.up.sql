@@ -0,0 +1,12 @@
+-- File: migrations/000015_moderation.up.sql
+
+-- How strictly the AI output of a novel is moderated. Novels without a row get the
+-- configured default rating. The moderation outcome of each generation is kept in
+-- ai_suggestions.generation_metadata.moderation.
+CREATE TABLE novel_moderation_settings (
+    novel_id UUID PRIMARY KEY REFERENCES novels(id) ON DELETE CASCADE,
+    content_rating VARCHAR(20) NOT NULL CHECK (content_rating IN ('general', 'teen', 'mature')),
+    allowed_categories TEXT[] NOT NULL DEFAULT '{}', -- Allowed whatever their rating
+    updated_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,10 @@
+-- File: migrations/000016_ai_suggestion_retries.down.sql
+
+-- Enum values cannot be dropped; cancelled suggestions are kept as failed
+UPDATE ai_suggestions SET status = 'failed' WHERE status = 'cancelled';
+
+ALTER TABLE ai_suggestions
+    DROP COLUMN IF EXISTS retry_at,
+    DROP COLUMN IF EXISTS last_error_kind,
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS attempts;
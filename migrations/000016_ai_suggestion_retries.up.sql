@@ -0,0 +1,13 @@
+-- File: migrations/000016_ai_suggestion_retries.up.sql
+
+-- Cancelled suggestions stop before their content is generated
+ALTER TYPE ai_suggestion_status ADD VALUE IF NOT EXISTS 'cancelled';
+
+-- The AI worker's retry policy. attempts counts the claims of the suggestion; a
+-- transient failure puts it back to pending until retry_at, a permanent one (or the
+-- last attempt) fails it. last_error and last_error_kind describe the latest failure.
+ALTER TABLE ai_suggestions
+    ADD COLUMN attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN last_error TEXT,
+    ADD COLUMN last_error_kind VARCHAR(20) CHECK (last_error_kind IN ('transient', 'permanent')),
+    ADD COLUMN retry_at TIMESTAMPTZ;
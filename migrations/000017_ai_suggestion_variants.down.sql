@@ -0,0 +1,8 @@
+-- File: migrations/000017_ai_suggestion_variants.down.sql
+
+DROP INDEX IF EXISTS idx_ai_suggestions_parent;
+ALTER TABLE ai_suggestions
+    DROP COLUMN IF EXISTS temperature,
+    DROP COLUMN IF EXISTS requested_model,
+    DROP COLUMN IF EXISTS variant_index,
+    DROP COLUMN IF EXISTS parent_suggestion_id;
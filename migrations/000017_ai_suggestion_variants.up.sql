@@ -0,0 +1,12 @@
+-- File: migrations/000017_ai_suggestion_variants.up.sql
+
+-- Variants of one request are suggestions of their own, generated side by side. The
+-- first variant is the parent of the others; variant_index numbers them from 1.
+-- requested_model and temperature override the worker's defaults for a variant.
+ALTER TABLE ai_suggestions
+    ADD COLUMN parent_suggestion_id UUID REFERENCES ai_suggestions(id) ON DELETE CASCADE,
+    ADD COLUMN variant_index INT CHECK (variant_index >= 1),
+    ADD COLUMN requested_model VARCHAR(100),
+    ADD COLUMN temperature REAL CHECK (temperature >= 0 AND temperature <= 2);
+
+CREATE INDEX idx_ai_suggestions_parent ON ai_suggestions(parent_suggestion_id) WHERE parent_suggestion_id IS NOT NULL;
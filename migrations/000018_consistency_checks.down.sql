@@ -0,0 +1,4 @@
+-- File: migrations/000018_consistency_checks.down.sql
+
+DROP TABLE IF EXISTS consistency_findings;
+DROP TABLE IF EXISTS consistency_checks;
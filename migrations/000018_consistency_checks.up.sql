@@ -0,0 +1,53 @@
+-- File: migrations/000018_consistency_checks.up.sql
+
+-- A consistency check compares a chapter with the novel's story bible (characters,
+-- places, world rule and magic system notes, timeline) in the AI worker. content_hash
+-- is the md5 of the chapter content it checked; finding ranges refer to that content.
+CREATE TABLE consistency_checks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    novel_id UUID NOT NULL REFERENCES novels(id) ON DELETE CASCADE,
+    chapter_id UUID NOT NULL REFERENCES chapters(id) ON DELETE CASCADE,
+    requested_by_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    use_llm BOOLEAN NOT NULL DEFAULT FALSE,
+    content_hash TEXT,
+    findings_count INT NOT NULL DEFAULT 0,
+    error TEXT, -- Why the check failed, or why its LLM pass was skipped
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_consistency_checks_chapter_id ON consistency_checks(chapter_id, requested_at DESC);
+CREATE INDEX idx_consistency_checks_claimable ON consistency_checks(updated_at) WHERE status IN ('pending', 'running');
+CREATE TRIGGER update_consistency_checks_updated_at BEFORE UPDATE ON consistency_checks FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Open findings are replaced by each check of their chapter; resolved and dismissed
+-- ones are kept. fingerprint identifies an issue across checks (kind, entity and what
+-- was found, not where), so a dismissed issue is not reported again.
+CREATE TABLE consistency_findings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    check_id UUID NOT NULL REFERENCES consistency_checks(id) ON DELETE CASCADE,
+    novel_id UUID NOT NULL REFERENCES novels(id) ON DELETE CASCADE,
+    chapter_id UUID NOT NULL REFERENCES chapters(id) ON DELETE CASCADE,
+    kind VARCHAR(30) NOT NULL,
+    severity VARCHAR(10) NOT NULL CHECK (severity IN ('info', 'warning', 'error')),
+    source VARCHAR(10) NOT NULL CHECK (source IN ('rules', 'llm')),
+    range_start INT, -- Rune offsets in the checked content; NULL for the whole chapter
+    range_end INT,
+    quote TEXT NOT NULL DEFAULT '',
+    message TEXT NOT NULL,
+    entity_type VARCHAR(50), -- The story bible entry contradicted, if any
+    entity_id UUID,
+    fingerprint TEXT NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved', 'dismissed')),
+    resolution_note TEXT,
+    resolved_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((range_start IS NULL) = (range_end IS NULL) AND range_start <= range_end)
+);
+
+CREATE INDEX idx_consistency_findings_chapter_id ON consistency_findings(chapter_id, status);
+CREATE INDEX idx_consistency_findings_check_id ON consistency_findings(check_id);
@@ -0,0 +1,3 @@
+-- File: migrations/000019_notifications.down.sql
+
+DROP TABLE IF EXISTS notifications;
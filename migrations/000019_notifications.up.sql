@@ -0,0 +1,24 @@
+-- File: migrations/000019_notifications.up.sql
+
+-- In-app notifications, written by the notifier from domain events. dedupe_key names
+-- the event a notification comes from, so a redelivered event adds nothing; the
+-- variants of a suggestion request share one. subject_type and subject_id point at
+-- what the notification is about (a comment, a suggestion, a chapter or a novel).
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    novel_id UUID REFERENCES novels(id) ON DELETE CASCADE,
+    actor_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    type VARCHAR(40) NOT NULL CHECK (type IN ('comment_reply', 'mention', 'collaborator_invite', 'ai_suggestion_generated', 'chapter_published')),
+    subject_type VARCHAR(40) NOT NULL,
+    subject_id UUID NOT NULL,
+    message TEXT NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}',
+    dedupe_key TEXT NOT NULL,
+    read_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, dedupe_key)
+);
+
+CREATE INDEX idx_notifications_user_id ON notifications(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
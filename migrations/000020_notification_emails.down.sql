@@ -0,0 +1,12 @@
+-- File: migrations/000020_notification_emails.down.sql
+
+DROP TABLE IF EXISTS notification_preferences;
+
+DROP INDEX IF EXISTS idx_notifications_email_status;
+
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS emailed_at,
+    DROP COLUMN IF EXISTS email_claimed_at,
+    DROP COLUMN IF EXISTS email_error,
+    DROP COLUMN IF EXISTS email_attempts,
+    DROP COLUMN IF EXISTS email_status;
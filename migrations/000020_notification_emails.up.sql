@@ -0,0 +1,35 @@
+-- File: migrations/000020_notification_emails.up.sql
+
+-- Email delivery state of notifications. The notifier claims pending notifications
+-- and, by the recipient's preferences, emails them at once (sent), keeps them for the
+-- daily digest (digest) or skips them. A claim is 'sending' until settled; claims
+-- older than a few minutes are taken over. Notifications from before the email
+-- channel are never emailed.
+ALTER TABLE notifications
+    ADD COLUMN email_status VARCHAR(20) NOT NULL DEFAULT 'skipped'
+        CHECK (email_status IN ('pending', 'sending', 'digest', 'sent', 'skipped', 'failed')),
+    ADD COLUMN email_attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN email_error TEXT,
+    ADD COLUMN email_claimed_at TIMESTAMPTZ,
+    ADD COLUMN emailed_at TIMESTAMPTZ;
+
+ALTER TABLE notifications ALTER COLUMN email_status SET DEFAULT 'pending';
+
+CREATE INDEX idx_notifications_email_status ON notifications(email_status, created_at)
+    WHERE email_status IN ('pending', 'sending', 'digest');
+
+-- How a user gets notification emails. A preference applies to a novel, a notification
+-- type, both, or everything when neither is set; the most specific one wins: novel and
+-- type, then novel, then type, then everything.
+CREATE TABLE notification_preferences (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    novel_id UUID REFERENCES novels(id) ON DELETE CASCADE,
+    type VARCHAR(40) CHECK (type IN ('comment_reply', 'mention', 'collaborator_invite', 'ai_suggestion_generated', 'chapter_published')),
+    email_mode VARCHAR(20) NOT NULL CHECK (email_mode IN ('instant', 'daily', 'off')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_notification_preferences_scope
+    ON notification_preferences(user_id, COALESCE(novel_id, '00000000-0000-0000-0000-000000000000'), COALESCE(type, ''));
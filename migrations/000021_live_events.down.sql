@@ -0,0 +1,3 @@
+-- File: migrations/000021_live_events.down.sql
+
+DROP TABLE IF EXISTS live_events;
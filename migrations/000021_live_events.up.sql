@@ -0,0 +1,20 @@
+-- File: migrations/000021_live_events.up.sql
+
+-- Recent events pushed to connected browsers over GET /events. Rows are kept for a
+-- retention window so a client that reconnects with Last-Event-ID can catch up; the id
+-- orders the events and is the event ID the client sees. Events with a user_id are for
+-- that user only; the others go to the members following novel_id, except their actor.
+CREATE TABLE live_events (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(40) NOT NULL,
+    novel_id UUID REFERENCES novels(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    actor_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    data JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (novel_id IS NOT NULL OR user_id IS NOT NULL)
+);
+
+CREATE INDEX idx_live_events_user_id ON live_events(user_id, id) WHERE user_id IS NOT NULL;
+CREATE INDEX idx_live_events_novel_id ON live_events(novel_id, id) WHERE user_id IS NULL;
+CREATE INDEX idx_live_events_created_at ON live_events(created_at);